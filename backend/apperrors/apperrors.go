@@ -0,0 +1,151 @@
+// Package apperrors define los errores de dominio que devuelven repositorios y
+// servicios. Cada error tiene una clase (ErrNotFound, ErrForbidden, ...) que
+// decide el status HTTP y un código estable que recibe el cliente.
+package apperrors
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	ErrValidation      = errors.New("validation")
+	ErrUnauthorized    = errors.New("unauthorized")
+	ErrForbidden       = errors.New("forbidden")
+	ErrNotFound        = errors.New("not found")
+	ErrConflict        = errors.New("conflict")
+	ErrTooManyRequests = errors.New("too many requests")
+)
+
+// Error es un error de dominio. errors.Is(err, ErrNotFound) y similares
+// funcionan contra Kind, y también contra la causa original si la hay (por
+// ejemplo mongo.ErrNoDocuments).
+//
+// El cliente recibe el mensaje del catálogo de i18n para Code; Message queda
+// para los logs y como respaldo si el código no está en el catálogo. Detail
+// lleva información puntual que no se traduce (qué campo falló, qué ids).
+// RetryAfter, en los ErrTooManyRequests, es cuánto esperar antes de reintentar.
+type Error struct {
+	Kind       error
+	Code       string
+	Message    string
+	Detail     string
+	RetryAfter time.Duration
+	Err        error
+}
+
+func (e *Error) Error() string {
+	if e.Detail != "" {
+		return e.Message + ": " + e.Detail
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() []error {
+	if e.Err == nil {
+		return []error{e.Kind}
+	}
+	return []error{e.Kind, e.Err}
+}
+
+func Validation(code, message string) error {
+	return &Error{Kind: ErrValidation, Code: code, Message: message}
+}
+
+func Unauthorized(code, message string) error {
+	return &Error{Kind: ErrUnauthorized, Code: code, Message: message}
+}
+
+func Forbidden(code, message string) error {
+	return &Error{Kind: ErrForbidden, Code: code, Message: message}
+}
+
+func NotFound(code, message string) error {
+	return &Error{Kind: ErrNotFound, Code: code, Message: message}
+}
+
+func Conflict(code, message string) error {
+	return &Error{Kind: ErrConflict, Code: code, Message: message}
+}
+
+func TooManyRequests(code, message string) error {
+	return &Error{Kind: ErrTooManyRequests, Code: code, Message: message}
+}
+
+// WithDetail devuelve una copia de err con Detail. Si err no es un *Error se
+// devuelve sin cambios.
+func WithDetail(err error, detail string) error {
+	var appErr *Error
+	if !errors.As(err, &appErr) {
+		return err
+	}
+	copied := *appErr
+	copied.Detail = detail
+	return &copied
+}
+
+// WithRetryAfter devuelve una copia de err con RetryAfter. Si err no es un
+// *Error se devuelve sin cambios.
+func WithRetryAfter(err error, d time.Duration) error {
+	var appErr *Error
+	if !errors.As(err, &appErr) {
+		return err
+	}
+	copied := *appErr
+	copied.RetryAfter = d
+	return &copied
+}
+
+// FromMongo traduce los errores del driver que tienen sentido para el cliente.
+// El resto se devuelve sin cambios y termina como 500.
+func FromMongo(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, mongo.ErrNoDocuments):
+		return &Error{Kind: ErrNotFound, Code: "not_found", Message: "recurso no encontrado", Err: err}
+	case mongo.IsDuplicateKeyError(err):
+		return &Error{Kind: ErrConflict, Code: "conflict", Message: "el recurso ya existe", Err: err}
+	}
+	return err
+}
+
+// HTTPStatus devuelve el status que corresponde a err.
+func HTTPStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrValidation):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, ErrTooManyRequests):
+		return http.StatusTooManyRequests
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout
+	}
+	return http.StatusInternalServerError
+}
+
+// Code devuelve el código estable de err, o uno genérico según el status
+// para los errores que no son de dominio.
+func Code(err error) string {
+	var appErr *Error
+	if errors.As(err, &appErr) && appErr.Code != "" {
+		return appErr.Code
+	}
+	switch HTTPStatus(err) {
+	case http.StatusGatewayTimeout:
+		return "timeout"
+	default:
+		return "internal_error"
+	}
+}
@@ -0,0 +1,50 @@
+package apperrors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestFromMongo(t *testing.T) {
+	err := FromMongo(mongo.ErrNoDocuments)
+	if !errors.Is(err, ErrNotFound) || !errors.Is(err, mongo.ErrNoDocuments) {
+		t.Fatalf("expected not found keeping the driver error, got %v", err)
+	}
+	dup := FromMongo(mongo.WriteException{WriteErrors: mongo.WriteErrors{{Code: 11000}}})
+	if !errors.Is(dup, ErrConflict) || !mongo.IsDuplicateKeyError(dup) {
+		t.Fatalf("expected conflict keeping the driver error, got %v", dup)
+	}
+	if FromMongo(nil) != nil {
+		t.Fatalf("nil must stay nil")
+	}
+}
+
+func TestHTTPStatusAndCode(t *testing.T) {
+	cases := []struct {
+		err    error
+		status int
+		code   string
+	}{
+		{Validation("invalid_body", "x"), http.StatusBadRequest, "invalid_body"},
+		{Unauthorized("token_invalid", "x"), http.StatusUnauthorized, "token_invalid"},
+		{Forbidden("not_owner", "x"), http.StatusForbidden, "not_owner"},
+		{fmt.Errorf("contexto: %w", NotFound("routine_not_found", "x")), http.StatusNotFound, "routine_not_found"},
+		{Conflict("email_taken", "x"), http.StatusConflict, "email_taken"},
+		{TooManyRequests("verification_throttled", "x"), http.StatusTooManyRequests, "verification_throttled"},
+		{context.DeadlineExceeded, http.StatusGatewayTimeout, "timeout"},
+		{errors.New("boom"), http.StatusInternalServerError, "internal_error"},
+	}
+	for _, tc := range cases {
+		if got := HTTPStatus(tc.err); got != tc.status {
+			t.Errorf("HTTPStatus(%v) = %d, want %d", tc.err, got, tc.status)
+		}
+		if got := Code(tc.err); got != tc.code {
+			t.Errorf("Code(%v) = %q, want %q", tc.err, got, tc.code)
+		}
+	}
+}
@@ -1,53 +1,208 @@
 package auth
 
 import (
+	"crypto/rand"
 	"errors"
+	"fmt"
+	"slices"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
-var jwtSecret = []byte("mi_clave_secreta_muy_segura")
+var (
+	jwtSecret        = randomSecret()
+	accessTokenTTL   = 24 * time.Hour
+	refreshTokenTTL  = 7 * 24 * time.Hour
+	impersonationTTL = 15 * time.Minute
+
+	// keyManager nil: se firma HS256 con jwtSecret y sin kid.
+	keyManager *KeyManager
+)
+
+type Settings struct {
+	Secret          []byte
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+	// ImpersonationTTL es la vigencia de los tokens de suplantación.
+	ImpersonationTTL time.Duration
+	BcryptCost       int
+	// RefreshTokenHashKey es la clave del HMAC de los refresh tokens
+	// guardados. Vacía se deriva de Secret.
+	RefreshTokenHashKey []byte
+	// MFAKey cifra los secretos TOTP guardados. Vacía se deriva de Secret.
+	MFAKey []byte
+}
+
+// Configure reemplaza el secreto y los tiempos de vida de los tokens. Debe
+// llamarse una sola vez al iniciar el proceso.
+func Configure(s Settings) {
+	if len(s.Secret) > 0 {
+		jwtSecret = s.Secret
+	}
+	if s.AccessTokenTTL > 0 {
+		accessTokenTTL = s.AccessTokenTTL
+	}
+	if s.RefreshTokenTTL > 0 {
+		refreshTokenTTL = s.RefreshTokenTTL
+	}
+	if s.ImpersonationTTL > 0 {
+		impersonationTTL = s.ImpersonationTTL
+	}
+	if s.BcryptCost > 0 {
+		bcryptCost = s.BcryptCost
+	}
+	if len(s.RefreshTokenHashKey) > 0 {
+		refreshHashKey = s.RefreshTokenHashKey
+	}
+	if len(s.MFAKey) > 0 {
+		mfaKey = s.MFAKey
+	}
+}
+
+func AccessTokenTTL() time.Duration {
+	return accessTokenTTL
+}
+
+func RefreshTokenTTL() time.Duration {
+	return refreshTokenTTL
+}
+
+// randomSecret evita firmar con una clave vacía si nadie llamó a Configure.
+func randomSecret() []byte {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// Tipos de token (claim token_use). Un refresh token no sirve como access
+// token ni al revés.
+const (
+	AccessToken  = "access"
+	RefreshToken = "refresh"
+	// MFAChallenge lo recibe quien pasó la contraseña pero todavía debe
+	// presentar el segundo factor; solo sirve en /auth/login/mfa.
+	MFAChallenge = "mfa_challenge"
+)
+
+// Métodos de autenticación del claim amr (RFC 8176).
+const (
+	AMRPassword = "pwd"
+	AMROTP      = "otp"
+	// AMRFederated es un login delegado en un proveedor OpenID Connect.
+	AMRFederated = "fed"
+)
+
+// mfaChallengeTTL es el tiempo para ingresar el código después de la
+// contraseña.
+const mfaChallengeTTL = 5 * time.Minute
 
 type Claims struct {
 	UserID string `json:"user_id"`
 	Email  string `json:"email"`
 	Role   string `json:"role"`
+	// Lang es el idioma preferido del usuario para los mensajes de error.
+	Lang string `json:"lang,omitempty"`
+	// SessionID es la familia de refresh tokens del login que emitió el
+	// token; identifica la sesión actual en /me/sessions.
+	SessionID string `json:"sid,omitempty"`
+	Type      string `json:"token_use,omitempty"`
+	// AMR son los métodos con los que se autenticó la sesión: "pwd" o "fed"
+	// y, si pasó el segundo factor, "otp".
+	AMR []string `json:"amr,omitempty"`
+	// Act es el administrador que usa el token a nombre del usuario (ver
+	// GenerateImpersonationToken). nil en los tokens comunes.
+	Act *Actor `json:"act,omitempty"`
 	jwt.RegisteredClaims
 }
 
-func GenerateToken(userID primitive.ObjectID, email string, role string) (string, string, int64, error) {
+// Actor es quien actúa a nombre del usuario del token: el claim act de RFC
+// 8693.
+type Actor struct {
+	UserID string `json:"sub"`
+	Email  string `json:"email,omitempty"`
+}
+
+// Impersonated indica si el token es de suplantación.
+func (c *Claims) Impersonated() bool {
+	return c.Act != nil
+}
+
+// HasAMR indica si la sesión se autenticó con method.
+func (c *Claims) HasAMR(method string) bool {
+	return slices.Contains(c.AMR, method)
+}
+
+// Subject es el usuario y la sesión para los que se emiten tokens.
+type Subject struct {
+	UserID    primitive.ObjectID
+	Email     string
+	Role      string
+	Lang      string
+	SessionID string
+	AMR       []string
+}
+
+// tokenType devuelve el tipo del token. Los emitidos antes de token_use se
+// distinguen porque solo el access token llevaba email.
+func (c *Claims) tokenType() string {
+	if c.Type != "" {
+		return c.Type
+	}
+	if c.Email != "" {
+		return AccessToken
+	}
+	return RefreshToken
+}
 
-	accessExp := time.Now().Add(24 * time.Hour)
+func GenerateToken(sub Subject) (string, string, int64, error) {
+	key, err := currentKey()
+	if err != nil {
+		return "", "", 0, err
+	}
+
+	accessExp := time.Now().Add(accessTokenTTL)
 	accessClaims := Claims{
-		UserID: userID.Hex(),
-		Email:  email,
-		Role:   role,
+		UserID:    sub.UserID.Hex(),
+		Email:     sub.Email,
+		Role:      sub.Role,
+		Lang:      sub.Lang,
+		SessionID: sub.SessionID,
+		Type:      AccessToken,
+		AMR:       sub.AMR,
 		RegisteredClaims: jwt.RegisteredClaims{
+			// jti: permite revocar este token puntual (ver services.TokenRevocationService).
+			ID:        primitive.NewObjectID().Hex(),
 			ExpiresAt: jwt.NewNumericDate(accessExp),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
 
-	accessToken := jwt.NewWithClaims(jwt.SigningMethodHS256, accessClaims)
-	accessStr, err := accessToken.SignedString(jwtSecret)
+	accessStr, err := sign(key, accessClaims)
 	if err != nil {
 		return "", "", 0, err
 	}
 
-	refreshExp := time.Now().Add(7 * 24 * time.Hour)
+	refreshExp := time.Now().Add(refreshTokenTTL)
 	refreshClaims := Claims{
-		UserID: userID.Hex(),
-		Role:   role,
+		UserID:    sub.UserID.Hex(),
+		Role:      sub.Role,
+		Lang:      sub.Lang,
+		SessionID: sub.SessionID,
+		Type:      RefreshToken,
 		RegisteredClaims: jwt.RegisteredClaims{
+			// Sin un ID propio, dos refresh del mismo usuario emitidos en el
+			// mismo segundo serían idénticos y la rotación los confundiría.
+			ID:        primitive.NewObjectID().Hex(),
 			ExpiresAt: jwt.NewNumericDate(refreshExp),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
 
-	refreshToken := jwt.NewWithClaims(jwt.SigningMethodHS256, refreshClaims)
-	refreshStr, err := refreshToken.SignedString(jwtSecret)
+	refreshStr, err := sign(key, refreshClaims)
 	if err != nil {
 		return "", "", 0, err
 	}
@@ -56,40 +211,160 @@ func GenerateToken(userID primitive.ObjectID, email string, role string) (string
 	return accessStr, refreshStr, expiresIn, nil
 }
 
-func ValidateToken(tokenString string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		return jwtSecret, nil
-	})
+// GenerateMFAChallenge emite el token que acredita que userID pasó el primer
+// factor, con los métodos de amr. Tiene jti para poder invalidarlo una vez
+// usado.
+func GenerateMFAChallenge(userID primitive.ObjectID, amr []string) (string, int64, error) {
+	key, err := currentKey()
+	if err != nil {
+		return "", 0, err
+	}
+	now := time.Now()
+	claims := Claims{
+		UserID: userID.Hex(),
+		Type:   MFAChallenge,
+		AMR:    amr,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        primitive.NewObjectID().Hex(),
+			ExpiresAt: jwt.NewNumericDate(now.Add(mfaChallengeTTL)),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	}
+	token, err := sign(key, claims)
+	if err != nil {
+		return "", 0, err
+	}
+	return token, int64(mfaChallengeTTL.Seconds()), nil
+}
+
+// GenerateImpersonationToken emite un access token de sub para que actor vea
+// lo mismo que el usuario. Vence a los ImpersonationTTL, no tiene refresh token
+// y lleva jti para poder terminarlo antes. Devuelve también el jti y el
+// vencimiento para el registro de auditoría.
+func GenerateImpersonationToken(sub Subject, actor Actor) (token, id string, expiresAt time.Time, err error) {
+	key, err := currentKey()
+	if err != nil {
+		return "", "", time.Time{}, err
+	}
+	now := time.Now()
+	expiresAt = now.Add(impersonationTTL)
+	claims := Claims{
+		UserID:    sub.UserID.Hex(),
+		Email:     sub.Email,
+		Role:      sub.Role,
+		Lang:      sub.Lang,
+		SessionID: sub.SessionID,
+		Type:      AccessToken,
+		AMR:       sub.AMR,
+		Act:       &actor,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        primitive.NewObjectID().Hex(),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	}
+	token, err = sign(key, claims)
+	if err != nil {
+		return "", "", time.Time{}, err
+	}
+	return token, claims.ID, expiresAt, nil
+}
+
+func sign(key *SigningKey, claims Claims) (string, error) {
+	token := jwt.NewWithClaims(key.method(), claims)
+	if key.ID != "" {
+		token.Header["kid"] = key.ID
+	}
+	return token.SignedString(key.private)
+}
+
+var errWrongTokenType = errors.New("tipo de token incorrecto")
+
+// ValidateAccessToken verifica firma, vencimiento y que sea un access token.
+func ValidateAccessToken(tokenString string) (*Claims, error) {
+	return validateToken(tokenString, AccessToken)
+}
+
+// ValidateRefreshToken verifica firma, vencimiento y que sea un refresh token.
+func ValidateRefreshToken(tokenString string) (*Claims, error) {
+	return validateToken(tokenString, RefreshToken)
+}
+
+// ValidateMFAChallenge verifica firma, vencimiento y que sea un desafío MFA.
+func ValidateMFAChallenge(tokenString string) (*Claims, error) {
+	return validateToken(tokenString, MFAChallenge)
+}
+
+func validateToken(tokenString string, tokenType string) (*Claims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, verificationKey,
+		jwt.WithValidMethods([]string{HS256, RS256, EdDSA}))
 
 	if err != nil {
 		return nil, err
 	}
 
-	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
-		return claims, nil
+	claims, ok := token.Claims.(*Claims)
+	if !ok || !token.Valid {
+		return nil, errors.New("invalid token")
 	}
+	if claims.tokenType() != tokenType {
+		return nil, errWrongTokenType
+	}
+	return claims, nil
+}
 
-	return nil, errors.New("invalid token")
+// verificationKey elige la clave por kid y exige que el algoritmo del header
+// sea el de la clave, para que un token HS256 no se verifique usando una
+// clave pública como secreto.
+func verificationKey(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := lookupKey(kid)
+	if !ok {
+		return nil, fmt.Errorf("clave de firma desconocida %q", kid)
+	}
+	if token.Method.Alg() != key.Algorithm {
+		return nil, fmt.Errorf("algoritmo %s no corresponde a la clave %q", token.Method.Alg(), kid)
+	}
+	return key.public, nil
 }
 
-func GenerateAccessTokenFromStrings(userID string, email string, role string) (string, int64, error) {
-	accessExp := time.Now().Add(24 * time.Hour)
-	accessClaims := Claims{
-		UserID: userID,
-		Email:  email,
-		Role:   role,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(accessExp),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-		},
+// UseKeyManager pasa a firmar con las claves de m. Los tokens sin kid,
+// firmados con el secreto HS256 anterior, se siguen aceptando durante el
+// período de gracia de m desde su primera clave.
+func UseKeyManager(m *KeyManager) {
+	keyManager = m
+}
+
+// JWKS devuelve las claves públicas para /.well-known/jwks.json. Con HS256 sin
+// KeyManager el conjunto está vacío: no hay nada público que publicar.
+func JWKS() JWKSet {
+	if keyManager == nil {
+		return JWKSet{Keys: []JWK{}}
 	}
+	return keyManager.JWKS()
+}
 
-	accessToken := jwt.NewWithClaims(jwt.SigningMethodHS256, accessClaims)
-	accessStr, err := accessToken.SignedString(jwtSecret)
-	if err != nil {
-		return "", 0, err
+func currentKey() (*SigningKey, error) {
+	if keyManager == nil {
+		return legacyKey(), nil
 	}
+	return keyManager.signingKey()
+}
 
-	expiresIn := int64(time.Until(accessExp).Seconds())
-	return accessStr, expiresIn, nil
+func lookupKey(kid string) (*SigningKey, bool) {
+	if kid == "" {
+		if keyManager != nil && time.Now().After(keyManager.legacyVerifyUntil()) {
+			return nil, false
+		}
+		return legacyKey(), true
+	}
+	if keyManager == nil {
+		return nil, false
+	}
+	return keyManager.verificationKey(kid)
+}
+
+// legacyKey es el secreto de Configure, sin kid.
+func legacyKey() *SigningKey {
+	return &SigningKey{Algorithm: HS256, private: jwtSecret, public: jwtSecret}
 }
@@ -0,0 +1,47 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestGenerateImpersonationToken_CarriesActor(t *testing.T) {
+	user := primitive.NewObjectID()
+	token, id, expiresAt, err := GenerateImpersonationToken(
+		Subject{UserID: user, Email: "member@example.com", Role: "user", AMR: []string{AMRPassword, AMROTP}},
+		Actor{UserID: "admin-id", Email: "admin@example.com"},
+	)
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	if ttl := time.Until(expiresAt); ttl <= 0 || ttl > impersonationTTL {
+		t.Fatalf("unexpected expiry in %v", ttl)
+	}
+
+	claims, err := ValidateAccessToken(token)
+	if err != nil {
+		t.Fatalf("impersonation token must validate as an access token: %v", err)
+	}
+	if !claims.Impersonated() || claims.Act.UserID != "admin-id" || claims.Act.Email != "admin@example.com" {
+		t.Fatalf("act claim not preserved: %+v", claims.Act)
+	}
+	if claims.UserID != user.Hex() || claims.ID != id || !claims.HasAMR(AMROTP) {
+		t.Fatalf("unexpected claims %+v", claims)
+	}
+}
+
+func TestGenerateToken_IsNotImpersonated(t *testing.T) {
+	access, _, _, err := GenerateToken(Subject{UserID: primitive.NewObjectID(), Email: "a@example.com", Role: "user"})
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	claims, err := ValidateAccessToken(access)
+	if err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	if claims.Impersonated() {
+		t.Fatalf("regular tokens must not carry act")
+	}
+}
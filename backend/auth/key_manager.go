@@ -0,0 +1,214 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"backend/models"
+)
+
+// KeyStore guarda las claves de firma para que todas las instancias usen las
+// mismas. Lo implementan los repositorios de signing_keys.
+type KeyStore interface {
+	ListKeys(ctx context.Context) ([]models.SigningKey, error)
+	SaveKey(ctx context.Context, key models.SigningKey) error
+	RetireKeys(ctx context.Context, keep string, verifyUntil time.Time) error
+}
+
+type KeyManagerOptions struct {
+	Algorithm string
+	// RotationInterval es cada cuánto se genera una clave nueva; 0 no rota.
+	RotationInterval time.Duration
+	// GracePeriod es cuánto sigue verificando una clave después de retirada.
+	// Tiene que cubrir el tiempo de vida de los refresh tokens.
+	GracePeriod time.Duration
+	// EncryptionKey cifra las claves privadas en el KeyStore. Vacía se
+	// deriva del secreto de Configure.
+	EncryptionKey []byte
+}
+
+// reloadMinInterval limita las recargas por un kid desconocido: un token con
+// kid inventado no puede provocar una consulta por pedido.
+const reloadMinInterval = 10 * time.Second
+
+// KeyManager firma con la clave activa más nueva y verifica con cualquier
+// clave activa o retirada dentro de su período de gracia. Las claves nuevas
+// generadas por otra instancia se ven en la próxima recarga (Run) o al
+// recibir un token con un kid desconocido.
+type KeyManager struct {
+	store KeyStore
+	opts  KeyManagerOptions
+
+	mu         sync.RWMutex
+	keys       map[string]*SigningKey
+	current    *SigningKey
+	lastReload time.Time
+	// oldestKey es la creación de la clave guardada más vieja; ver
+	// legacyVerifyUntil.
+	oldestKey time.Time
+}
+
+func NewKeyManager(store KeyStore, opts KeyManagerOptions) *KeyManager {
+	if len(opts.EncryptionKey) == 0 {
+		opts.EncryptionKey = deriveKey(jwtSecret, "signing-key-encryption")
+	}
+	return &KeyManager{store: store, opts: opts, keys: make(map[string]*SigningKey)}
+}
+
+// Load recarga las claves del store y rota si no hay una clave activa del
+// algoritmo configurado o si la actual ya cumplió RotationInterval.
+func (m *KeyManager) Load(ctx context.Context) error {
+	if err := m.reload(ctx); err != nil {
+		return err
+	}
+	m.mu.RLock()
+	current := m.current
+	m.mu.RUnlock()
+	if current == nil || (m.opts.RotationInterval > 0 && time.Since(current.CreatedAt) >= m.opts.RotationInterval) {
+		return m.Rotate(ctx)
+	}
+	return nil
+}
+
+// Rotate genera una clave nueva, la guarda como activa y retira las demás.
+func (m *KeyManager) Rotate(ctx context.Context) error {
+	key, err := GenerateSigningKey(m.opts.Algorithm)
+	if err != nil {
+		return err
+	}
+	stored, err := sealKey(key, m.opts.EncryptionKey)
+	if err != nil {
+		return err
+	}
+	if err := m.store.SaveKey(ctx, stored); err != nil {
+		return err
+	}
+	if err := m.store.RetireKeys(ctx, key.ID, time.Now().Add(m.opts.GracePeriod)); err != nil {
+		return err
+	}
+	log.Printf("auth: nueva clave de firma %s (%s)", key.ID, key.Algorithm)
+	return m.reload(ctx)
+}
+
+func (m *KeyManager) reload(ctx context.Context) error {
+	stored, err := m.store.ListKeys(ctx)
+	if err != nil {
+		return err
+	}
+
+	keys := make(map[string]*SigningKey, len(stored))
+	var active []*SigningKey
+	var oldest time.Time
+	for _, s := range stored {
+		if oldest.IsZero() || s.CreatedAt.Before(oldest) {
+			oldest = s.CreatedAt
+		}
+		key, err := openKey(s, m.opts.EncryptionKey)
+		if err != nil {
+			// Una clave que no se puede descifrar (otra clave de cifrado)
+			// no debe impedir firmar con las demás.
+			log.Printf("auth: se ignora la clave %s: %v", s.ID, err)
+			continue
+		}
+		keys[key.ID] = key
+		if key.VerifyUntil.IsZero() && key.Algorithm == m.opts.Algorithm {
+			active = append(active, key)
+		}
+	}
+	sort.Slice(active, func(i, j int) bool { return active[i].CreatedAt.After(active[j].CreatedAt) })
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.keys = keys
+	m.current = nil
+	if len(active) > 0 {
+		m.current = active[0]
+	}
+	m.lastReload = time.Now()
+	m.oldestKey = oldest
+	return nil
+}
+
+// legacyVerifyUntil es hasta cuándo se aceptan los tokens sin kid, firmados
+// con el secreto HS256 de antes de las claves guardadas: el período de gracia
+// contado desde la primera clave, no desde el arranque, para que reiniciar no
+// lo extienda. Cuando esa clave vence y deja de listarse, la siguiente se creó
+// al retirarla y su plazo también pasó.
+func (m *KeyManager) legacyVerifyUntil() time.Time {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.oldestKey.Add(m.opts.GracePeriod)
+}
+
+// Run recarga y rota las claves periódicamente hasta que ctx se cancele.
+func (m *KeyManager) Run(ctx context.Context) {
+	interval := time.Minute
+	if m.opts.RotationInterval > 0 && m.opts.RotationInterval/10 < interval {
+		interval = max(m.opts.RotationInterval/10, time.Second)
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := m.Load(ctx); err != nil {
+				log.Printf("auth: error recargando claves de firma: %v", err)
+			}
+		}
+	}
+}
+
+var errNoSigningKey = errors.New("no hay clave de firma activa")
+
+func (m *KeyManager) signingKey() (*SigningKey, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.current == nil {
+		return nil, errNoSigningKey
+	}
+	return m.current, nil
+}
+
+func (m *KeyManager) verificationKey(kid string) (*SigningKey, bool) {
+	m.mu.RLock()
+	key, ok := m.keys[kid]
+	stale := time.Since(m.lastReload) >= reloadMinInterval
+	m.mu.RUnlock()
+
+	if !ok && stale {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := m.reload(ctx); err != nil {
+			log.Printf("auth: error recargando claves de firma: %v", err)
+		}
+		m.mu.RLock()
+		key, ok = m.keys[kid]
+		m.mu.RUnlock()
+	}
+	if !ok || (!key.VerifyUntil.IsZero() && time.Now().After(key.VerifyUntil)) {
+		return nil, false
+	}
+	return key, true
+}
+
+// JWKS devuelve las claves públicas vigentes, incluidas las retiradas que
+// todavía verifican.
+func (m *KeyManager) JWKS() JWKSet {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	set := JWKSet{Keys: []JWK{}}
+	for _, key := range m.keys {
+		if jwk, ok := key.jwk(); ok {
+			set.Keys = append(set.Keys, jwk)
+		}
+	}
+	sort.Slice(set.Keys, func(i, j int) bool { return set.Keys[i].KeyID < set.Keys[j].KeyID })
+	return set
+}
@@ -0,0 +1,176 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"backend/repositories/memory"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func useTestKeyManager(t *testing.T, alg string, grace time.Duration) *KeyManager {
+	t.Helper()
+	m := NewKeyManager(memory.NewSigningKeyRepository(), KeyManagerOptions{Algorithm: alg, GracePeriod: grace})
+	if err := m.Load(context.Background()); err != nil {
+		t.Fatalf("load keys: %v", err)
+	}
+	UseKeyManager(m)
+	t.Cleanup(func() { keyManager = nil })
+	return m
+}
+
+func kidOf(t *testing.T, token string) string {
+	t.Helper()
+	parsed, _, err := jwt.NewParser().ParseUnverified(token, &Claims{})
+	if err != nil {
+		t.Fatalf("parse token: %v", err)
+	}
+	kid, _ := parsed.Header["kid"].(string)
+	return kid
+}
+
+func TestKeyManager_RotationKeepsOldKeysDuringGrace(t *testing.T) {
+	for _, alg := range []string{RS256, EdDSA} {
+		t.Run(alg, func(t *testing.T) {
+			m := useTestKeyManager(t, alg, 200*time.Millisecond)
+
+			old, _, _, err := GenerateToken(Subject{UserID: primitive.NewObjectID(), Email: "a@example.com", Role: "user"})
+			if err != nil {
+				t.Fatalf("generate: %v", err)
+			}
+			oldKid := kidOf(t, old)
+			if jwks := JWKS(); len(jwks.Keys) != 1 || jwks.Keys[0].KeyID != oldKid || jwks.Keys[0].Algorithm != alg {
+				t.Fatalf("expected the signing key in the JWKS, got %+v", jwks)
+			}
+
+			if err := m.Rotate(context.Background()); err != nil {
+				t.Fatalf("rotate: %v", err)
+			}
+			current, _, _, _ := GenerateToken(Subject{UserID: primitive.NewObjectID(), Email: "a@example.com", Role: "user"})
+			if kidOf(t, current) == oldKid {
+				t.Fatalf("expected new tokens to use the new key")
+			}
+			if _, err := ValidateAccessToken(old); err != nil {
+				t.Fatalf("old tokens must verify during the grace period: %v", err)
+			}
+			if len(JWKS().Keys) != 2 {
+				t.Fatalf("expected both keys in the JWKS, got %+v", JWKS())
+			}
+
+			time.Sleep(250 * time.Millisecond)
+			if _, err := ValidateAccessToken(old); err == nil {
+				t.Fatalf("old tokens must be rejected after the grace period")
+			}
+			if _, err := ValidateAccessToken(current); err != nil {
+				t.Fatalf("current tokens must verify: %v", err)
+			}
+		})
+	}
+}
+
+// El plazo para los tokens HS256 sin kid cuenta desde la primera clave
+// guardada: reiniciar el proceso no lo vuelve a abrir.
+func TestKeyManager_LegacyGraceSurvivesRestarts(t *testing.T) {
+	t.Cleanup(func() { keyManager = nil })
+	keyManager = nil
+	legacy, _, _, err := GenerateToken(Subject{UserID: primitive.NewObjectID(), Email: "a@example.com", Role: "user"})
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+
+	store := memory.NewSigningKeyRepository()
+	opts := KeyManagerOptions{Algorithm: EdDSA, GracePeriod: 30 * time.Minute}
+	first := NewKeyManager(store, opts)
+	if err := first.Load(context.Background()); err != nil {
+		t.Fatalf("load keys: %v", err)
+	}
+	UseKeyManager(first)
+	if _, err := ValidateAccessToken(legacy); err != nil {
+		t.Fatalf("legacy tokens must verify right after switching: %v", err)
+	}
+
+	// Una clave creada hace una hora, como si el cambio hubiera sido antes
+	// del último arranque.
+	old, err := GenerateSigningKey(EdDSA)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	old.CreatedAt = time.Now().Add(-time.Hour)
+	sealed, err := sealKey(old, first.opts.EncryptionKey)
+	if err != nil {
+		t.Fatalf("seal: %v", err)
+	}
+	if err := store.SaveKey(context.Background(), sealed); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	restarted := NewKeyManager(store, opts)
+	if err := restarted.Load(context.Background()); err != nil {
+		t.Fatalf("load keys: %v", err)
+	}
+	UseKeyManager(restarted)
+	if _, err := ValidateAccessToken(legacy); err == nil {
+		t.Fatalf("legacy tokens must be rejected once the grace period since the first key passed")
+	}
+}
+
+func TestValidate_RejectsWrongTokenType(t *testing.T) {
+	useTestKeyManager(t, EdDSA, time.Hour)
+
+	access, refresh, _, err := GenerateToken(Subject{UserID: primitive.NewObjectID(), Email: "a@example.com", Role: "user"})
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	if _, err := ValidateAccessToken(refresh); err == nil {
+		t.Fatalf("a refresh token must not be accepted as an access token")
+	}
+	if _, err := ValidateRefreshToken(access); err == nil {
+		t.Fatalf("an access token must not be accepted as a refresh token")
+	}
+	if _, err := ValidateRefreshToken(refresh); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestValidate_RejectsAlgorithmMismatch(t *testing.T) {
+	m := useTestKeyManager(t, RS256, time.Hour)
+	key, _ := m.signingKey()
+
+	// Un token HS256 con el kid de una clave RSA no debe verificarse usando
+	// la clave pública como secreto.
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, Claims{UserID: "x", Email: "x@example.com", Type: AccessToken})
+	forged.Header["kid"] = key.ID
+	token, err := forged.SignedString([]byte("cualquier secreto"))
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	if _, err := ValidateAccessToken(token); err == nil {
+		t.Fatalf("expected algorithm mismatch to be rejected")
+	}
+}
+
+func TestKeyManager_StoredKeysAreEncrypted(t *testing.T) {
+	store := memory.NewSigningKeyRepository()
+	m := NewKeyManager(store, KeyManagerOptions{Algorithm: EdDSA, GracePeriod: time.Hour, EncryptionKey: []byte("kek")})
+	if err := m.Load(context.Background()); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	other := NewKeyManager(store, KeyManagerOptions{Algorithm: EdDSA, GracePeriod: time.Hour, EncryptionKey: []byte("kek")})
+	if err := other.reload(context.Background()); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	a, _ := m.signingKey()
+	b, err := other.signingKey()
+	if err != nil || a.ID != b.ID {
+		t.Fatalf("instances sharing the store must share the key, got %v, %v", b, err)
+	}
+
+	wrong := NewKeyManager(store, KeyManagerOptions{Algorithm: EdDSA, GracePeriod: time.Hour, EncryptionKey: []byte("otra")})
+	wrong.reload(context.Background())
+	if _, err := wrong.signingKey(); err == nil {
+		t.Fatalf("keys must not open with another encryption key")
+	}
+}
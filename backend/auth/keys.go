@@ -0,0 +1,261 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"time"
+
+	"backend/models"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Algoritmos de firma soportados.
+const (
+	HS256 = "HS256"
+	RS256 = "RS256"
+	EdDSA = "EdDSA"
+)
+
+// SupportedAlgorithm indica si alg es uno de los algoritmos de firma soportados.
+func SupportedAlgorithm(alg string) bool {
+	return alg == HS256 || alg == RS256 || alg == EdDSA
+}
+
+// SigningKey es una clave de firma en memoria, ya descifrada.
+type SigningKey struct {
+	ID        string
+	Algorithm string
+	CreatedAt time.Time
+	// VerifyUntil distinto de cero: la clave está retirada y solo verifica.
+	VerifyUntil time.Time
+
+	private any
+	public  any
+}
+
+func (k *SigningKey) method() jwt.SigningMethod {
+	switch k.Algorithm {
+	case RS256:
+		return jwt.SigningMethodRS256
+	case EdDSA:
+		return jwt.SigningMethodEdDSA
+	default:
+		return jwt.SigningMethodHS256
+	}
+}
+
+// GenerateSigningKey crea una clave nueva para alg con un kid aleatorio.
+func GenerateSigningKey(alg string) (*SigningKey, error) {
+	key := &SigningKey{ID: primitive.NewObjectID().Hex(), Algorithm: alg, CreatedAt: time.Now()}
+	switch alg {
+	case HS256:
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, err
+		}
+		key.private, key.public = secret, secret
+	case RS256:
+		private, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			return nil, err
+		}
+		key.private, key.public = private, &private.PublicKey
+	case EdDSA:
+		public, private, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		key.private, key.public = private, public
+	default:
+		return nil, fmt.Errorf("algoritmo de firma no soportado: %q", alg)
+	}
+	return key, nil
+}
+
+// sealKey cifra la clave privada con AES-GCM para guardarla.
+func sealKey(key *SigningKey, kek []byte) (models.SigningKey, error) {
+	var plain []byte
+	if key.Algorithm == HS256 {
+		plain = key.private.([]byte)
+	} else {
+		der, err := x509.MarshalPKCS8PrivateKey(key.private)
+		if err != nil {
+			return models.SigningKey{}, err
+		}
+		plain = der
+	}
+
+	gcm, err := newGCM(kek)
+	if err != nil {
+		return models.SigningKey{}, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return models.SigningKey{}, err
+	}
+	// El kid va como dato asociado: una clave cifrada no se puede pegar en
+	// otro documento.
+	sealed := gcm.Seal(nonce, nonce, plain, []byte(key.ID))
+
+	stored := models.SigningKey{ID: key.ID, Algorithm: key.Algorithm, PrivateKey: sealed, CreatedAt: key.CreatedAt}
+	if !key.VerifyUntil.IsZero() {
+		until := key.VerifyUntil
+		stored.VerifyUntil = &until
+	}
+	return stored, nil
+}
+
+// openKey descifra una clave guardada con sealKey.
+func openKey(stored models.SigningKey, kek []byte) (*SigningKey, error) {
+	gcm, err := newGCM(kek)
+	if err != nil {
+		return nil, err
+	}
+	if len(stored.PrivateKey) < gcm.NonceSize() {
+		return nil, errors.New("clave cifrada truncada")
+	}
+	nonce, sealed := stored.PrivateKey[:gcm.NonceSize()], stored.PrivateKey[gcm.NonceSize():]
+	plain, err := gcm.Open(nil, nonce, sealed, []byte(stored.ID))
+	if err != nil {
+		return nil, fmt.Errorf("no se pudo descifrar la clave %s: %w", stored.ID, err)
+	}
+
+	key := &SigningKey{ID: stored.ID, Algorithm: stored.Algorithm, CreatedAt: stored.CreatedAt}
+	if stored.VerifyUntil != nil {
+		key.VerifyUntil = *stored.VerifyUntil
+	}
+	switch stored.Algorithm {
+	case HS256:
+		key.private, key.public = plain, plain
+	case RS256, EdDSA:
+		private, err := x509.ParsePKCS8PrivateKey(plain)
+		if err != nil {
+			return nil, err
+		}
+		signer, ok := private.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("clave %s: tipo %T no soportado", stored.ID, private)
+		}
+		key.private, key.public = private, signer.Public()
+	default:
+		return nil, fmt.Errorf("clave %s: algoritmo no soportado %q", stored.ID, stored.Algorithm)
+	}
+	return key, nil
+}
+
+func newGCM(kek []byte) (cipher.AEAD, error) {
+	// Se normaliza a 32 bytes para aceptar claves de cualquier largo.
+	sum := sha256.Sum256(kek)
+	block, err := aes.NewCipher(sum[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// deriveKey deriva una clave para un uso puntual a partir de secret.
+func deriveKey(secret []byte, purpose string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(purpose))
+	return mac.Sum(nil)
+}
+
+// JWK es la representación pública de una clave (RFC 7517).
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// OKP (Ed25519) y EC
+	Curve string `json:"crv,omitempty"`
+	X     string `json:"x,omitempty"`
+	Y     string `json:"y,omitempty"`
+}
+
+// PublicKey decodifica la clave para verificar firmas. Soporta RSA, Ed25519 y
+// EC P-256, que son las que publican los proveedores OIDC habituales.
+func (k JWK) PublicKey() (crypto.PublicKey, error) {
+	dec := base64.RawURLEncoding
+	switch k.KeyType {
+	case "RSA":
+		n, errN := dec.DecodeString(k.N)
+		e, errE := dec.DecodeString(k.E)
+		if errN != nil || errE != nil || len(n) == 0 || len(e) == 0 || len(e) > 4 {
+			return nil, fmt.Errorf("jwk %s: clave RSA inválida", k.KeyID)
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "OKP":
+		x, err := dec.DecodeString(k.X)
+		if k.Curve != "Ed25519" || err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("jwk %s: clave OKP inválida", k.KeyID)
+		}
+		return ed25519.PublicKey(x), nil
+	case "EC":
+		x, errX := dec.DecodeString(k.X)
+		y, errY := dec.DecodeString(k.Y)
+		if k.Curve != "P-256" || errX != nil || errY != nil {
+			return nil, fmt.Errorf("jwk %s: clave EC inválida", k.KeyID)
+		}
+		// ecdh valida que el punto esté en la curva.
+		raw := append(append([]byte{4}, leftPad(x, 32)...), leftPad(y, 32)...)
+		if _, err := ecdh.P256().NewPublicKey(raw); err != nil {
+			return nil, fmt.Errorf("jwk %s: %w", k.KeyID, err)
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	default:
+		return nil, fmt.Errorf("jwk %s: tipo de clave %q no soportado", k.KeyID, k.KeyType)
+	}
+}
+
+func leftPad(b []byte, n int) []byte {
+	if len(b) >= n {
+		return b
+	}
+	return append(make([]byte, n-len(b)), b...)
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// jwk devuelve la clave pública; las claves HS256 son secretas y no se
+// publican.
+func (k *SigningKey) jwk() (JWK, bool) {
+	enc := base64.RawURLEncoding
+	switch public := k.public.(type) {
+	case *rsa.PublicKey:
+		return JWK{
+			KeyType: "RSA", KeyID: k.ID, Use: "sig", Algorithm: RS256,
+			N: enc.EncodeToString(public.N.Bytes()),
+			E: enc.EncodeToString(big.NewInt(int64(public.E)).Bytes()),
+		}, true
+	case ed25519.PublicKey:
+		return JWK{
+			KeyType: "OKP", KeyID: k.ID, Use: "sig", Algorithm: EdDSA,
+			Curve: "Ed25519",
+			X:     enc.EncodeToString(public),
+		}, true
+	default:
+		return JWK{}, false
+	}
+}
@@ -0,0 +1,36 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+)
+
+// NewOpaqueToken genera un token aleatorio para enviar por email (verificación,
+// recuperación de contraseña). A diferencia de un JWT no lleva datos: solo
+// sirve si su hash está guardado.
+func NewOpaqueToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// HashOpaqueToken devuelve el HMAC-SHA256 en hex con el que se guarda un token
+// de NewOpaqueToken, para que una copia de la base no permita usarlo.
+func HashOpaqueToken(token string) string {
+	mac := hmac.New(sha256.New, deriveKey(refreshTokenHashKey(), "opaque-token"))
+	mac.Write([]byte(token))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// HashAPIKey es como HashOpaqueToken pero con la clave de auth.api_keys, que
+// no depende de jwt_secret.
+func HashAPIKey(key []byte, apiKey string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(apiKey))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -2,8 +2,10 @@ package auth
 
 import "golang.org/x/crypto/bcrypt"
 
+var bcryptCost = 14
+
 func HashPassword(password string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), 14)
+	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcryptCost)
 	return string(bytes), err
 }
 
@@ -0,0 +1,26 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+var refreshHashKey []byte
+
+// HashRefreshToken devuelve el HMAC-SHA256 en hex con el que se guarda y se
+// busca un refresh token. Cambiar la clave invalida todas las sesiones.
+func HashRefreshToken(token string) string {
+	mac := hmac.New(sha256.New, refreshTokenHashKey())
+	mac.Write([]byte(token))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// refreshTokenHashKey usa la clave configurada o, si no hay, una derivada del
+// secreto JWT para no usar el mismo material en dos funciones distintas.
+func refreshTokenHashKey() []byte {
+	if len(refreshHashKey) > 0 {
+		return refreshHashKey
+	}
+	return deriveKey(jwtSecret, "refresh-token-hash")
+}
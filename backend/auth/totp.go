@@ -0,0 +1,182 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Parámetros TOTP (RFC 6238) que entienden todas las apps autenticadoras:
+// HMAC-SHA1, 6 dígitos, pasos de 30 segundos.
+const (
+	totpDigits = 6
+	totpPeriod = 30
+	// totpSkew es cuántos pasos antes y después se aceptan, por relojes
+	// desfasados.
+	totpSkew = 1
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+var mfaKey []byte
+
+// NewTOTPSecret genera un secreto de 160 bits en base32, como lo espera el
+// parámetro secret de otpauth://.
+func NewTOTPSecret() (string, error) {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return totpEncoding.EncodeToString(b), nil
+}
+
+// TOTPProvisioningURI arma el otpauth:// que se muestra como QR para enrolar
+// la app autenticadora.
+func TOTPProvisioningURI(issuer, account, secret string) string {
+	q := url.Values{}
+	q.Set("secret", secret)
+	q.Set("issuer", issuer)
+	q.Set("algorithm", "SHA1")
+	q.Set("digits", fmt.Sprint(totpDigits))
+	q.Set("period", fmt.Sprint(totpPeriod))
+	label := url.PathEscape(issuer + ":" + account)
+	return "otpauth://totp/" + label + "?" + q.Encode()
+}
+
+// TOTPCode devuelve el código de secret para el instante t.
+func TOTPCode(secret string, t time.Time) (string, error) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return "", err
+	}
+	return totpCode(key, totpStep(t)), nil
+}
+
+// ValidateTOTP compara code con los pasos alrededor de t y devuelve el paso
+// que coincidió, para que quien llama rechace un código ya usado.
+func ValidateTOTP(secret, code string, t time.Time) (int64, bool) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(secret))
+	if err != nil || len(code) != totpDigits {
+		return 0, false
+	}
+	now := totpStep(t)
+	for step := now - totpSkew; step <= now+totpSkew; step++ {
+		if hmac.Equal([]byte(totpCode(key, step)), []byte(code)) {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+func totpStep(t time.Time) int64 {
+	return t.Unix() / totpPeriod
+}
+
+// totpCode es el HOTP de RFC 4226 con el paso como contador.
+func totpCode(key []byte, step int64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(step))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", totpDigits, value%1_000_000)
+}
+
+// SealTOTPSecret cifra el secreto para guardarlo. userID va como dato asociado:
+// el secreto de un usuario no sirve copiado en otro.
+func SealTOTPSecret(secret, userID string) (string, error) {
+	gcm, err := newGCM(mfaEncryptionKey())
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(secret), []byte(userID))
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func OpenTOTPSecret(sealed, userID string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := newGCM(mfaEncryptionKey())
+	if err != nil {
+		return "", err
+	}
+	if len(data) < gcm.NonceSize() {
+		return "", errors.New("secreto TOTP truncado")
+	}
+	nonce, ciphertext := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+	plain, err := gcm.Open(nil, nonce, ciphertext, []byte(userID))
+	if err != nil {
+		return "", fmt.Errorf("no se pudo descifrar el secreto TOTP: %w", err)
+	}
+	return string(plain), nil
+}
+
+// mfaEncryptionKey usa la clave configurada o una derivada del secreto JWT.
+func mfaEncryptionKey() []byte {
+	if len(mfaKey) > 0 {
+		return mfaKey
+	}
+	return deriveKey(jwtSecret, "totp-secret")
+}
+
+// recoveryEncoding es el base32 de Crockford: sin i, l, o ni u, que se
+// confunden al copiar el código a mano.
+var recoveryEncoding = base32.NewEncoding("0123456789abcdefghjkmnpqrstvwxyz").WithPadding(base32.NoPadding)
+
+// NewRecoveryCodes genera n códigos de un solo uso con forma xxxxx-xxxxx.
+func NewRecoveryCodes(n int) ([]string, error) {
+	codes := make([]string, n)
+	for i := range codes {
+		b := make([]byte, 7)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		s := recoveryEncoding.EncodeToString(b)[:10]
+		codes[i] = s[:5] + "-" + s[5:]
+	}
+	return codes, nil
+}
+
+// HashRecoveryCode normaliza el código (minúsculas, sin guiones ni espacios)
+// y devuelve el hash con el que se guarda.
+func HashRecoveryCode(code string) string {
+	normalized := strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToLower(strings.TrimSpace(code)))
+	return HashOpaqueToken(normalized)
+}
+
+// IsTOTPCode distingue un código de la app (6 dígitos) de un código de
+// recuperación.
+func IsTOTPCode(code string) bool {
+	if len(code) != totpDigits {
+		return false
+	}
+	for _, r := range code {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,72 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// Vectores de RFC 6238, apéndice B (SHA1), truncados a 6 dígitos.
+func TestTOTPCode_RFC6238Vectors(t *testing.T) {
+	secret := totpEncoding.EncodeToString([]byte("12345678901234567890"))
+	cases := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+	for _, tc := range cases {
+		got, err := TOTPCode(secret, time.Unix(tc.unix, 0))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got != tc.code {
+			t.Errorf("TOTPCode(%d) = %s, want %s", tc.unix, got, tc.code)
+		}
+	}
+}
+
+func TestValidateTOTP_AcceptsAdjacentSteps(t *testing.T) {
+	secret, _ := NewTOTPSecret()
+	now := time.Now()
+	prev, _ := TOTPCode(secret, now.Add(-totpPeriod*time.Second))
+	old, _ := TOTPCode(secret, now.Add(-3*totpPeriod*time.Second))
+
+	step, ok := ValidateTOTP(secret, prev, now)
+	if !ok || step != totpStep(now)-1 {
+		t.Fatalf("expected the previous step to be accepted, got %d %v", step, ok)
+	}
+	if _, ok := ValidateTOTP(secret, old, now); ok && old != prev {
+		t.Fatalf("expected a code three steps old to be rejected")
+	}
+}
+
+func TestSealTOTPSecret_BoundToUser(t *testing.T) {
+	sealed, err := SealTOTPSecret("JBSWY3DPEHPK3PXP", "u1")
+	if err != nil {
+		t.Fatalf("seal: %v", err)
+	}
+	if got, err := OpenTOTPSecret(sealed, "u1"); err != nil || got != "JBSWY3DPEHPK3PXP" {
+		t.Fatalf("open: %q %v", got, err)
+	}
+	if _, err := OpenTOTPSecret(sealed, "u2"); err == nil {
+		t.Fatalf("expected a secret sealed for another user to fail")
+	}
+}
+
+func TestHashRecoveryCode_Normalizes(t *testing.T) {
+	codes, err := NewRecoveryCodes(2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(codes[0]) != 11 || codes[0] == codes[1] {
+		t.Fatalf("unexpected codes: %v", codes)
+	}
+	loose := " " + strings.ToUpper(strings.ReplaceAll(codes[0], "-", "")) + " "
+	if HashRecoveryCode(loose) != HashRecoveryCode(codes[0]) {
+		t.Fatalf("expected case, spaces and dashes to be ignored")
+	}
+}
@@ -0,0 +1,118 @@
+// Comando migrate: aplica o revierte las migraciones de datos.
+//
+//	go run ./cmd/migrate -config config.yaml status
+//	go run ./cmd/migrate -config config.yaml up [-to N] [-dry-run]
+//	go run ./cmd/migrate -config config.yaml down [-steps N] [-dry-run]
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"backend/auth"
+	"backend/config"
+	"backend/database"
+	"backend/migrations"
+)
+
+func main() {
+	configPath := flag.String("config", os.Getenv("APP_CONFIG"), "ruta al archivo de configuración (YAML o JSON)")
+	to := flag.Int("to", 0, "up: aplicar hasta esta versión inclusive (0 = todas)")
+	steps := flag.Int("steps", 1, "down: cantidad de migraciones a revertir")
+	dryRun := flag.Bool("dry-run", false, "mostrar qué se ejecutaría sin modificar la base")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "uso: migrate [flags] status|up|down\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if cfg.Storage.Driver != config.StorageMongo {
+		log.Fatalf("las migraciones solo aplican con storage.driver %q", config.StorageMongo)
+	}
+
+	// Algunas migraciones hashean refresh tokens: necesitan la misma clave
+	// que el servidor.
+	auth.Configure(auth.Settings{
+		Secret:              []byte(cfg.Auth.JWTSecret),
+		RefreshTokenHashKey: []byte(cfg.Auth.RefreshTokenHashKey),
+	})
+
+	mongoDB, err := database.NewMongoDB(database.Options{
+		URI:            cfg.Mongo.URI,
+		Database:       cfg.Mongo.Database,
+		ConnectTimeout: cfg.Mongo.ConnectTimeout,
+	})
+	if err != nil {
+		log.Fatalf("no se pudo conectar a MongoDB: %v", err)
+	}
+	defer mongoDB.Disconnect()
+
+	runner, err := migrations.NewRunner(mongoDB.GetDatabase(), migrations.All())
+	if err != nil {
+		log.Fatal(err)
+	}
+	runner.DryRun = *dryRun
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if err := run(ctx, runner, flag.Arg(0), *to, *steps); err != nil {
+		mongoDB.Disconnect()
+		log.Fatal(err)
+	}
+}
+
+func run(ctx context.Context, runner *migrations.Runner, command string, to, steps int) error {
+	switch command {
+	case "status":
+		statuses, err := runner.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, s := range statuses {
+			if s.Applied {
+				fmt.Printf("[x] %s  %s\n", s.Migration, s.AppliedAt.Format("2006-01-02 15:04:05"))
+			} else {
+				fmt.Printf("[ ] %s\n", s.Migration)
+			}
+		}
+		return nil
+	case "up":
+		done, err := runner.Up(ctx, to)
+		report(runner.DryRun, "aplicar", done)
+		return err
+	case "down":
+		done, err := runner.Down(ctx, steps)
+		report(runner.DryRun, "revertir", done)
+		return err
+	default:
+		return fmt.Errorf("comando desconocido %q", command)
+	}
+}
+
+func report(dryRun bool, verb string, done []migrations.Migration) {
+	if len(done) == 0 {
+		fmt.Println("nada para " + verb)
+		return
+	}
+	for _, m := range done {
+		if dryRun {
+			fmt.Printf("se va a %s %s\n", verb, m)
+		} else {
+			fmt.Printf("ok %s\n", m)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+// Comando mock-oidc: proveedor OpenID Connect de prueba para desarrollo local.
+// Aprueba cada login con la identidad de los flags, sin pedir credenciales.
+//
+//	go run ./cmd/mock-oidc -addr :9000 -email ana@example.com
+//
+// Configúrelo en auth.oidc.providers con issuer http://localhost:9000 y el
+// mismo client_id y client_secret.
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+
+	"backend/oidc/oidctest"
+)
+
+func main() {
+	addr := flag.String("addr", "localhost:9000", "dirección en la que escuchar")
+	issuer := flag.String("issuer", "", "emisor anunciado (por defecto http://<addr>)")
+	clientID := flag.String("client-id", "fitness", "client_id aceptado")
+	clientSecret := flag.String("client-secret", "secret", "client_secret aceptado")
+	subject := flag.String("sub", "mock-user", "sub de la identidad")
+	email := flag.String("email", "mock@example.com", "email de la identidad")
+	verified := flag.Bool("email-verified", true, "si el email figura como verificado")
+	name := flag.String("name", "Mock User", "nombre de la identidad")
+	flag.Parse()
+
+	if *issuer == "" {
+		*issuer = "http://" + *addr
+	}
+	p, err := oidctest.New(*issuer, *clientID, *clientSecret)
+	if err != nil {
+		log.Fatal(err)
+	}
+	p.SetIdentity(oidctest.Identity{Subject: *subject, Email: *email, EmailVerified: *verified, Name: *name})
+
+	log.Printf("mock-oidc escuchando en %s (issuer %s)", *addr, *issuer)
+	log.Fatal(http.ListenAndServe(*addr, p))
+}
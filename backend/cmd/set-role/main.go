@@ -0,0 +1,99 @@
+// Comando set-role: asigna un rol a un usuario desde la línea de comandos.
+// Sirve para nombrar al primer administrador; después los roles se cambian
+// con PUT /api/users/:id/role.
+//
+//	go run ./cmd/set-role -config config.yaml -email ana@example.com -role admin
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"backend/auth"
+	"backend/config"
+	"backend/database"
+	"backend/models"
+	"backend/repositories"
+	"backend/services"
+)
+
+func main() {
+	configPath := flag.String("config", os.Getenv("APP_CONFIG"), "ruta al archivo de configuración (YAML o JSON)")
+	email := flag.String("email", "", "email del usuario")
+	role := flag.String("role", "", "rol a asignar: user, coach, moderator o admin")
+	flag.Parse()
+	if *email == "" || !models.Role(*role).Valid() {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if cfg.Storage.Driver != config.StorageMongo {
+		log.Fatalf("set-role solo aplica con storage.driver %q", config.StorageMongo)
+	}
+	// La revocación de los tokens del usuario dura lo que un access token.
+	auth.Configure(auth.Settings{AccessTokenTTL: cfg.Auth.AccessTokenTTL})
+
+	mongoDB, err := database.NewMongoDB(database.Options{
+		URI:            cfg.Mongo.URI,
+		Database:       cfg.Mongo.Database,
+		ConnectTimeout: cfg.Mongo.ConnectTimeout,
+	})
+	if err != nil {
+		log.Fatalf("no se pudo conectar a MongoDB: %v", err)
+	}
+	defer mongoDB.Disconnect()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if err := run(ctx, mongoDB, *email, *role); err != nil {
+		mongoDB.Disconnect()
+		log.Fatal(err)
+	}
+}
+
+func run(ctx context.Context, db database.DB, email, role string) error {
+	users := repositories.NewUserRepository(db)
+	user, err := users.GetUserByEmail(ctx, email)
+	if err != nil {
+		return fmt.Errorf("buscar %s: %w", email, err)
+	}
+	if user.Role == models.Role(role) {
+		fmt.Printf("%s ya tiene el rol %s\n", email, role)
+		return nil
+	}
+
+	if !models.Role(role).Valid() {
+		return fmt.Errorf("rol %q inexistente", role)
+	}
+
+	// Sin usuario que la haga, la acción queda a nombre del comando. Se
+	// registra antes de hacerla, como en la API.
+	err = services.NewAuditService(repositories.NewAuditRepository(db)).Record(ctx, models.AuditEvent{
+		Action:     models.AuditUserRoleChanged,
+		ActorEmail: "cmd/set-role",
+		TargetType: "user",
+		TargetID:   user.ID.Hex(),
+		Details:    map[string]string{"email": user.Email, "from": string(user.Role), "to": role},
+	})
+	if err != nil {
+		return fmt.Errorf("no se pudo registrar en la auditoría, el rol no se cambió: %w", err)
+	}
+
+	revocations := services.NewTokenRevocationService(repositories.NewTokenRevocationRepository(db), 0)
+	svc := services.NewUserService(users, repositories.NewRefreshTokenRepository(db), revocations, nil, repositories.NewAPIKeyRepository(db), repositories.NewLinkedIdentityRepository(db))
+	if err := svc.ChangeRole(ctx, user.ID.Hex(), role); err != nil {
+		return fmt.Errorf("registrado en la auditoría pero el rol no se cambió: %w", err)
+	}
+	fmt.Printf("%s: %s -> %s\n", email, user.Role, role)
+	return nil
+}
@@ -0,0 +1,755 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	EnvDevelopment = "development"
+	EnvProduction  = "production"
+
+	StorageMongo  = "mongo"
+	StorageMemory = "memory"
+
+	MailOutbox = "outbox"
+	MailSMTP   = "smtp"
+)
+
+type Config struct {
+	Env       string          `yaml:"env" json:"env"`
+	Server    ServerConfig    `yaml:"server" json:"server"`
+	Storage   StorageConfig   `yaml:"storage" json:"storage"`
+	Mongo     MongoConfig     `yaml:"mongo" json:"mongo"`
+	Auth      AuthConfig      `yaml:"auth" json:"auth"`
+	Mail      MailConfig      `yaml:"mail" json:"mail"`
+	RateLimit RateLimitConfig `yaml:"rate_limit" json:"rate_limit"`
+}
+
+// RateLimitConfig limita los pedidos por usuario (o IP, sin sesión) en cada
+// grupo de rutas. Store es "memory", con un contador por instancia, o "mongo",
+// compartido entre instancias.
+type RateLimitConfig struct {
+	Enabled  bool              `yaml:"enabled" json:"enabled"`
+	Store    string            `yaml:"store" json:"store"`
+	Policies RateLimitPolicies `yaml:"policies" json:"policies"`
+}
+
+type RateLimitPolicies struct {
+	// Auth se cuenta por IP: las rutas de /auth no tienen sesión.
+	Auth RateLimitPolicy `yaml:"auth" json:"auth"`
+	// API vale para todo /api además de la política de cada grupo.
+	API       RateLimitPolicy `yaml:"api" json:"api"`
+	Exercises RateLimitPolicy `yaml:"exercises" json:"exercises"`
+	Workouts  RateLimitPolicy `yaml:"workouts" json:"workouts"`
+}
+
+// RateLimitPolicy permite Requests pedidos cada Per, con ráfagas de hasta
+// Requests. Roles reemplaza Requests para esos roles. 0 no limita.
+type RateLimitPolicy struct {
+	Requests int            `yaml:"requests" json:"requests"`
+	Per      time.Duration  `yaml:"per" json:"per"`
+	Roles    map[string]int `yaml:"roles" json:"roles"`
+}
+
+type ServerConfig struct {
+	Addr            string        `yaml:"addr" json:"addr"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" json:"shutdown_timeout"`
+	RequestTimeout  time.Duration `yaml:"request_timeout" json:"request_timeout"`
+	CORSOrigins     []string      `yaml:"cors_origins" json:"cors_origins"`
+}
+
+// StorageConfig elige la implementación de los repositorios: "mongo" o
+// "memory" (sin persistencia, pensado para desarrollo y tests de integración).
+type StorageConfig struct {
+	Driver string `yaml:"driver" json:"driver"`
+}
+
+type MongoConfig struct {
+	URI            string        `yaml:"uri" json:"uri"`
+	Database       string        `yaml:"database" json:"database"`
+	MaxPoolSize    uint64        `yaml:"max_pool_size" json:"max_pool_size"`
+	MinPoolSize    uint64        `yaml:"min_pool_size" json:"min_pool_size"`
+	ConnectTimeout time.Duration `yaml:"connect_timeout" json:"connect_timeout"`
+	QueryTimeout   time.Duration `yaml:"query_timeout" json:"query_timeout"`
+	Indexes        IndexConfig   `yaml:"indexes" json:"indexes"`
+}
+
+// IndexConfig controla el bootstrap de índices al arrancar. Con Rebuild los
+// índices cuya definición no coincide se eliminan y se vuelven a crear; si no,
+// solo se reporta el drift en el log.
+type IndexConfig struct {
+	Bootstrap bool `yaml:"bootstrap" json:"bootstrap"`
+	Rebuild   bool `yaml:"rebuild" json:"rebuild"`
+}
+
+type AuthConfig struct {
+	JWTSecret       string        `yaml:"jwt_secret" json:"jwt_secret"`
+	AccessTokenTTL  time.Duration `yaml:"access_token_ttl" json:"access_token_ttl"`
+	RefreshTokenTTL time.Duration `yaml:"refresh_token_ttl" json:"refresh_token_ttl"`
+	BcryptCost      int           `yaml:"bcrypt_cost" json:"bcrypt_cost"`
+	// RefreshTokenHashKey es la clave con la que se hashean los refresh tokens
+	// guardados. Si está vacía se deriva de JWTSecret.
+	RefreshTokenHashKey string `yaml:"refresh_token_hash_key" json:"refresh_token_hash_key"`
+	// RevocationCacheTTL es cuánto se cachean en memoria las revocaciones de
+	// access tokens. Con varias instancias, una revocación hecha en otra tarda
+	// hasta este tiempo en aplicarse. 0 consulta la base en cada pedido.
+	RevocationCacheTTL time.Duration `yaml:"revocation_cache_ttl" json:"revocation_cache_ttl"`
+	// PermissionsCacheTTL es cuánto se cachean los permisos de cada rol. Un
+	// cambio hecho en otra instancia tarda hasta este tiempo en aplicarse.
+	PermissionsCacheTTL time.Duration           `yaml:"permissions_cache_ttl" json:"permissions_cache_ttl"`
+	Signing             SigningConfig           `yaml:"signing" json:"signing"`
+	EmailVerification   EmailVerificationConfig `yaml:"email_verification" json:"email_verification"`
+	PasswordReset       PasswordResetConfig     `yaml:"password_reset" json:"password_reset"`
+	MFA                 MFAConfig               `yaml:"mfa" json:"mfa"`
+	BruteForce          BruteForceConfig        `yaml:"brute_force" json:"brute_force"`
+	OIDC                OIDCConfig              `yaml:"oidc" json:"oidc"`
+	APIKeys             APIKeyConfig            `yaml:"api_keys" json:"api_keys"`
+	Impersonation       ImpersonationConfig     `yaml:"impersonation" json:"impersonation"`
+}
+
+// ImpersonationConfig son los tokens con los que soporte ve la aplicación como
+// un usuario.
+type ImpersonationConfig struct {
+	// TTL es la vigencia de cada token; no puede superar access_token_ttl.
+	TTL time.Duration `yaml:"ttl" json:"ttl"`
+}
+
+// APIKeyConfig limita las API keys que crean los usuarios para scripts e
+// integraciones.
+type APIKeyConfig struct {
+	// Enabled habilita la creación y el uso de claves; requiere HashKey.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// HashKey es la clave del HMAC con el que se guardan las claves. Es
+	// propia y no se deriva de jwt_secret: cambiarla invalida todas las
+	// claves.
+	HashKey string `yaml:"hash_key" json:"hash_key"`
+	// DefaultTTL es la vigencia de las claves creadas sin vencimiento y
+	// MaxTTL, la mayor que se puede pedir.
+	DefaultTTL time.Duration `yaml:"default_ttl" json:"default_ttl"`
+	MaxTTL     time.Duration `yaml:"max_ttl" json:"max_ttl"`
+	// MaxPerUser limita las claves vigentes por usuario; 0 no limita.
+	MaxPerUser int `yaml:"max_per_user" json:"max_per_user"`
+}
+
+// OIDCConfig habilita el login con proveedores OpenID Connect (Google,
+// Microsoft, Keycloak...). Sin proveedores el login externo queda apagado.
+type OIDCConfig struct {
+	// RedirectURL es la página del frontend a la que vuelve el proveedor;
+	// recibe ?code=&state= y llama a POST /auth/oidc/callback. Tiene que
+	// estar registrada en cada proveedor.
+	RedirectURL string `yaml:"redirect_url" json:"redirect_url"`
+	// StateTTL es el tiempo para completar el login en el proveedor.
+	StateTTL  time.Duration        `yaml:"state_ttl" json:"state_ttl"`
+	Providers []OIDCProviderConfig `yaml:"providers" json:"providers"`
+}
+
+type OIDCProviderConfig struct {
+	// Name aparece en las rutas (/auth/oidc/<name>/start) y en las
+	// identidades vinculadas: cambiarlo desvincula a los usuarios.
+	Name string `yaml:"name" json:"name"`
+	// Issuer es la URL del emisor; la configuración se descubre en
+	// <issuer>/.well-known/openid-configuration.
+	Issuer   string `yaml:"issuer" json:"issuer"`
+	ClientID string `yaml:"client_id" json:"client_id"`
+	// ClientSecret también se puede pasar con OIDC_<NAME>_CLIENT_SECRET.
+	ClientSecret string `yaml:"client_secret" json:"client_secret"`
+	// Scopes se piden además de openid.
+	Scopes []string `yaml:"scopes" json:"scopes"`
+}
+
+// BruteForceConfig limita los intentos fallidos de login, refresh y
+// recuperación de contraseña. Pasadas las fallas permitidas, cada intento
+// espera el doble que el anterior desde backoff_base hasta backoff_max.
+type BruteForceConfig struct {
+	EmailFreeAttempts int           `yaml:"email_free_attempts" json:"email_free_attempts"`
+	IPFreeAttempts    int           `yaml:"ip_free_attempts" json:"ip_free_attempts"`
+	BackoffBase       time.Duration `yaml:"backoff_base" json:"backoff_base"`
+	BackoffMax        time.Duration `yaml:"backoff_max" json:"backoff_max"`
+	// LockoutThreshold fallas de un mismo email bloquean la cuenta por
+	// LockoutDuration y envían un link de desbloqueo.
+	LockoutThreshold int           `yaml:"lockout_threshold" json:"lockout_threshold"`
+	LockoutDuration  time.Duration `yaml:"lockout_duration" json:"lockout_duration"`
+	// Window es el tiempo sin fallas tras el que un contador vuelve a cero.
+	Window time.Duration `yaml:"window" json:"window"`
+	// UnlockURL es la página del frontend a la que apunta el link del correo;
+	// recibe ?token= y llama a POST /auth/unlock.
+	UnlockURL string `yaml:"unlock_url" json:"unlock_url"`
+}
+
+type MFAConfig struct {
+	// Issuer es el nombre de la cuenta que muestra la app autenticadora.
+	Issuer string `yaml:"issuer" json:"issuer"`
+	// RequiredRoles deben iniciar sesión con segundo factor: sin él solo
+	// acceden a /api/me para enrolarse. Por defecto son todos los roles con
+	// permisos sobre datos de otros usuarios.
+	RequiredRoles []string `yaml:"required_roles" json:"required_roles"`
+	// EncryptionKey cifra los secretos TOTP guardados; vacía se deriva de
+	// jwt_secret.
+	EncryptionKey string `yaml:"encryption_key" json:"encryption_key"`
+}
+
+type EmailVerificationConfig struct {
+	// LinkURL es la página del frontend a la que apunta el link del correo;
+	// recibe ?token= y llama a POST /auth/verify-email.
+	LinkURL  string        `yaml:"link_url" json:"link_url"`
+	TokenTTL time.Duration `yaml:"token_ttl" json:"token_ttl"`
+	// ResendCooldown es la espera mínima entre dos reenvíos.
+	ResendCooldown time.Duration `yaml:"resend_cooldown" json:"resend_cooldown"`
+	// MaxPerHour limita los correos de verificación por usuario y hora.
+	MaxPerHour int `yaml:"max_per_hour" json:"max_per_hour"`
+}
+
+// SigningConfig elige cómo se firman los JWT. Con HS256 y sin rotación se
+// firma con jwt_secret como siempre; en cualquier otro caso las claves se
+// generan y se guardan cifradas en signing_keys.
+type SigningConfig struct {
+	// Algorithm es HS256, RS256 o EdDSA.
+	Algorithm        string        `yaml:"algorithm" json:"algorithm"`
+	RotationInterval time.Duration `yaml:"rotation_interval" json:"rotation_interval"`
+	// GracePeriod es cuánto siguen verificando las claves retiradas; 0 usa
+	// refresh_token_ttl.
+	GracePeriod time.Duration `yaml:"grace_period" json:"grace_period"`
+	// KeyEncryptionKey cifra las claves privadas guardadas; vacía se deriva
+	// de jwt_secret.
+	KeyEncryptionKey string `yaml:"key_encryption_key" json:"key_encryption_key"`
+}
+
+type PasswordResetConfig struct {
+	// LinkURL es la página del frontend a la que apunta el link del correo;
+	// recibe ?token= y llama a POST /auth/password/reset.
+	LinkURL  string        `yaml:"link_url" json:"link_url"`
+	TokenTTL time.Duration `yaml:"token_ttl" json:"token_ttl"`
+	// MaxPerHour limita los correos de recuperación por email y hora.
+	MaxPerHour int `yaml:"max_per_hour" json:"max_per_hour"`
+}
+
+// MailConfig elige cómo salen los correos: "smtp" o "outbox" (no envía nada,
+// los guarda en OutboxDir para verlos en desarrollo).
+type MailConfig struct {
+	Driver    string     `yaml:"driver" json:"driver"`
+	From      string     `yaml:"from" json:"from"`
+	OutboxDir string     `yaml:"outbox_dir" json:"outbox_dir"`
+	SMTP      SMTPConfig `yaml:"smtp" json:"smtp"`
+}
+
+type SMTPConfig struct {
+	Host     string `yaml:"host" json:"host"`
+	Port     int    `yaml:"port" json:"port"`
+	Username string `yaml:"username" json:"username"`
+	Password string `yaml:"password" json:"password"`
+}
+
+// ManagedKeys indica si las claves de firma las administra un KeyManager.
+func (s SigningConfig) ManagedKeys() bool {
+	return s.Algorithm != "HS256" || s.RotationInterval > 0
+}
+
+func Default() Config {
+	return Config{
+		Env: EnvDevelopment,
+		Server: ServerConfig{
+			Addr:            ":8080",
+			ShutdownTimeout: 10 * time.Second,
+			RequestTimeout:  30 * time.Second,
+		},
+		Storage: StorageConfig{
+			Driver: StorageMongo,
+		},
+		Mongo: MongoConfig{
+			URI:            "mongodb://localhost:27017",
+			Database:       "fitness_db",
+			MaxPoolSize:    100,
+			ConnectTimeout: 10 * time.Second,
+			QueryTimeout:   5 * time.Second,
+			Indexes: IndexConfig{
+				Bootstrap: true,
+			},
+		},
+		Auth: AuthConfig{
+			AccessTokenTTL:      24 * time.Hour,
+			RefreshTokenTTL:     7 * 24 * time.Hour,
+			BcryptCost:          14,
+			RevocationCacheTTL:  10 * time.Second,
+			PermissionsCacheTTL: 10 * time.Second,
+			Signing: SigningConfig{
+				Algorithm: "HS256",
+			},
+			EmailVerification: EmailVerificationConfig{
+				LinkURL:        "http://localhost:3000/verify-email",
+				TokenTTL:       24 * time.Hour,
+				ResendCooldown: time.Minute,
+				MaxPerHour:     5,
+			},
+			PasswordReset: PasswordResetConfig{
+				LinkURL:    "http://localhost:3000/reset-password",
+				TokenTTL:   30 * time.Minute,
+				MaxPerHour: 3,
+			},
+			MFA: MFAConfig{
+				Issuer:        "Fitness",
+				RequiredRoles: []string{"admin", "coach", "moderator"},
+			},
+			BruteForce: BruteForceConfig{
+				EmailFreeAttempts: 3,
+				IPFreeAttempts:    20,
+				BackoffBase:       time.Second,
+				BackoffMax:        5 * time.Minute,
+				LockoutThreshold:  10,
+				LockoutDuration:   30 * time.Minute,
+				Window:            time.Hour,
+				UnlockURL:         "http://localhost:3000/unlock",
+			},
+			OIDC: OIDCConfig{
+				RedirectURL: "http://localhost:3000/oidc/callback",
+				StateTTL:    10 * time.Minute,
+			},
+			APIKeys: APIKeyConfig{
+				DefaultTTL: 90 * 24 * time.Hour,
+				MaxTTL:     365 * 24 * time.Hour,
+				MaxPerUser: 20,
+			},
+			Impersonation: ImpersonationConfig{
+				TTL: 15 * time.Minute,
+			},
+		},
+		Mail: MailConfig{
+			Driver:    MailOutbox,
+			From:      "no-reply@localhost",
+			OutboxDir: "tmp/outbox",
+			SMTP: SMTPConfig{
+				Port: 587,
+			},
+		},
+		RateLimit: RateLimitConfig{
+			Enabled: true,
+			Store:   StorageMemory,
+			Policies: RateLimitPolicies{
+				Auth:      RateLimitPolicy{Requests: 30, Per: time.Minute},
+				API:       RateLimitPolicy{Requests: 300, Per: time.Minute, Roles: map[string]int{"admin": 1200}},
+				Exercises: RateLimitPolicy{Requests: 60, Per: time.Minute, Roles: map[string]int{"admin": 600}},
+				Workouts:  RateLimitPolicy{Requests: 60, Per: time.Minute, Roles: map[string]int{"admin": 600}},
+			},
+		},
+	}
+}
+
+// Load arma la configuración a partir de los valores por defecto, el archivo
+// opcional (YAML o JSON) y las variables de entorno, en ese orden de prioridad.
+func Load(path string) (Config, error) {
+	cfg := Default()
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return Config{}, fmt.Errorf("leyendo archivo de configuración: %w", err)
+		}
+		// YAML es un superconjunto de JSON, así que el mismo decoder sirve para ambos.
+		if err := yaml.Unmarshal(data, &cfg); err != nil {
+			return Config{}, fmt.Errorf("parseando archivo de configuración: %w", err)
+		}
+	}
+
+	if err := applyEnv(&cfg); err != nil {
+		return Config{}, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+func applyEnv(cfg *Config) error {
+	setString(&cfg.Env, "APP_ENV")
+	setString(&cfg.Server.Addr, "HTTP_ADDR")
+	setString(&cfg.Storage.Driver, "STORAGE_DRIVER")
+	setString(&cfg.Mongo.URI, "MONGO_URI")
+	setString(&cfg.Mongo.Database, "MONGO_DATABASE")
+	setString(&cfg.Auth.JWTSecret, "JWT_SECRET")
+	setString(&cfg.Auth.RefreshTokenHashKey, "REFRESH_TOKEN_HASH_KEY")
+	setString(&cfg.Auth.Signing.Algorithm, "JWT_ALGORITHM")
+	setString(&cfg.Auth.Signing.KeyEncryptionKey, "JWT_KEY_ENCRYPTION_KEY")
+	setString(&cfg.Auth.EmailVerification.LinkURL, "EMAIL_VERIFICATION_URL")
+	setString(&cfg.Auth.PasswordReset.LinkURL, "PASSWORD_RESET_URL")
+	setString(&cfg.Auth.BruteForce.UnlockURL, "ACCOUNT_UNLOCK_URL")
+	setString(&cfg.Auth.MFA.Issuer, "MFA_ISSUER")
+	setString(&cfg.Auth.MFA.EncryptionKey, "MFA_ENCRYPTION_KEY")
+	setString(&cfg.Auth.APIKeys.HashKey, "API_KEY_HASH_KEY")
+	setString(&cfg.RateLimit.Store, "RATE_LIMIT_STORE")
+	setString(&cfg.Mail.Driver, "MAIL_DRIVER")
+	setString(&cfg.Mail.From, "MAIL_FROM")
+	setString(&cfg.Mail.OutboxDir, "MAIL_OUTBOX_DIR")
+	setString(&cfg.Mail.SMTP.Host, "SMTP_HOST")
+	setString(&cfg.Mail.SMTP.Username, "SMTP_USERNAME")
+	setString(&cfg.Mail.SMTP.Password, "SMTP_PASSWORD")
+
+	if v, ok := os.LookupEnv("CORS_ORIGINS"); ok {
+		cfg.Server.CORSOrigins = splitList(v)
+	}
+	if v, ok := os.LookupEnv("MFA_REQUIRED_ROLES"); ok {
+		cfg.Auth.MFA.RequiredRoles = splitList(v)
+	}
+	setString(&cfg.Auth.OIDC.RedirectURL, "OIDC_REDIRECT_URL")
+	// Los secretos no deberían quedar en el archivo: cada proveedor toma el
+	// suyo de OIDC_<NAME>_CLIENT_SECRET.
+	for i := range cfg.Auth.OIDC.Providers {
+		p := &cfg.Auth.OIDC.Providers[i]
+		setString(&p.ClientSecret, "OIDC_"+strings.ToUpper(strings.ReplaceAll(p.Name, "-", "_"))+"_CLIENT_SECRET")
+	}
+	if err := setDuration(&cfg.Server.ShutdownTimeout, "SHUTDOWN_TIMEOUT"); err != nil {
+		return err
+	}
+	if err := setDuration(&cfg.Server.RequestTimeout, "REQUEST_TIMEOUT"); err != nil {
+		return err
+	}
+	if err := setDuration(&cfg.Mongo.QueryTimeout, "MONGO_QUERY_TIMEOUT"); err != nil {
+		return err
+	}
+	if err := setDuration(&cfg.Mongo.ConnectTimeout, "MONGO_CONNECT_TIMEOUT"); err != nil {
+		return err
+	}
+	if err := setUint(&cfg.Mongo.MaxPoolSize, "MONGO_MAX_POOL_SIZE"); err != nil {
+		return err
+	}
+	if err := setBool(&cfg.Mongo.Indexes.Bootstrap, "MONGO_INDEX_BOOTSTRAP"); err != nil {
+		return err
+	}
+	if err := setBool(&cfg.Mongo.Indexes.Rebuild, "MONGO_INDEX_REBUILD"); err != nil {
+		return err
+	}
+	if err := setDuration(&cfg.Auth.AccessTokenTTL, "ACCESS_TOKEN_TTL"); err != nil {
+		return err
+	}
+	if err := setDuration(&cfg.Auth.RefreshTokenTTL, "REFRESH_TOKEN_TTL"); err != nil {
+		return err
+	}
+	if err := setDuration(&cfg.Auth.RevocationCacheTTL, "REVOCATION_CACHE_TTL"); err != nil {
+		return err
+	}
+	if err := setDuration(&cfg.Auth.PermissionsCacheTTL, "PERMISSIONS_CACHE_TTL"); err != nil {
+		return err
+	}
+	if err := setDuration(&cfg.Auth.Signing.RotationInterval, "JWT_ROTATION_INTERVAL"); err != nil {
+		return err
+	}
+	if err := setDuration(&cfg.Auth.Signing.GracePeriod, "JWT_GRACE_PERIOD"); err != nil {
+		return err
+	}
+	if err := setInt(&cfg.Auth.BcryptCost, "BCRYPT_COST"); err != nil {
+		return err
+	}
+	if err := setDuration(&cfg.Auth.EmailVerification.TokenTTL, "EMAIL_VERIFICATION_TTL"); err != nil {
+		return err
+	}
+	if err := setDuration(&cfg.Auth.EmailVerification.ResendCooldown, "EMAIL_VERIFICATION_RESEND_COOLDOWN"); err != nil {
+		return err
+	}
+	if err := setDuration(&cfg.Auth.PasswordReset.TokenTTL, "PASSWORD_RESET_TTL"); err != nil {
+		return err
+	}
+	if err := setInt(&cfg.Auth.BruteForce.LockoutThreshold, "LOGIN_LOCKOUT_THRESHOLD"); err != nil {
+		return err
+	}
+	if err := setDuration(&cfg.Auth.BruteForce.LockoutDuration, "LOGIN_LOCKOUT_DURATION"); err != nil {
+		return err
+	}
+	if err := setDuration(&cfg.Auth.BruteForce.BackoffMax, "LOGIN_BACKOFF_MAX"); err != nil {
+		return err
+	}
+	if err := setBool(&cfg.Auth.APIKeys.Enabled, "API_KEYS_ENABLED"); err != nil {
+		return err
+	}
+	if err := setBool(&cfg.RateLimit.Enabled, "RATE_LIMIT_ENABLED"); err != nil {
+		return err
+	}
+	if err := setInt(&cfg.Mail.SMTP.Port, "SMTP_PORT"); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (cfg Config) Validate() error {
+	var errs []error
+
+	if cfg.Env != EnvDevelopment && cfg.Env != EnvProduction {
+		errs = append(errs, fmt.Errorf("env debe ser %q o %q", EnvDevelopment, EnvProduction))
+	}
+	if cfg.Server.Addr == "" {
+		errs = append(errs, errors.New("server.addr requerido"))
+	}
+	if cfg.Server.ShutdownTimeout <= 0 {
+		errs = append(errs, errors.New("server.shutdown_timeout debe ser > 0"))
+	}
+	if cfg.Server.RequestTimeout < 0 || cfg.Mongo.QueryTimeout < 0 {
+		errs = append(errs, errors.New("server.request_timeout y mongo.query_timeout no pueden ser negativos"))
+	}
+	if cfg.Storage.Driver != StorageMongo && cfg.Storage.Driver != StorageMemory {
+		errs = append(errs, fmt.Errorf("storage.driver debe ser %q o %q", StorageMongo, StorageMemory))
+	}
+	if cfg.Env == EnvProduction && cfg.Storage.Driver == StorageMemory {
+		errs = append(errs, errors.New("storage.driver \"memory\" no está permitido en producción"))
+	}
+	if !strings.HasPrefix(cfg.Mongo.URI, "mongodb://") && !strings.HasPrefix(cfg.Mongo.URI, "mongodb+srv://") {
+		errs = append(errs, errors.New("mongo.uri inválida"))
+	}
+	if cfg.Mongo.Database == "" {
+		errs = append(errs, errors.New("mongo.database requerido"))
+	}
+	if cfg.Mongo.MaxPoolSize == 0 || cfg.Mongo.MinPoolSize > cfg.Mongo.MaxPoolSize {
+		errs = append(errs, errors.New("mongo.max_pool_size debe ser > 0 y >= mongo.min_pool_size"))
+	}
+	if cfg.Mongo.ConnectTimeout <= 0 {
+		errs = append(errs, errors.New("mongo.connect_timeout debe ser > 0"))
+	}
+	if cfg.Env == EnvProduction && len(cfg.Auth.JWTSecret) < 32 {
+		errs = append(errs, errors.New("auth.jwt_secret debe tener al menos 32 caracteres en producción"))
+	}
+	if cfg.Auth.RefreshTokenHashKey != "" && len(cfg.Auth.RefreshTokenHashKey) < 32 {
+		errs = append(errs, errors.New("auth.refresh_token_hash_key debe tener al menos 32 caracteres"))
+	}
+	if cfg.Auth.AccessTokenTTL <= 0 {
+		errs = append(errs, errors.New("auth.access_token_ttl debe ser > 0"))
+	}
+	if cfg.Auth.RefreshTokenTTL <= cfg.Auth.AccessTokenTTL {
+		errs = append(errs, errors.New("auth.refresh_token_ttl debe ser mayor que auth.access_token_ttl"))
+	}
+	switch cfg.Auth.Signing.Algorithm {
+	case "HS256", "RS256", "EdDSA":
+	default:
+		errs = append(errs, fmt.Errorf("auth.signing.algorithm %q no soportado (HS256, RS256 o EdDSA)", cfg.Auth.Signing.Algorithm))
+	}
+	if cfg.Auth.Signing.RotationInterval < 0 {
+		errs = append(errs, errors.New("auth.signing.rotation_interval no puede ser negativo"))
+	}
+	if cfg.Auth.Signing.GracePeriod != 0 && cfg.Auth.Signing.GracePeriod < cfg.Auth.RefreshTokenTTL {
+		errs = append(errs, errors.New("auth.signing.grace_period debe cubrir auth.refresh_token_ttl"))
+	}
+	if cfg.Auth.RevocationCacheTTL < 0 {
+		errs = append(errs, errors.New("auth.revocation_cache_ttl no puede ser negativo"))
+	}
+	if cfg.Auth.PermissionsCacheTTL < 0 {
+		errs = append(errs, errors.New("auth.permissions_cache_ttl no puede ser negativo"))
+	}
+	if cfg.Auth.BcryptCost < 4 || cfg.Auth.BcryptCost > 31 {
+		errs = append(errs, errors.New("auth.bcrypt_cost debe estar entre 4 y 31"))
+	}
+	// El límite por hora cuenta los tokens guardados, que el índice TTL borra
+	// al vencer.
+	if cfg.Auth.EmailVerification.TokenTTL < time.Hour {
+		errs = append(errs, errors.New("auth.email_verification.token_ttl debe ser de al menos 1h"))
+	}
+	if cfg.Auth.EmailVerification.ResendCooldown < 0 || cfg.Auth.EmailVerification.MaxPerHour < 0 {
+		errs = append(errs, errors.New("auth.email_verification.resend_cooldown y max_per_hour no pueden ser negativos"))
+	}
+	if cfg.Auth.EmailVerification.LinkURL == "" {
+		errs = append(errs, errors.New("auth.email_verification.link_url requerido"))
+	}
+	// password_resets se borra un día después de creado cada pedido.
+	if cfg.Auth.PasswordReset.TokenTTL <= 0 || cfg.Auth.PasswordReset.TokenTTL > 24*time.Hour {
+		errs = append(errs, errors.New("auth.password_reset.token_ttl debe estar entre 0 y 24h"))
+	}
+	if cfg.Auth.PasswordReset.MaxPerHour < 0 {
+		errs = append(errs, errors.New("auth.password_reset.max_per_hour no puede ser negativo"))
+	}
+	if cfg.Auth.PasswordReset.LinkURL == "" {
+		errs = append(errs, errors.New("auth.password_reset.link_url requerido"))
+	}
+	if cfg.Auth.MFA.Issuer == "" || strings.Contains(cfg.Auth.MFA.Issuer, ":") {
+		errs = append(errs, errors.New("auth.mfa.issuer requerido y sin \":\""))
+	}
+	if cfg.Auth.MFA.EncryptionKey != "" && len(cfg.Auth.MFA.EncryptionKey) < 32 {
+		errs = append(errs, errors.New("auth.mfa.encryption_key debe tener al menos 32 caracteres"))
+	}
+	if bf := cfg.Auth.BruteForce; bf.EmailFreeAttempts < 0 || bf.IPFreeAttempts < 0 {
+		errs = append(errs, errors.New("auth.brute_force.email_free_attempts e ip_free_attempts no pueden ser negativos"))
+	}
+	if bf := cfg.Auth.BruteForce; bf.BackoffBase <= 0 || bf.BackoffMax < bf.BackoffBase {
+		errs = append(errs, errors.New("auth.brute_force.backoff_base debe ser > 0 y no mayor que backoff_max"))
+	}
+	if bf := cfg.Auth.BruteForce; bf.LockoutThreshold <= bf.EmailFreeAttempts || bf.LockoutDuration <= 0 {
+		errs = append(errs, errors.New("auth.brute_force.lockout_threshold debe superar email_free_attempts y lockout_duration ser > 0"))
+	}
+	if cfg.Auth.BruteForce.Window <= 0 {
+		errs = append(errs, errors.New("auth.brute_force.window debe ser > 0"))
+	}
+	if cfg.Auth.BruteForce.UnlockURL == "" {
+		errs = append(errs, errors.New("auth.brute_force.unlock_url requerido"))
+	}
+	errs = append(errs, cfg.Auth.OIDC.validate()...)
+	if k := cfg.Auth.APIKeys; k.DefaultTTL <= 0 || k.MaxTTL < k.DefaultTTL {
+		errs = append(errs, errors.New("auth.api_keys.default_ttl debe ser > 0 y no mayor que max_ttl"))
+	}
+	if cfg.Auth.APIKeys.Enabled && len(cfg.Auth.APIKeys.HashKey) < 32 {
+		errs = append(errs, errors.New("auth.api_keys.hash_key de al menos 32 caracteres requerida con auth.api_keys.enabled"))
+	}
+	if cfg.Auth.APIKeys.MaxPerUser < 0 {
+		errs = append(errs, errors.New("auth.api_keys.max_per_user no puede ser negativo"))
+	}
+	if ttl := cfg.Auth.Impersonation.TTL; ttl <= 0 || ttl > cfg.Auth.AccessTokenTTL {
+		errs = append(errs, errors.New("auth.impersonation.ttl debe ser > 0 y no mayor que auth.access_token_ttl"))
+	}
+	switch cfg.Mail.Driver {
+	case MailOutbox:
+	case MailSMTP:
+		if cfg.Mail.SMTP.Host == "" || cfg.Mail.SMTP.Port <= 0 {
+			errs = append(errs, errors.New("mail.smtp.host y mail.smtp.port requeridos con mail.driver \"smtp\""))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("mail.driver debe ser %q o %q", MailOutbox, MailSMTP))
+	}
+	if cfg.Mail.From == "" {
+		errs = append(errs, errors.New("mail.from requerido"))
+	}
+	switch cfg.RateLimit.Store {
+	case StorageMemory:
+	case StorageMongo:
+		if cfg.Storage.Driver != StorageMongo {
+			errs = append(errs, errors.New("rate_limit.store \"mongo\" requiere storage.driver \"mongo\""))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("rate_limit.store debe ser %q o %q", StorageMemory, StorageMongo))
+	}
+	policies := cfg.RateLimit.Policies
+	for _, p := range []struct {
+		name   string
+		policy RateLimitPolicy
+	}{{"auth", policies.Auth}, {"api", policies.API}, {"exercises", policies.Exercises}, {"workouts", policies.Workouts}} {
+		if err := p.policy.validate(); err != nil {
+			errs = append(errs, fmt.Errorf("rate_limit.policies.%s: %w", p.name, err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("configuración inválida: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+func setString(dst *string, key string) {
+	if v, ok := os.LookupEnv(key); ok && v != "" {
+		*dst = v
+	}
+}
+
+func setDuration(dst *time.Duration, key string) error {
+	v, ok := os.LookupEnv(key)
+	if !ok || v == "" {
+		return nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return fmt.Errorf("%s: %w", key, err)
+	}
+	*dst = d
+	return nil
+}
+
+func setInt(dst *int, key string) error {
+	v, ok := os.LookupEnv(key)
+	if !ok || v == "" {
+		return nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return fmt.Errorf("%s: %w", key, err)
+	}
+	*dst = n
+	return nil
+}
+
+func setUint(dst *uint64, key string) error {
+	v, ok := os.LookupEnv(key)
+	if !ok || v == "" {
+		return nil
+	}
+	n, err := strconv.ParseUint(v, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%s: %w", key, err)
+	}
+	*dst = n
+	return nil
+}
+
+func setBool(dst *bool, key string) error {
+	v, ok := os.LookupEnv(key)
+	if !ok || v == "" {
+		return nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return fmt.Errorf("%s: %w", key, err)
+	}
+	*dst = b
+	return nil
+}
+
+func splitList(v string) []string {
+	var out []string
+	for _, part := range strings.Split(v, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+func (p RateLimitPolicy) validate() error {
+	limited := p.Requests > 0
+	for role, n := range p.Roles {
+		if n < 0 {
+			return fmt.Errorf("roles.%s no puede ser negativo", role)
+		}
+		limited = limited || n > 0
+	}
+	if p.Requests < 0 {
+		return errors.New("requests no puede ser negativo")
+	}
+	if limited && p.Per <= 0 {
+		return errors.New("per debe ser > 0")
+	}
+	return nil
+}
+
+func (c OIDCConfig) validate() []error {
+	if len(c.Providers) == 0 {
+		return nil
+	}
+	var errs []error
+	if !isAbsoluteURL(c.RedirectURL) {
+		errs = append(errs, errors.New("auth.oidc.redirect_url debe ser una URL absoluta"))
+	}
+	if c.StateTTL <= 0 {
+		errs = append(errs, errors.New("auth.oidc.state_ttl debe ser > 0"))
+	}
+	seen := make(map[string]bool, len(c.Providers))
+	for i, p := range c.Providers {
+		if !oidcProviderName.MatchString(p.Name) {
+			errs = append(errs, fmt.Errorf("auth.oidc.providers[%d].name %q inválido (minúsculas, dígitos y guiones)", i, p.Name))
+		} else if seen[p.Name] {
+			errs = append(errs, fmt.Errorf("auth.oidc.providers[%d].name %q repetido", i, p.Name))
+		}
+		seen[p.Name] = true
+		if !isAbsoluteURL(p.Issuer) {
+			errs = append(errs, fmt.Errorf("auth.oidc.providers.%s.issuer debe ser una URL absoluta", p.Name))
+		}
+		if p.ClientID == "" {
+			errs = append(errs, fmt.Errorf("auth.oidc.providers.%s.client_id requerido", p.Name))
+		}
+	}
+	return errs
+}
+
+var oidcProviderName = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+func isAbsoluteURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "https" || u.Scheme == "http") && u.Host != ""
+}
@@ -0,0 +1,132 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoad_DefaultsAreValid(t *testing.T) {
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Mongo.Database != "fitness_db" || cfg.Server.Addr != ":8080" {
+		t.Fatalf("unexpected defaults: %+v", cfg)
+	}
+}
+
+func TestLoad_FileThenEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	content := "mongo:\n  database: staging_db\nauth:\n  access_token_ttl: 15m\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	t.Setenv("MONGO_DATABASE", "override_db")
+	t.Setenv("CORS_ORIGINS", "https://a.example, https://b.example")
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Mongo.Database != "override_db" {
+		t.Fatalf("expected env to override file, got %s", cfg.Mongo.Database)
+	}
+	if cfg.Auth.AccessTokenTTL != 15*time.Minute {
+		t.Fatalf("expected 15m access ttl, got %s", cfg.Auth.AccessTokenTTL)
+	}
+	if len(cfg.Server.CORSOrigins) != 2 {
+		t.Fatalf("expected 2 cors origins, got %v", cfg.Server.CORSOrigins)
+	}
+}
+
+func TestValidate_ProductionRequiresSecret(t *testing.T) {
+	cfg := Default()
+	cfg.Env = EnvProduction
+	if err := cfg.Validate(); err == nil {
+		t.Fatalf("expected error for missing jwt secret in production")
+	}
+	cfg.Auth.JWTSecret = "0123456789abcdef0123456789abcdef"
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestValidate_RateLimit(t *testing.T) {
+	cfg := Default()
+	cfg.Storage.Driver = StorageMemory
+	cfg.RateLimit.Store = StorageMongo
+	if err := cfg.Validate(); err == nil {
+		t.Fatalf("expected error for a mongo rate limit store without mongo storage")
+	}
+
+	cfg = Default()
+	cfg.RateLimit.Policies.Workouts = RateLimitPolicy{Roles: map[string]int{"admin": 10}}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "rate_limit.policies.workouts") {
+		t.Fatalf("expected error for a policy without period, got %v", err)
+	}
+	cfg.RateLimit.Policies.Workouts = RateLimitPolicy{}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("a zero policy disables the limit, got %v", err)
+	}
+}
+
+func TestValidate_APIKeysRequireHashKey(t *testing.T) {
+	cfg := Default()
+	cfg.Auth.APIKeys.Enabled = true
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "auth.api_keys.hash_key") {
+		t.Fatalf("expected error for API keys without hash_key, got %v", err)
+	}
+	cfg.Auth.APIKeys.HashKey = strings.Repeat("k", 32)
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestLoad_OIDCSecretFromEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	content := "auth:\n  oidc:\n    providers:\n      - name: google-work\n        issuer: https://accounts.google.com\n        client_id: abc\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	t.Setenv("OIDC_GOOGLE_WORK_CLIENT_SECRET", "s3cret")
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := cfg.Auth.OIDC.Providers[0].ClientSecret; got != "s3cret" {
+		t.Fatalf("client secret = %q, want it from env", got)
+	}
+}
+
+func TestValidate_OIDCProviders(t *testing.T) {
+	cfg := Default()
+	cfg.Auth.OIDC.Providers = []OIDCProviderConfig{
+		{Name: "google", Issuer: "https://accounts.google.com", ClientID: "abc"},
+		{Name: "google", Issuer: "accounts.google.com"},
+	}
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("expected errors for a repeated provider without issuer URL or client_id")
+	}
+	for _, want := range []string{"repetido", "issuer", "client_id"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("error %q does not mention %q", err, want)
+		}
+	}
+}
+
+func TestValidate_SMTPRequiresHost(t *testing.T) {
+	cfg := Default()
+	cfg.Mail.Driver = MailSMTP
+	if err := cfg.Validate(); err == nil {
+		t.Fatalf("expected error for smtp without host")
+	}
+	cfg.Mail.SMTP.Host = "smtp.example.com"
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
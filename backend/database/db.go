@@ -1,6 +1,8 @@
 package database
 
 import (
+	"context"
+
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
@@ -8,4 +10,7 @@ type DB interface {
 	Connect() error
 	Disconnect() error
 	GetClient() *mongo.Client
+	GetDatabase() *mongo.Database
+	// QueryContext aplica el deadline por defecto de las consultas sobre ctx.
+	QueryContext(ctx context.Context) (context.Context, context.CancelFunc)
 }
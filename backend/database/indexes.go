@@ -0,0 +1,63 @@
+package database
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Indexes es la declaración de los índices de todas las colecciones. Al
+// agregar una consulta nueva en un repositorio, declarar acá el índice que la
+// sostiene.
+var Indexes = []IndexSpec{
+	{Collection: "users", Name: "users_email_unique", Keys: bson.D{{Key: "email", Value: 1}}, Unique: true},
+	{Collection: "users", Name: "users_name", Keys: bson.D{{Key: "name", Value: 1}}},
+	{Collection: "users", Name: "users_created_at", Keys: bson.D{{Key: "created_at", Value: -1}}},
+
+	{Collection: "exercises", Name: "exercises_category_muscle_group", Keys: bson.D{{Key: "category", Value: 1}, {Key: "muscle_group", Value: 1}}},
+	{Collection: "exercises", Name: "exercises_text", Keys: bson.D{{Key: "name", Value: "text"}, {Key: "description", Value: "text"}}},
+
+	{Collection: "routines", Name: "routines_owner_name", Keys: bson.D{{Key: "owner_id", Value: 1}, {Key: "name", Value: 1}}},
+
+	{Collection: "workouts", Name: "workouts_user_completed_at", Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "completed_at", Value: -1}}},
+
+	{Collection: "refresh_tokens", Name: "refresh_tokens_token_hash_unique", Keys: bson.D{{Key: "token_hash", Value: 1}}, Unique: true},
+	{Collection: "refresh_tokens", Name: "refresh_tokens_user", Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "revoked", Value: 1}}},
+	{Collection: "refresh_tokens", Name: "refresh_tokens_family", Keys: bson.D{{Key: "family_id", Value: 1}}},
+	{Collection: "refresh_tokens", Name: "refresh_tokens_expires_at_ttl", Keys: bson.D{{Key: "expires_at", Value: 1}}, ExpireAfter: TTL(0)},
+
+	{Collection: "revoked_tokens", Name: "revoked_tokens_expires_at_ttl", Keys: bson.D{{Key: "expires_at", Value: 1}}, ExpireAfter: TTL(0)},
+	{Collection: "token_watermarks", Name: "token_watermarks_expires_at_ttl", Keys: bson.D{{Key: "expires_at", Value: 1}}, ExpireAfter: TTL(0)},
+
+	{Collection: "email_verifications", Name: "email_verifications_token_hash_unique", Keys: bson.D{{Key: "token_hash", Value: 1}}, Unique: true},
+	{Collection: "email_verifications", Name: "email_verifications_user_created_at", Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}},
+	{Collection: "email_verifications", Name: "email_verifications_expires_at_ttl", Keys: bson.D{{Key: "expires_at", Value: 1}}, ExpireAfter: TTL(0)},
+
+	// Los pedidos se conservan un día después de creados, aunque el token
+	// venza antes, para que cuenten en el límite por email.
+	{Collection: "password_resets", Name: "password_resets_token_hash_unique", Keys: bson.D{{Key: "token_hash", Value: 1}}, Unique: true},
+	{Collection: "password_resets", Name: "password_resets_email_created_at", Keys: bson.D{{Key: "email", Value: 1}, {Key: "created_at", Value: -1}}},
+	{Collection: "password_resets", Name: "password_resets_user", Keys: bson.D{{Key: "user_id", Value: 1}}},
+	{Collection: "password_resets", Name: "password_resets_created_at_ttl", Keys: bson.D{{Key: "created_at", Value: 1}}, ExpireAfter: TTL(24 * time.Hour)},
+
+	{Collection: "login_attempts", Name: "login_attempts_key_unique", Keys: bson.D{{Key: "key", Value: 1}}, Unique: true},
+	{Collection: "login_attempts", Name: "login_attempts_unlock_token_hash", Keys: bson.D{{Key: "unlock_token_hash", Value: 1}}},
+	{Collection: "login_attempts", Name: "login_attempts_expires_at_ttl", Keys: bson.D{{Key: "expires_at", Value: 1}}, ExpireAfter: TTL(0)},
+
+	{Collection: "oidc_states", Name: "oidc_states_state_hash_unique", Keys: bson.D{{Key: "state_hash", Value: 1}}, Unique: true},
+	{Collection: "oidc_states", Name: "oidc_states_expires_at_ttl", Keys: bson.D{{Key: "expires_at", Value: 1}}, ExpireAfter: TTL(0)},
+
+	{Collection: "linked_identities", Name: "linked_identities_provider_subject_unique", Keys: bson.D{{Key: "provider", Value: 1}, {Key: "subject", Value: 1}}, Unique: true},
+	{Collection: "linked_identities", Name: "linked_identities_user", Keys: bson.D{{Key: "user_id", Value: 1}}},
+
+	{Collection: "api_keys", Name: "api_keys_key_hash_unique", Keys: bson.D{{Key: "key_hash", Value: 1}}, Unique: true},
+	{Collection: "api_keys", Name: "api_keys_user_created_at", Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}},
+
+	{Collection: "audit_events", Name: "audit_events_created_at", Keys: bson.D{{Key: "created_at", Value: -1}}},
+	{Collection: "audit_events", Name: "audit_events_actor_created_at", Keys: bson.D{{Key: "actor_id", Value: 1}, {Key: "created_at", Value: -1}}},
+	{Collection: "audit_events", Name: "audit_events_target_created_at", Keys: bson.D{{Key: "target_id", Value: 1}, {Key: "created_at", Value: -1}}},
+
+	{Collection: "rate_limits", Name: "rate_limits_expires_at_ttl", Keys: bson.D{{Key: "expires_at", Value: 1}}, ExpireAfter: TTL(0)},
+
+	{Collection: "signing_keys", Name: "signing_keys_verify_until_ttl", Keys: bson.D{{Key: "verify_until", Value: 1}}, ExpireAfter: TTL(0)},
+}
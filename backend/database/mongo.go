@@ -2,35 +2,75 @@ package database
 
 import (
 	"context"
+	"time"
 
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+type Options struct {
+	URI            string
+	Database       string
+	MaxPoolSize    uint64
+	MinPoolSize    uint64
+	ConnectTimeout time.Duration
+	QueryTimeout   time.Duration
+}
+
 type MongoDB struct {
-	Client *mongo.Client
+	Client  *mongo.Client
+	Options Options
 }
 
-func NewMongoDB() *MongoDB {
-	instancia := &MongoDB{}
-	instancia.Connect()
-	return instancia
+// NewMongoDB abre el pool de conexiones del proceso. Se llama una sola vez al
+// iniciar y la instancia se comparte entre todos los repositorios.
+func NewMongoDB(opts Options) (*MongoDB, error) {
+	instancia := &MongoDB{Options: opts}
+	if err := instancia.Connect(); err != nil {
+		return nil, err
+	}
+	return instancia, nil
 }
 
 func (mongoDB *MongoDB) GetClient() *mongo.Client {
 	return mongoDB.Client
 }
 
+func (mongoDB *MongoDB) GetDatabase() *mongo.Database {
+	return mongoDB.Client.Database(mongoDB.Options.Database)
+}
+
+func (mongoDB *MongoDB) QueryContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if mongoDB.Options.QueryTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, mongoDB.Options.QueryTimeout)
+}
+
 func (mongoDB *MongoDB) Connect() error {
-	clientOptions := options.Client().ApplyURI("mongodb://localhost:27017")
+	clientOptions := options.Client().ApplyURI(mongoDB.Options.URI)
+	if mongoDB.Options.MaxPoolSize > 0 {
+		clientOptions.SetMaxPoolSize(mongoDB.Options.MaxPoolSize)
+	}
+	if mongoDB.Options.MinPoolSize > 0 {
+		clientOptions.SetMinPoolSize(mongoDB.Options.MinPoolSize)
+	}
 
-	client, err := mongo.Connect(context.Background(), clientOptions)
+	timeout := mongoDB.Options.ConnectTimeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	client, err := mongo.Connect(ctx, clientOptions)
 	if err != nil {
 		return err
 	}
 
-	err = client.Ping(context.Background(), nil)
+	err = client.Ping(ctx, nil)
 	if err != nil {
+		_ = client.Disconnect(context.Background())
 		return err
 	}
 
@@ -39,5 +79,8 @@ func (mongoDB *MongoDB) Connect() error {
 }
 
 func (mongoDB *MongoDB) Disconnect() error {
+	if mongoDB.Client == nil {
+		return nil
+	}
 	return mongoDB.Client.Disconnect(context.Background())
 }
@@ -0,0 +1,217 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// IndexSpec declara un índice esperado en una colección. Para índices de
+// texto se usa "text" como valor de cada campo en Keys.
+type IndexSpec struct {
+	Collection string
+	Name       string
+	Keys       bson.D
+	Unique     bool
+	// ExpireAfter != nil crea un índice TTL: los documentos se borran ese
+	// tiempo después de la fecha del campo indexado.
+	ExpireAfter *time.Duration
+}
+
+// TTL devuelve un valor para IndexSpec.ExpireAfter.
+func TTL(d time.Duration) *time.Duration {
+	return &d
+}
+
+func (spec IndexSpec) isText() bool {
+	for _, k := range spec.Keys {
+		if k.Value == "text" {
+			return true
+		}
+	}
+	return false
+}
+
+func (spec IndexSpec) model() mongo.IndexModel {
+	opts := options.Index().SetName(spec.Name)
+	if spec.Unique {
+		opts.SetUnique(true)
+	}
+	if spec.ExpireAfter != nil {
+		opts.SetExpireAfterSeconds(int32(*spec.ExpireAfter / time.Second))
+	}
+	return mongo.IndexModel{Keys: spec.Keys, Options: opts}
+}
+
+// IndexDrift describe una diferencia entre lo declarado y lo que existe en la
+// base que el bootstrap no corrigió.
+type IndexDrift struct {
+	Collection string
+	Index      string
+	Problem    string
+}
+
+func (d IndexDrift) String() string {
+	return fmt.Sprintf("%s.%s: %s", d.Collection, d.Index, d.Problem)
+}
+
+type BootstrapOptions struct {
+	// Rebuild elimina y vuelve a crear los índices cuya definición difiere de
+	// la declarada. Si es false solo se reportan como drift.
+	Rebuild bool
+}
+
+type existingIndex struct {
+	Name               string   `bson:"name"`
+	Key                bson.D   `bson:"key"`
+	Unique             bool     `bson:"unique"`
+	ExpireAfterSeconds *int32   `bson:"expireAfterSeconds"`
+	Weights            bson.Raw `bson:"weights"`
+}
+
+// EnsureIndexes crea los índices faltantes de specs y devuelve el drift
+// encontrado: índices con otra definición e índices no declarados.
+func EnsureIndexes(ctx context.Context, db *mongo.Database, specs []IndexSpec, opts BootstrapOptions) ([]IndexDrift, error) {
+	byCollection := make(map[string][]IndexSpec)
+	var collections []string
+	for _, spec := range specs {
+		if _, ok := byCollection[spec.Collection]; !ok {
+			collections = append(collections, spec.Collection)
+		}
+		byCollection[spec.Collection] = append(byCollection[spec.Collection], spec)
+	}
+
+	var drift []IndexDrift
+	for _, name := range collections {
+		d, err := ensureCollectionIndexes(ctx, db.Collection(name), byCollection[name], opts)
+		if err != nil {
+			return drift, fmt.Errorf("índices de %s: %w", name, err)
+		}
+		drift = append(drift, d...)
+	}
+	return drift, nil
+}
+
+func ensureCollectionIndexes(ctx context.Context, collection *mongo.Collection, specs []IndexSpec, opts BootstrapOptions) ([]IndexDrift, error) {
+	existing, err := listIndexes(ctx, collection)
+	if err != nil {
+		return nil, err
+	}
+
+	var drift []IndexDrift
+	var missing []mongo.IndexModel
+	declared := make(map[string]bool, len(specs))
+	for _, spec := range specs {
+		declared[spec.Name] = true
+		current, ok := existing[spec.Name]
+		if !ok {
+			missing = append(missing, spec.model())
+			continue
+		}
+		problem := compareIndex(spec, current)
+		if problem == "" {
+			continue
+		}
+		if !opts.Rebuild {
+			drift = append(drift, IndexDrift{Collection: collection.Name(), Index: spec.Name, Problem: problem})
+			continue
+		}
+		if _, err := collection.Indexes().DropOne(ctx, spec.Name); err != nil {
+			return drift, err
+		}
+		missing = append(missing, spec.model())
+	}
+
+	if len(missing) > 0 {
+		if _, err := collection.Indexes().CreateMany(ctx, missing); err != nil {
+			return drift, err
+		}
+	}
+
+	var extra []string
+	for name := range existing {
+		if name != "_id_" && !declared[name] {
+			extra = append(extra, name)
+		}
+	}
+	sort.Strings(extra)
+	for _, name := range extra {
+		drift = append(drift, IndexDrift{Collection: collection.Name(), Index: name, Problem: "índice no declarado"})
+	}
+	return drift, nil
+}
+
+func listIndexes(ctx context.Context, collection *mongo.Collection) (map[string]existingIndex, error) {
+	cursor, err := collection.Indexes().List(ctx)
+	if err != nil {
+		// La colección todavía no existe: no tiene índices.
+		if cmdErr, ok := err.(mongo.CommandError); ok && cmdErr.Code == 26 {
+			return map[string]existingIndex{}, nil
+		}
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	out := make(map[string]existingIndex)
+	for cursor.Next(ctx) {
+		var idx existingIndex
+		if err := cursor.Decode(&idx); err != nil {
+			return nil, err
+		}
+		out[idx.Name] = idx
+	}
+	return out, cursor.Err()
+}
+
+func compareIndex(spec IndexSpec, current existingIndex) string {
+	if spec.isText() {
+		want := make([]string, 0, len(spec.Keys))
+		for _, k := range spec.Keys {
+			want = append(want, k.Key)
+		}
+		var got []string
+		if elems, err := current.Weights.Elements(); err == nil {
+			for _, e := range elems {
+				got = append(got, e.Key())
+			}
+		}
+		sort.Strings(want)
+		sort.Strings(got)
+		if strings.Join(want, ",") != strings.Join(got, ",") {
+			return fmt.Sprintf("campos de texto %v, se esperaba %v", got, want)
+		}
+	} else if keyString(spec.Keys) != keyString(current.Key) {
+		return fmt.Sprintf("claves %s, se esperaba %s", keyString(current.Key), keyString(spec.Keys))
+	}
+
+	if spec.Unique != current.Unique {
+		return fmt.Sprintf("unique=%t, se esperaba %t", current.Unique, spec.Unique)
+	}
+
+	wantTTL := int32(-1)
+	if spec.ExpireAfter != nil {
+		wantTTL = int32(*spec.ExpireAfter / time.Second)
+	}
+	gotTTL := int32(-1)
+	if current.ExpireAfterSeconds != nil {
+		gotTTL = *current.ExpireAfterSeconds
+	}
+	if wantTTL != gotTTL {
+		return fmt.Sprintf("expireAfterSeconds=%d, se esperaba %d", gotTTL, wantTTL)
+	}
+	return ""
+}
+
+func keyString(keys bson.D) string {
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, fmt.Sprintf("%s:%v", k.Key, k.Value))
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,32 @@
+package dto
+
+import "time"
+
+type AuditEvent struct {
+	ID         string            `json:"id"`
+	Action     string            `json:"action"`
+	ActorID    string            `json:"actor_id"`
+	ActorEmail string            `json:"actor_email"`
+	TargetType string            `json:"target_type"`
+	TargetID   string            `json:"target_id"`
+	Details    map[string]string `json:"details,omitempty"`
+	IP         string            `json:"ip,omitempty"`
+	UserAgent  string            `json:"user_agent,omitempty"`
+	CreatedAt  time.Time         `json:"created_at"`
+}
+
+// AuditSearch son los filtros del registro de auditoría.
+type AuditSearch struct {
+	ActorID  string `form:"actor_id"`
+	TargetID string `form:"target_id"`
+	Action   string `form:"action"`
+	Page     int    `form:"page" binding:"omitempty,min=1"`
+	PerPage  int    `form:"per_page" binding:"omitempty,min=1,max=100"`
+}
+
+type AuditPage struct {
+	Events  []AuditEvent `json:"events"`
+	Total   int64        `json:"total"`
+	Page    int          `json:"page"`
+	PerPage int          `json:"per_page"`
+}
@@ -1,5 +1,7 @@
 package dto
 
+import "time"
+
 type AuthResponse struct {
 	AccessToken  string `json:"accessToken"`
 	RefreshToken string `json:"refreshToken"`
@@ -11,7 +13,127 @@ type RefreshRequest struct {
 	RefreshToken string `json:"refreshToken"`
 }
 
+// RefreshResponse trae el refresh token nuevo: el que se presentó queda
+// revocado y no puede volver a usarse.
 type RefreshResponse struct {
-	AccessToken string `json:"accessToken"`
+	AccessToken  string `json:"accessToken"`
+	RefreshToken string `json:"refreshToken"`
+	ExpiresIn    int64  `json:"expiresIn"`
+}
+
+type ImpersonateRequest struct {
+	// Reason es el motivo (por ejemplo el número de ticket); queda en el
+	// registro de auditoría.
+	Reason string `json:"reason" binding:"required,max=500"`
+}
+
+// ImpersonationResponse es el token para ver la aplicación como User. No trae
+// refresh token: al vencer hay que pedir otro.
+type ImpersonationResponse struct {
+	AccessToken string    `json:"accessToken"`
+	ExpiresIn   int64     `json:"expiresIn"`
+	ExpiresAt   time.Time `json:"expiresAt"`
+	User        User      `json:"user"`
+}
+
+// Session es un login activo: una familia de refresh tokens. El id se mantiene
+// entre rotaciones.
+type Session struct {
+	ID         string    `json:"id"`
+	UserAgent  string    `json:"userAgent,omitempty"`
+	IP         string    `json:"ip,omitempty"`
+	CreatedAt  time.Time `json:"createdAt"`
+	LastUsedAt time.Time `json:"lastUsedAt,omitempty"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+	// Current marca la sesión del token con el que se hizo el pedido.
+	Current bool `json:"current"`
+}
+
+// MFAChallengeResponse es la respuesta del login cuando el usuario tiene
+// segundo factor: en vez de tokens trae el desafío para /auth/login/mfa.
+type MFAChallengeResponse struct {
+	MFARequired bool   `json:"mfaRequired"`
+	MFAToken    string `json:"mfaToken"`
 	ExpiresIn   int64  `json:"expiresIn"`
 }
+
+// LoginMFARequest completa el login con un código de la app o uno de
+// recuperación.
+type LoginMFARequest struct {
+	MFAToken string `json:"mfaToken" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+// TOTPEnrollment es lo que la app autenticadora necesita: el URI se muestra
+// como QR y el secreto sirve para cargarlo a mano.
+type TOTPEnrollment struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioningUri"`
+}
+
+type MFACodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type DisableMFARequest struct {
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+// RecoveryCodesResponse trae los códigos en claro; no se pueden volver a
+// consultar.
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+// OIDCStartResponse trae la URL del proveedor a la que hay que mandar al
+// usuario. El frontend guarda state y, cuando el proveedor vuelve, lo compara
+// con el recibido antes de llamar al callback.
+type OIDCStartResponse struct {
+	AuthorizationURL string `json:"authorizationUrl"`
+	State            string `json:"state"`
+}
+
+// OIDCCallbackRequest son los parámetros con los que el proveedor redirigió
+// al frontend.
+type OIDCCallbackRequest struct {
+	Code  string `json:"code" binding:"required"`
+	State string `json:"state" binding:"required"`
+}
+
+// LinkedIdentity es una cuenta externa con la que el usuario puede iniciar
+// sesión.
+type LinkedIdentity struct {
+	ID          string     `json:"id"`
+	Provider    string     `json:"provider"`
+	Email       string     `json:"email,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	LastLoginAt *time.Time `json:"lastLoginAt,omitempty"`
+}
+
+// CreateAPIKeyRequest crea una API key. Scopes son "<recurso>:read" o
+// "<recurso>:write" (exercises, routines, workouts). Sin ExpiresAt vence en el
+// plazo por defecto de la configuración.
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name" binding:"required,max=100"`
+	Scopes    []string   `json:"scopes" binding:"required,min=1"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+type APIKey struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  time.Time  `json:"expiresAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+	LastUsedIP string     `json:"lastUsedIp,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+// CreatedAPIKeyResponse trae la clave en claro; no se puede volver a
+// consultar.
+type CreatedAPIKeyResponse struct {
+	APIKey APIKey `json:"apiKey"`
+	Key    string `json:"key"`
+}
@@ -7,18 +7,33 @@ import (
 )
 
 type User struct {
-	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	Name         string             `bson:"name" json:"name"`
-	Email        string             `bson:"email" json:"email"`
-	PasswordHash string             `bson:"password_hash" json:"-"`
-	Role         string             `bson:"role" json:"role"`
-	DateOfBirth  time.Time          `bson:"date_of_birth" json:"date_of_birth"`
-	Weight       float64            `bson:"weight,omitempty" json:"weight,omitempty"`
-	Height       float64            `bson:"height,omitempty" json:"height,omitempty"`
-	Level        string             `bson:"level,omitempty" json:"level,omitempty"`
-	Goals        []string           `bson:"goals,omitempty" json:"goals,omitempty"`
-	CreatedAt    time.Time          `bson:"created_at" json:"created_at"`
-	UpdatedAt    time.Time          `bson:"updated_at" json:"updated_at"`
+	ID              primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Name            string             `bson:"name" json:"name"`
+	Email           string             `bson:"email" json:"email"`
+	PasswordHash    string             `bson:"password_hash" json:"-"`
+	Role            string             `bson:"role" json:"role"`
+	DateOfBirth     time.Time          `bson:"date_of_birth" json:"date_of_birth"`
+	Weight          float64            `bson:"weight,omitempty" json:"weight,omitempty"`
+	Height          float64            `bson:"height,omitempty" json:"height,omitempty"`
+	Level           string             `bson:"level,omitempty" json:"level,omitempty"`
+	Goals           []string           `bson:"goals,omitempty" json:"goals,omitempty"`
+	Language        string             `bson:"language,omitempty" json:"language,omitempty"`
+	EmailVerified   bool               `bson:"email_verified" json:"email_verified"`
+	EmailVerifiedAt *time.Time         `bson:"email_verified_at,omitempty" json:"email_verified_at,omitempty"`
+	MFAEnabled      bool               `bson:"mfa_enabled" json:"mfa_enabled"`
+	Disabled        bool               `bson:"disabled" json:"disabled"`
+	DisabledAt      *time.Time         `bson:"disabled_at,omitempty" json:"disabled_at,omitempty"`
+	CreatedAt       time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt       time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// PublicUser es lo que cualquier usuario autenticado puede ver de otro: sin
+// email, rol ni estado de la cuenta.
+type PublicUser struct {
+	ID    primitive.ObjectID `json:"id"`
+	Name  string             `json:"name"`
+	Level string             `json:"level,omitempty"`
+	Goals []string           `json:"goals,omitempty"`
 }
 
 type RegisterRequest struct {
@@ -30,6 +45,7 @@ type RegisterRequest struct {
 	Height      *float64 `json:"height,omitempty"`
 	Level       string   `json:"level,omitempty"`
 	Goals       []string `json:"goals,omitempty"`
+	Language    string   `json:"language,omitempty"`
 }
 
 type RegisterResponse struct {
@@ -56,9 +72,73 @@ type UpdateUserRequest struct {
 	Height float64  `json:"height,omitempty"`
 	Level  string   `json:"level,omitempty"`
 	Goals  []string `json:"goals,omitempty"`
+	// Language es el idioma de los mensajes de error ("es" o "en"). Se aplica
+	// a los tokens emitidos desde el próximo login.
+	Language string `json:"language,omitempty"`
 }
 
 type ChangePasswordRequest struct {
 	OldPassword string `json:"old_password" binding:"required"`
 	NewPassword string `json:"new_password" binding:"required,min=6"`
 }
+
+type ChangeRoleRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+// UserSearch son los filtros del listado de usuarios para administradores.
+// Query busca en nombre y email.
+type UserSearch struct {
+	Query    string `form:"q"`
+	Role     string `form:"role"`
+	Disabled *bool  `form:"disabled"`
+	Page     int    `form:"page" binding:"omitempty,min=1"`
+	PerPage  int    `form:"per_page" binding:"omitempty,min=1,max=100"`
+}
+
+type UserPage struct {
+	Users   []User `json:"users"`
+	Total   int64  `json:"total"`
+	Page    int    `json:"page"`
+	PerPage int    `json:"per_page"`
+}
+
+type DisableUserRequest struct {
+	Reason string `json:"reason" binding:"max=500"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required,min=6"`
+}
+
+type UnlockAccountRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// RolePermissions son los permisos vigentes de un rol. Default indica que son
+// los de fábrica y no se cambiaron.
+type RolePermissions struct {
+	Role        string     `json:"role"`
+	Permissions []string   `json:"permissions"`
+	Default     bool       `json:"default"`
+	UpdatedAt   *time.Time `json:"updated_at,omitempty"`
+}
+
+type SetRolePermissionsRequest struct {
+	Permissions []string `json:"permissions" binding:"required"`
+}
+
+// MyPermissions es el rol del usuario autenticado y lo que puede hacer con él.
+type MyPermissions struct {
+	Role        string   `json:"role"`
+	Permissions []string `json:"permissions"`
+}
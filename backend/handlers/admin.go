@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"backend/apperrors"
+	"backend/dto"
+	"backend/models"
+	"backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// errAdminSelfAction evita que un administrador se quite el acceso a sí
+// mismo por error; otro administrador puede hacerlo.
+var errAdminSelfAction = apperrors.Conflict("admin_self_action", "no puede aplicar esta acción a su propia cuenta")
+
+// AdminHandler es la administración de usuarios. Cada consulta y cada acción
+// que cambia algo queda en el registro de auditoría antes de hacerse.
+type AdminHandler struct {
+	users services.UserServiceInterface
+	audit services.AuditServiceInterface
+}
+
+func NewAdminHandler(users services.UserServiceInterface, audit services.AuditServiceInterface) *AdminHandler {
+	return &AdminHandler{users: users, audit: audit}
+}
+
+func (h *AdminHandler) ListUsers(c *gin.Context) {
+	var search dto.UserSearch
+	if err := c.ShouldBindQuery(&search); err != nil {
+		c.Error(invalidBody(err))
+		return
+	}
+
+	page, err := h.users.SearchUsers(c.Request.Context(), search)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	details := map[string]string{"q": search.Query, "role": search.Role, "page": strconv.Itoa(page.Page), "total": strconv.FormatInt(page.Total, 10)}
+	if search.Disabled != nil {
+		details["disabled"] = strconv.FormatBool(*search.Disabled)
+	}
+	if !recordAudit(c, h.audit, models.AuditUsersListed, "user", "", details) {
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+func (h *AdminHandler) GetUser(c *gin.Context) {
+	user, err := h.users.GetUserByID(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if !recordAudit(c, h.audit, models.AuditUserViewed, "user", user.ID.Hex(), map[string]string{"email": user.Email}) {
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
+
+// target devuelve el usuario de la ruta. Con notSelf rechaza que sea el
+// usuario del pedido.
+func (h *AdminHandler) target(c *gin.Context, notSelf bool) (dto.User, bool) {
+	id := c.Param("id")
+	if notSelf && id == c.GetString("user_id") {
+		c.Error(errAdminSelfAction)
+		return dto.User{}, false
+	}
+	user, err := h.users.GetUserByID(c.Request.Context(), id)
+	if err != nil {
+		c.Error(err)
+		return dto.User{}, false
+	}
+	return user, true
+}
+
+// ChangeRole cambia el rol de un usuario. Los access tokens que tenga dejan de
+// valer en el momento; los nuevos llevan el rol actualizado.
+func (h *AdminHandler) ChangeRole(c *gin.Context) {
+	var req dto.ChangeRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(invalidBody(err))
+		return
+	}
+	user, ok := h.target(c, true)
+	if !ok {
+		return
+	}
+
+	if user.Role != req.Role {
+		ok = recordAudit(c, h.audit, models.AuditUserRoleChanged, "user", user.ID.Hex(), map[string]string{
+			"email": user.Email,
+			"from":  user.Role,
+			"to":    req.Role,
+		})
+		if !ok {
+			return
+		}
+	}
+	if err := h.users.ChangeRole(c.Request.Context(), user.ID.Hex(), req.Role); err != nil {
+		auditedActionFailed(c, models.AuditUserRoleChanged, user.ID.Hex(), err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Rol actualizado correctamente"})
+}
+
+// DisableUser impide que el usuario inicie sesión o use sus API keys y cierra
+// sus sesiones.
+func (h *AdminHandler) DisableUser(c *gin.Context) {
+	var req dto.DisableUserRequest
+	// El motivo es opcional: un cuerpo vacío también sirve.
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Error(invalidBody(err))
+			return
+		}
+	}
+	h.setDisabled(c, true, req.Reason)
+}
+
+func (h *AdminHandler) EnableUser(c *gin.Context) {
+	h.setDisabled(c, false, "")
+}
+
+func (h *AdminHandler) setDisabled(c *gin.Context, disabled bool, reason string) {
+	user, ok := h.target(c, disabled)
+	if !ok {
+		return
+	}
+
+	action, details := models.AuditUserEnabled, map[string]string{"email": user.Email}
+	if disabled {
+		action = models.AuditUserDisabled
+		if reason != "" {
+			details["reason"] = reason
+		}
+	}
+	if user.Disabled != disabled && !recordAudit(c, h.audit, action, "user", user.ID.Hex(), details) {
+		return
+	}
+	updated, err := h.users.SetDisabled(c.Request.Context(), user.ID.Hex(), disabled)
+	if err != nil {
+		auditedActionFailed(c, action, user.ID.Hex(), err)
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
+
+// RevokeUserSessions cierra todas las sesiones del usuario: tiene que volver
+// a iniciar sesión en todos sus dispositivos.
+func (h *AdminHandler) RevokeUserSessions(c *gin.Context) {
+	user, ok := h.target(c, false)
+	if !ok {
+		return
+	}
+
+	if !recordAudit(c, h.audit, models.AuditUserSessionsRevoked, "user", user.ID.Hex(), map[string]string{"email": user.Email}) {
+		return
+	}
+	if err := h.users.RevokeSessions(c.Request.Context(), user.ID.Hex()); err != nil {
+		auditedActionFailed(c, models.AuditUserSessionsRevoked, user.ID.Hex(), err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "sesiones cerradas"})
+}
+
+func (h *AdminHandler) DeleteUser(c *gin.Context) {
+	user, ok := h.target(c, true)
+	if !ok {
+		return
+	}
+
+	ok = recordAudit(c, h.audit, models.AuditUserDeleted, "user", user.ID.Hex(), map[string]string{
+		"email": user.Email,
+		"role":  user.Role,
+	})
+	if !ok {
+		return
+	}
+	if err := h.users.DeleteUser(c.Request.Context(), user.ID.Hex()); err != nil {
+		auditedActionFailed(c, models.AuditUserDeleted, user.ID.Hex(), err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Usuario eliminado"})
+}
+
+func (h *AdminHandler) ListAuditEvents(c *gin.Context) {
+	var search dto.AuditSearch
+	if err := c.ShouldBindQuery(&search); err != nil {
+		c.Error(invalidBody(err))
+		return
+	}
+
+	page, err := h.audit.List(c.Request.Context(), search)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"backend/dto"
+	"backend/models"
+	"backend/repositories"
+	"backend/repositories/memory"
+	"backend/services"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestDisableUser_RecordsAudit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	admin, target := primitive.NewObjectID(), primitive.NewObjectID()
+	svc := &mockUserService{
+		getByIDFn: func(id string) (dto.User, error) {
+			return dto.User{ID: target, Email: "bob@example.com", Role: "user"}, nil
+		},
+		setDisabledFn: func(id string, disabled bool) (dto.User, error) {
+			return dto.User{ID: target, Disabled: disabled}, nil
+		},
+	}
+	auditRepo := memory.NewAuditRepository()
+	h := NewAdminHandler(svc, services.NewAuditService(auditRepo))
+
+	ctxVals := map[string]interface{}{"user_id": admin.Hex(), "user_email": "admin@example.com"}
+	c, w := makeReqWithCtx(t, "POST", "/api/users/"+target.Hex()+"/disable", dto.DisableUserRequest{Reason: "spam"}, ctxVals)
+	c.Params = gin.Params{{Key: "id", Value: target.Hex()}}
+	serve(c, h.DisableUser)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 got %d body=%s", w.Code, w.Body.String())
+	}
+	events, _, err := auditRepo.List(context.Background(), repositories.AuditFilter{})
+	if err != nil || len(events) != 1 {
+		t.Fatalf("expected one audit event, got %v, %v", events, err)
+	}
+	e := events[0]
+	if e.Action != models.AuditUserDisabled || e.ActorID != admin || e.TargetID != target.Hex() || e.Details["reason"] != "spam" {
+		t.Fatalf("unexpected audit event: %+v", e)
+	}
+}
+
+func TestAdminSelfActionRejected(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	admin := primitive.NewObjectID().Hex()
+	auditRepo := memory.NewAuditRepository()
+	h := NewAdminHandler(&mockUserService{}, services.NewAuditService(auditRepo))
+
+	c, w := makeReqWithCtx(t, "DELETE", "/api/users/"+admin, nil, map[string]interface{}{"user_id": admin})
+	c.Params = gin.Params{{Key: "id", Value: admin}}
+	serve(c, h.DeleteUser)
+
+	if w.Code != http.StatusConflict {
+		t.Fatalf("expected 409 got %d body=%s", w.Code, w.Body.String())
+	}
+	if _, total, _ := auditRepo.List(context.Background(), repositories.AuditFilter{}); total != 0 {
+		t.Fatalf("rejected actions must not be audited, got %d events", total)
+	}
+}
+
+type failingAudit struct{ services.AuditServiceInterface }
+
+func (failingAudit) Record(ctx context.Context, event models.AuditEvent) error {
+	return errors.New("audit store down")
+}
+
+func TestDeleteUser_NotDoneWithoutAudit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	admin, target := primitive.NewObjectID(), primitive.NewObjectID()
+	deleted := false
+	svc := &mockUserService{
+		getByIDFn: func(id string) (dto.User, error) {
+			return dto.User{ID: target, Email: "bob@example.com", Role: "user"}, nil
+		},
+		deleteFn: func(id string) error {
+			deleted = true
+			return nil
+		},
+	}
+	h := NewAdminHandler(svc, failingAudit{})
+
+	c, w := makeReqWithCtx(t, "DELETE", "/api/users/"+target.Hex(), nil, map[string]interface{}{"user_id": admin.Hex()})
+	c.Params = gin.Params{{Key: "id", Value: target.Hex()}}
+	serve(c, h.DeleteUser)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500 got %d body=%s", w.Code, w.Body.String())
+	}
+	if deleted {
+		t.Fatalf("the user must not be deleted when the audit event cannot be written")
+	}
+}
+
+func TestGetUser_AuditsTheRead(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	admin, target := primitive.NewObjectID(), primitive.NewObjectID()
+	svc := &mockUserService{
+		getByIDFn: func(id string) (dto.User, error) {
+			return dto.User{ID: target, Email: "bob@example.com", Role: "user"}, nil
+		},
+	}
+	auditRepo := memory.NewAuditRepository()
+	h := NewAdminHandler(svc, services.NewAuditService(auditRepo))
+
+	c, w := makeReqWithCtx(t, "GET", "/api/users/"+target.Hex(), nil, map[string]interface{}{"user_id": admin.Hex()})
+	c.Params = gin.Params{{Key: "id", Value: target.Hex()}}
+	serve(c, h.GetUser)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 got %d body=%s", w.Code, w.Body.String())
+	}
+	events, _, _ := auditRepo.List(context.Background(), repositories.AuditFilter{Action: models.AuditUserViewed})
+	if len(events) != 1 || events[0].ActorID != admin || events[0].TargetID != target.Hex() {
+		t.Fatalf("expected the read to be audited, got %+v", events)
+	}
+}
+
+// Sin permiso de administración el perfil de otro usuario no muestra email,
+// rol ni el estado de la cuenta.
+func TestGetUserByID_PublicProfileOnly(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	target := primitive.NewObjectID()
+	svc := &mockUserService{
+		getByIDFn: func(id string) (dto.User, error) {
+			return dto.User{ID: target, Name: "Bob", Email: "bob@example.com", Role: "admin", Level: "beginner", MFAEnabled: false}, nil
+		},
+	}
+	h := NewUserHandler(svc, &mockRefreshTokenRepo{}, nil, nil, newThrottle())
+
+	c, w := makeReqWithCtx(t, "GET", "/api/users/"+target.Hex(), nil, map[string]interface{}{"user_id": primitive.NewObjectID().Hex()})
+	c.Params = gin.Params{{Key: "id", Value: target.Hex()}}
+	serve(c, h.GetUserByID)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 got %d body=%s", w.Code, w.Body.String())
+	}
+	var body map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if body["name"] != "Bob" || body["level"] != "beginner" {
+		t.Fatalf("expected the public profile, got %v", body)
+	}
+	for _, field := range []string{"email", "role", "mfa_enabled", "disabled", "email_verified"} {
+		if _, ok := body[field]; ok {
+			t.Fatalf("public profile must not include %q: %v", field, body)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"net/http"
+
+	"backend/dto"
+	"backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type APIKeyHandler struct {
+	service services.APIKeyServiceInterface
+}
+
+func NewAPIKeyHandler(service services.APIKeyServiceInterface) *APIKeyHandler {
+	return &APIKeyHandler{service: service}
+}
+
+// CreateAPIKey devuelve la clave en claro una sola vez.
+func (h *APIKeyHandler) CreateAPIKey(c *gin.Context) {
+	var req dto.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(invalidBody(err))
+		return
+	}
+	userID, ok := c.Get("user_id")
+	if !ok {
+		c.Error(errNotAuthenticated)
+		return
+	}
+
+	created, err := h.service.Create(c.Request.Context(), userID.(string), c.GetStringSlice("user_amr"), req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	logSecurityEvent(c, "api_key_created", "user", userID, "key", created.APIKey.ID, "scopes", created.APIKey.Scopes)
+
+	c.JSON(http.StatusCreated, created)
+}
+
+func (h *APIKeyHandler) ListAPIKeys(c *gin.Context) {
+	userID, ok := c.Get("user_id")
+	if !ok {
+		c.Error(errNotAuthenticated)
+		return
+	}
+
+	keys, err := h.service.List(c.Request.Context(), userID.(string))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, keys)
+}
+
+func (h *APIKeyHandler) RevokeAPIKey(c *gin.Context) {
+	userID, ok := c.Get("user_id")
+	if !ok {
+		c.Error(errNotAuthenticated)
+		return
+	}
+
+	if err := h.service.Revoke(c.Request.Context(), userID.(string), c.Param("id")); err != nil {
+		c.Error(err)
+		return
+	}
+	logSecurityEvent(c, "api_key_revoked", "user", userID, "key", c.Param("id"))
+
+	c.JSON(http.StatusOK, gin.H{"message": "API key revocada"})
+}
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"backend/middleware"
+	"backend/models"
+	"backend/services"
+	"backend/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// recordAudit guarda en el registro de auditoría una acción del usuario del
+// pedido sobre target; durante una suplantación, del administrador. Se llama
+// antes de hacer la acción o de devolver los datos: si el evento no se puede
+// guardar responde el error y devuelve false, y la acción no se hace.
+func recordAudit(c *gin.Context, audit services.AuditServiceInterface, action, targetType, targetID string, details map[string]string) bool {
+	actorID, actorEmail := c.GetString("user_id"), c.GetString("user_email")
+	if middleware.Impersonating(c) {
+		actorID, actorEmail = c.GetString("impersonator_id"), c.GetString("impersonator_email")
+	}
+	actor, _ := utils.ParseObjectID(actorID)
+	err := audit.Record(c.Request.Context(), models.AuditEvent{
+		Action:     action,
+		ActorID:    actor,
+		ActorEmail: actorEmail,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Details:    details,
+		IP:         c.ClientIP(),
+		UserAgent:  c.Request.UserAgent(),
+	})
+	if err != nil {
+		logSecurityEvent(c, "audit_write_failed", "action", action, "actor", actor.Hex(), "target", targetID, "details", details, "err", err)
+		c.Error(err)
+		return false
+	}
+	return true
+}
+
+// auditedActionFailed responde err de una acción que ya quedó en el registro
+// de auditoría, y deja en el log de seguridad que no se completó.
+func auditedActionFailed(c *gin.Context, action, targetID string, err error) {
+	logSecurityEvent(c, "audited_action_failed", "action", action, "target", targetID, "err", err)
+	c.Error(err)
+}
@@ -1,40 +1,57 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 
+	"backend/apperrors"
 	"backend/auth"
-	"backend/database"
 	"backend/dto"
 	"backend/models"
 	"backend/repositories"
 	"backend/services"
-	"time"
+)
+
+var (
+	errRefreshTokenRequired = apperrors.Validation("refresh_token_required", "refreshToken es requerido")
+	errRefreshTokenRevoked  = apperrors.Unauthorized("refresh_token_revoked", "refresh token inválido o revocado")
+	errRefreshTokenReused   = apperrors.Unauthorized("refresh_token_reused", "refresh token ya utilizado, sesión revocada")
+	errAccountDisabled      = apperrors.Forbidden("account_disabled", "la cuenta está deshabilitada")
+	errMFATokenInvalid      = apperrors.Unauthorized("mfa_token_invalid", "desafío de segundo factor inválido o vencido, vuelva a iniciar sesión")
 )
 
 type UserHandler struct {
-	service services.UserServiceInterface
+	service     services.UserServiceInterface
+	refreshRepo repositories.RefreshTokenRepositoryInterface
+	revocations services.TokenRevocationServiceInterface
+	mfa         services.MFAServiceInterface
+	throttle    services.LoginThrottleServiceInterface
 }
 
-func NewUserHandler(service services.UserServiceInterface) *UserHandler {
+func NewUserHandler(service services.UserServiceInterface, refreshRepo repositories.RefreshTokenRepositoryInterface, revocations services.TokenRevocationServiceInterface, mfa services.MFAServiceInterface, throttle services.LoginThrottleServiceInterface) *UserHandler {
 	return &UserHandler{
-		service: service,
+		service:     service,
+		refreshRepo: refreshRepo,
+		revocations: revocations,
+		mfa:         mfa,
+		throttle:    throttle,
 	}
 }
 
 func (handler *UserHandler) Register(c *gin.Context) {
 	var request dto.RegisterRequest
 	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(invalidBody(err))
 		return
 	}
 
-	user, err := handler.service.Register(request)
+	user, err := handler.service.Register(c.Request.Context(), request)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -44,100 +61,330 @@ func (handler *UserHandler) Register(c *gin.Context) {
 func (handler *UserHandler) Login(c *gin.Context) {
 	var req dto.LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(invalidBody(err))
+		return
+	}
+
+	// El intento se cuenta antes de bcrypt: los frenados no cuestan CPU y
+	// los pedidos en paralelo no pasan todos antes de que se sume la falla.
+	ctx := c.Request.Context()
+	if err := handler.throttle.Attempt(ctx, req.Email, c.ClientIP()); err != nil {
+		c.Error(err)
+		return
+	}
+
+	user, err := handler.service.Login(ctx, req)
+	if errors.Is(err, apperrors.ErrUnauthorized) {
+		failAttempt(c, handler.throttle, req.Email, err)
+		return
+	}
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if user.ID == primitive.NilObjectID {
+		failAttempt(c, handler.throttle, req.Email, apperrors.Unauthorized("invalid_credentials", "credenciales inválidas"))
+		return
+	}
+
+	// Con segundo factor la contraseña sola no emite tokens: se devuelve un
+	// desafío que se completa en /auth/login/mfa.
+	if user.MFAEnabled {
+		// El contador del email sigue hasta que se complete el segundo
+		// factor, para que la contraseña correcta no habilite adivinar
+		// códigos sin límite. A la IP sí se le devuelve el intento.
+		if err := handler.throttle.RecordSuccess(ctx, "", c.ClientIP()); err != nil {
+			c.Error(err)
+			return
+		}
+		handler.challengeMFA(c, user, []string{auth.AMRPassword})
+		return
+	}
+
+	if err := handler.throttle.RecordSuccess(ctx, user.Email, c.ClientIP()); err != nil {
+		c.Error(err)
+		return
+	}
+	handler.completeLogin(c, user, []string{auth.AMRPassword})
+}
+
+// LoginMFA completa el login de un usuario con segundo factor. El desafío se
+// invalida al usarse, así que cada intento fallido exige la contraseña de
+// nuevo.
+func (handler *UserHandler) LoginMFA(c *gin.Context) {
+	var req dto.LoginMFARequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(invalidBody(err))
 		return
 	}
 
-	user, err := handler.service.Login(req)
-	if err != nil || user.ID == primitive.NilObjectID {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "credenciales inválidas"})
+	ctx := c.Request.Context()
+	claims, err := auth.ValidateMFAChallenge(req.MFAToken)
+	if err != nil {
+		c.Error(errMFATokenInvalid)
+		return
+	}
+	revoked, err := handler.revocations.IsRevoked(ctx, claims)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if revoked {
+		c.Error(errMFATokenInvalid)
+		return
+	}
+	user, err := handler.service.GetUserByID(ctx, claims.UserID)
+	if errors.Is(err, apperrors.ErrNotFound) {
+		c.Error(errMFATokenInvalid)
+		return
+	}
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	// Un intento frenado no consume el desafío.
+	if err := handler.throttle.Attempt(ctx, user.Email, c.ClientIP()); err != nil {
+		c.Error(err)
+		return
+	}
+	if err := handler.revocations.RevokeToken(ctx, claims); err != nil {
+		c.Error(err)
 		return
 	}
 
-	if !auth.CheckPasswordHash(req.Password, user.PasswordHash) {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "credenciales inválidas"})
+	if err := handler.mfa.VerifyLoginCode(ctx, claims.UserID, req.Code); err != nil {
+		logSecurityEvent(c, "mfa_login_failed", "user", claims.UserID)
+		if errors.Is(err, apperrors.ErrUnauthorized) {
+			failAttempt(c, handler.throttle, user.Email, err)
+			return
+		}
+		c.Error(err)
 		return
 	}
 
-	access, refresh, expiresIn, err := auth.GenerateToken(user.ID, user.Email, string(user.Role))
+	if err := handler.throttle.RecordSuccess(ctx, user.Email, c.ClientIP()); err != nil {
+		c.Error(err)
+		return
+	}
+	handler.completeLogin(c, user, append(claims.AMR, auth.AMROTP))
+}
+
+// challengeMFA responde con el desafío de segundo factor de user, que ya pasó
+// el primero con los métodos de amr.
+func (handler *UserHandler) challengeMFA(c *gin.Context, user dto.User, amr []string) {
+	challenge, expiresIn, err := auth.GenerateMFAChallenge(user.ID, amr)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "no se pudieron generar tokens"})
+		c.Error(err)
 		return
 	}
+	c.JSON(http.StatusOK, dto.MFAChallengeResponse{MFARequired: true, MFAToken: challenge, ExpiresIn: expiresIn})
+}
 
-	db := database.NewMongoDB()
-	refreshRepo := repositories.NewRefreshTokenRepository(db)
-	rt := models.RefreshToken{
-		UserID:    user.ID,
-		Token:     refresh,
-		ExpiresAt: time.Now().Add(7 * 24 * time.Hour),
-		Revoked:   false,
+func (handler *UserHandler) completeLogin(c *gin.Context, user dto.User, amr []string) {
+	// Login ya lo rechaza; esto cubre el segundo factor y el login externo.
+	if user.Disabled {
+		c.Error(errAccountDisabled)
+		return
+	}
+	tokens, err := handler.issueTokens(c, user, models.RefreshToken{AMR: amr})
+	if err != nil {
+		c.Error(err)
+		return
 	}
-	_, _ = refreshRepo.Save(rt)
 
 	c.JSON(http.StatusOK, dto.AuthResponse{
-		AccessToken:  access,
-		RefreshToken: refresh,
-		ExpiresIn:    expiresIn,
+		AccessToken:  tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresIn:    tokens.ExpiresIn,
 		User:         user,
 	})
 }
 
+// issueTokens emite un par de tokens para user y guarda el refresh a partir de
+// rt, que trae el id, la familia, el inicio y los métodos de autenticación de
+// la sesión. Con FamilyID nulo el refresh abre una sesión nueva.
+func (handler *UserHandler) issueTokens(c *gin.Context, user dto.User, rt models.RefreshToken) (dto.RefreshResponse, error) {
+	now := time.Now()
+	if rt.ID.IsZero() {
+		rt.ID = primitive.NewObjectID()
+	}
+	if rt.FamilyID.IsZero() {
+		rt.FamilyID = rt.ID
+	}
+	if rt.SessionStartedAt.IsZero() {
+		rt.SessionStartedAt = now
+	}
+
+	access, refresh, expiresIn, err := auth.GenerateToken(auth.Subject{
+		UserID:    user.ID,
+		Email:     user.Email,
+		Role:      user.Role,
+		Lang:      user.Language,
+		SessionID: rt.FamilyID.Hex(),
+		AMR:       rt.AMR,
+	})
+	if err != nil {
+		return dto.RefreshResponse{}, err
+	}
+
+	rt.UserID = user.ID
+	rt.TokenHash = auth.HashRefreshToken(refresh)
+	rt.ExpiresAt = now.Add(auth.RefreshTokenTTL())
+	rt.UserAgent = c.Request.UserAgent()
+	rt.IP = c.ClientIP()
+	rt.LastUsedAt = now
+	if _, err := handler.refreshRepo.Save(c.Request.Context(), rt); err != nil {
+		return dto.RefreshResponse{}, err
+	}
+
+	return dto.RefreshResponse{AccessToken: access, RefreshToken: refresh, ExpiresIn: expiresIn}, nil
+}
+
 func (handler *UserHandler) Refresh(c *gin.Context) {
 	var req dto.RefreshRequest
 	if err := c.ShouldBindJSON(&req); err != nil || req.RefreshToken == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "refreshToken es requerido"})
+		c.Error(errRefreshTokenRequired)
 		return
 	}
 
-	claims, err := auth.ValidateToken(req.RefreshToken)
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "refresh token inválido o expirado"})
+	ctx := c.Request.Context()
+	if err := handler.throttle.Attempt(ctx, "", c.ClientIP()); err != nil {
+		c.Error(err)
+		return
+	}
+	if _, err := auth.ValidateRefreshToken(req.RefreshToken); err != nil {
+		failAttempt(c, handler.throttle, "", apperrors.Unauthorized("refresh_token_invalid", "refresh token inválido o expirado"))
 		return
 	}
 
-	db := database.NewMongoDB()
-	refreshRepo := repositories.NewRefreshTokenRepository(db)
-	saved, err := refreshRepo.GetByToken(req.RefreshToken)
+	hash := auth.HashRefreshToken(req.RefreshToken)
+	saved, err := handler.refreshRepo.GetByHash(ctx, hash)
+	if err != nil && !errors.Is(err, apperrors.ErrNotFound) {
+		c.Error(err)
+		return
+	}
+	if err == nil && saved.Rotated() {
+		handler.revokeReusedFamily(c, saved)
+		return
+	}
+	if err != nil || saved.Revoked || saved.ExpiresAt.Before(time.Now()) {
+		failAttempt(c, handler.throttle, "", errRefreshTokenRevoked)
+		return
+	}
+	// El token existe y está vigente: no era un intento de adivinarlo.
+	if err := handler.throttle.RecordSuccess(ctx, "", c.ClientIP()); err != nil {
+		c.Error(err)
+		return
+	}
+
+	// Se relee el usuario para que el token nuevo lleve su rol e idioma
+	// actuales y para no renovar sesiones de usuarios eliminados.
+	user, err := handler.service.GetUserByID(ctx, saved.UserID.Hex())
+	if errors.Is(err, apperrors.ErrNotFound) {
+		c.Error(errRefreshTokenRevoked)
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "refresh token inválido o revocado"})
+		c.Error(err)
 		return
 	}
-	if saved.Revoked || saved.ExpiresAt.Before(time.Now()) {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "refresh token inválido o revocado"})
+	if user.Disabled {
+		c.Error(errAccountDisabled)
 		return
 	}
 
-	access, expiresIn, err := auth.GenerateAccessTokenFromStrings(claims.UserID, claims.Email, claims.Role)
+	// El token presentado se invalida antes de emitir el nuevo: si dos
+	// pedidos lo usan a la vez, solo uno gana y el otro es un reuso.
+	next := primitive.NewObjectID()
+	res, err := handler.refreshRepo.MarkRotated(ctx, hash, next)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "no se pudo generar access token"})
+		c.Error(err)
+		return
+	}
+	if res.ModifiedCount == 0 {
+		handler.revokeReusedFamily(c, saved)
 		return
 	}
 
-	c.JSON(http.StatusOK, dto.RefreshResponse{
-		AccessToken: access,
-		ExpiresIn:   expiresIn,
+	tokens, err := handler.issueTokens(c, user, models.RefreshToken{
+		ID:               next,
+		FamilyID:         saved.Family(),
+		SessionStartedAt: saved.StartedAt(),
+		AMR:              saved.AMR,
 	})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// revokeReusedFamily responde a la presentación de un refresh ya rotado:
+// alguien más tiene una copia, así que se cierra toda la familia y tanto el
+// atacante como el usuario legítimo tienen que volver a iniciar sesión.
+func (handler *UserHandler) revokeReusedFamily(c *gin.Context, saved models.RefreshToken) {
+	res, err := handler.refreshRepo.RevokeFamily(c.Request.Context(), saved.Family())
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	logSecurityEvent(c, "refresh_token_reuse",
+		"user", saved.UserID.Hex(),
+		"family", saved.Family().Hex(),
+		"revoked", res.ModifiedCount,
+	)
+	c.Error(errRefreshTokenReused)
+}
+
+// UnlockAccount levanta el bloqueo por intentos fallidos con el token del
+// correo. Como el link de verificación, no requiere sesión.
+func (handler *UserHandler) UnlockAccount(c *gin.Context) {
+	var req dto.UnlockAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(invalidBody(err))
+		return
+	}
+
+	if err := handler.throttle.Unlock(c.Request.Context(), req.Token); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Cuenta desbloqueada, ya puede iniciar sesión"})
 }
 
 func (handler *UserHandler) Logout(c *gin.Context) {
 	var req dto.RefreshRequest
 	if err := c.ShouldBindJSON(&req); err != nil || req.RefreshToken == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "refreshToken es requerido"})
+		c.Error(errRefreshTokenRequired)
 		return
 	}
 
-	_, err := auth.ValidateToken(req.RefreshToken)
+	_, err := auth.ValidateRefreshToken(req.RefreshToken)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "refresh token inválido"})
+		c.Error(apperrors.Validation("refresh_token_invalid", "refresh token inválido"))
 		return
 	}
 
-	db := database.NewMongoDB()
-	refreshRepo := repositories.NewRefreshTokenRepository(db)
-	_, err = refreshRepo.Revoke(req.RefreshToken)
+	hash := auth.HashRefreshToken(req.RefreshToken)
+	rt, err := handler.refreshRepo.GetByHash(c.Request.Context(), hash)
+	if errors.Is(err, apperrors.ErrNotFound) {
+		c.Error(apperrors.Validation("refresh_token_invalid", "refresh token inválido"))
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "no se pudo revocar el token"})
+		c.Error(err)
+		return
+	}
+	if _, err := handler.refreshRepo.Revoke(c.Request.Context(), hash); err != nil {
+		c.Error(err)
+		return
+	}
+	// Los access tokens de la sesión llevan su familia en sid: se revocan
+	// todos, los mande o no el cliente.
+	if err := handler.revocations.RevokeSession(c.Request.Context(), rt.Family().Hex()); err != nil {
+		c.Error(err)
 		return
 	}
 
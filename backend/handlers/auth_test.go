@@ -2,43 +2,162 @@ package handlers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 
+	"backend/apperrors"
 	"backend/auth"
 	"backend/dto"
 	"backend/models"
+	"backend/repositories/memory"
+	"backend/services"
 )
 
 type mockUserService struct {
-	registerFn func(req dto.RegisterRequest) (string, error)
-	loginFn    func(req dto.LoginRequest) (dto.User, error)
+	registerFn    func(req dto.RegisterRequest) (dto.User, error)
+	loginFn       func(req dto.LoginRequest) (dto.User, error)
+	getByIDFn     func(id string) (dto.User, error)
+	setDisabledFn func(id string, disabled bool) (dto.User, error)
+	deleteFn      func(id string) error
 }
 
-func (m *mockUserService) Register(req dto.RegisterRequest) (string, error) {
+func (m *mockUserService) Register(ctx context.Context, req dto.RegisterRequest) (dto.User, error) {
 	if m.registerFn != nil {
 		return m.registerFn(req)
 	}
-	return "", nil
+	return dto.User{}, nil
 }
-func (m *mockUserService) Login(req dto.LoginRequest) (dto.User, error) {
+func (m *mockUserService) Login(ctx context.Context, req dto.LoginRequest) (dto.User, error) {
 	if m.loginFn != nil {
 		return m.loginFn(req)
 	}
 	return dto.User{}, nil
 }
 
-func (m *mockUserService) GetUsers(name string) ([]dto.User, error)                      { return nil, nil }
-func (m *mockUserService) GetUserByID(id string) (dto.User, error)                       { return dto.User{}, nil }
-func (m *mockUserService) UpdateUser(id string, req dto.UpdateUserRequest) error         { return nil }
-func (m *mockUserService) ChangePassword(id string, req dto.ChangePasswordRequest) error { return nil }
-func (m *mockUserService) DeleteUser(id string) error                                    { return nil }
+func (m *mockUserService) GetUsers(ctx context.Context, name string) ([]dto.User, error) {
+	return nil, nil
+}
+func (m *mockUserService) GetUserByID(ctx context.Context, id string) (dto.User, error) {
+	if m.getByIDFn != nil {
+		return m.getByIDFn(id)
+	}
+	return dto.User{}, nil
+}
+func (m *mockUserService) UpdateUser(ctx context.Context, id string, req dto.UpdateUserRequest) error {
+	return nil
+}
+func (m *mockUserService) ChangePassword(ctx context.Context, id string, req dto.ChangePasswordRequest) error {
+	return nil
+}
+func (m *mockUserService) ChangeRole(ctx context.Context, id string, role string) error {
+	return nil
+}
+func (m *mockUserService) DeleteUser(ctx context.Context, id string) error {
+	if m.deleteFn != nil {
+		return m.deleteFn(id)
+	}
+	return nil
+}
+func (m *mockUserService) SearchUsers(ctx context.Context, search dto.UserSearch) (dto.UserPage, error) {
+	return dto.UserPage{}, nil
+}
+func (m *mockUserService) SetDisabled(ctx context.Context, id string, disabled bool) (dto.User, error) {
+	if m.setDisabledFn != nil {
+		return m.setDisabledFn(id, disabled)
+	}
+	return dto.User{}, nil
+}
+func (m *mockUserService) RevokeSessions(ctx context.Context, id string) error { return nil }
+
+type mockRefreshTokenRepo struct {
+	saved           []models.RefreshToken
+	revoked         []primitive.ObjectID
+	revokedFamilies []primitive.ObjectID
+}
+
+func (m *mockRefreshTokenRepo) Save(ctx context.Context, token models.RefreshToken) (*mongo.InsertOneResult, error) {
+	m.saved = append(m.saved, token)
+	return &mongo.InsertOneResult{InsertedID: token.ID}, nil
+}
+func (m *mockRefreshTokenRepo) GetByHash(ctx context.Context, hash string) (models.RefreshToken, error) {
+	for _, rt := range m.saved {
+		if rt.TokenHash == hash {
+			return rt, nil
+		}
+	}
+	return models.RefreshToken{}, apperrors.FromMongo(mongo.ErrNoDocuments)
+}
+func (m *mockRefreshTokenRepo) Revoke(ctx context.Context, token string) (*mongo.UpdateResult, error) {
+	return &mongo.UpdateResult{}, nil
+}
+func (m *mockRefreshTokenRepo) RevokeAllForUser(ctx context.Context, userID primitive.ObjectID) (*mongo.UpdateResult, error) {
+	m.revoked = append(m.revoked, userID)
+	return &mongo.UpdateResult{}, nil
+}
+func (m *mockRefreshTokenRepo) MarkRotated(ctx context.Context, hash string, replacedBy primitive.ObjectID) (*mongo.UpdateResult, error) {
+	for i := range m.saved {
+		if m.saved[i].TokenHash == hash && !m.saved[i].Revoked {
+			m.saved[i].Revoked = true
+			m.saved[i].ReplacedBy = replacedBy
+			return &mongo.UpdateResult{MatchedCount: 1, ModifiedCount: 1}, nil
+		}
+	}
+	return &mongo.UpdateResult{}, nil
+}
+func (m *mockRefreshTokenRepo) RevokeFamily(ctx context.Context, familyID primitive.ObjectID) (*mongo.UpdateResult, error) {
+	m.revokedFamilies = append(m.revokedFamilies, familyID)
+	res := &mongo.UpdateResult{}
+	for i := range m.saved {
+		if m.saved[i].Family() == familyID && !m.saved[i].Revoked {
+			m.saved[i].Revoked = true
+			res.ModifiedCount++
+		}
+	}
+	return res, nil
+}
+
+func (m *mockRefreshTokenRepo) ListActiveForUser(ctx context.Context, userID primitive.ObjectID) ([]models.RefreshToken, error) {
+	var out []models.RefreshToken
+	for _, rt := range m.saved {
+		if rt.UserID == userID && !rt.Revoked {
+			out = append(out, rt)
+		}
+	}
+	return out, nil
+}
+func (m *mockRefreshTokenRepo) RevokeSession(ctx context.Context, userID, sessionID primitive.ObjectID) (*mongo.UpdateResult, error) {
+	res := &mongo.UpdateResult{}
+	for i := range m.saved {
+		if m.saved[i].UserID == userID && m.saved[i].Family() == sessionID && !m.saved[i].Revoked {
+			m.saved[i].Revoked = true
+			res.MatchedCount++
+			res.ModifiedCount++
+		}
+	}
+	return res, nil
+}
+func (m *mockRefreshTokenRepo) RevokeOtherSessions(ctx context.Context, userID, keep primitive.ObjectID) (*mongo.UpdateResult, error) {
+	res := &mongo.UpdateResult{}
+	for i := range m.saved {
+		if m.saved[i].UserID == userID && m.saved[i].Family() != keep && !m.saved[i].Revoked {
+			m.saved[i].Revoked = true
+			res.MatchedCount++
+			res.ModifiedCount++
+		}
+	}
+	return res, nil
+}
 
 func makeReq(t *testing.T, method, path string, body interface{}) (*gin.Context, *httptest.ResponseRecorder) {
 	var buf bytes.Buffer
@@ -55,6 +174,20 @@ func makeReq(t *testing.T, method, path string, body interface{}) (*gin.Context,
 	return c, w
 }
 
+// newThrottle demora un minuto el intento siguiente a la tercera falla de un
+// email.
+func newThrottle() *services.LoginThrottleService {
+	return services.NewLoginThrottleService(memory.NewLoginAttemptRepository(), memory.NewUserRepository(), nil, services.LoginThrottleOptions{
+		EmailFreeAttempts: 2,
+		IPFreeAttempts:    100,
+		BackoffBase:       time.Minute,
+		BackoffMax:        5 * time.Minute,
+		LockoutThreshold:  10,
+		LockoutDuration:   30 * time.Minute,
+		Window:            time.Hour,
+	})
+}
+
 func TestRegister_Success(t *testing.T) {
 
 	gin.SetMode(gin.TestMode)
@@ -71,13 +204,13 @@ func TestRegister_Success(t *testing.T) {
 	}
 
 	msvc := &mockUserService{
-		registerFn: func(req dto.RegisterRequest) (string, error) {
+		registerFn: func(req dto.RegisterRequest) (dto.User, error) {
 
-			return mockedUser.Email, nil
+			return mockedUser, nil
 		},
 	}
 
-	handler := NewUserHandler(msvc)
+	handler := NewUserHandler(msvc, &mockRefreshTokenRepo{}, services.NewTokenRevocationService(memory.NewTokenRevocationRepository(), 0), nil, newThrottle())
 
 	reqBody := dto.RegisterRequest{
 		Name:        "Alice",
@@ -88,17 +221,17 @@ func TestRegister_Success(t *testing.T) {
 
 	c, w := makeReq(t, "POST", "/register", reqBody)
 
-	handler.Register(c)
+	serve(c, handler.Register)
 
 	if w.Code != http.StatusCreated {
 		t.Fatalf("expected status %d got %d", http.StatusCreated, w.Code)
 	}
 
-	var got string
+	var got dto.User
 	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
 		t.Fatalf("unmarshal response: %v", err)
 	}
-	if got != mockedUser.Email {
+	if got.Email != mockedUser.Email {
 		t.Fatalf("unexpected response: %v", got)
 	}
 }
@@ -135,7 +268,8 @@ func TestLogin_Success(t *testing.T) {
 		},
 	}
 
-	handler := NewUserHandler(msvc)
+	refreshRepo := &mockRefreshTokenRepo{}
+	handler := NewUserHandler(msvc, refreshRepo, services.NewTokenRevocationService(memory.NewTokenRevocationRepository(), 0), nil, newThrottle())
 
 	reqBody := dto.LoginRequest{
 		Email:    returnedUser.Email,
@@ -144,7 +278,7 @@ func TestLogin_Success(t *testing.T) {
 
 	c, w := makeReq(t, "POST", "/login", reqBody)
 
-	handler.Login(c)
+	serve(c, handler.Login)
 
 	if w.Code != http.StatusOK {
 		t.Fatalf("expected status %d got %d body: %s", http.StatusOK, w.Code, w.Body.String())
@@ -156,4 +290,279 @@ func TestLogin_Success(t *testing.T) {
 	if resp.User.Email != returnedUser.Email {
 		t.Fatalf("unexpected user in response: %+v", resp.User)
 	}
+	if len(refreshRepo.saved) != 1 || refreshRepo.saved[0].TokenHash != auth.HashRefreshToken(resp.RefreshToken) {
+		t.Fatalf("expected the refresh token hash to be persisted, got %+v", refreshRepo.saved)
+	}
+	if refreshRepo.saved[0].TokenHash == resp.RefreshToken {
+		t.Fatalf("the raw refresh token must not be stored")
+	}
+}
+
+func TestLogin_ThrottledAfterFailures(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	calls := 0
+	msvc := &mockUserService{
+		loginFn: func(req dto.LoginRequest) (dto.User, error) {
+			calls++
+			return dto.User{}, apperrors.Unauthorized("invalid_credentials", "x")
+		},
+	}
+	handler := NewUserHandler(msvc, &mockRefreshTokenRepo{}, services.NewTokenRevocationService(memory.NewTokenRevocationRepository(), 0), nil, newThrottle())
+
+	login := func() *httptest.ResponseRecorder {
+		c, w := makeReq(t, "POST", "/login", dto.LoginRequest{Email: "Dana@example.com", Password: "wrong"})
+		serve(c, handler.Login)
+		return w
+	}
+	for i := 0; i < 3; i++ {
+		if w := login(); w.Code != http.StatusUnauthorized {
+			t.Fatalf("attempt %d: expected 401 got %d", i+1, w.Code)
+		}
+	}
+
+	w := login()
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 got %d body: %s", w.Code, w.Body.String())
+	}
+	if got := w.Header().Get("Retry-After"); got != "60" {
+		t.Fatalf("expected Retry-After 60, got %q", got)
+	}
+	if calls != 3 {
+		t.Fatalf("throttled attempts must not check the password, got %d calls", calls)
+	}
+}
+
+// Una ráfaga de intentos en paralelo no puede pasar el freno antes de que se
+// cuenten las fallas: solo se verifican los intentos libres y el siguiente.
+func TestLogin_ConcurrentFailuresThrottled(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var calls atomic.Int32
+	msvc := &mockUserService{
+		loginFn: func(req dto.LoginRequest) (dto.User, error) {
+			calls.Add(1)
+			// Lo que tarda bcrypt: los demás pedidos llegan mientras tanto.
+			time.Sleep(20 * time.Millisecond)
+			return dto.User{}, apperrors.Unauthorized("invalid_credentials", "x")
+		},
+	}
+	handler := NewUserHandler(msvc, &mockRefreshTokenRepo{}, services.NewTokenRevocationService(memory.NewTokenRevocationRepository(), 0), nil, newThrottle())
+
+	const attempts = 20
+	recorders := make([]*httptest.ResponseRecorder, attempts)
+	var wg sync.WaitGroup
+	for i := range attempts {
+		c, w := makeReq(t, "POST", "/login", dto.LoginRequest{Email: "dana@example.com", Password: "wrong"})
+		recorders[i] = w
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			serve(c, handler.Login)
+		}()
+	}
+	wg.Wait()
+
+	// newThrottle deja pasar dos fallas y una más sin demora.
+	if got := calls.Load(); got != 3 {
+		t.Fatalf("expected only 3 password checks, got %d", got)
+	}
+	throttled := 0
+	for _, w := range recorders {
+		if w.Code == http.StatusTooManyRequests {
+			throttled++
+		}
+	}
+	if throttled != attempts-3 {
+		t.Fatalf("expected %d throttled attempts, got %d", attempts-3, throttled)
+	}
+}
+
+// Un Login que no devuelve usuario se cuenta como intento fallido, igual que
+// una contraseña incorrecta.
+func TestLogin_EmptyUserCountsAsFailure(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	msvc := &mockUserService{
+		loginFn: func(req dto.LoginRequest) (dto.User, error) { return dto.User{}, nil },
+	}
+	handler := NewUserHandler(msvc, &mockRefreshTokenRepo{}, services.NewTokenRevocationService(memory.NewTokenRevocationRepository(), 0), nil, newThrottle())
+
+	for i := 0; i < 3; i++ {
+		c, w := makeReq(t, "POST", "/login", dto.LoginRequest{Email: "erin@example.com", Password: "x"})
+		serve(c, handler.Login)
+		if w.Code != http.StatusUnauthorized {
+			t.Fatalf("attempt %d: expected 401 got %d", i+1, w.Code)
+		}
+	}
+	c, w := makeReq(t, "POST", "/login", dto.LoginRequest{Email: "erin@example.com", Password: "x"})
+	serve(c, handler.Login)
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 got %d body: %s", w.Code, w.Body.String())
+	}
+}
+
+func loginForRefresh(t *testing.T) (*UserHandler, *mockRefreshTokenRepo, dto.AuthResponse) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	user := dto.User{ID: primitive.NewObjectID(), Email: "carla@example.com", Role: string(models.RoleUser)}
+	msvc := &mockUserService{
+		loginFn:   func(req dto.LoginRequest) (dto.User, error) { return user, nil },
+		getByIDFn: func(id string) (dto.User, error) { return user, nil },
+	}
+	refreshRepo := &mockRefreshTokenRepo{}
+	handler := NewUserHandler(msvc, refreshRepo, services.NewTokenRevocationService(memory.NewTokenRevocationRepository(), 0), nil, newThrottle())
+
+	c, w := makeReq(t, "POST", "/login", dto.LoginRequest{Email: user.Email, Password: "secret123"})
+	serve(c, handler.Login)
+	if w.Code != http.StatusOK {
+		t.Fatalf("login: expected 200 got %d body: %s", w.Code, w.Body.String())
+	}
+	var resp dto.AuthResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("unmarshal response: %v", err)
+	}
+	return handler, refreshRepo, resp
+}
+
+func refresh(t *testing.T, handler *UserHandler, token string) (*httptest.ResponseRecorder, dto.RefreshResponse) {
+	t.Helper()
+	c, w := makeReq(t, "POST", "/refresh", dto.RefreshRequest{RefreshToken: token})
+	serve(c, handler.Refresh)
+	var resp dto.RefreshResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	return w, resp
+}
+
+func TestRefresh_RotatesToken(t *testing.T) {
+	handler, refreshRepo, login := loginForRefresh(t)
+
+	w, first := refresh(t, handler, login.RefreshToken)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 got %d body: %s", w.Code, w.Body.String())
+	}
+	if first.RefreshToken == "" || first.RefreshToken == login.RefreshToken {
+		t.Fatalf("expected a new refresh token, got %q", first.RefreshToken)
+	}
+
+	if len(refreshRepo.saved) != 2 {
+		t.Fatalf("expected 2 stored tokens, got %d", len(refreshRepo.saved))
+	}
+	old, current := refreshRepo.saved[0], refreshRepo.saved[1]
+	if !old.Revoked || old.ReplacedBy != current.ID {
+		t.Fatalf("presented token must be revoked and point to its replacement: %+v", old)
+	}
+	if current.FamilyID != old.Family() {
+		t.Fatalf("rotated token must stay in the family %s, got %s", old.Family(), current.FamilyID)
+	}
+
+	if w, _ := refresh(t, handler, first.RefreshToken); w.Code != http.StatusOK {
+		t.Fatalf("the new refresh token must be usable, got %d", w.Code)
+	}
+}
+
+func TestRefresh_ReuseRevokesFamily(t *testing.T) {
+	handler, refreshRepo, login := loginForRefresh(t)
+
+	_, rotated := refresh(t, handler, login.RefreshToken)
+
+	w, _ := refresh(t, handler, login.RefreshToken)
+	if w.Code != http.StatusUnauthorized || !strings.Contains(w.Body.String(), "refresh_token_reused") {
+		t.Fatalf("expected refresh_token_reused, got %d body: %s", w.Code, w.Body.String())
+	}
+	if len(refreshRepo.revokedFamilies) != 1 || refreshRepo.revokedFamilies[0] != refreshRepo.saved[0].Family() {
+		t.Fatalf("expected the family to be revoked, got %v", refreshRepo.revokedFamilies)
+	}
+
+	if w, _ := refresh(t, handler, rotated.RefreshToken); w.Code != http.StatusUnauthorized {
+		t.Fatalf("tokens of a revoked family must be rejected, got %d", w.Code)
+	}
+}
+
+func TestSessions_ListAndRevokeOthers(t *testing.T) {
+	handler, _, phone := loginForRefresh(t)
+
+	c, w := makeReq(t, "POST", "/login", dto.LoginRequest{Email: "carla@example.com", Password: "secret123"})
+	c.Request.Header.Set("User-Agent", "laptop")
+	serve(c, handler.Login)
+	var laptop dto.AuthResponse
+	json.Unmarshal(w.Body.Bytes(), &laptop)
+	claims, err := auth.ValidateAccessToken(laptop.AccessToken)
+	if err != nil || claims.SessionID == "" {
+		t.Fatalf("expected the access token to carry the session id, got %+v, %v", claims, err)
+	}
+
+	list := func() []dto.Session {
+		c, w := makeReq(t, "GET", "/me/sessions", nil)
+		c.Set("user_id", claims.UserID)
+		c.Set("session_id", claims.SessionID)
+		serve(c, handler.ListSessions)
+		var sessions []dto.Session
+		if err := json.Unmarshal(w.Body.Bytes(), &sessions); err != nil {
+			t.Fatalf("unmarshal sessions: %v body: %s", err, w.Body.String())
+		}
+		return sessions
+	}
+
+	sessions := list()
+	if len(sessions) != 2 {
+		t.Fatalf("expected 2 sessions, got %+v", sessions)
+	}
+	for _, s := range sessions {
+		if s.Current != (s.ID == claims.SessionID) {
+			t.Fatalf("only the laptop session must be current: %+v", s)
+		}
+		if s.Current && s.UserAgent != "laptop" {
+			t.Fatalf("expected device metadata, got %+v", s)
+		}
+	}
+
+	c, w = makeReq(t, "DELETE", "/me/sessions", nil)
+	c.Set("user_id", claims.UserID)
+	c.Set("session_id", claims.SessionID)
+	serve(c, handler.RevokeOtherSessions)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 got %d body: %s", w.Code, w.Body.String())
+	}
+
+	if sessions := list(); len(sessions) != 1 || !sessions[0].Current {
+		t.Fatalf("expected only the current session to remain, got %+v", sessions)
+	}
+	if w, _ := refresh(t, handler, phone.RefreshToken); w.Code != http.StatusUnauthorized {
+		t.Fatalf("the revoked session must not refresh, got %d", w.Code)
+	}
+}
+
+func TestRevokeSession_OtherUserIsNotFound(t *testing.T) {
+	handler, refreshRepo, _ := loginForRefresh(t)
+
+	c, w := makeReq(t, "DELETE", "/me/sessions/x", nil)
+	c.Set("user_id", primitive.NewObjectID().Hex())
+	c.Params = gin.Params{{Key: "id", Value: refreshRepo.saved[0].Family().Hex()}}
+	serve(c, handler.RevokeSession)
+
+	if w.Code != http.StatusNotFound || refreshRepo.saved[0].Revoked {
+		t.Fatalf("expected 404 without revoking, got %d body: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestLogout_RevokesAccessToken(t *testing.T) {
+	handler, _, login := loginForRefresh(t)
+	claims, err := auth.ValidateAccessToken(login.AccessToken)
+	if err != nil {
+		t.Fatalf("validate access token: %v", err)
+	}
+
+	c, w := makeReq(t, "POST", "/logout", dto.RefreshRequest{RefreshToken: login.RefreshToken})
+	c.Request.Header.Set("Authorization", "Bearer "+login.AccessToken)
+	serve(c, handler.Logout)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 got %d body: %s", w.Code, w.Body.String())
+	}
+
+	revoked, err := handler.revocations.IsRevoked(context.Background(), claims)
+	if err != nil || !revoked {
+		t.Fatalf("expected the access token to be revoked, got %v, %v", revoked, err)
+	}
 }
@@ -0,0 +1,16 @@
+package handlers
+
+import (
+	"backend/apperrors"
+)
+
+// Los handlers no escriben respuestas de error: registran el error con
+// c.Error y middleware.Errors lo renderiza con el status y el código.
+
+var errNotAuthenticated = apperrors.Unauthorized("not_authenticated", "Usuario no autenticado")
+
+var errInvalidBody = apperrors.Validation("invalid_body", "cuerpo de la solicitud inválido")
+
+func invalidBody(err error) error {
+	return apperrors.WithDetail(errInvalidBody, err.Error())
+}
@@ -2,7 +2,9 @@ package handlers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -11,6 +13,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 
+	"backend/apperrors"
 	"backend/dto"
 	"backend/models"
 )
@@ -22,39 +25,54 @@ type mockExerciseService struct {
 	updateFn  func(id string, req dto.ExerciseRequest) (dto.ExerciseResponse, error)
 	deleteFn  func(ownerID, exerciseID string) error
 	searchFn  func(search dto.ExerciseSearch) ([]dto.ExerciseResponse, error)
+
+	moderateFn  func(id string, req dto.ExerciseRequest) (dto.ExerciseResponse, error)
+	deleteAnyFn func(exerciseID string) error
 }
 
-func (m *mockExerciseService) GetExercises(name, category, muscleGroup string) ([]models.Exercise, error) {
+func (m *mockExerciseService) GetExercises(ctx context.Context, name, category, muscleGroup string) ([]models.Exercise, error) {
 	if m.getListFn != nil {
 		return m.getListFn(name, category, muscleGroup)
 	}
 	return nil, nil
 }
-func (m *mockExerciseService) GetExerciseByID(id string) (models.Exercise, error) {
+func (m *mockExerciseService) GetExerciseByID(ctx context.Context, id string) (models.Exercise, error) {
 	if m.getByIDFn != nil {
 		return m.getByIDFn(id)
 	}
 	return models.Exercise{}, nil
 }
-func (m *mockExerciseService) CreateExercise(req dto.ExerciseRequest) (dto.ExerciseResponse, error) {
+func (m *mockExerciseService) CreateExercise(ctx context.Context, req dto.ExerciseRequest) (dto.ExerciseResponse, error) {
 	if m.createFn != nil {
 		return m.createFn(req)
 	}
 	return dto.ExerciseResponse{}, nil
 }
-func (m *mockExerciseService) UpdateExercise(id string, req dto.ExerciseRequest) (dto.ExerciseResponse, error) {
+func (m *mockExerciseService) UpdateExercise(ctx context.Context, id string, req dto.ExerciseRequest) (dto.ExerciseResponse, error) {
 	if m.updateFn != nil {
 		return m.updateFn(id, req)
 	}
 	return dto.ExerciseResponse{}, nil
 }
-func (m *mockExerciseService) DeleteExercise(ownerID, exerciseID string) error {
+func (m *mockExerciseService) DeleteExercise(ctx context.Context, ownerID, exerciseID string) error {
 	if m.deleteFn != nil {
 		return m.deleteFn(ownerID, exerciseID)
 	}
 	return nil
 }
-func (m *mockExerciseService) SearchExercises(search dto.ExerciseSearch) ([]dto.ExerciseResponse, error) {
+func (m *mockExerciseService) ModerateExercise(ctx context.Context, id string, req dto.ExerciseRequest) (dto.ExerciseResponse, error) {
+	if m.moderateFn != nil {
+		return m.moderateFn(id, req)
+	}
+	return dto.ExerciseResponse{}, nil
+}
+func (m *mockExerciseService) DeleteAnyExercise(ctx context.Context, exerciseID string) error {
+	if m.deleteAnyFn != nil {
+		return m.deleteAnyFn(exerciseID)
+	}
+	return nil
+}
+func (m *mockExerciseService) SearchExercises(ctx context.Context, search dto.ExerciseSearch) ([]dto.ExerciseResponse, error) {
 	if m.searchFn != nil {
 		return m.searchFn(search)
 	}
@@ -109,7 +127,7 @@ func TestGetExercise_ByID_Success(t *testing.T) {
 
 	c.Params = gin.Params{{Key: "id", Value: "123"}}
 
-	h.GetExercise(c)
+	serve(c, h.GetExercise)
 
 	if w.Code != http.StatusOK {
 		t.Fatalf("expected status %d got %d body=%s", http.StatusOK, w.Code, w.Body.String())
@@ -145,7 +163,7 @@ func TestGetExercise_Search_Success(t *testing.T) {
 	q.Add("name", "Squat")
 	c.Request.URL.RawQuery = q.Encode()
 
-	h.GetExercise(c)
+	serve(c, h.GetExercise)
 
 	if w.Code != http.StatusOK {
 		t.Fatalf("expected status %d got %d body=%s", http.StatusOK, w.Code, w.Body.String())
@@ -162,7 +180,7 @@ func TestCreateExercise_Unauthorized(t *testing.T) {
 
 	c, w := makeReqWithCtx(t, "POST", "/exercises", req, nil)
 
-	h.CreateExercise(c)
+	serve(c, h.CreateExercise)
 
 	if w.Code != http.StatusUnauthorized {
 		t.Fatalf("expected status %d got %d body=%s", http.StatusUnauthorized, w.Code, w.Body.String())
@@ -185,7 +203,7 @@ func TestCreateExercise_Success(t *testing.T) {
 	req := dto.ExerciseRequest{Name: "Bench", Category: "c", MuscleGroup: "m", Difficulty: "d"}
 	c, w := makeReqWithCtx(t, "POST", "/exercises", req, ctxVals)
 
-	h.CreateExercise(c)
+	serve(c, h.CreateExercise)
 
 	if w.Code != http.StatusCreated {
 		t.Fatalf("expected status %d got %d body=%s", http.StatusCreated, w.Code, w.Body.String())
@@ -201,7 +219,7 @@ func TestUpdateExercise_BadRequest_NoID(t *testing.T) {
 	req := dto.ExerciseRequest{Name: "Bench", Category: "c", MuscleGroup: "m", Difficulty: "d"}
 	c, w := makeReqWithCtx(t, "PUT", "/exercises/", req, ctxVals)
 
-	h.UpdateExercise(c)
+	serve(c, h.UpdateExercise)
 
 	if w.Code != http.StatusBadRequest {
 		t.Fatalf("expected status %d got %d body=%s", http.StatusBadRequest, w.Code, w.Body.String())
@@ -216,9 +234,89 @@ func TestDeleteExercise_MissingID(t *testing.T) {
 	ctxVals := map[string]interface{}{"user_id": primitive.NewObjectID().Hex(), "user_role": "admin"}
 	c, w := makeReqWithCtx(t, "DELETE", "/exercises/", nil, ctxVals)
 
-	h.DeleteExercise(c)
+	serve(c, h.DeleteExercise)
 
 	if w.Code != http.StatusBadRequest {
 		t.Fatalf("expected status %d got %d body=%s", http.StatusBadRequest, w.Code, w.Body.String())
 	}
 }
+
+func TestDeleteExercise_ForbiddenEnvelope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	userID := primitive.NewObjectID().Hex()
+	var gotOwner, gotExercise string
+	svc := &mockExerciseService{deleteFn: func(ownerID, exerciseID string) error {
+		gotOwner, gotExercise = ownerID, exerciseID
+		return apperrors.Forbidden("not_exercise_owner", "unauthorized: cannot delete exercise you do not own")
+	}}
+	h := NewExerciseHandler(svc)
+	ctxVals := map[string]interface{}{"user_id": userID, "user_role": "admin"}
+	c, w := makeReqWithCtx(t, "DELETE", "/exercises/e1", nil, ctxVals)
+	c.Params = gin.Params{{Key: "id", Value: "e1"}}
+
+	serve(c, h.DeleteExercise)
+
+	if gotOwner != userID || gotExercise != "e1" {
+		t.Fatalf("service called with owner=%q exercise=%q", gotOwner, gotExercise)
+	}
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected status %d got %d body=%s", http.StatusForbidden, w.Code, w.Body.String())
+	}
+	var body map[string]string
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	if body["code"] != "not_exercise_owner" || body["error"] == "" {
+		t.Fatalf("unexpected error envelope: %v", body)
+	}
+}
+
+func TestDeleteExercise_ModeratorSkipsOwnerCheck(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var gotExercise string
+	svc := &mockExerciseService{
+		deleteFn: func(ownerID, exerciseID string) error {
+			t.Fatalf("owner-checked delete called for a moderator")
+			return nil
+		},
+		deleteAnyFn: func(exerciseID string) error {
+			gotExercise = exerciseID
+			return nil
+		},
+	}
+	h := NewExerciseHandler(svc)
+	ctxVals := map[string]interface{}{
+		"user_id":          primitive.NewObjectID().Hex(),
+		"user_role":        "coach",
+		"user_permissions": []string{string(models.PermExerciseWrite), string(models.PermExerciseModerate)},
+	}
+	c, w := makeReqWithCtx(t, "DELETE", "/exercises/e1", nil, ctxVals)
+	c.Params = gin.Params{{Key: "id", Value: "e1"}}
+
+	serve(c, h.DeleteExercise)
+
+	if w.Code != http.StatusOK || gotExercise != "e1" {
+		t.Fatalf("expected moderated delete of e1, got status %d exercise=%q body=%s", w.Code, gotExercise, w.Body.String())
+	}
+}
+
+func TestGetExercise_InternalErrorIsNotLeaked(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	svc := &mockExerciseService{getListFn: func(name, category, muscleGroup string) ([]models.Exercise, error) {
+		return nil, errors.New("connection refused 10.0.0.3:27017")
+	}}
+	h := NewExerciseHandler(svc)
+	c, w := makeReqWithCtx(t, "GET", "/exercises", nil, nil)
+
+	serve(c, h.GetExercise)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("expected status %d got %d", http.StatusInternalServerError, w.Code)
+	}
+	if bytes.Contains(w.Body.Bytes(), []byte("10.0.0.3")) || !bytes.Contains(w.Body.Bytes(), []byte(`"internal_error"`)) {
+		t.Fatalf("unexpected body: %s", w.Body.String())
+	}
+}
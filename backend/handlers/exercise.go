@@ -1,14 +1,18 @@
 package handlers
 
 import (
+	"backend/apperrors"
 	"backend/dto"
 	"backend/middleware"
+	"backend/models"
 	"backend/services"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 )
 
+var errMissingExerciseID = apperrors.Validation("id_required", "Missing exercise ID")
+
 type ExerciseHandler struct {
 	service services.ExerciseInterface
 }
@@ -20,9 +24,9 @@ func NewExerciseHandler(service services.ExerciseInterface) *ExerciseHandler {
 func (h *ExerciseHandler) GetExercise(c *gin.Context) {
 
 	if id := c.Param("id"); id != "" {
-		exercise, err := h.service.GetExerciseByID(id)
+		exercise, err := h.service.GetExerciseByID(c.Request.Context(), id)
 		if err != nil {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Exercise not found"})
+			c.Error(err)
 			return
 		}
 		c.JSON(http.StatusOK, exercise)
@@ -31,13 +35,13 @@ func (h *ExerciseHandler) GetExercise(c *gin.Context) {
 
 	var search dto.ExerciseSearch
 	if err := c.ShouldBindQuery(&search); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(invalidBody(err))
 		return
 	}
 
-	exercises, err := h.service.GetExercises(search.Name, search.Category, search.MuscleGroup)
+	exercises, err := h.service.GetExercises(c.Request.Context(), search.Name, search.Category, search.MuscleGroup)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch exercises"})
+		c.Error(err)
 		return
 	}
 
@@ -47,22 +51,18 @@ func (h *ExerciseHandler) GetExercise(c *gin.Context) {
 func (h *ExerciseHandler) CreateExercise(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
-		return
-	}
-	middleware.RequireRole("admin")(c)
-	if c.IsAborted() {
+		c.Error(errNotAuthenticated)
 		return
 	}
 	var exerciseReq dto.ExerciseRequest
 	if err := c.ShouldBindJSON(&exerciseReq); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(invalidBody(err))
 		return
 	}
 	exerciseReq.UserID = userID.(string)
-	exercise, err := h.service.CreateExercise(exerciseReq)
+	exercise, err := h.service.CreateExercise(c.Request.Context(), exerciseReq)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create exercise"})
+		c.Error(err)
 		return
 	}
 
@@ -72,27 +72,29 @@ func (h *ExerciseHandler) CreateExercise(c *gin.Context) {
 func (h *ExerciseHandler) UpdateExercise(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
-		return
-	}
-	middleware.RequireRole("admin")(c)
-	if c.IsAborted() {
+		c.Error(errNotAuthenticated)
 		return
 	}
 	id := c.Param("id")
 	if id == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing exercise ID"})
+		c.Error(errMissingExerciseID)
 		return
 	}
 	var exerciseReq dto.ExerciseRequest
 	if err := c.ShouldBindJSON(&exerciseReq); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(invalidBody(err))
 		return
 	}
-	exerciseReq.UserID = userID.(string)
-	exercise, err := h.service.UpdateExercise(id, exerciseReq)
+	var exercise dto.ExerciseResponse
+	var err error
+	if middleware.HasPermission(c, models.PermExerciseModerate) {
+		exercise, err = h.service.ModerateExercise(c.Request.Context(), id, exerciseReq)
+	} else {
+		exerciseReq.UserID = userID.(string)
+		exercise, err = h.service.UpdateExercise(c.Request.Context(), id, exerciseReq)
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update exercise"})
+		c.Error(err)
 		return
 	}
 
@@ -102,26 +104,23 @@ func (h *ExerciseHandler) UpdateExercise(c *gin.Context) {
 func (h *ExerciseHandler) DeleteExercise(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
-		return
-	}
-	middleware.RequireRole("admin")(c)
-	if c.IsAborted() {
+		c.Error(errNotAuthenticated)
 		return
 	}
 	id := c.Param("id")
 	if id == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing exercise ID"})
+		c.Error(errMissingExerciseID)
 		return
 	}
 
-	err := h.service.DeleteExercise(id, userID.(string))
+	var err error
+	if middleware.HasPermission(c, models.PermExerciseModerate) {
+		err = h.service.DeleteAnyExercise(c.Request.Context(), id)
+	} else {
+		err = h.service.DeleteExercise(c.Request.Context(), userID.(string), id)
+	}
 	if err != nil {
-		if err.Error() == "forbidden: only admins can delete exercises" {
-			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete exercise"})
+		c.Error(err)
 		return
 	}
 
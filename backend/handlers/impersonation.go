@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"backend/apperrors"
+	"backend/auth"
+	"backend/dto"
+	"backend/middleware"
+	"backend/models"
+	"backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	// Suplantar a quien administra le daría a quien suplanta permisos que no
+	// se le asignaron.
+	errImpersonationNotAllowed = apperrors.Forbidden("impersonation_not_allowed", "no se puede suplantar a un usuario con permisos de administración")
+	errNotImpersonating        = apperrors.Validation("not_impersonating", "el pedido no se hizo con un token de suplantación")
+)
+
+// ImpersonationHandler permite a soporte ver la aplicación como un usuario.
+// Los tokens son cortos, quedan marcados con el administrador que los pidió y
+// todo lo que se hace con ellos queda en el registro de auditoría.
+type ImpersonationHandler struct {
+	users       services.UserServiceInterface
+	revocations services.TokenRevocationServiceInterface
+	audit       services.AuditServiceInterface
+	roles       services.RBACServiceInterface
+}
+
+func NewImpersonationHandler(users services.UserServiceInterface, revocations services.TokenRevocationServiceInterface, audit services.AuditServiceInterface, roles services.RBACServiceInterface) *ImpersonationHandler {
+	return &ImpersonationHandler{users: users, revocations: revocations, audit: audit, roles: roles}
+}
+
+// Impersonate emite un token de suplantación del usuario de la ruta.
+func (h *ImpersonationHandler) Impersonate(c *gin.Context) {
+	var req dto.ImpersonateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(invalidBody(err))
+		return
+	}
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		c.Error(apperrors.Validation("incomplete_data", "datos incompletos"))
+		return
+	}
+
+	id := c.Param("id")
+	if id == c.GetString("user_id") {
+		c.Error(errAdminSelfAction)
+		return
+	}
+	user, err := h.users.GetUserByID(c.Request.Context(), id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if user.Disabled {
+		c.Error(errAccountDisabled)
+		return
+	}
+	// Se mira qué puede hacer el rol y no su nombre: los permisos de
+	// cualquier rol se cambian en tiempo de ejecución.
+	perms, err := h.roles.Permissions(c.Request.Context(), user.Role)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if hasAdminPermission(perms) {
+		c.Error(errImpersonationNotAllowed)
+		return
+	}
+
+	// El token lleva los métodos de autenticación del administrador: es él
+	// quien está detrás de cada pedido.
+	token, tokenID, expiresAt, err := auth.GenerateImpersonationToken(auth.Subject{
+		UserID: user.ID,
+		Email:  user.Email,
+		Role:   user.Role,
+		Lang:   user.Language,
+		AMR:    c.GetStringSlice("user_amr"),
+	}, auth.Actor{
+		UserID: c.GetString("user_id"),
+		Email:  c.GetString("user_email"),
+	})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	// Sin el evento guardado el token no se entrega.
+	ok := recordAudit(c, h.audit, models.AuditImpersonationStarted, "user", user.ID.Hex(), map[string]string{
+		"email":      user.Email,
+		"reason":     reason,
+		"token_id":   tokenID,
+		"expires_at": expiresAt.UTC().Format(time.RFC3339),
+	})
+	if !ok {
+		return
+	}
+	logSecurityEvent(c, "impersonation_started", "actor", c.GetString("user_id"), "user", user.ID.Hex(), "token_id", tokenID)
+
+	c.JSON(http.StatusOK, dto.ImpersonationResponse{
+		AccessToken: token,
+		ExpiresIn:   int64(time.Until(expiresAt).Seconds()),
+		ExpiresAt:   expiresAt,
+		User:        user,
+	})
+}
+
+// hasAdminPermission indica si perms incluye alguno de los permisos de
+// administración.
+func hasAdminPermission(perms []string) bool {
+	return slices.ContainsFunc(models.AdminPermissions, func(p models.Permission) bool {
+		return slices.Contains(perms, string(p))
+	})
+}
+
+// EndImpersonation invalida el token de suplantación con el que se hizo el
+// pedido, sin esperar a que venza.
+func (h *ImpersonationHandler) EndImpersonation(c *gin.Context) {
+	if !middleware.Impersonating(c) {
+		c.Error(errNotImpersonating)
+		return
+	}
+	access, _ := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	claims, err := auth.ValidateAccessToken(access)
+	if err != nil {
+		c.Error(apperrors.Unauthorized("token_invalid", "Token inválido"))
+		return
+	}
+	ok := recordAudit(c, h.audit, models.AuditImpersonationEnded, "user", claims.UserID, map[string]string{
+		"email":    claims.Email,
+		"token_id": claims.ID,
+	})
+	if !ok {
+		return
+	}
+	if err := h.revocations.RevokeToken(c.Request.Context(), claims); err != nil {
+		auditedActionFailed(c, models.AuditImpersonationEnded, claims.UserID, err)
+		return
+	}
+	logSecurityEvent(c, "impersonation_ended", "actor", claims.Act.UserID, "user", claims.UserID, "token_id", claims.ID)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Suplantación terminada"})
+}
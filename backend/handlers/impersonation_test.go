@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"backend/auth"
+	"backend/dto"
+	"backend/middleware"
+	"backend/models"
+	"backend/repositories"
+	"backend/repositories/memory"
+	"backend/services"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// newRBAC resuelve los permisos de fábrica de cada rol.
+func newRBAC() *services.RBACService {
+	return services.NewRBACService(memory.NewRolePermissionsRepository(), 0)
+}
+
+func TestImpersonate_IssuesMarkedTokenAndAudits(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	admin, target := primitive.NewObjectID(), primitive.NewObjectID()
+	svc := &mockUserService{
+		getByIDFn: func(id string) (dto.User, error) {
+			return dto.User{ID: target, Email: "bob@example.com", Role: "user"}, nil
+		},
+	}
+	auditRepo := memory.NewAuditRepository()
+	revocations := services.NewTokenRevocationService(memory.NewTokenRevocationRepository(), 0)
+	h := NewImpersonationHandler(svc, revocations, services.NewAuditService(auditRepo), newRBAC())
+
+	ctxVals := map[string]interface{}{"user_id": admin.Hex(), "user_email": "admin@example.com", "user_amr": []string{auth.AMRPassword, auth.AMROTP}}
+	c, w := makeReqWithCtx(t, "POST", "/api/users/"+target.Hex()+"/impersonate", dto.ImpersonateRequest{Reason: "ticket 42"}, ctxVals)
+	c.Params = gin.Params{{Key: "id", Value: target.Hex()}}
+	serve(c, h.Impersonate)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 got %d body=%s", w.Code, w.Body.String())
+	}
+	var resp dto.ImpersonationResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	claims, err := auth.ValidateAccessToken(resp.AccessToken)
+	if err != nil {
+		t.Fatalf("token must validate: %v", err)
+	}
+	if claims.UserID != target.Hex() || claims.Act == nil || claims.Act.UserID != admin.Hex() {
+		t.Fatalf("unexpected claims %+v", claims)
+	}
+
+	events, _, err := auditRepo.List(context.Background(), repositories.AuditFilter{})
+	if err != nil || len(events) != 1 {
+		t.Fatalf("expected one audit event, got %v, %v", events, err)
+	}
+	e := events[0]
+	if e.Action != models.AuditImpersonationStarted || e.ActorID != admin || e.TargetID != target.Hex() ||
+		e.Details["reason"] != "ticket 42" || e.Details["token_id"] != claims.ID {
+		t.Fatalf("unexpected audit event: %+v", e)
+	}
+}
+
+func TestImpersonate_RejectsAdmins(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	admin, other := primitive.NewObjectID(), primitive.NewObjectID()
+	svc := &mockUserService{
+		getByIDFn: func(id string) (dto.User, error) {
+			return dto.User{ID: other, Email: "other-admin@example.com", Role: string(models.RoleAdmin)}, nil
+		},
+	}
+	auditRepo := memory.NewAuditRepository()
+	revocations := services.NewTokenRevocationService(memory.NewTokenRevocationRepository(), 0)
+	h := NewImpersonationHandler(svc, revocations, services.NewAuditService(auditRepo), newRBAC())
+
+	c, w := makeReqWithCtx(t, "POST", "/api/users/"+other.Hex()+"/impersonate", dto.ImpersonateRequest{Reason: "ticket 42"}, map[string]interface{}{"user_id": admin.Hex()})
+	c.Params = gin.Params{{Key: "id", Value: other.Hex()}}
+	serve(c, h.Impersonate)
+
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 got %d body=%s", w.Code, w.Body.String())
+	}
+	if _, total, _ := auditRepo.List(context.Background(), repositories.AuditFilter{}); total != 0 {
+		t.Fatalf("rejected impersonations must not be audited, got %d events", total)
+	}
+}
+
+// Un rol que no se llama admin pero recibió un permiso de administración
+// tampoco se puede suplantar.
+func TestImpersonate_RejectsRolesWithAdminPermissions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	admin, coach := primitive.NewObjectID(), primitive.NewObjectID()
+	svc := &mockUserService{
+		getByIDFn: func(id string) (dto.User, error) {
+			return dto.User{ID: coach, Email: "coach@example.com", Role: string(models.RoleCoach)}, nil
+		},
+	}
+	rbac := newRBAC()
+	perms := []string{string(models.PermExerciseWrite), string(models.PermUserManage)}
+	if _, err := rbac.SetPermissions(context.Background(), admin.Hex(), string(models.RoleCoach), perms); err != nil {
+		t.Fatalf("set permissions: %v", err)
+	}
+	auditRepo := memory.NewAuditRepository()
+	revocations := services.NewTokenRevocationService(memory.NewTokenRevocationRepository(), 0)
+	h := NewImpersonationHandler(svc, revocations, services.NewAuditService(auditRepo), rbac)
+
+	c, w := makeReqWithCtx(t, "POST", "/api/users/"+coach.Hex()+"/impersonate", dto.ImpersonateRequest{Reason: "ticket 42"}, map[string]interface{}{"user_id": admin.Hex()})
+	c.Params = gin.Params{{Key: "id", Value: coach.Hex()}}
+	serve(c, h.Impersonate)
+
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 got %d body=%s", w.Code, w.Body.String())
+	}
+	if _, total, _ := auditRepo.List(context.Background(), repositories.AuditFilter{}); total != 0 {
+		t.Fatalf("rejected impersonations must not be audited, got %d events", total)
+	}
+}
+
+// Con el token de suplantación las lecturas pasan y llevan la cabecera, las
+// operaciones sensibles se rechazan y todo queda auditado a nombre del
+// administrador. Al terminar la suplantación el token deja de valer.
+func TestImpersonationToken_MarkedBlockedAndAudited(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	admin, target := primitive.NewObjectID(), primitive.NewObjectID()
+	token, _, _, err := auth.GenerateImpersonationToken(
+		auth.Subject{UserID: target, Email: "bob@example.com", Role: "user"},
+		auth.Actor{UserID: admin.Hex(), Email: "admin@example.com"},
+	)
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+
+	auditRepo := memory.NewAuditRepository()
+	audit := services.NewAuditService(auditRepo)
+	revocations := services.NewTokenRevocationService(memory.NewTokenRevocationRepository(), 0)
+	h := NewImpersonationHandler(&mockUserService{}, revocations, audit, newRBAC())
+
+	router := gin.New()
+	router.Use(middleware.Errors())
+	api := router.Group("/api", middleware.AuthMiddleware(revocations, nil), middleware.AuditImpersonation(audit))
+	me := api.Group("/me", middleware.ForbidImpersonation(http.MethodPut))
+	me.GET("", func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"id": c.GetString("user_id")}) })
+	me.PUT("/password", func(c *gin.Context) { t.Fatalf("password change must not run while impersonating") })
+	api.DELETE("/impersonation", h.EndImpersonation)
+
+	do := func(method, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do("GET", "/api/me")
+	if w.Code != http.StatusOK || w.Header().Get(middleware.ImpersonatedByHeader) != "admin@example.com" {
+		t.Fatalf("expected marked 200, got %d header=%q", w.Code, w.Header().Get(middleware.ImpersonatedByHeader))
+	}
+	w = do("PUT", "/api/me/password")
+	var body map[string]string
+	json.Unmarshal(w.Body.Bytes(), &body)
+	if w.Code != http.StatusForbidden || body["code"] != "impersonation_forbidden" {
+		t.Fatalf("expected 403 impersonation_forbidden, got %d %s", w.Code, w.Body.String())
+	}
+	if w = do("DELETE", "/api/impersonation"); w.Code != http.StatusOK {
+		t.Fatalf("expected 200 ending impersonation, got %d %s", w.Code, w.Body.String())
+	}
+	w = do("GET", "/api/me")
+	json.Unmarshal(w.Body.Bytes(), &body)
+	if w.Code != http.StatusUnauthorized || body["code"] != "token_revoked" {
+		t.Fatalf("ended impersonation token must be revoked, got %d %s", w.Code, w.Body.String())
+	}
+
+	events, _, err := auditRepo.List(context.Background(), repositories.AuditFilter{ActorID: admin})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	var requests, ended int
+	for _, e := range events {
+		switch e.Action {
+		case models.AuditImpersonatedRequest:
+			requests++
+			if e.TargetID != target.Hex() {
+				t.Fatalf("unexpected target in %+v", e)
+			}
+		case models.AuditImpersonationEnded:
+			ended++
+		}
+	}
+	if requests != 3 || ended != 1 {
+		t.Fatalf("expected 3 audited requests and 1 end event, got %d and %d: %+v", requests, ended, events)
+	}
+	for _, e := range events {
+		if e.Action == models.AuditImpersonatedRequest && e.Details["method"] == "PUT" && e.Details["status"] != "403" {
+			t.Fatalf("blocked request must be audited with its status, got %+v", e.Details)
+		}
+	}
+}
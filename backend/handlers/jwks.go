@@ -0,0 +1,17 @@
+package handlers
+
+import (
+	"net/http"
+
+	"backend/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+// JWKS publica las claves públicas de firma para que otros servicios
+// verifiquen los tokens sin compartir un secreto. Los clientes deberían volver
+// a pedirlo al encontrar un kid desconocido.
+func JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, auth.JWKS())
+}
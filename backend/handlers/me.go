@@ -3,6 +3,7 @@ package handlers
 import (
 	"net/http"
 
+	"backend/apperrors"
 	"backend/dto"
 
 	"github.com/gin-gonic/gin"
@@ -11,35 +12,36 @@ import (
 func (handler *UserHandler) GetUserByID(c *gin.Context) {
 	id := c.Param("id")
 	if id == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "id requerido"})
+		c.Error(apperrors.Validation("id_required", "id requerido"))
 		return
 	}
 
-	user, err := handler.service.GetUserByID(id)
+	user, err := handler.service.GetUserByID(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, user)
+	// La vista completa es la de administración (AdminHandler.GetUser).
+	c.JSON(http.StatusOK, dto.PublicUser{ID: user.ID, Name: user.Name, Level: user.Level, Goals: user.Goals})
 }
 
 func (handler *UserHandler) GetMe(c *gin.Context) {
 	userID, ok := c.Get("user_id")
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		c.Error(errNotAuthenticated)
 		return
 	}
 
 	idStr, ok := userID.(string)
 	if !ok || idStr == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		c.Error(errNotAuthenticated)
 		return
 	}
 
-	user, err := handler.service.GetUserByID(idStr)
+	user, err := handler.service.GetUserByID(c.Request.Context(), idStr)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -49,24 +51,24 @@ func (handler *UserHandler) GetMe(c *gin.Context) {
 func (handler *UserHandler) UpdateMe(c *gin.Context) {
 	userID, ok := c.Get("user_id")
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		c.Error(errNotAuthenticated)
 		return
 	}
 
 	idStr, ok := userID.(string)
 	if !ok || idStr == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		c.Error(errNotAuthenticated)
 		return
 	}
 
 	var req dto.UpdateUserRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(invalidBody(err))
 		return
 	}
 
-	if err := handler.service.UpdateUser(idStr, req); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := handler.service.UpdateUser(c.Request.Context(), idStr, req); err != nil {
+		c.Error(err)
 		return
 	}
 
@@ -76,24 +78,24 @@ func (handler *UserHandler) UpdateMe(c *gin.Context) {
 func (handler *UserHandler) ChangePassword(c *gin.Context) {
 	userID, ok := c.Get("user_id")
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		c.Error(errNotAuthenticated)
 		return
 	}
 
 	idStr, ok := userID.(string)
 	if !ok || idStr == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		c.Error(errNotAuthenticated)
 		return
 	}
 
 	var req dto.ChangePasswordRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(invalidBody(err))
 		return
 	}
 
-	if err := handler.service.ChangePassword(idStr, req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := handler.service.ChangePassword(c.Request.Context(), idStr, req); err != nil {
+		c.Error(err)
 		return
 	}
 
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"net/http"
+
+	"backend/dto"
+	"backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MFAHandler struct {
+	service services.MFAServiceInterface
+}
+
+func NewMFAHandler(service services.MFAServiceInterface) *MFAHandler {
+	return &MFAHandler{service: service}
+}
+
+// StartTOTP devuelve el secreto y el URI otpauth:// para el QR. El segundo
+// factor no se activa hasta ConfirmTOTP.
+func (h *MFAHandler) StartTOTP(c *gin.Context) {
+	userID, ok := c.Get("user_id")
+	if !ok {
+		c.Error(errNotAuthenticated)
+		return
+	}
+
+	enrollment, err := h.service.StartTOTPEnrollment(c.Request.Context(), userID.(string))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, enrollment)
+}
+
+// ConfirmTOTP activa el segundo factor con un código de la app y devuelve los
+// códigos de recuperación, que no se pueden volver a consultar.
+func (h *MFAHandler) ConfirmTOTP(c *gin.Context) {
+	var req dto.MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(invalidBody(err))
+		return
+	}
+	userID, ok := c.Get("user_id")
+	if !ok {
+		c.Error(errNotAuthenticated)
+		return
+	}
+
+	codes, err := h.service.ConfirmTOTPEnrollment(c.Request.Context(), userID.(string), req.Code)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, codes)
+}
+
+// RegenerateRecoveryCodes reemplaza los códigos de recuperación; los
+// anteriores dejan de valer.
+func (h *MFAHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	var req dto.MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(invalidBody(err))
+		return
+	}
+	userID, ok := c.Get("user_id")
+	if !ok {
+		c.Error(errNotAuthenticated)
+		return
+	}
+
+	codes, err := h.service.RegenerateRecoveryCodes(c.Request.Context(), userID.(string), req.Code)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, codes)
+}
+
+func (h *MFAHandler) DisableMFA(c *gin.Context) {
+	var req dto.DisableMFARequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(invalidBody(err))
+		return
+	}
+	userID, ok := c.Get("user_id")
+	if !ok {
+		c.Error(errNotAuthenticated)
+		return
+	}
+
+	if err := h.service.DisableMFA(c.Request.Context(), userID.(string), req); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Segundo factor desactivado"})
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"net/http"
+
+	"backend/auth"
+	"backend/dto"
+	"backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OIDCHandler atiende el login con proveedores OpenID Connect. El proveedor
+// redirige al frontend, que valida el state y llama a Callback; las sesiones
+// se emiten igual que en el login con contraseña.
+type OIDCHandler struct {
+	service  services.OIDCServiceInterface
+	sessions *UserHandler
+}
+
+func NewOIDCHandler(service services.OIDCServiceInterface, sessions *UserHandler) *OIDCHandler {
+	return &OIDCHandler{service: service, sessions: sessions}
+}
+
+func (h *OIDCHandler) ListProviders(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"providers": h.service.Providers()})
+}
+
+// Start devuelve la URL del proveedor y el state que el frontend tiene que
+// comparar al volver.
+func (h *OIDCHandler) Start(c *gin.Context) {
+	res, err := h.service.Start(c.Request.Context(), c.Param("provider"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// Callback responde como /auth/login: tokens o, si el usuario tiene segundo
+// factor, el desafío para /auth/login/mfa.
+func (h *OIDCHandler) Callback(c *gin.Context) {
+	var req dto.OIDCCallbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(invalidBody(err))
+		return
+	}
+
+	user, err := h.service.Callback(c.Request.Context(), req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	amr := []string{auth.AMRFederated}
+	if user.MFAEnabled {
+		h.sessions.challengeMFA(c, user, amr)
+		return
+	}
+	h.sessions.completeLogin(c, user, amr)
+}
+
+func (h *OIDCHandler) ListIdentities(c *gin.Context) {
+	userID, ok := c.Get("user_id")
+	if !ok {
+		c.Error(errNotAuthenticated)
+		return
+	}
+
+	identities, err := h.service.ListIdentities(c.Request.Context(), userID.(string))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, identities)
+}
+
+func (h *OIDCHandler) UnlinkIdentity(c *gin.Context) {
+	userID, ok := c.Get("user_id")
+	if !ok {
+		c.Error(errNotAuthenticated)
+		return
+	}
+
+	if err := h.service.Unlink(c.Request.Context(), userID.(string), c.Param("id")); err != nil {
+		c.Error(err)
+		return
+	}
+	logSecurityEvent(c, "oidc_identity_unlinked", "user", userID, "identity", c.Param("id"))
+
+	c.JSON(http.StatusOK, gin.H{"message": "Identidad desvinculada"})
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"backend/apperrors"
+	"backend/dto"
+	"backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PasswordResetHandler struct {
+	service  services.PasswordResetServiceInterface
+	throttle services.LoginThrottleServiceInterface
+}
+
+func NewPasswordResetHandler(service services.PasswordResetServiceInterface, throttle services.LoginThrottleServiceInterface) *PasswordResetHandler {
+	return &PasswordResetHandler{service: service, throttle: throttle}
+}
+
+// ForgotPassword responde lo mismo exista o no el email, para no revelar qué
+// cuentas están registradas.
+func (h *PasswordResetHandler) ForgotPassword(c *gin.Context) {
+	var req dto.ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(invalidBody(err))
+		return
+	}
+
+	// Solo se mira la IP: una cuenta bloqueada tiene que poder recuperarse.
+	ctx := c.Request.Context()
+	if err := h.throttle.Check(ctx, "", c.ClientIP()); err != nil {
+		c.Error(err)
+		return
+	}
+	if err := h.service.RequestReset(ctx, req.Email); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "Si el email está registrado, recibirá un link para restablecer la contraseña"})
+}
+
+func (h *PasswordResetHandler) ResetPassword(c *gin.Context) {
+	var req dto.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(invalidBody(err))
+		return
+	}
+
+	ctx := c.Request.Context()
+	if err := h.throttle.Attempt(ctx, "", c.ClientIP()); err != nil {
+		c.Error(err)
+		return
+	}
+	err := h.service.ResetPassword(ctx, req.Token, req.NewPassword)
+	// Un token inválido cuenta como intento de adivinarlo; una contraseña
+	// que no cumple la política, no.
+	if errors.Is(err, apperrors.ErrValidation) && apperrors.Code(err) != "password_invalid" {
+		failAttempt(c, h.throttle, "", err)
+		return
+	}
+	if terr := h.throttle.RecordSuccess(ctx, "", c.ClientIP()); terr != nil {
+		c.Error(terr)
+		return
+	}
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Contraseña restablecida, inicie sesión con la nueva"})
+}
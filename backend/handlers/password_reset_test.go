@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+type mockPasswordResetService struct {
+	requested []string
+	resetFn   func(token, password string) error
+}
+
+func (m *mockPasswordResetService) RequestReset(ctx context.Context, email string) error {
+	m.requested = append(m.requested, email)
+	return nil
+}
+func (m *mockPasswordResetService) ResetPassword(ctx context.Context, token, newPassword string) error {
+	return m.resetFn(token, newPassword)
+}
+
+func TestForgotPassword_AlwaysAccepted(t *testing.T) {
+	svc := &mockPasswordResetService{}
+	handler := NewPasswordResetHandler(svc, newThrottle())
+
+	c, w := makeReq(t, http.MethodPost, "/auth/password/forgot", map[string]string{"email": "nadie@example.com"})
+	serve(c, handler.ForgotPassword)
+
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(svc.requested) != 1 {
+		t.Fatalf("expected the service to be called once, got %v", svc.requested)
+	}
+}
+
+func TestResetPassword_ShortPassword(t *testing.T) {
+	handler := NewPasswordResetHandler(&mockPasswordResetService{}, newThrottle())
+
+	c, w := makeReq(t, http.MethodPost, "/auth/password/reset", map[string]string{"token": "abc", "new_password": "123"})
+	serve(c, handler.ResetPassword)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d: %s", w.Code, w.Body.String())
+	}
+}
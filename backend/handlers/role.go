@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"backend/dto"
+	"backend/models"
+	"backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RoleHandler struct {
+	service services.RBACServiceInterface
+	audit   services.AuditServiceInterface
+}
+
+func NewRoleHandler(service services.RBACServiceInterface, audit services.AuditServiceInterface) *RoleHandler {
+	return &RoleHandler{service: service, audit: audit}
+}
+
+// MyPermissions devuelve el rol y los permisos del usuario autenticado, para
+// que el cliente muestre solo lo que puede usar.
+func (h *RoleHandler) MyPermissions(c *gin.Context) {
+	perms := c.GetStringSlice("user_permissions")
+	if perms == nil {
+		perms = []string{}
+	}
+	c.JSON(http.StatusOK, dto.MyPermissions{Role: c.GetString("user_role"), Permissions: perms})
+}
+
+func (h *RoleHandler) ListRoles(c *gin.Context) {
+	roles, err := h.service.ListRoles(c.Request.Context())
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"roles": roles})
+}
+
+// SetRolePermissions reemplaza los permisos de un rol. Se aplica a los
+// pedidos siguientes de todos los usuarios con ese rol, sin volver a iniciar
+// sesión.
+func (h *RoleHandler) SetRolePermissions(c *gin.Context) {
+	var req dto.SetRolePermissionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(invalidBody(err))
+		return
+	}
+	userID, ok := c.Get("user_id")
+	if !ok {
+		c.Error(errNotAuthenticated)
+		return
+	}
+
+	ok = recordAudit(c, h.audit, models.AuditRolePermissionsChanged, "role", c.Param("role"), map[string]string{
+		"permissions": strings.Join(req.Permissions, ","),
+	})
+	if !ok {
+		return
+	}
+	role, err := h.service.SetPermissions(c.Request.Context(), userID.(string), c.Param("role"), req.Permissions)
+	if err != nil {
+		auditedActionFailed(c, models.AuditRolePermissionsChanged, c.Param("role"), err)
+		return
+	}
+	c.JSON(http.StatusOK, role)
+}
+
+// ResetRolePermissions vuelve un rol a sus permisos de fábrica.
+func (h *RoleHandler) ResetRolePermissions(c *gin.Context) {
+	userID, ok := c.Get("user_id")
+	if !ok {
+		c.Error(errNotAuthenticated)
+		return
+	}
+
+	if !recordAudit(c, h.audit, models.AuditRolePermissionsReset, "role", c.Param("role"), nil) {
+		return
+	}
+	role, err := h.service.ResetPermissions(c.Request.Context(), userID.(string), c.Param("role"))
+	if err != nil {
+		auditedActionFailed(c, models.AuditRolePermissionsReset, c.Param("role"), err)
+		return
+	}
+	c.JSON(http.StatusOK, role)
+}
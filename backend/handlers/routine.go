@@ -3,12 +3,17 @@ package handlers
 import (
 	"net/http"
 
+	"backend/apperrors"
 	"backend/dto"
+	"backend/middleware"
+	"backend/models"
 	"backend/services"
 
 	"github.com/gin-gonic/gin"
 )
 
+var errMissingRoutineID = apperrors.Validation("id_required", "Missing routine ID")
+
 type RoutineHandler struct {
 	service services.RoutineServiceInterface
 }
@@ -19,21 +24,21 @@ func NewRoutineHandler(service services.RoutineServiceInterface) *RoutineHandler
 func (h *RoutineHandler) GetRoutineByID(c *gin.Context) {
 	userID, ok := c.Get("user_id")
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		c.Error(errNotAuthenticated)
 		return
 	}
 	id := c.Param("id")
 	if id == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing routine ID"})
+		c.Error(errMissingRoutineID)
 		return
 	}
-	routine, err := h.service.GetRoutineByID(id)
+	routine, err := h.service.GetRoutineByID(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Routine not found"})
+		c.Error(err)
 		return
 	}
-	if routine.UserID != userID.(string) && !routine.IsPublic {
-		c.JSON(http.StatusForbidden, gin.H{"error": "no autorizado: acceso restringido"})
+	if routine.UserID != userID.(string) && !routine.IsPublic && !middleware.HasPermission(c, models.PermRoutineModerate) {
+		c.Error(apperrors.Forbidden("routine_private", "no autorizado: acceso restringido"))
 		return
 	}
 
@@ -43,14 +48,14 @@ func (h *RoutineHandler) GetRoutineByID(c *gin.Context) {
 func (h *RoutineHandler) GetRoutines(c *gin.Context) {
 	userID, ok := c.Get("user_id")
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		c.Error(errNotAuthenticated)
 		return
 	}
 
 	name := c.Query("name")
-	routines, err := h.service.GetRoutines(userID.(string), name)
+	routines, err := h.service.GetRoutines(c.Request.Context(), userID.(string), name)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch routines"})
+		c.Error(err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"routines": routines})
@@ -59,20 +64,20 @@ func (h *RoutineHandler) GetRoutines(c *gin.Context) {
 func (h *RoutineHandler) CreateRoutine(c *gin.Context) {
 	var req dto.RoutineRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(invalidBody(err))
 		return
 	}
 
 	userID, ok := c.Get("user_id")
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		c.Error(errNotAuthenticated)
 		return
 	}
 	req.UserID = userID.(string)
 
-	routine, err := h.service.CreateRoutine(userID.(string), req)
+	routine, err := h.service.CreateRoutine(c.Request.Context(), userID.(string), req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 	c.JSON(http.StatusCreated, routine)
@@ -81,29 +86,25 @@ func (h *RoutineHandler) CreateRoutine(c *gin.Context) {
 func (h *RoutineHandler) UpdateRoutine(c *gin.Context) {
 	id := c.Param("id")
 	if id == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing routine ID"})
+		c.Error(errMissingRoutineID)
 		return
 	}
 
 	var req dto.RoutineRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(invalidBody(err))
 		return
 	}
 
 	userID, ok := c.Get("user_id")
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		c.Error(errNotAuthenticated)
 		return
 	}
 
-	updated, err := h.service.UpdateRoutine(userID.(string), id, req)
+	updated, err := h.service.UpdateRoutine(c.Request.Context(), userID.(string), id, req)
 	if err != nil {
-		if err.Error() == "no autorizado: no es el owner de la rutina" {
-			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -113,23 +114,24 @@ func (h *RoutineHandler) UpdateRoutine(c *gin.Context) {
 func (h *RoutineHandler) DeleteRoutine(c *gin.Context) {
 	id := c.Param("id")
 	if id == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing routine ID"})
+		c.Error(errMissingRoutineID)
 		return
 	}
 
 	userID, ok := c.Get("user_id")
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		c.Error(errNotAuthenticated)
 		return
 	}
 
-	err := h.service.DeleteRoutine(userID.(string), id)
+	var err error
+	if middleware.HasPermission(c, models.PermRoutineModerate) {
+		err = h.service.DeleteAnyRoutine(c.Request.Context(), id)
+	} else {
+		err = h.service.DeleteRoutine(c.Request.Context(), userID.(string), id)
+	}
 	if err != nil {
-		if err.Error() == "no autorizado: no es el owner de la rutina" {
-			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "Routine deleted successfully"})
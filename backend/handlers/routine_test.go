@@ -2,13 +2,16 @@ package handlers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
-	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
+	"backend/apperrors"
 	"backend/dto"
+	"backend/middleware"
+	"backend/models"
 
 	"github.com/gin-gonic/gin"
 )
@@ -20,39 +23,46 @@ type mockRoutineService struct {
 	UpdateRoutineFunc  func(ownerID, routineID string, input dto.RoutineRequest) (dto.RoutineResponse, error)
 	DeleteRoutineFunc  func(ownerID, routineID string) error
 	DuplicateFunc      func(ownerID, sourceRoutineID, newName string) (string, error)
+	DeleteAnyFunc      func(routineID string) error
 }
 
-func (m *mockRoutineService) CreateRoutine(ownerID string, input dto.RoutineRequest) (dto.RoutineResponse, error) {
+func (m *mockRoutineService) CreateRoutine(ctx context.Context, ownerID string, input dto.RoutineRequest) (dto.RoutineResponse, error) {
 	if m.CreateRoutineFunc != nil {
 		return m.CreateRoutineFunc(ownerID, input)
 	}
 	return dto.RoutineResponse{}, nil
 }
-func (m *mockRoutineService) GetRoutines(ownerID string, name string) ([]dto.RoutineResponse, error) {
+func (m *mockRoutineService) GetRoutines(ctx context.Context, ownerID string, name string) ([]dto.RoutineResponse, error) {
 	if m.GetRoutinesFunc != nil {
 		return m.GetRoutinesFunc(ownerID, name)
 	}
 	return nil, nil
 }
-func (m *mockRoutineService) GetRoutineByID(id string) (dto.RoutineResponse, error) {
+func (m *mockRoutineService) GetRoutineByID(ctx context.Context, id string) (dto.RoutineResponse, error) {
 	if m.GetRoutineByIDFunc != nil {
 		return m.GetRoutineByIDFunc(id)
 	}
 	return dto.RoutineResponse{}, nil
 }
-func (m *mockRoutineService) UpdateRoutine(ownerID string, routineID string, input dto.RoutineRequest) (dto.RoutineResponse, error) {
+func (m *mockRoutineService) UpdateRoutine(ctx context.Context, ownerID string, routineID string, input dto.RoutineRequest) (dto.RoutineResponse, error) {
 	if m.UpdateRoutineFunc != nil {
 		return m.UpdateRoutineFunc(ownerID, routineID, input)
 	}
 	return dto.RoutineResponse{}, nil
 }
-func (m *mockRoutineService) DeleteRoutine(ownerID string, routineID string) error {
+func (m *mockRoutineService) DeleteRoutine(ctx context.Context, ownerID string, routineID string) error {
 	if m.DeleteRoutineFunc != nil {
 		return m.DeleteRoutineFunc(ownerID, routineID)
 	}
 	return nil
 }
-func (m *mockRoutineService) DuplicateRoutine(ownerID string, sourceRoutineID string, newName string) (string, error) {
+func (m *mockRoutineService) DeleteAnyRoutine(ctx context.Context, routineID string) error {
+	if m.DeleteAnyFunc != nil {
+		return m.DeleteAnyFunc(routineID)
+	}
+	return nil
+}
+func (m *mockRoutineService) DuplicateRoutine(ctx context.Context, ownerID string, sourceRoutineID string, newName string) (string, error) {
 	if m.DuplicateFunc != nil {
 		return m.DuplicateFunc(ownerID, sourceRoutineID, newName)
 	}
@@ -63,6 +73,12 @@ func setupGinTest() {
 	gin.SetMode(gin.TestMode)
 }
 
+// serve ejecuta handler y después el middleware de errores, como en el router.
+func serve(c *gin.Context, handler gin.HandlerFunc) {
+	handler(c)
+	middleware.Errors()(c)
+}
+
 func TestGetRoutineByID_Success(t *testing.T) {
 	setupGinTest()
 
@@ -84,7 +100,7 @@ func TestGetRoutineByID_Success(t *testing.T) {
 	c.Params = gin.Params{{Key: "id", Value: routineID}}
 	c.Set("user_id", userID)
 
-	handler.GetRoutineByID(c)
+	serve(c, handler.GetRoutineByID)
 
 	if w.Code != http.StatusOK {
 		t.Fatalf("expected status 200, got %d, body: %s", w.Code, w.Body.String())
@@ -110,7 +126,7 @@ func TestGetRoutineByID_Unauthorized(t *testing.T) {
 	c.Request = req
 	c.Params = gin.Params{{Key: "id", Value: "any"}}
 
-	handler.GetRoutineByID(c)
+	serve(c, handler.GetRoutineByID)
 
 	if w.Code != http.StatusUnauthorized {
 		t.Fatalf("expected status 401, got %d", w.Code)
@@ -122,7 +138,7 @@ func TestGetRoutineByID_NotFoundAndForbidden(t *testing.T) {
 
 	mock1 := &mockRoutineService{
 		GetRoutineByIDFunc: func(id string) (dto.RoutineResponse, error) {
-			return dto.RoutineResponse{}, errors.New("not found")
+			return dto.RoutineResponse{}, apperrors.NotFound("routine_not_found", "not found")
 		},
 	}
 	handler1 := NewRoutineHandler(mock1)
@@ -131,7 +147,7 @@ func TestGetRoutineByID_NotFoundAndForbidden(t *testing.T) {
 	c1.Request = httptest.NewRequest(http.MethodGet, "/", nil)
 	c1.Params = gin.Params{{Key: "id", Value: "r"}}
 	c1.Set("user_id", "u")
-	handler1.GetRoutineByID(c1)
+	serve(c1, handler1.GetRoutineByID)
 	if w1.Code != http.StatusNotFound {
 		t.Fatalf("expected 404, got %d", w1.Code)
 	}
@@ -147,10 +163,22 @@ func TestGetRoutineByID_NotFoundAndForbidden(t *testing.T) {
 	c2.Request = httptest.NewRequest(http.MethodGet, "/", nil)
 	c2.Params = gin.Params{{Key: "id", Value: "r"}}
 	c2.Set("user_id", "u")
-	handler2.GetRoutineByID(c2)
+	serve(c2, handler2.GetRoutineByID)
 	if w2.Code != http.StatusForbidden {
 		t.Fatalf("expected 403, got %d", w2.Code)
 	}
+
+	// Un moderador ve las rutinas privadas de otros.
+	w3 := httptest.NewRecorder()
+	c3, _ := gin.CreateTestContext(w3)
+	c3.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	c3.Params = gin.Params{{Key: "id", Value: "r"}}
+	c3.Set("user_id", "u")
+	c3.Set("user_permissions", []string{string(models.PermRoutineModerate)})
+	serve(c3, handler2.GetRoutineByID)
+	if w3.Code != http.StatusOK {
+		t.Fatalf("expected 200 for a moderator, got %d", w3.Code)
+	}
 }
 
 func TestGetRoutines_SuccessAndUnauthorized(t *testing.T) {
@@ -168,7 +196,7 @@ func TestGetRoutines_SuccessAndUnauthorized(t *testing.T) {
 	req := httptest.NewRequest(http.MethodGet, "/?name=x", nil)
 	c.Request = req
 	c.Set("user_id", "u1")
-	handler.GetRoutines(c)
+	serve(c, handler.GetRoutines)
 	if w.Code != http.StatusOK {
 		t.Fatalf("expected 200, got %d, body: %s", w.Code, w.Body.String())
 	}
@@ -176,7 +204,7 @@ func TestGetRoutines_SuccessAndUnauthorized(t *testing.T) {
 	w2 := httptest.NewRecorder()
 	c2, _ := gin.CreateTestContext(w2)
 	c2.Request = httptest.NewRequest(http.MethodGet, "/", nil)
-	handler.GetRoutines(c2)
+	serve(c2, handler.GetRoutines)
 	if w2.Code != http.StatusUnauthorized {
 		t.Fatalf("expected 401, got %d", w2.Code)
 	}
@@ -194,7 +222,7 @@ func TestCreateRoutine_BadRequestAndSuccess(t *testing.T) {
 	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString("{invalid}"))
 	req.Header.Set("Content-Type", "application/json")
 	c.Request = req
-	handler.CreateRoutine(c)
+	serve(c, handler.CreateRoutine)
 	if w.Code != http.StatusBadRequest {
 		t.Fatalf("expected 400 for bad JSON, got %d", w.Code)
 	}
@@ -213,7 +241,7 @@ func TestCreateRoutine_BadRequestAndSuccess(t *testing.T) {
 	req2.Header.Set("Content-Type", "application/json")
 	c2.Request = req2
 	c2.Set("user_id", "owner1")
-	handler2.CreateRoutine(c2)
+	serve(c2, handler2.CreateRoutine)
 	if w2.Code != http.StatusCreated {
 		t.Fatalf("expected 201, got %d, body: %s", w2.Code, w2.Body.String())
 	}
@@ -224,7 +252,7 @@ func TestUpdateAndDeleteRoutine_AuthorizationPaths(t *testing.T) {
 
 	mockUpd := &mockRoutineService{
 		UpdateRoutineFunc: func(ownerID, routineID string, input dto.RoutineRequest) (dto.RoutineResponse, error) {
-			return dto.RoutineResponse{}, errors.New("no autorizado: no es el owner de la rutina")
+			return dto.RoutineResponse{}, apperrors.Forbidden("not_routine_owner", "no autorizado: no es el owner de la rutina")
 		},
 	}
 	hUpd := NewRoutineHandler(mockUpd)
@@ -235,7 +263,7 @@ func TestUpdateAndDeleteRoutine_AuthorizationPaths(t *testing.T) {
 	cUpd.Request.Header.Set("Content-Type", "application/json")
 	cUpd.Params = gin.Params{{Key: "id", Value: "r1"}}
 	cUpd.Set("user_id", "owner1")
-	hUpd.UpdateRoutine(cUpd)
+	serve(cUpd, hUpd.UpdateRoutine)
 	if wUpd.Code != http.StatusForbidden {
 		t.Fatalf("expected 403 for update forbidden, got %d, body: %s", wUpd.Code, wUpd.Body.String())
 	}
@@ -249,13 +277,15 @@ func TestUpdateAndDeleteRoutine_AuthorizationPaths(t *testing.T) {
 	cDel.Request = httptest.NewRequest(http.MethodDelete, "/", nil)
 	cDel.Params = gin.Params{{Key: "id", Value: "r1"}}
 	cDel.Set("user_id", "owner1")
-	hDel.DeleteRoutine(cDel)
+	serve(cDel, hDel.DeleteRoutine)
 	if wDel.Code != http.StatusOK {
 		t.Fatalf("expected 200 for delete success, got %d, body: %s", wDel.Code, wDel.Body.String())
 	}
 
 	mockDelForbidden := &mockRoutineService{
-		DeleteRoutineFunc: func(ownerID, routineID string) error { return errors.New("no autorizado: no es el owner de la rutina") },
+		DeleteRoutineFunc: func(ownerID, routineID string) error {
+			return apperrors.Forbidden("not_routine_owner", "no autorizado: no es el owner de la rutina")
+		},
 	}
 	hDelF := NewRoutineHandler(mockDelForbidden)
 	wDelF := httptest.NewRecorder()
@@ -263,8 +293,48 @@ func TestUpdateAndDeleteRoutine_AuthorizationPaths(t *testing.T) {
 	cDelF.Request = httptest.NewRequest(http.MethodDelete, "/", nil)
 	cDelF.Params = gin.Params{{Key: "id", Value: "r1"}}
 	cDelF.Set("user_id", "owner1")
-	hDelF.DeleteRoutine(cDelF)
+	serve(cDelF, hDelF.DeleteRoutine)
 	if wDelF.Code != http.StatusForbidden {
 		t.Fatalf("expected 403 for delete forbidden, got %d, body: %s", wDelF.Code, wDelF.Body.String())
 	}
 }
+
+func TestErrorMessages_FollowLanguage(t *testing.T) {
+	setupGinTest()
+
+	mock := &mockRoutineService{
+		GetRoutineByIDFunc: func(id string) (dto.RoutineResponse, error) {
+			return dto.RoutineResponse{}, apperrors.NotFound("routine_not_found", "not found")
+		},
+	}
+	handler := NewRoutineHandler(mock)
+
+	cases := []struct {
+		acceptLanguage string
+		userLang       string
+		want           string
+	}{
+		{"en-US,en;q=0.9", "", "Routine not found"},
+		{"es-AR", "", "Rutina no encontrada"},
+		{"", "", "Rutina no encontrada"},
+		{"es", "en", "Routine not found"},
+	}
+	for _, tc := range cases {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+		c.Request.Header.Set("Accept-Language", tc.acceptLanguage)
+		c.Params = gin.Params{{Key: "id", Value: "r"}}
+		c.Set("user_id", "u")
+		c.Set("user_lang", tc.userLang)
+		serve(c, handler.GetRoutineByID)
+
+		var body map[string]string
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatalf("decode body: %v", err)
+		}
+		if body["error"] != tc.want || body["code"] != "routine_not_found" {
+			t.Errorf("Accept-Language %q, user %q: got %v", tc.acceptLanguage, tc.userLang, body)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// logSecurityEvent deja en el log un evento de seguridad con la IP y el
+// user agent del pedido. kv son pares clave, valor.
+func logSecurityEvent(c *gin.Context, event string, kv ...any) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "security: event=%s ip=%s user_agent=%q", event, c.ClientIP(), c.Request.UserAgent())
+	for i := 0; i+1 < len(kv); i += 2 {
+		fmt.Fprintf(&b, " %v=%v", kv[i], kv[i+1])
+	}
+	log.Print(b.String())
+}
+
+// failAttempt confirma que falló el intento de email (puede ser vacío) que ya
+// contó Attempt y responde err. Si la falla no se pudo registrar se responde
+// ese error: sin el bloqueo no hay protección contra la fuerza bruta.
+func failAttempt(c *gin.Context, throttle services.LoginThrottleServiceInterface, email string, err error) {
+	if terr := throttle.RecordFailure(c.Request.Context(), email); terr != nil {
+		c.Error(terr)
+		return
+	}
+	c.Error(err)
+}
@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"net/http"
+
+	"backend/apperrors"
+	"backend/dto"
+	"backend/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	errSessionNotFound = apperrors.NotFound("session_not_found", "sesión no encontrada")
+	// Los access tokens emitidos antes de las sesiones no traen sid.
+	errSessionUnknown = apperrors.Validation("session_unknown", "no se pudo identificar la sesión actual, vuelva a iniciar sesión")
+)
+
+func (handler *UserHandler) ListSessions(c *gin.Context) {
+	userID, ok := c.Get("user_id")
+	if !ok {
+		c.Error(errNotAuthenticated)
+		return
+	}
+	uid, err := utils.ParseObjectID(userID.(string))
+	if err != nil {
+		c.Error(errNotAuthenticated)
+		return
+	}
+
+	tokens, err := handler.refreshRepo.ListActiveForUser(c.Request.Context(), uid)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	current := c.GetString("session_id")
+	sessions := make([]dto.Session, 0, len(tokens))
+	for _, rt := range tokens {
+		id := rt.Family().Hex()
+		lastUsed := rt.LastUsedAt
+		if lastUsed.IsZero() {
+			lastUsed = rt.CreatedAt
+		}
+		sessions = append(sessions, dto.Session{
+			ID:         id,
+			UserAgent:  rt.UserAgent,
+			IP:         rt.IP,
+			CreatedAt:  rt.StartedAt(),
+			LastUsedAt: lastUsed,
+			ExpiresAt:  rt.ExpiresAt,
+			Current:    id == current,
+		})
+	}
+
+	c.JSON(http.StatusOK, sessions)
+}
+
+// RevokeSession cierra una sesión del usuario: dejan de servir su refresh
+// token y sus access tokens, y el dispositivo tiene que volver a iniciar
+// sesión.
+func (handler *UserHandler) RevokeSession(c *gin.Context) {
+	userID, ok := c.Get("user_id")
+	if !ok {
+		c.Error(errNotAuthenticated)
+		return
+	}
+	uid, err := utils.ParseObjectID(userID.(string))
+	if err != nil {
+		c.Error(errNotAuthenticated)
+		return
+	}
+	sessionID, err := utils.ParseObjectID(c.Param("id"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	res, err := handler.refreshRepo.RevokeSession(c.Request.Context(), uid, sessionID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if res.MatchedCount == 0 {
+		c.Error(errSessionNotFound)
+		return
+	}
+	if err := handler.revocations.RevokeSession(c.Request.Context(), sessionID.Hex()); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "sesión cerrada"})
+}
+
+// RevokeOtherSessions cierra todas las sesiones del usuario menos la del token
+// con el que se hizo el pedido.
+func (handler *UserHandler) RevokeOtherSessions(c *gin.Context) {
+	userID, ok := c.Get("user_id")
+	if !ok {
+		c.Error(errNotAuthenticated)
+		return
+	}
+	uid, err := utils.ParseObjectID(userID.(string))
+	if err != nil {
+		c.Error(errNotAuthenticated)
+		return
+	}
+	current, err := utils.ParseObjectID(c.GetString("session_id"))
+	if err != nil {
+		c.Error(errSessionUnknown)
+		return
+	}
+
+	// Las sesiones se listan antes de revocarlas para después invalidar sus
+	// access tokens.
+	active, err := handler.refreshRepo.ListActiveForUser(c.Request.Context(), uid)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	res, err := handler.refreshRepo.RevokeOtherSessions(c.Request.Context(), uid, current)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	for _, rt := range active {
+		if rt.Family() == current {
+			continue
+		}
+		if err := handler.revocations.RevokeSession(c.Request.Context(), rt.Family().Hex()); err != nil {
+			c.Error(err)
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "sesiones cerradas", "revoked": res.ModifiedCount})
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"backend/auth"
+	"backend/middleware"
+	"backend/models"
+	"backend/repositories/memory"
+	"backend/services"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Cerrar la sesión del teléfono perdido invalida también el access token que
+// el teléfono todavía tiene, no solo su refresh token.
+func TestRevokeSession_RevokesItsAccessTokens(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	user := primitive.NewObjectID()
+	laptop, phone := primitive.NewObjectID(), primitive.NewObjectID()
+	refreshRepo := memory.NewRefreshTokenRepository()
+	for _, family := range []primitive.ObjectID{laptop, phone} {
+		_, err := refreshRepo.Save(context.Background(), models.RefreshToken{
+			ID:        family,
+			UserID:    user,
+			FamilyID:  family,
+			TokenHash: family.Hex(),
+			ExpiresAt: time.Now().Add(time.Hour),
+			CreatedAt: time.Now(),
+		})
+		if err != nil {
+			t.Fatalf("save: %v", err)
+		}
+	}
+	token := func(family primitive.ObjectID) string {
+		access, _, _, err := auth.GenerateToken(auth.Subject{UserID: user, Email: "bob@example.com", Role: "user", SessionID: family.Hex()})
+		if err != nil {
+			t.Fatalf("generate: %v", err)
+		}
+		return access
+	}
+	laptopToken, phoneToken := token(laptop), token(phone)
+
+	revocations := services.NewTokenRevocationService(memory.NewTokenRevocationRepository(), 0)
+	handler := NewUserHandler(&mockUserService{}, refreshRepo, revocations, nil, newThrottle())
+	router := gin.New()
+	router.Use(middleware.Errors())
+	me := router.Group("/api/me", middleware.AuthMiddleware(revocations, nil))
+	me.GET("/sessions", handler.ListSessions)
+	me.DELETE("/sessions/:id", handler.RevokeSession)
+
+	do := func(method, path, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := do("DELETE", "/api/me/sessions/"+phone.Hex(), laptopToken); w.Code != http.StatusOK {
+		t.Fatalf("expected 200 got %d body=%s", w.Code, w.Body.String())
+	}
+	w := do("GET", "/api/me/sessions", phoneToken)
+	var body map[string]string
+	json.Unmarshal(w.Body.Bytes(), &body)
+	if w.Code != http.StatusUnauthorized || body["code"] != "token_revoked" {
+		t.Fatalf("expected 401 token_revoked for the revoked session, got %d %s", w.Code, w.Body.String())
+	}
+	if w := do("GET", "/api/me/sessions", laptopToken); w.Code != http.StatusOK {
+		t.Fatalf("the current session must keep working, got %d %s", w.Code, w.Body.String())
+	}
+}
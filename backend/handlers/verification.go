@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"net/http"
+
+	"backend/dto"
+	"backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type EmailVerificationHandler struct {
+	service services.EmailVerificationServiceInterface
+}
+
+func NewEmailVerificationHandler(service services.EmailVerificationServiceInterface) *EmailVerificationHandler {
+	return &EmailVerificationHandler{service: service}
+}
+
+// VerifyEmail confirma el email con el token del link. No requiere sesión: el
+// link se puede abrir en otro dispositivo.
+func (h *EmailVerificationHandler) VerifyEmail(c *gin.Context) {
+	var req dto.VerifyEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(invalidBody(err))
+		return
+	}
+
+	if err := h.service.VerifyEmail(c.Request.Context(), req.Token); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Email verificado correctamente"})
+}
+
+// ResendVerification envía un link nuevo al email del usuario autenticado.
+func (h *EmailVerificationHandler) ResendVerification(c *gin.Context) {
+	userID, ok := c.Get("user_id")
+	if !ok {
+		c.Error(errNotAuthenticated)
+		return
+	}
+
+	if err := h.service.ResendVerification(c.Request.Context(), userID.(string)); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "Email de verificación enviado"})
+}
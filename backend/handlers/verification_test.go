@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"backend/apperrors"
+	"backend/models"
+)
+
+type mockVerificationService struct {
+	verifyFn func(token string) error
+	resendFn func(userID string) error
+}
+
+func (m *mockVerificationService) SendVerification(ctx context.Context, user models.User) error {
+	return nil
+}
+func (m *mockVerificationService) VerifyEmail(ctx context.Context, token string) error {
+	return m.verifyFn(token)
+}
+func (m *mockVerificationService) ResendVerification(ctx context.Context, userID string) error {
+	return m.resendFn(userID)
+}
+
+func TestVerifyEmail_InvalidToken(t *testing.T) {
+	svc := &mockVerificationService{verifyFn: func(token string) error {
+		return apperrors.Validation("verification_token_invalid", "x")
+	}}
+	handler := NewEmailVerificationHandler(svc)
+
+	c, w := makeReq(t, http.MethodPost, "/auth/verify-email", map[string]string{"token": "abc"})
+	serve(c, handler.VerifyEmail)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestResendVerification_Throttled(t *testing.T) {
+	var got string
+	svc := &mockVerificationService{resendFn: func(userID string) error {
+		got = userID
+		return apperrors.TooManyRequests("verification_throttled", "x")
+	}}
+	handler := NewEmailVerificationHandler(svc)
+
+	c, w := makeReq(t, http.MethodPost, "/api/me/verification", nil)
+	c.Set("user_id", "u1")
+	serve(c, handler.ResendVerification)
+
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d: %s", w.Code, w.Body.String())
+	}
+	if got != "u1" {
+		t.Fatalf("expected the authenticated user id, got %q", got)
+	}
+}
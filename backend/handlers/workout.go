@@ -4,22 +4,25 @@ import (
 	"net/http"
 	"time"
 
+	"backend/apperrors"
 	"backend/dto"
 	"backend/services"
 
 	"github.com/gin-gonic/gin"
 )
 
+var errMissingWorkoutID = apperrors.Validation("id_required", "ID de workout requerido")
+
 func GetWorkout(c *gin.Context, workoutService services.WorkoutServiceInterface) {
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		c.Error(errNotAuthenticated)
 		return
 	}
 
-	workouts, err := workoutService.GetWorkouts(userID.(string))
+	workouts, err := workoutService.GetWorkouts(c.Request.Context(), userID.(string))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -29,13 +32,13 @@ func GetWorkout(c *gin.Context, workoutService services.WorkoutServiceInterface)
 func CreateWorkout(c *gin.Context, workoutService services.WorkoutServiceInterface) {
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		c.Error(errNotAuthenticated)
 		return
 	}
 
 	var workout dto.WorkoutDTO
 	if err := c.ShouldBindJSON(&workout); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(invalidBody(err))
 		return
 	}
 
@@ -43,9 +46,9 @@ func CreateWorkout(c *gin.Context, workoutService services.WorkoutServiceInterfa
 	workout.CompletedAt = time.Now()
 	workout.UpdatedAt = time.Now()
 
-	id, err := workoutService.CreateWorkout(workout)
+	id, err := workoutService.CreateWorkout(c.Request.Context(), workout)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -55,30 +58,30 @@ func CreateWorkout(c *gin.Context, workoutService services.WorkoutServiceInterfa
 func UpdateWorkout(c *gin.Context, workoutService services.WorkoutServiceInterface) {
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		c.Error(errNotAuthenticated)
 		return
 	}
 
 	workoutID := c.Param("id")
 	if workoutID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de workout requerido"})
+		c.Error(errMissingWorkoutID)
 		return
 	}
 
-	existingWorkout, err := workoutService.GetWorkoutByID(workoutID)
+	existingWorkout, err := workoutService.GetWorkoutByID(c.Request.Context(), workoutID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Workout no encontrado"})
+		c.Error(err)
 		return
 	}
 
 	if existingWorkout.UserID != userID.(string) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "No tienes permiso para modificar este workout"})
+		c.Error(apperrors.Forbidden("not_workout_owner", "No tienes permiso para modificar este workout"))
 		return
 	}
 
 	var workout dto.WorkoutDTO
 	if err := c.ShouldBindJSON(&workout); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(invalidBody(err))
 		return
 	}
 
@@ -86,8 +89,8 @@ func UpdateWorkout(c *gin.Context, workoutService services.WorkoutServiceInterfa
 	workout.UserID = userID.(string)
 	workout.UpdatedAt = time.Now()
 
-	if err := workoutService.UpdateWorkout(workout); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := workoutService.UpdateWorkout(c.Request.Context(), workout); err != nil {
+		c.Error(err)
 		return
 	}
 
@@ -97,29 +100,29 @@ func UpdateWorkout(c *gin.Context, workoutService services.WorkoutServiceInterfa
 func DeleteWorkout(c *gin.Context, workoutService services.WorkoutServiceInterface) {
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		c.Error(errNotAuthenticated)
 		return
 	}
 
 	workoutID := c.Param("id")
 	if workoutID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de workout requerido"})
+		c.Error(errMissingWorkoutID)
 		return
 	}
 
-	existingWorkout, err := workoutService.GetWorkoutByID(workoutID)
+	existingWorkout, err := workoutService.GetWorkoutByID(c.Request.Context(), workoutID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Workout no encontrado"})
+		c.Error(err)
 		return
 	}
 
 	if existingWorkout.UserID != userID.(string) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "No tienes permiso para eliminar este workout"})
+		c.Error(apperrors.Forbidden("not_workout_owner", "No tienes permiso para eliminar este workout"))
 		return
 	}
 
-	if err := workoutService.DeleteWorkout(workoutID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := workoutService.DeleteWorkout(c.Request.Context(), workoutID); err != nil {
+		c.Error(err)
 		return
 	}
 
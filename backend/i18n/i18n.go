@@ -0,0 +1,67 @@
+// Package i18n traduce los códigos de error de apperrors a mensajes en los
+// idiomas que soporta la API.
+package i18n
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	Spanish = "es"
+	English = "en"
+
+	Default = Spanish
+)
+
+// Supported indica si lang es uno de los idiomas con catálogo.
+func Supported(lang string) bool {
+	_, ok := catalogs[lang]
+	return ok
+}
+
+// Message devuelve el mensaje de code en lang. Si el idioma no tiene el código
+// se usa el idioma por defecto; ok es false si ninguno lo tiene.
+func Message(lang, code string) (string, bool) {
+	if msg, ok := catalogs[lang][code]; ok {
+		return msg, true
+	}
+	msg, ok := catalogs[Default][code]
+	return msg, ok
+}
+
+// Negotiate elige el idioma soportado de mayor peso en un header
+// Accept-Language ("en-US,en;q=0.9,es;q=0.8"). Devuelve Default si ninguno
+// coincide.
+func Negotiate(acceptLanguage string) string {
+	type candidate struct {
+		lang string
+		q    float64
+	}
+	var candidates []candidate
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if tag == "" {
+			continue
+		}
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		// Solo importa el idioma base: es-AR y es-ES usan el mismo catálogo.
+		base, _, _ := strings.Cut(strings.ToLower(tag), "-")
+		if q > 0 && Supported(base) {
+			candidates = append(candidates, candidate{lang: base, q: q})
+		}
+	}
+	if len(candidates) == 0 {
+		return Default
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+	return candidates[0].lang
+}
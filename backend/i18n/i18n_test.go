@@ -0,0 +1,43 @@
+package i18n
+
+import "testing"
+
+func TestNegotiate(t *testing.T) {
+	cases := map[string]string{
+		"":                           Default,
+		"en":                         English,
+		"en-US,en;q=0.9,es;q=0.8":    English,
+		"fr-FR,es-AR;q=0.7,en;q=0.5": Spanish,
+		"de,fr":                      Default,
+		"en;q=0,es;q=0.1":            Spanish,
+	}
+	for header, want := range cases {
+		if got := Negotiate(header); got != want {
+			t.Errorf("Negotiate(%q) = %q, want %q", header, got, want)
+		}
+	}
+}
+
+func TestMessage_FallsBackToDefault(t *testing.T) {
+	if msg, ok := Message("fr", "routine_not_found"); !ok || msg != catalogs[Default]["routine_not_found"] {
+		t.Fatalf("expected default language message, got %q", msg)
+	}
+	if _, ok := Message(English, "no_such_code"); ok {
+		t.Fatalf("unknown codes must report ok=false")
+	}
+}
+
+func TestCatalogsHaveSameCodes(t *testing.T) {
+	for lang, catalog := range catalogs {
+		for code := range catalogs[Default] {
+			if _, ok := catalog[code]; !ok {
+				t.Errorf("%s: missing %q", lang, code)
+			}
+		}
+		for code := range catalog {
+			if _, ok := catalogs[Default][code]; !ok {
+				t.Errorf("%s: %q is not in the default catalog", lang, code)
+			}
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package i18n
+
+// Al agregar un código en apperrors hay que agregarlo en los dos catálogos;
+// TestCatalogsHaveSameCodes lo verifica.
+var catalogs = map[string]map[string]string{
+	Spanish: {
+		"internal_error": "Error interno",
+		"timeout":        "La operación tardó demasiado",
+		"not_found":      "Recurso no encontrado",
+		"conflict":       "El recurso ya existe",
+		"invalid_body":   "El cuerpo de la solicitud es inválido",
+		"invalid_id":     "Id inválido",
+		"id_required":    "El id es obligatorio",
+
+		"not_authenticated":      "Usuario no autenticado",
+		"token_required":         "Token de autorización requerido",
+		"token_malformed":        "Formato de token inválido",
+		"token_invalid":          "Token inválido",
+		"token_revoked":          "El token fue revocado, vuelva a iniciar sesión",
+		"permission_required":    "No tiene permisos para esta operación",
+		"invalid_credentials":    "Credenciales inválidas",
+		"credentials_required":   "Email y contraseña son obligatorios",
+		"refresh_token_required": "El refresh token es obligatorio",
+		"refresh_token_invalid":  "Refresh token inválido o expirado",
+		"refresh_token_revoked":  "Refresh token inválido o revocado",
+		"refresh_token_reused":   "El refresh token ya fue usado: se cerró la sesión, vuelva a iniciar sesión",
+		"session_not_found":      "Sesión no encontrada",
+		"session_unknown":        "No se pudo identificar la sesión actual, vuelva a iniciar sesión",
+
+		"user_not_found":        "Usuario no encontrado",
+		"user_required":         "El usuario es obligatorio",
+		"incomplete_data":       "Faltan datos obligatorios",
+		"invalid_email":         "Email inválido",
+		"invalid_date_of_birth": "Fecha de nacimiento inválida, use formato ISO",
+		"unsupported_language":  "Idioma no soportado",
+		"email_taken":           "El email ya está registrado",
+		"wrong_password":        "La contraseña actual es incorrecta",
+		"password_invalid":      "La contraseña debe tener al menos 6 caracteres y no más de 72 bytes",
+		"invalid_role":          "Rol inválido",
+
+		"verification_token_invalid": "El link de verificación es inválido, ya se usó o venció",
+		"email_already_verified":     "El email ya está verificado",
+		"verification_throttled":     "Ya se envió un email de verificación, espere unos minutos antes de pedir otro",
+		"email_not_verified":         "Confirme su email para publicar rutinas",
+		"reset_token_invalid":        "El link para restablecer la contraseña es inválido, ya se usó o venció",
+
+		"mfa_already_enabled":        "El segundo factor ya está activado",
+		"mfa_not_enabled":            "El segundo factor no está activado",
+		"mfa_enrollment_not_started": "Primero inicie el enrolamiento del segundo factor",
+		"mfa_code_invalid":           "El código es inválido o ya se usó",
+		"mfa_required_for_role":      "Su rol no puede desactivar el segundo factor",
+		"mfa_token_invalid":          "El desafío de segundo factor es inválido o venció, inicie sesión de nuevo",
+		"mfa_required":               "Su rol requiere iniciar sesión con segundo factor",
+
+		"too_many_attempts":    "Demasiados intentos fallidos, espere antes de volver a intentar",
+		"account_locked":       "La cuenta está bloqueada por intentos fallidos. Revise su email para desbloquearla",
+		"unlock_token_invalid": "El link de desbloqueo es inválido, ya se usó o venció",
+		"rate_limited":         "Demasiados pedidos, espere unos segundos antes de reintentar",
+
+		"oidc_provider_unknown":   "Proveedor de login desconocido",
+		"oidc_state_invalid":      "El login externo es inválido o venció, vuelva a intentarlo",
+		"oidc_login_failed":       "El proveedor no confirmó el login",
+		"oidc_email_not_verified": "El proveedor no confirma que su email esté verificado",
+		"oidc_identity_not_found": "Identidad vinculada no encontrada",
+		"oidc_last_login_method":  "Es la única forma de iniciar sesión de su cuenta; defina una contraseña antes de desvincularla",
+
+		"api_keys_disabled":       "Las API keys no están habilitadas",
+		"api_key_invalid":         "La API key es inválida, fue revocada o venció",
+		"api_key_not_found":       "API key no encontrada",
+		"api_key_scope_invalid":   "Scope inválido, use <recurso>:read o <recurso>:write",
+		"api_key_expiry_invalid":  "La fecha de vencimiento es inválida o demasiado lejana",
+		"api_key_limit":           "Alcanzó el máximo de API keys vigentes, revoque alguna",
+		"api_key_route_forbidden": "Esta ruta no acepta API keys, inicie sesión",
+		"api_key_scope_missing":   "La API key no tiene el scope necesario",
+
+		"role_not_found":     "Rol inexistente",
+		"permission_invalid": "Permiso inexistente",
+		"role_lockout":       "El rol admin no puede perder el permiso de administrar roles",
+		"account_disabled":   "La cuenta está deshabilitada",
+		"last_admin":         "No se puede quitar al último administrador activo",
+		"admin_self_action":  "No puede aplicar esta acción a su propia cuenta",
+
+		"impersonation_not_allowed": "No se puede suplantar a un usuario con permisos de administración",
+		"impersonation_forbidden":   "Esta operación no está permitida durante una suplantación",
+		"not_impersonating":         "El pedido no se hizo con un token de suplantación",
+
+		"exercise_not_found": "Ejercicio no encontrado",
+		"invalid_exercise":   "Datos de ejercicio inválidos",
+		"not_exercise_owner": "No puede modificar un ejercicio que no creó",
+
+		"routine_not_found": "Rutina no encontrada",
+		"routine_required":  "La rutina de origen es obligatoria",
+		"owner_required":    "El dueño es obligatorio",
+		"invalid_routine":   "Datos de rutina inválidos",
+		"unknown_exercises": "La rutina referencia ejercicios que no existen",
+		"not_routine_owner": "No es el dueño de la rutina",
+		"routine_private":   "La rutina es privada",
+
+		"workout_not_found": "Workout no encontrado",
+		"not_workout_owner": "No tiene permiso sobre este workout",
+	},
+	English: {
+		"internal_error": "Internal error",
+		"timeout":        "The operation took too long",
+		"not_found":      "Resource not found",
+		"conflict":       "Resource already exists",
+		"invalid_body":   "Invalid request body",
+		"invalid_id":     "Invalid id",
+		"id_required":    "Id is required",
+
+		"not_authenticated":      "User not authenticated",
+		"token_required":         "Authorization token required",
+		"token_malformed":        "Malformed token",
+		"token_invalid":          "Invalid token",
+		"token_revoked":          "The token was revoked, please log in again",
+		"permission_required":    "You are not allowed to perform this operation",
+		"invalid_credentials":    "Invalid credentials",
+		"credentials_required":   "Email and password are required",
+		"refresh_token_required": "Refresh token is required",
+		"refresh_token_invalid":  "Invalid or expired refresh token",
+		"refresh_token_revoked":  "Invalid or revoked refresh token",
+		"refresh_token_reused":   "Refresh token already used: the session was closed, please log in again",
+		"session_not_found":      "Session not found",
+		"session_unknown":        "Could not identify the current session, please log in again",
+
+		"user_not_found":        "User not found",
+		"user_required":         "User is required",
+		"incomplete_data":       "Required fields are missing",
+		"invalid_email":         "Invalid email",
+		"invalid_date_of_birth": "Invalid date of birth, use ISO format",
+		"unsupported_language":  "Unsupported language",
+		"email_taken":           "Email is already registered",
+		"wrong_password":        "Current password is incorrect",
+		"password_invalid":      "The password must be at least 6 characters and at most 72 bytes long",
+		"invalid_role":          "Invalid role",
+
+		"verification_token_invalid": "The verification link is invalid, already used or expired",
+		"email_already_verified":     "The email is already verified",
+		"verification_throttled":     "A verification email was already sent, wait a few minutes before requesting another",
+		"email_not_verified":         "Confirm your email to publish routines",
+		"reset_token_invalid":        "The password reset link is invalid, already used or expired",
+
+		"mfa_already_enabled":        "Two-factor authentication is already enabled",
+		"mfa_not_enabled":            "Two-factor authentication is not enabled",
+		"mfa_enrollment_not_started": "Start two-factor enrollment first",
+		"mfa_code_invalid":           "The code is invalid or was already used",
+		"mfa_required_for_role":      "Your role cannot disable two-factor authentication",
+		"mfa_token_invalid":          "The two-factor challenge is invalid or expired, log in again",
+		"mfa_required":               "Your role requires logging in with two-factor authentication",
+
+		"too_many_attempts":    "Too many failed attempts, wait before trying again",
+		"account_locked":       "The account is locked after too many failed attempts. Check your email to unlock it",
+		"unlock_token_invalid": "The unlock link is invalid, already used or expired",
+		"rate_limited":         "Too many requests, wait a few seconds before trying again",
+
+		"oidc_provider_unknown":   "Unknown login provider",
+		"oidc_state_invalid":      "The external login is invalid or expired, try again",
+		"oidc_login_failed":       "The provider did not confirm the login",
+		"oidc_email_not_verified": "The provider does not confirm that your email is verified",
+		"oidc_identity_not_found": "Linked identity not found",
+		"oidc_last_login_method":  "This is the only way to log in to your account; set a password before unlinking it",
+
+		"api_keys_disabled":       "API keys are not enabled",
+		"api_key_invalid":         "The API key is invalid, revoked or expired",
+		"api_key_not_found":       "API key not found",
+		"api_key_scope_invalid":   "Invalid scope, use <resource>:read or <resource>:write",
+		"api_key_expiry_invalid":  "The expiration date is invalid or too far away",
+		"api_key_limit":           "You reached the maximum number of active API keys, revoke one",
+		"api_key_route_forbidden": "This route does not accept API keys, log in",
+		"api_key_scope_missing":   "The API key lacks the required scope",
+
+		"role_not_found":     "Role not found",
+		"permission_invalid": "Unknown permission",
+		"role_lockout":       "The admin role cannot lose the permission to manage roles",
+		"account_disabled":   "The account is disabled",
+		"last_admin":         "The last active administrator cannot be removed",
+		"admin_self_action":  "You cannot apply this action to your own account",
+
+		"impersonation_not_allowed": "Users with administrative permissions cannot be impersonated",
+		"impersonation_forbidden":   "This operation is not allowed while impersonating",
+		"not_impersonating":         "The request was not made with an impersonation token",
+
+		"exercise_not_found": "Exercise not found",
+		"invalid_exercise":   "Invalid exercise data",
+		"not_exercise_owner": "You cannot modify an exercise you did not create",
+
+		"routine_not_found": "Routine not found",
+		"routine_required":  "Source routine is required",
+		"owner_required":    "Owner is required",
+		"invalid_routine":   "Invalid routine data",
+		"unknown_exercises": "The routine references exercises that do not exist",
+		"not_routine_owner": "You do not own this routine",
+		"routine_private":   "This routine is private",
+
+		"workout_not_found": "Workout not found",
+		"not_workout_owner": "You are not allowed to access this workout",
+	},
+}
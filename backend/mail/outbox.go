@@ -0,0 +1,83 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Outbox no envía nada: guarda los mensajes en memoria y, si tiene
+// directorio, también como archivos .eml para abrirlos en desarrollo.
+type Outbox struct {
+	dir  string
+	from string
+
+	mu       sync.Mutex
+	messages []Message
+}
+
+// NewOutbox crea un outbox; con dir vacío los mensajes solo quedan en memoria.
+func NewOutbox(dir, from string) *Outbox {
+	return &Outbox{dir: dir, from: from}
+}
+
+func (o *Outbox) Send(ctx context.Context, msg Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := validHeader(msg.To, msg.Subject); err != nil {
+		return err
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.dir != "" {
+		if err := os.MkdirAll(o.dir, 0o755); err != nil {
+			return err
+		}
+		now := time.Now()
+		name := fmt.Sprintf("%s-%03d-%s.eml", now.Format("20060102T150405.000"), len(o.messages), sanitize(msg.To))
+		if err := os.WriteFile(filepath.Join(o.dir, name), format(o.from, msg, now), 0o644); err != nil {
+			return err
+		}
+	}
+	o.messages = append(o.messages, msg)
+	return nil
+}
+
+// Messages devuelve una copia de los mensajes enviados, en orden.
+func (o *Outbox) Messages() []Message {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	out := make([]Message, len(o.messages))
+	copy(out, o.messages)
+	return out
+}
+
+// Last devuelve el último mensaje enviado a to.
+func (o *Outbox) Last(to string) (Message, bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for i := len(o.messages) - 1; i >= 0; i-- {
+		if o.messages[i].To == to {
+			return o.messages[i], true
+		}
+	}
+	return Message{}, false
+}
+
+func sanitize(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '_', r == '@':
+			return r
+		default:
+			return '_'
+		}
+	}, s)
+}
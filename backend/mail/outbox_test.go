@@ -0,0 +1,40 @@
+package mail
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOutbox_KeepsMessagesAndWritesFiles(t *testing.T) {
+	dir := t.TempDir()
+	outbox := NewOutbox(dir, "no-reply@example.com")
+
+	msg := Message{To: "alice@example.com", Subject: "Hola", Body: "línea 1\nlínea 2"}
+	if err := outbox.Send(context.Background(), msg); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+
+	last, ok := outbox.Last("alice@example.com")
+	if !ok || last.Subject != "Hola" {
+		t.Fatalf("expected the message in memory, got %+v", last)
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "*.eml"))
+	if len(files) != 1 {
+		t.Fatalf("expected 1 .eml file, got %v", files)
+	}
+	data, _ := os.ReadFile(files[0])
+	if !strings.Contains(string(data), "To: alice@example.com\r\n") || !strings.Contains(string(data), "línea 1\r\nlínea 2") {
+		t.Fatalf("unexpected file content: %q", data)
+	}
+}
+
+func TestOutbox_RejectsHeaderInjection(t *testing.T) {
+	outbox := NewOutbox("", "no-reply@example.com")
+	err := outbox.Send(context.Background(), Message{To: "a@example.com\r\nBcc: b@example.com", Subject: "x"})
+	if err == nil {
+		t.Fatalf("expected an error for a recipient with a line break")
+	}
+}
@@ -0,0 +1,92 @@
+package mail
+
+import (
+	"fmt"
+	"time"
+
+	"backend/i18n"
+)
+
+type template struct {
+	subject string
+	// body recibe el link y la vigencia ya formateada ("24 horas").
+	body string
+}
+
+var verificationTemplates = map[string]template{
+	i18n.Spanish: {
+		subject: "Confirme su email",
+		body: "Para confirmar su dirección de email abra este link:\n\n%s\n\n" +
+			"El link vence en %s. Si usted no creó una cuenta, ignore este mensaje.\n",
+	},
+	i18n.English: {
+		subject: "Confirm your email",
+		body: "To confirm your email address open this link:\n\n%s\n\n" +
+			"The link expires in %s. If you did not create an account, ignore this message.\n",
+	},
+}
+
+var passwordResetTemplates = map[string]template{
+	i18n.Spanish: {
+		subject: "Restablecer contraseña",
+		body: "Recibimos un pedido para restablecer su contraseña. Para elegir una nueva abra este link:\n\n%s\n\n" +
+			"El link vence en %s y solo se puede usar una vez. Si usted no lo pidió, ignore este mensaje: su contraseña no cambia.\n",
+	},
+	i18n.English: {
+		subject: "Reset your password",
+		body: "We received a request to reset your password. To choose a new one open this link:\n\n%s\n\n" +
+			"The link expires in %s and can only be used once. If you did not request it, ignore this message: your password stays the same.\n",
+	},
+}
+
+var accountUnlockTemplates = map[string]template{
+	i18n.Spanish: {
+		subject: "Su cuenta fue bloqueada",
+		body: "Bloqueamos el inicio de sesión en su cuenta después de varios intentos con una contraseña incorrecta. Para desbloquearla ahora abra este link:\n\n%s\n\n" +
+			"Si no hace nada, el bloqueo se levanta solo en %s. Si los intentos no fueron suyos, le recomendamos cambiar la contraseña.\n",
+	},
+	i18n.English: {
+		subject: "Your account was locked",
+		body: "We locked sign-in to your account after several attempts with a wrong password. To unlock it now open this link:\n\n%s\n\n" +
+			"If you do nothing, the lock is lifted automatically in %s. If those attempts were not yours, we recommend changing your password.\n",
+	},
+}
+
+// VerificationMessage arma el correo con el link de verificación en el idioma
+// del usuario.
+func VerificationMessage(to, lang, link string, ttl time.Duration) Message {
+	return render(verificationTemplates, to, lang, link, ttl)
+}
+
+// PasswordResetMessage arma el correo con el link para restablecer la
+// contraseña.
+func PasswordResetMessage(to, lang, link string, ttl time.Duration) Message {
+	return render(passwordResetTemplates, to, lang, link, ttl)
+}
+
+// AccountUnlockMessage avisa del bloqueo por intentos fallidos y trae el link
+// para levantarlo antes de que venza.
+func AccountUnlockMessage(to, lang, link string, lockout time.Duration) Message {
+	return render(accountUnlockTemplates, to, lang, link, lockout)
+}
+
+func render(templates map[string]template, to, lang, link string, ttl time.Duration) Message {
+	t, ok := templates[lang]
+	if !ok {
+		lang = i18n.Default
+		t = templates[lang]
+	}
+	return Message{To: to, Subject: t.subject, Body: fmt.Sprintf(t.body, link, validity(lang, ttl))}
+}
+
+// validity expresa ttl en horas o, si es menos de una hora, en minutos.
+func validity(lang string, ttl time.Duration) string {
+	hours, minutes := "horas", "minutos"
+	if lang == i18n.English {
+		hours, minutes = "hours", "minutes"
+	}
+	if ttl < time.Hour {
+		return fmt.Sprintf("%d %s", max(int(ttl.Round(time.Minute)/time.Minute), 1), minutes)
+	}
+	return fmt.Sprintf("%d %s", int(ttl.Round(time.Hour)/time.Hour), hours)
+}
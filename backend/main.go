@@ -8,3 +8,409 @@ package main
 
 // repositories: métodos de acceso a datos
 // model: estructuras para mapeos de ORM
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"backend/auth"
+	"backend/config"
+	"backend/database"
+	"backend/handlers"
+	"backend/mail"
+	"backend/middleware"
+	"backend/migrations"
+	"backend/models"
+	"backend/oidc"
+	"backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+func main() {
+	configPath := flag.String("config", os.Getenv("APP_CONFIG"), "ruta al archivo de configuración (YAML o JSON)")
+	flag.Parse()
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if cfg.Env == config.EnvProduction {
+		gin.SetMode(gin.ReleaseMode)
+	}
+
+	if cfg.Auth.JWTSecret == "" {
+		log.Println("JWT_SECRET no definido: se usa un secreto aleatorio, los tokens no sobreviven a un reinicio")
+	}
+	auth.Configure(auth.Settings{
+		Secret:              []byte(cfg.Auth.JWTSecret),
+		AccessTokenTTL:      cfg.Auth.AccessTokenTTL,
+		RefreshTokenTTL:     cfg.Auth.RefreshTokenTTL,
+		ImpersonationTTL:    cfg.Auth.Impersonation.TTL,
+		BcryptCost:          cfg.Auth.BcryptCost,
+		RefreshTokenHashKey: []byte(cfg.Auth.RefreshTokenHashKey),
+		MFAKey:              []byte(cfg.Auth.MFA.EncryptionKey),
+	})
+
+	var repos repositorySet
+	var mongoDB *database.MongoDB
+	switch cfg.Storage.Driver {
+	case config.StorageMemory:
+		log.Println("usando almacenamiento en memoria: los datos se pierden al reiniciar")
+		repos = newMemoryRepositories()
+	default:
+		mongoDB, err = database.NewMongoDB(database.Options{
+			URI:            cfg.Mongo.URI,
+			Database:       cfg.Mongo.Database,
+			MaxPoolSize:    cfg.Mongo.MaxPoolSize,
+			MinPoolSize:    cfg.Mongo.MinPoolSize,
+			ConnectTimeout: cfg.Mongo.ConnectTimeout,
+			QueryTimeout:   cfg.Mongo.QueryTimeout,
+		})
+		if err != nil {
+			log.Fatalf("no se pudo conectar a MongoDB: %v", err)
+		}
+		if cfg.Mongo.Indexes.Bootstrap {
+			bootstrapIndexes(mongoDB, cfg.Mongo.Indexes.Rebuild)
+		}
+		warnPendingMigrations(mongoDB)
+		repos = newMongoRepositories(mongoDB)
+	}
+
+	keysCtx, stopKeys := context.WithCancel(context.Background())
+	defer stopKeys()
+	if cfg.Auth.Signing.ManagedKeys() {
+		startKeyManager(keysCtx, cfg, repos)
+	}
+
+	router := setupRouter(cfg, repos)
+
+	server := &http.Server{
+		Addr:    cfg.Server.Addr,
+		Handler: router,
+	}
+
+	go func() {
+		log.Printf("servidor escuchando en %s", server.Addr)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("error en el servidor HTTP: %v", err)
+		}
+	}()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+	log.Println("apagando servidor...")
+	stopKeys()
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(ctx); err != nil {
+		log.Printf("error cerrando conexiones HTTP: %v", err)
+	}
+	if mongoDB != nil {
+		if err := mongoDB.Disconnect(); err != nil {
+			log.Printf("error desconectando MongoDB: %v", err)
+		}
+	}
+	log.Println("servidor detenido")
+}
+
+// startKeyManager carga (o genera) las claves de firma y las rota en segundo
+// plano. Sin una clave con la que firmar el servidor no puede emitir tokens,
+// así que un error al cargar es fatal.
+func startKeyManager(ctx context.Context, cfg config.Config, repos repositorySet) {
+	grace := cfg.Auth.Signing.GracePeriod
+	if grace == 0 {
+		grace = cfg.Auth.RefreshTokenTTL
+	}
+	keys := auth.NewKeyManager(repos.signingKeys, auth.KeyManagerOptions{
+		Algorithm:        cfg.Auth.Signing.Algorithm,
+		RotationInterval: cfg.Auth.Signing.RotationInterval,
+		GracePeriod:      grace,
+		EncryptionKey:    []byte(cfg.Auth.Signing.KeyEncryptionKey),
+	})
+
+	loadCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	if err := keys.Load(loadCtx); err != nil {
+		log.Fatalf("no se pudieron cargar las claves de firma: %v", err)
+	}
+	auth.UseKeyManager(keys)
+	go keys.Run(ctx)
+}
+
+// bootstrapIndexes crea los índices faltantes y deja en el log el drift que no
+// se corrigió. Un error no impide arrancar: la API funciona sin índices, solo
+// más lenta y sin la garantía de email único.
+func bootstrapIndexes(db *database.MongoDB, rebuild bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	drift, err := database.EnsureIndexes(ctx, db.GetDatabase(), database.Indexes, database.BootstrapOptions{Rebuild: rebuild})
+	for _, d := range drift {
+		log.Printf("drift de índices: %s", d)
+	}
+	if err != nil {
+		log.Printf("error creando índices: %v", err)
+	}
+}
+
+// warnPendingMigrations no aplica nada: las migraciones se corren con
+// cmd/migrate para que solo una instancia las ejecute de forma controlada.
+func warnPendingMigrations(db *database.MongoDB) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	runner, err := migrations.NewRunner(db.GetDatabase(), migrations.All())
+	if err != nil {
+		log.Fatal(err)
+	}
+	pending, err := runner.Pending(ctx)
+	if err != nil {
+		log.Printf("no se pudo consultar schema_migrations: %v", err)
+		return
+	}
+	for _, m := range pending {
+		log.Printf("migración pendiente: %s (ejecutar go run ./cmd/migrate up)", m)
+	}
+}
+
+// newMailer elige la implementación según mail.driver. Con outbox los correos
+// no salen: quedan como .eml en mail.outbox_dir.
+func newMailer(cfg config.MailConfig) mail.Mailer {
+	if cfg.Driver == config.MailSMTP {
+		return mail.NewSMTPMailer(mail.SMTPConfig{
+			Host:     cfg.SMTP.Host,
+			Port:     cfg.SMTP.Port,
+			Username: cfg.SMTP.Username,
+			Password: cfg.SMTP.Password,
+			From:     cfg.From,
+		})
+	}
+	log.Printf("mail.driver outbox: los correos no se envían, se guardan en %s", cfg.OutboxDir)
+	return mail.NewOutbox(cfg.OutboxDir, cfg.From)
+}
+
+// newOIDCProviders arma los proveedores de auth.oidc. El discovery se hace en
+// el primer login, así que un proveedor caído no impide arrancar.
+func newOIDCProviders(cfg config.OIDCConfig) []*oidc.Provider {
+	providers := make([]*oidc.Provider, 0, len(cfg.Providers))
+	for _, p := range cfg.Providers {
+		providers = append(providers, oidc.NewProvider(oidc.Config{
+			Name:         p.Name,
+			Issuer:       p.Issuer,
+			ClientID:     p.ClientID,
+			ClientSecret: p.ClientSecret,
+			RedirectURL:  cfg.RedirectURL,
+			Scopes:       p.Scopes,
+		}, nil))
+	}
+	return providers
+}
+
+func setupRouter(cfg config.Config, repos repositorySet) *gin.Engine {
+	mailer := newMailer(cfg.Mail)
+	revocationService := services.NewTokenRevocationService(repos.revocations, cfg.Auth.RevocationCacheTTL)
+	verificationService := services.NewEmailVerificationService(repos.users, repos.verifications, mailer, services.EmailVerificationOptions{
+		TokenTTL:       cfg.Auth.EmailVerification.TokenTTL,
+		ResendCooldown: cfg.Auth.EmailVerification.ResendCooldown,
+		MaxPerHour:     cfg.Auth.EmailVerification.MaxPerHour,
+		LinkBaseURL:    cfg.Auth.EmailVerification.LinkURL,
+	})
+	throttleService := services.NewLoginThrottleService(repos.loginAttempts, repos.users, mailer, services.LoginThrottleOptions{
+		EmailFreeAttempts: cfg.Auth.BruteForce.EmailFreeAttempts,
+		IPFreeAttempts:    cfg.Auth.BruteForce.IPFreeAttempts,
+		BackoffBase:       cfg.Auth.BruteForce.BackoffBase,
+		BackoffMax:        cfg.Auth.BruteForce.BackoffMax,
+		LockoutThreshold:  cfg.Auth.BruteForce.LockoutThreshold,
+		LockoutDuration:   cfg.Auth.BruteForce.LockoutDuration,
+		Window:            cfg.Auth.BruteForce.Window,
+		UnlockLinkBaseURL: cfg.Auth.BruteForce.UnlockURL,
+	})
+	passwordResetService := services.NewPasswordResetService(repos.users, repos.resets, repos.refreshTokens, revocationService, throttleService, mailer, services.PasswordResetOptions{
+		TokenTTL:    cfg.Auth.PasswordReset.TokenTTL,
+		MaxPerHour:  cfg.Auth.PasswordReset.MaxPerHour,
+		LinkBaseURL: cfg.Auth.PasswordReset.LinkURL,
+	})
+	userService := services.NewUserService(repos.users, repos.refreshTokens, revocationService, verificationService, repos.apiKeys, repos.identities)
+	mfaService := services.NewMFAService(repos.users, services.MFAOptions{
+		Issuer:        cfg.Auth.MFA.Issuer,
+		RequiredRoles: cfg.Auth.MFA.RequiredRoles,
+	})
+	oidcService := services.NewOIDCService(newOIDCProviders(cfg.Auth.OIDC), repos.users, repos.oidcStates, repos.identities, repos.refreshTokens, revocationService, services.OIDCOptions{
+		StateTTL: cfg.Auth.OIDC.StateTTL,
+	})
+	apiKeyOptions := services.APIKeyOptions{
+		DefaultTTL: cfg.Auth.APIKeys.DefaultTTL,
+		MaxTTL:     cfg.Auth.APIKeys.MaxTTL,
+		MaxPerUser: cfg.Auth.APIKeys.MaxPerUser,
+	}
+	if cfg.Auth.APIKeys.Enabled {
+		apiKeyOptions.HashKey = []byte(cfg.Auth.APIKeys.HashKey)
+	}
+	apiKeyService := services.NewAPIKeyService(repos.apiKeys, repos.users, apiKeyOptions)
+	rbacService := services.NewRBACService(repos.roles, cfg.Auth.PermissionsCacheTTL)
+	auditService := services.NewAuditService(repos.audit)
+	exerciseService := services.NewExerciseService(repos.exercises)
+	routineService := services.NewRoutineService(repos.routines, repos.exercises, repos.users)
+	workoutService := services.NewWorkoutService(repos.workouts)
+
+	userHandler := handlers.NewUserHandler(userService, repos.refreshTokens, revocationService, mfaService, throttleService)
+	exerciseHandler := handlers.NewExerciseHandler(exerciseService)
+	routineHandler := handlers.NewRoutineHandler(routineService)
+	verificationHandler := handlers.NewEmailVerificationHandler(verificationService)
+	passwordResetHandler := handlers.NewPasswordResetHandler(passwordResetService, throttleService)
+	mfaHandler := handlers.NewMFAHandler(mfaService)
+	oidcHandler := handlers.NewOIDCHandler(oidcService, userHandler)
+	apiKeyHandler := handlers.NewAPIKeyHandler(apiKeyService)
+	roleHandler := handlers.NewRoleHandler(rbacService, auditService)
+	adminHandler := handlers.NewAdminHandler(userService, auditService)
+	impersonationHandler := handlers.NewImpersonationHandler(userService, revocationService, auditService, rbacService)
+
+	limiter := newRateLimiter(cfg.RateLimit, repos)
+	rateLimit := func(name string, p config.RateLimitPolicy) gin.HandlerFunc {
+		if !cfg.RateLimit.Enabled {
+			return func(c *gin.Context) { c.Next() }
+		}
+		return middleware.RateLimit(limiter, services.RateLimitPolicy{Name: name, Requests: p.Requests, Per: p.Per, RoleRequests: p.Roles})
+	}
+
+	router := gin.Default()
+	router.Use(middleware.Errors())
+	router.Use(middleware.CORS(cfg.Server.CORSOrigins))
+	router.Use(middleware.RequestTimeout(cfg.Server.RequestTimeout))
+	router.Static("/static", "./static")
+
+	router.GET("/health", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	})
+	router.GET("/.well-known/jwks.json", handlers.JWKS)
+
+	authRoutes := router.Group("/auth")
+	authRoutes.Use(rateLimit("auth", cfg.RateLimit.Policies.Auth))
+	{
+		authRoutes.POST("/register", userHandler.Register)
+		authRoutes.POST("/login", userHandler.Login)
+		authRoutes.POST("/login/mfa", userHandler.LoginMFA)
+		authRoutes.POST("/refresh", userHandler.Refresh)
+		authRoutes.POST("/logout", userHandler.Logout)
+		authRoutes.POST("/verify-email", verificationHandler.VerifyEmail)
+		authRoutes.POST("/unlock", userHandler.UnlockAccount)
+		authRoutes.POST("/password/forgot", passwordResetHandler.ForgotPassword)
+		authRoutes.POST("/password/reset", passwordResetHandler.ResetPassword)
+		authRoutes.GET("/oidc/providers", oidcHandler.ListProviders)
+		authRoutes.POST("/oidc/:provider/start", oidcHandler.Start)
+		authRoutes.POST("/oidc/callback", oidcHandler.Callback)
+	}
+
+	api := router.Group("/api")
+	api.Use(middleware.AuthMiddleware(revocationService, apiKeyService))
+	api.Use(middleware.AuditImpersonation(auditService))
+	// Las API keys solo llegan a estos grupos; /api/me y la administración
+	// exigen una sesión.
+	api.Use(middleware.APIKeyScopes(map[string]string{
+		"/api/exercises": models.APIKeyResourceExercises,
+		"/api/routines":  models.APIKeyResourceRoutines,
+		"/api/workouts":  models.APIKeyResourceWorkouts,
+	}))
+	api.Use(middleware.RequireMFA(cfg.Auth.MFA.RequiredRoles, "/api/me"))
+	api.Use(middleware.Permissions(rbacService))
+	api.Use(rateLimit("api", cfg.RateLimit.Policies.API))
+
+	me := api.Group("/me")
+	// Durante una suplantación se puede ver la cuenta pero no cambiar datos,
+	// credenciales, sesiones ni API keys.
+	me.Use(middleware.ForbidImpersonation(http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete))
+	{
+		me.GET("", userHandler.GetMe)
+		me.PUT("", userHandler.UpdateMe)
+		me.PUT("/password", userHandler.ChangePassword)
+		me.POST("/verification", verificationHandler.ResendVerification)
+		me.GET("/sessions", userHandler.ListSessions)
+		me.DELETE("/sessions", userHandler.RevokeOtherSessions)
+		me.DELETE("/sessions/:id", userHandler.RevokeSession)
+		me.POST("/mfa/totp", mfaHandler.StartTOTP)
+		me.POST("/mfa/totp/confirm", mfaHandler.ConfirmTOTP)
+		me.POST("/mfa/recovery-codes", mfaHandler.RegenerateRecoveryCodes)
+		me.DELETE("/mfa", mfaHandler.DisableMFA)
+		me.GET("/identities", oidcHandler.ListIdentities)
+		me.DELETE("/identities/:id", oidcHandler.UnlinkIdentity)
+		me.GET("/api-keys", apiKeyHandler.ListAPIKeys)
+		me.POST("/api-keys", apiKeyHandler.CreateAPIKey)
+		me.DELETE("/api-keys/:id", apiKeyHandler.RevokeAPIKey)
+		me.GET("/permissions", roleHandler.MyPermissions)
+	}
+
+	// GET /api/users/:id sigue abierto a cualquier usuario autenticado, que ve
+	// solo el perfil público; con permiso de administración la lectura
+	// completa pasa por la versión auditada.
+	api.GET("/users/:id", func(c *gin.Context) {
+		if middleware.HasPermission(c, models.PermUserManage) && !middleware.Impersonating(c) {
+			adminHandler.GetUser(c)
+			return
+		}
+		userHandler.GetUserByID(c)
+	})
+	users := api.Group("/users")
+	users.Use(middleware.ForbidImpersonation(), middleware.RequirePermission(models.PermUserManage))
+	{
+		users.GET("", adminHandler.ListUsers)
+		users.PUT("/:id/role", adminHandler.ChangeRole)
+		users.POST("/:id/disable", adminHandler.DisableUser)
+		users.POST("/:id/enable", adminHandler.EnableUser)
+		users.DELETE("/:id/sessions", adminHandler.RevokeUserSessions)
+		users.DELETE("/:id", adminHandler.DeleteUser)
+	}
+	api.POST("/users/:id/impersonate", middleware.ForbidImpersonation(), middleware.RequirePermission(models.PermUserImpersonate), impersonationHandler.Impersonate)
+	api.DELETE("/impersonation", impersonationHandler.EndImpersonation)
+	api.GET("/audit-events", middleware.ForbidImpersonation(), middleware.RequirePermission(models.PermAuditRead), adminHandler.ListAuditEvents)
+
+	roles := api.Group("/roles")
+	roles.Use(middleware.ForbidImpersonation(), middleware.RequirePermission(models.PermRoleManage))
+	{
+		roles.GET("", roleHandler.ListRoles)
+		roles.PUT("/:role", roleHandler.SetRolePermissions)
+		roles.DELETE("/:role", roleHandler.ResetRolePermissions)
+	}
+
+	exercises := api.Group("/exercises")
+	exercises.Use(rateLimit("exercises", cfg.RateLimit.Policies.Exercises))
+	{
+		exercises.GET("", exerciseHandler.GetExercise)
+		exercises.GET("/:id", exerciseHandler.GetExercise)
+		exercises.POST("", middleware.RequirePermission(models.PermExerciseWrite), exerciseHandler.CreateExercise)
+		// Con exercise:write se editan los propios; con exercise:moderate,
+		// cualquiera.
+		exercises.PUT("/:id", middleware.RequirePermission(models.PermExerciseWrite, models.PermExerciseModerate), exerciseHandler.UpdateExercise)
+		exercises.DELETE("/:id", middleware.RequirePermission(models.PermExerciseWrite, models.PermExerciseModerate), exerciseHandler.DeleteExercise)
+	}
+
+	routines := api.Group("/routines")
+	{
+		routines.GET("", routineHandler.GetRoutines)
+		routines.GET("/:id", routineHandler.GetRoutineByID)
+		routines.POST("", routineHandler.CreateRoutine)
+		routines.PUT("/:id", routineHandler.UpdateRoutine)
+		routines.DELETE("/:id", routineHandler.DeleteRoutine)
+	}
+
+	workouts := api.Group("/workouts")
+	workouts.Use(rateLimit("workouts", cfg.RateLimit.Policies.Workouts))
+	{
+		workouts.GET("", func(c *gin.Context) { handlers.GetWorkout(c, workoutService) })
+		workouts.POST("", func(c *gin.Context) { handlers.CreateWorkout(c, workoutService) })
+		workouts.PUT("/:id", func(c *gin.Context) { handlers.UpdateWorkout(c, workoutService) })
+		workouts.DELETE("/:id", func(c *gin.Context) { handlers.DeleteWorkout(c, workoutService) })
+	}
+
+	return router
+}
@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"backend/apperrors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyScopes limita los pedidos hechos con API key. resources asocia
+// prefijos de ruta (como los registra gin) con el recurso de los scopes; las
+// rutas que no figuran solo aceptan sesiones. GET y HEAD piden
+// "<recurso>:read" y el resto "<recurso>:write", que también habilita leer.
+func APIKeyScopes(resources map[string]string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := c.Get("api_key_id"); !ok {
+			c.Next()
+			return
+		}
+
+		resource := ""
+		for prefix, r := range resources {
+			if c.FullPath() == prefix || strings.HasPrefix(c.FullPath(), prefix+"/") {
+				resource = r
+				break
+			}
+		}
+		if resource == "" {
+			abort(c, apperrors.Forbidden("api_key_route_forbidden", "esta ruta no acepta API keys, inicie sesión"))
+			return
+		}
+
+		scopes := c.GetStringSlice("api_key_scopes")
+		allowed := slices.Contains(scopes, resource+":write")
+		if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+			allowed = allowed || slices.Contains(scopes, resource+":read")
+		}
+		if !allowed {
+			abort(c, apperrors.WithDetail(apperrors.Forbidden("api_key_scope_missing", "la API key no tiene el scope necesario"), resource))
+			return
+		}
+		c.Next()
+	}
+}
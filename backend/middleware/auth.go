@@ -1,42 +1,108 @@
 package middleware
 
 import (
-	"net/http"
+	"context"
 	"strings"
 
+	"backend/apperrors"
 	"backend/auth"
+	"backend/services"
 
 	"github.com/gin-gonic/gin"
 )
 
-func AuthMiddleware() gin.HandlerFunc {
+// RevocationChecker indica si un token con firma válida fue revocado antes de
+// vencer (logout, cambio de contraseña o de rol).
+type RevocationChecker interface {
+	IsRevoked(ctx context.Context, claims *auth.Claims) (bool, error)
+}
+
+// APIKeyAuthenticator resuelve una API key al usuario dueño.
+type APIKeyAuthenticator interface {
+	Authenticate(ctx context.Context, key, ip string) (services.APIKeyPrincipal, error)
+}
+
+// AuthMiddleware acepta un access token (Authorization: Bearer) o una API key
+// (X-API-Key o Authorization: Token). Con API key además quedan api_key_id y
+// api_key_scopes en el contexto para APIKeyScopes. Con un token de
+// suplantación quedan impersonator_id e impersonator_email y la respuesta
+// lleva ImpersonatedByHeader.
+func AuthMiddleware(revocations RevocationChecker, apiKeys APIKeyAuthenticator) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Token de autorización requerido"})
-			c.Abort()
+		apiKey := c.GetHeader("X-API-Key")
+		if authHeader == "" && apiKey == "" {
+			abort(c, apperrors.Unauthorized("token_required", "Token de autorización requerido"))
 			return
 		}
 
 		tokenParts := strings.Split(authHeader, " ")
+		if apiKey == "" && len(tokenParts) == 2 && tokenParts[0] == "Token" {
+			apiKey = tokenParts[1]
+		}
+		if apiKey != "" {
+			authenticateAPIKey(c, apiKeys, apiKey)
+			return
+		}
 		if len(tokenParts) != 2 || tokenParts[0] != "Bearer" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Formato de token inválido"})
-			c.Abort()
+			abort(c, apperrors.Unauthorized("token_malformed", "Formato de token inválido"))
 			return
 		}
 
 		tokenString := tokenParts[1]
-		claims, err := auth.ValidateToken(tokenString)
+		claims, err := auth.ValidateAccessToken(tokenString)
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Token inválido"})
-			c.Abort()
+			abort(c, apperrors.Unauthorized("token_invalid", "Token inválido"))
+			return
+		}
+		revoked, err := revocations.IsRevoked(c.Request.Context(), claims)
+		if err == nil && !revoked && claims.Impersonated() {
+			// La suplantación termina también cuando se revocan los tokens
+			// del administrador (logout global, cambio de rol, cuenta
+			// deshabilitada).
+			actor := *claims
+			actor.UserID = claims.Act.UserID
+			revoked, err = revocations.IsRevoked(c.Request.Context(), &actor)
+		}
+		if err != nil {
+			abort(c, err)
+			return
+		}
+		if revoked {
+			abort(c, apperrors.Unauthorized("token_revoked", "Token revocado"))
 			return
 		}
 
 		c.Set("user_id", claims.UserID)
 		c.Set("user_email", claims.Email)
 		c.Set("user_role", claims.Role)
+		c.Set("user_lang", claims.Lang)
+		c.Set("session_id", claims.SessionID)
+		c.Set("user_amr", claims.AMR)
+		if claims.Impersonated() {
+			c.Set("impersonator_id", claims.Act.UserID)
+			c.Set("impersonator_email", claims.Act.Email)
+			c.Header(ImpersonatedByHeader, claims.Act.Email)
+		}
 
 		c.Next()
 	}
 }
+
+func authenticateAPIKey(c *gin.Context, apiKeys APIKeyAuthenticator, key string) {
+	principal, err := apiKeys.Authenticate(c.Request.Context(), key, c.ClientIP())
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	c.Set("user_id", principal.UserID)
+	c.Set("user_email", principal.Email)
+	c.Set("user_role", principal.Role)
+	c.Set("user_lang", principal.Lang)
+	c.Set("user_amr", principal.AMR)
+	c.Set("api_key_id", principal.KeyID)
+	c.Set("api_key_scopes", principal.Scopes)
+
+	c.Next()
+}
@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"errors"
+	"log"
+	"strconv"
+
+	"backend/apperrors"
+	"backend/i18n"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Errors renderiza el último error registrado con c.Error como
+// {"error": mensaje, "code": código, "detail": ...}. El mensaje sale del
+// catálogo en el idioma de Language; el código no cambia con el idioma. Tiene
+// que ser el primer middleware para ver también los errores de autenticación y
+// de los demás middlewares.
+func Errors() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+		err := c.Errors.Last().Err
+		code := apperrors.Code(err)
+		lang := Language(c)
+
+		var appErr *apperrors.Error
+		isDomain := errors.As(err, &appErr)
+		if !isDomain {
+			// Los errores internos pueden traer detalles del driver o de la
+			// base: van al log y no al cliente.
+			log.Printf("%s %s: %v", c.Request.Method, c.Request.URL.Path, err)
+		}
+
+		message, ok := i18n.Message(lang, code)
+		if !ok && isDomain {
+			message = appErr.Message
+		}
+		body := gin.H{"error": message, "code": code}
+		if isDomain && appErr.Detail != "" {
+			body["detail"] = appErr.Detail
+		}
+
+		if isDomain && appErr.RetryAfter > 0 {
+			c.Header("Retry-After", strconv.Itoa(seconds(appErr.RetryAfter)))
+		}
+		c.Header("Content-Language", lang)
+		c.Writer.Header().Add("Vary", "Accept-Language")
+		c.JSON(apperrors.HTTPStatus(err), body)
+	}
+}
+
+// Language devuelve el idioma de la respuesta: la preferencia del usuario
+// autenticado si tiene una, o la mejor coincidencia de Accept-Language.
+func Language(c *gin.Context) string {
+	if lang := c.GetString("user_lang"); i18n.Supported(lang) {
+		return lang
+	}
+	return i18n.Negotiate(c.GetHeader("Accept-Language"))
+}
+
+// abort registra err para que lo renderice Errors y corta la cadena.
+func abort(c *gin.Context, err error) {
+	c.Error(err)
+	c.Abort()
+}
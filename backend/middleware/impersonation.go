@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"context"
+	"log"
+	"slices"
+	"strconv"
+
+	"backend/apperrors"
+	"backend/models"
+	"backend/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ImpersonatedByHeader va en cada respuesta a un pedido con token de
+// suplantación, con el email del administrador, para que el cliente lo
+// muestre.
+const ImpersonatedByHeader = "X-Impersonated-By"
+
+// AuditRecorder guarda un evento en el registro de auditoría.
+type AuditRecorder interface {
+	Record(ctx context.Context, event models.AuditEvent) error
+}
+
+// Impersonating indica si el pedido se hizo con un token de suplantación.
+func Impersonating(c *gin.Context) bool {
+	return c.GetString("impersonator_id") != ""
+}
+
+// ForbidImpersonation rechaza los pedidos hechos con un token de suplantación.
+// Con methods solo rechaza los de esos métodos HTTP.
+func ForbidImpersonation(methods ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if Impersonating(c) && (len(methods) == 0 || slices.Contains(methods, c.Request.Method)) {
+			abort(c, apperrors.Forbidden("impersonation_forbidden", "esta operación no está permitida durante una suplantación"))
+			return
+		}
+		c.Next()
+	}
+}
+
+// AuditImpersonation deja en el registro de auditoría cada pedido hecho con un
+// token de suplantación, con el administrador como actor. Va después de
+// AuthMiddleware.
+func AuditImpersonation(audit AuditRecorder) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		if !Impersonating(c) {
+			return
+		}
+
+		actor, _ := utils.ParseObjectID(c.GetString("impersonator_id"))
+		details := map[string]string{
+			"method": c.Request.Method,
+			"path":   c.Request.URL.Path,
+			"status": strconv.Itoa(c.Writer.Status()),
+		}
+		// Errors escribe la respuesta de error recién a la vuelta.
+		if len(c.Errors) > 0 && !c.Writer.Written() {
+			err := c.Errors.Last().Err
+			details["status"] = strconv.Itoa(apperrors.HTTPStatus(err))
+			details["code"] = apperrors.Code(err)
+		}
+		// El pedido pudo vencer o cancelarse; el evento se guarda igual.
+		ctx := context.WithoutCancel(c.Request.Context())
+		err := audit.Record(ctx, models.AuditEvent{
+			Action:     models.AuditImpersonatedRequest,
+			ActorID:    actor,
+			ActorEmail: c.GetString("impersonator_email"),
+			TargetType: "user",
+			TargetID:   c.GetString("user_id"),
+			Details:    details,
+			IP:         c.ClientIP(),
+			UserAgent:  c.Request.UserAgent(),
+		})
+		if err != nil {
+			log.Printf("security: event=audit_write_failed action=%s actor=%s target=%s err=%q", models.AuditImpersonatedRequest, actor.Hex(), c.GetString("user_id"), err)
+		}
+	}
+}
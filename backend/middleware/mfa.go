@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"slices"
+	"strings"
+
+	"backend/apperrors"
+	"backend/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireMFA rechaza a los usuarios de roles que deben usar segundo factor si
+// el token no viene de un login con código. Las rutas bajo los prefijos de
+// exempt quedan permitidas para que puedan enrolarse y volver a iniciar
+// sesión.
+func RequireMFA(roles []string, exempt ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !slices.Contains(roles, c.GetString("user_role")) {
+			c.Next()
+			return
+		}
+		if slices.Contains(c.GetStringSlice("user_amr"), auth.AMROTP) {
+			c.Next()
+			return
+		}
+		for _, prefix := range exempt {
+			if strings.HasPrefix(c.FullPath(), prefix) {
+				c.Next()
+				return
+			}
+		}
+		abort(c, apperrors.Forbidden("mfa_required", "su rol requiere iniciar sesión con segundo factor"))
+	}
+}
@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"backend/apperrors"
+	"backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PermissionResolver devuelve los permisos de un rol.
+type PermissionResolver interface {
+	Permissions(ctx context.Context, role string) ([]string, error)
+}
+
+// Permissions carga en user_permissions los permisos del rol del usuario
+// autenticado. Va después de AuthMiddleware.
+func Permissions(resolver PermissionResolver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		perms, err := resolver.Permissions(c.Request.Context(), c.GetString("user_role"))
+		if err != nil {
+			abort(c, err)
+			return
+		}
+		c.Set("user_permissions", perms)
+		c.Next()
+	}
+}
+
+// HasPermission dice si el usuario del pedido tiene el permiso.
+func HasPermission(c *gin.Context, perm models.Permission) bool {
+	return slices.Contains(c.GetStringSlice("user_permissions"), string(perm))
+}
+
+// RequirePermission deja pasar al usuario que tenga alguno de los permisos.
+func RequirePermission(perms ...models.Permission) gin.HandlerFunc {
+	names := make([]string, 0, len(perms))
+	for _, perm := range perms {
+		names = append(names, string(perm))
+	}
+	return func(c *gin.Context) {
+		if _, ok := c.Get("user_id"); !ok {
+			abort(c, apperrors.Unauthorized("not_authenticated", "Usuario no autenticado"))
+			return
+		}
+		for _, perm := range perms {
+			if HasPermission(c, perm) {
+				c.Next()
+				return
+			}
+		}
+		abort(c, apperrors.WithDetail(apperrors.Forbidden("permission_required", "no tiene permisos para esta operación"), strings.Join(names, ",")))
+	}
+}
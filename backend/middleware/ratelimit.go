@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"log"
+	"math"
+	"strconv"
+	"time"
+
+	"backend/apperrors"
+	"backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+var errRateLimited = apperrors.TooManyRequests("rate_limited", "demasiados pedidos, espere antes de reintentar")
+
+// RateLimit limita los pedidos según policy y responde las cabeceras
+// X-RateLimit-Limit, X-RateLimit-Remaining y X-RateLimit-Reset (segundos hasta
+// recuperar el límite completo). Detrás de AuthMiddleware cuenta por usuario y
+// aplica el límite de su rol; si no hay sesión cuenta por IP. Un límite 0 no
+// limita. Si se encadenan varias, las cabeceras son las de la última.
+//
+// Si el almacenamiento falla el pedido pasa: el límite protege al servidor y
+// no debe ser él quien lo tire.
+func RateLimit(limiter services.RateLimiterInterface, policy services.RateLimitPolicy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := c.GetString("user_role")
+		if policy.RequestsFor(role) <= 0 {
+			c.Next()
+			return
+		}
+		subject := "ip:" + c.ClientIP()
+		if id := c.GetString("user_id"); id != "" {
+			subject = "user:" + id
+		}
+
+		res, err := limiter.Allow(c.Request.Context(), policy, subject, role)
+		if err != nil {
+			log.Printf("rate limit %s: %v", policy.Name, err)
+			c.Next()
+			return
+		}
+
+		c.Header("X-RateLimit-Limit", strconv.Itoa(res.Limit))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		c.Header("X-RateLimit-Reset", strconv.Itoa(seconds(res.Reset)))
+		if !res.Allowed {
+			abort(c, apperrors.WithRetryAfter(errRateLimited, res.RetryAfter))
+			return
+		}
+		c.Next()
+	}
+}
+
+// seconds redondea d hacia arriba.
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,27 @@
+package migrations
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Usuarios creados antes de que Register asignara rol quedaron sin "role" y
+// sin rol no tienen ningún permiso.
+func init() {
+	register(Migration{
+		Version: 1,
+		Name:    "users_default_role",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			filter := bson.M{"$or": bson.A{
+				bson.M{"role": bson.M{"$exists": false}},
+				bson.M{"role": ""},
+			}}
+			_, err := db.Collection("users").UpdateMany(ctx, filter, bson.M{"$set": bson.M{"role": "user"}})
+			return err
+		},
+		// No se puede distinguir a quién se le asignó el rol acá.
+		Down: nil,
+	})
+}
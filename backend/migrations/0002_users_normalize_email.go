@@ -0,0 +1,26 @@
+package migrations
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// GetUserByEmail busca por el email normalizado (minúsculas, sin espacios), así
+// que los usuarios guardados antes de normalizar no se encontrarían. Si dos
+// cuentas difieren solo en mayúsculas el update falla por users_email_unique y
+// hay que resolver el duplicado a mano antes de reintentar.
+func init() {
+	register(Migration{
+		Version: 2,
+		Name:    "users_normalize_email",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			pipeline := mongo.Pipeline{
+				{{Key: "$set", Value: bson.M{"email": bson.M{"$toLower": bson.M{"$trim": bson.M{"input": "$email"}}}}}},
+			}
+			_, err := db.Collection("users").UpdateMany(ctx, bson.M{"email": bson.M{"$type": "string"}}, pipeline)
+			return err
+		},
+	})
+}
@@ -0,0 +1,92 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+
+	"backend/auth"
+	"backend/database"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Los refresh tokens se guardaban en claro en "token". Esta migración los
+// reemplaza por token_hash con la clave de auth.refresh_token_hash_key (o la
+// derivada de jwt_secret), así que hay que correrla con la misma configuración
+// que el servidor. No tiene down: el hash no se puede revertir.
+//
+// Mientras queden documentos sin token_hash, el índice único sobre ese campo
+// no se puede crear (todos valen null); por eso se crea acá al final en lugar
+// de esperar al próximo arranque.
+func init() {
+	register(Migration{
+		Version: 3,
+		Name:    "refresh_tokens_hash",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			collection := db.Collection("refresh_tokens")
+			cursor, err := collection.Find(ctx, bson.M{"token": bson.M{"$type": "string"}})
+			if err != nil {
+				return err
+			}
+			defer cursor.Close(ctx)
+
+			var batch []mongo.WriteModel
+			flush := func() error {
+				if len(batch) == 0 {
+					return nil
+				}
+				_, err := collection.BulkWrite(ctx, batch)
+				batch = batch[:0]
+				return err
+			}
+			for cursor.Next(ctx) {
+				var doc struct {
+					ID    primitive.ObjectID `bson:"_id"`
+					Token string             `bson:"token"`
+				}
+				if err := cursor.Decode(&doc); err != nil {
+					return err
+				}
+				batch = append(batch, mongo.NewUpdateOneModel().
+					SetFilter(bson.M{"_id": doc.ID}).
+					SetUpdate(bson.M{
+						"$set":   bson.M{"token_hash": auth.HashRefreshToken(doc.Token)},
+						"$unset": bson.M{"token": ""},
+					}))
+				if len(batch) == 500 {
+					if err := flush(); err != nil {
+						return err
+					}
+				}
+			}
+			if err := cursor.Err(); err != nil {
+				return err
+			}
+			if err := flush(); err != nil {
+				return err
+			}
+
+			// Sin el campo token, el índice único viejo también chocaría con
+			// nulls en el próximo insert.
+			if _, err := collection.Indexes().DropOne(ctx, "refresh_tokens_token_unique"); err != nil && !isIndexNotFound(err) {
+				return err
+			}
+			var specs []database.IndexSpec
+			for _, spec := range database.Indexes {
+				if spec.Collection == "refresh_tokens" {
+					specs = append(specs, spec)
+				}
+			}
+			_, err = database.EnsureIndexes(ctx, db, specs, database.BootstrapOptions{})
+			return err
+		},
+	})
+}
+
+func isIndexNotFound(err error) bool {
+	var cmdErr mongo.CommandError
+	// 26: la colección no existe; 27: el índice no existe.
+	return errors.As(err, &cmdErr) && (cmdErr.Code == 26 || cmdErr.Code == 27)
+}
@@ -0,0 +1,24 @@
+package migrations
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Las cuentas creadas antes de la verificación de email no recibieron link:
+// se dan por verificadas para no quitarles la publicación de rutinas.
+func init() {
+	register(Migration{
+		Version: 4,
+		Name:    "users_email_verified",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			filter := bson.M{"email_verified": bson.M{"$exists": false}}
+			_, err := db.Collection("users").UpdateMany(ctx, filter, bson.M{"$set": bson.M{"email_verified": true}})
+			return err
+		},
+		// No se puede distinguir a quién se lo marcó acá.
+		Down: nil,
+	})
+}
@@ -0,0 +1,87 @@
+// Package migrations aplica cambios versionados sobre los documentos de Mongo
+// cuando los modelos evolucionan. Cada migración se registra en un archivo
+// propio (NNNN_descripcion.go) y las versiones aplicadas quedan en la
+// colección schema_migrations.
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Migration es un paso versionado. Down puede ser nil si el cambio no se puede
+// deshacer; en ese caso el rollback se detiene en esa versión.
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(ctx context.Context, db *mongo.Database) error
+	Down    func(ctx context.Context, db *mongo.Database) error
+}
+
+func (m Migration) String() string {
+	return fmt.Sprintf("%04d_%s", m.Version, m.Name)
+}
+
+var ErrIrreversible = errors.New("la migración no tiene down")
+
+var registry []Migration
+
+func register(m Migration) {
+	registry = append(registry, m)
+}
+
+// All devuelve las migraciones registradas ordenadas por versión.
+func All() []Migration {
+	out := make([]Migration, len(registry))
+	copy(out, registry)
+	sort.Slice(out, func(i, j int) bool { return out[i].Version < out[j].Version })
+	return out
+}
+
+func validate(migrations []Migration) error {
+	seen := make(map[int]string, len(migrations))
+	for _, m := range migrations {
+		if m.Version <= 0 {
+			return fmt.Errorf("migración %q: la versión debe ser > 0", m.Name)
+		}
+		if m.Up == nil {
+			return fmt.Errorf("migración %s: falta up", m)
+		}
+		if prev, ok := seen[m.Version]; ok {
+			return fmt.Errorf("versión %d duplicada: %s y %s", m.Version, prev, m.Name)
+		}
+		seen[m.Version] = m.Name
+	}
+	return nil
+}
+
+// pendingUp devuelve, en orden, las migraciones no aplicadas hasta target
+// inclusive (0 = todas).
+func pendingUp(migrations []Migration, applied map[int]bool, target int) []Migration {
+	var out []Migration
+	for _, m := range migrations {
+		if target > 0 && m.Version > target {
+			break
+		}
+		if !applied[m.Version] {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+// pendingDown devuelve, de la más nueva a la más vieja, las últimas steps
+// migraciones aplicadas.
+func pendingDown(migrations []Migration, applied map[int]bool, steps int) []Migration {
+	var out []Migration
+	for i := len(migrations) - 1; i >= 0 && len(out) < steps; i-- {
+		if applied[migrations[i].Version] {
+			out = append(out, migrations[i])
+		}
+	}
+	return out
+}
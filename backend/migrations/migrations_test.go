@@ -0,0 +1,51 @@
+package migrations
+
+import (
+	"context"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func noop(ctx context.Context, db *mongo.Database) error { return nil }
+
+func versions(ms []Migration) []int {
+	out := make([]int, 0, len(ms))
+	for _, m := range ms {
+		out = append(out, m.Version)
+	}
+	return out
+}
+
+func TestRegistryIsValid(t *testing.T) {
+	if err := validate(All()); err != nil {
+		t.Fatalf("registered migrations are invalid: %v", err)
+	}
+}
+
+func TestValidate_RejectsDuplicatesAndMissingUp(t *testing.T) {
+	if err := validate([]Migration{{Version: 1, Name: "a", Up: noop}, {Version: 1, Name: "b", Up: noop}}); err == nil {
+		t.Fatalf("expected duplicate version error")
+	}
+	if err := validate([]Migration{{Version: 1, Name: "a"}}); err == nil {
+		t.Fatalf("expected missing up error")
+	}
+}
+
+func TestPendingUpAndDown(t *testing.T) {
+	all := []Migration{{Version: 1}, {Version: 2}, {Version: 3}, {Version: 4}}
+	applied := map[int]bool{1: true, 2: true}
+
+	if got := versions(pendingUp(all, applied, 0)); len(got) != 2 || got[0] != 3 || got[1] != 4 {
+		t.Fatalf("unexpected up plan: %v", got)
+	}
+	if got := versions(pendingUp(all, applied, 3)); len(got) != 1 || got[0] != 3 {
+		t.Fatalf("unexpected up plan with target: %v", got)
+	}
+	if got := versions(pendingDown(all, applied, 1)); len(got) != 1 || got[0] != 2 {
+		t.Fatalf("unexpected down plan: %v", got)
+	}
+	if got := versions(pendingDown(all, applied, 5)); len(got) != 2 || got[0] != 2 || got[1] != 1 {
+		t.Fatalf("down must go newest first and stop at applied ones: %v", got)
+	}
+}
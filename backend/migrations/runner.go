@@ -0,0 +1,217 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	migrationsCollection = "schema_migrations"
+	lockCollection       = "schema_migrations_lock"
+	lockID               = "migrate"
+)
+
+var ErrLocked = errors.New("otra instancia está ejecutando migraciones")
+
+type record struct {
+	Version    int       `bson:"_id"`
+	Name       string    `bson:"name"`
+	AppliedAt  time.Time `bson:"applied_at"`
+	DurationMS int64     `bson:"duration_ms"`
+}
+
+// Status es el estado de una migración registrada.
+type Status struct {
+	Migration Migration
+	Applied   bool
+	AppliedAt time.Time
+}
+
+type Runner struct {
+	db         *mongo.Database
+	migrations []Migration
+	owner      string
+	// LockTTL es cuánto dura el lock si el proceso muere sin liberarlo.
+	// Tiene que ser mayor que la migración más lenta.
+	LockTTL time.Duration
+	// DryRun solo informa qué se ejecutaría, sin tocar la base ni el lock.
+	DryRun bool
+}
+
+func NewRunner(db *mongo.Database, migrations []Migration) (*Runner, error) {
+	if err := validate(migrations); err != nil {
+		return nil, err
+	}
+	host, _ := os.Hostname()
+	return &Runner{
+		db:         db,
+		migrations: migrations,
+		owner:      fmt.Sprintf("%s:%d", host, os.Getpid()),
+		LockTTL:    15 * time.Minute,
+	}, nil
+}
+
+func (r *Runner) Status(ctx context.Context) ([]Status, error) {
+	records, err := r.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Status, 0, len(r.migrations))
+	for _, m := range r.migrations {
+		rec, ok := records[m.Version]
+		out = append(out, Status{Migration: m, Applied: ok, AppliedAt: rec.AppliedAt})
+	}
+	return out, nil
+}
+
+// Pending devuelve las migraciones que todavía no se aplicaron.
+func (r *Runner) Pending(ctx context.Context) ([]Migration, error) {
+	applied, err := r.appliedSet(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return pendingUp(r.migrations, applied, 0), nil
+}
+
+// Up aplica en orden las migraciones pendientes hasta target inclusive
+// (0 = todas) y devuelve las que se ejecutaron.
+func (r *Runner) Up(ctx context.Context, target int) ([]Migration, error) {
+	return r.run(ctx, func(applied map[int]bool) []Migration {
+		return pendingUp(r.migrations, applied, target)
+	}, r.up)
+}
+
+// Down deshace las últimas steps migraciones aplicadas, de la más nueva a la
+// más vieja.
+func (r *Runner) Down(ctx context.Context, steps int) ([]Migration, error) {
+	return r.run(ctx, func(applied map[int]bool) []Migration {
+		return pendingDown(r.migrations, applied, steps)
+	}, r.down)
+}
+
+func (r *Runner) run(ctx context.Context, plan func(map[int]bool) []Migration, step func(context.Context, Migration) error) ([]Migration, error) {
+	if !r.DryRun {
+		if err := r.lock(ctx); err != nil {
+			return nil, err
+		}
+		defer r.unlock()
+	}
+
+	// El plan se calcula con el lock tomado para no repetir lo que otra
+	// instancia acaba de aplicar.
+	applied, err := r.appliedSet(ctx)
+	if err != nil {
+		return nil, err
+	}
+	todo := plan(applied)
+	if r.DryRun {
+		return todo, nil
+	}
+
+	var done []Migration
+	for _, m := range todo {
+		if err := step(ctx, m); err != nil {
+			return done, fmt.Errorf("%s: %w", m, err)
+		}
+		done = append(done, m)
+	}
+	return done, nil
+}
+
+func (r *Runner) up(ctx context.Context, m Migration) error {
+	start := time.Now()
+	log.Printf("migrations: aplicando %s", m)
+	if err := m.Up(ctx, r.db); err != nil {
+		return err
+	}
+	_, err := r.db.Collection(migrationsCollection).InsertOne(ctx, record{
+		Version:    m.Version,
+		Name:       m.Name,
+		AppliedAt:  time.Now(),
+		DurationMS: time.Since(start).Milliseconds(),
+	})
+	return err
+}
+
+func (r *Runner) down(ctx context.Context, m Migration) error {
+	if m.Down == nil {
+		return ErrIrreversible
+	}
+	log.Printf("migrations: revirtiendo %s", m)
+	if err := m.Down(ctx, r.db); err != nil {
+		return err
+	}
+	_, err := r.db.Collection(migrationsCollection).DeleteOne(ctx, bson.M{"_id": m.Version})
+	return err
+}
+
+func (r *Runner) applied(ctx context.Context) (map[int]record, error) {
+	cursor, err := r.db.Collection(migrationsCollection).Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	out := make(map[int]record)
+	for cursor.Next(ctx) {
+		var rec record
+		if err := cursor.Decode(&rec); err != nil {
+			return nil, err
+		}
+		out[rec.Version] = rec
+	}
+	return out, cursor.Err()
+}
+
+func (r *Runner) appliedSet(ctx context.Context) (map[int]bool, error) {
+	records, err := r.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[int]bool, len(records))
+	for v := range records {
+		out[v] = true
+	}
+	return out, nil
+}
+
+// lock toma un lock con vencimiento en schema_migrations_lock. El upsert solo
+// matchea si el lock está vencido o ya es nuestro; si lo tiene otra instancia
+// el upsert choca con el _id existente y devuelve duplicate key.
+func (r *Runner) lock(ctx context.Context) error {
+	now := time.Now()
+	filter := bson.M{
+		"_id": lockID,
+		"$or": bson.A{
+			bson.M{"expires_at": bson.M{"$lt": now}},
+			bson.M{"owner": r.owner},
+		},
+	}
+	update := bson.M{"$set": bson.M{
+		"owner":      r.owner,
+		"locked_at":  now,
+		"expires_at": now.Add(r.LockTTL),
+	}}
+	_, err := r.db.Collection(lockCollection).UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrLocked
+	}
+	return err
+}
+
+func (r *Runner) unlock() {
+	// Se libera aunque el contexto de la ejecución se haya cancelado.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if _, err := r.db.Collection(lockCollection).DeleteOne(ctx, bson.M{"_id": lockID, "owner": r.owner}); err != nil {
+		log.Printf("migrations: no se pudo liberar el lock: %v", err)
+	}
+}
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// APIKey es una credencial de larga duración que un usuario crea para scripts
+// e integraciones. Se guarda solo el hash: la clave se muestra una vez, al
+// crearla.
+type APIKey struct {
+	ID     primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID primitive.ObjectID `bson:"user_id" json:"user_id"`
+	Name   string             `bson:"name" json:"name"`
+	// Prefix son los primeros caracteres de la clave, para reconocerla en el
+	// listado.
+	Prefix  string `bson:"prefix" json:"prefix"`
+	KeyHash string `bson:"key_hash" json:"-"`
+	// Scopes son "<recurso>:read" o "<recurso>:write"; write incluye read.
+	Scopes []string `bson:"scopes" json:"scopes"`
+	// AMR son los métodos del login con el que se creó la clave: los pedidos
+	// con ella cuentan como autenticados de esa forma (ver RequireMFA).
+	AMR        []string   `bson:"amr,omitempty" json:"-"`
+	ExpiresAt  time.Time  `bson:"expires_at" json:"expires_at"`
+	LastUsedAt *time.Time `bson:"last_used_at,omitempty" json:"last_used_at,omitempty"`
+	LastUsedIP string     `bson:"last_used_ip,omitempty" json:"last_used_ip,omitempty"`
+	RevokedAt  *time.Time `bson:"revoked_at,omitempty" json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `bson:"created_at" json:"created_at"`
+}
+
+// Active indica si la clave sirve en now.
+func (k APIKey) Active(now time.Time) bool {
+	return k.RevokedAt == nil && k.ExpiresAt.After(now)
+}
+
+// Recursos que se pueden habilitar en una API key. El resto de la API, en
+// particular /api/me, solo acepta sesiones.
+const (
+	APIKeyResourceExercises = "exercises"
+	APIKeyResourceRoutines  = "routines"
+	APIKeyResourceWorkouts  = "workouts"
+)
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Acciones del registro de auditoría.
+const (
+	AuditUsersListed            = "user.listed"
+	AuditUserViewed             = "user.viewed"
+	AuditUserRoleChanged        = "user.role_changed"
+	AuditUserDisabled           = "user.disabled"
+	AuditUserEnabled            = "user.enabled"
+	AuditUserSessionsRevoked    = "user.sessions_revoked"
+	AuditUserDeleted            = "user.deleted"
+	AuditImpersonationStarted   = "user.impersonation_started"
+	AuditImpersonationEnded     = "user.impersonation_ended"
+	AuditRolePermissionsChanged = "role.permissions_changed"
+	AuditRolePermissionsReset   = "role.permissions_reset"
+	// AuditImpersonatedRequest es cada pedido hecho con un token de
+	// suplantación.
+	AuditImpersonatedRequest = "user.impersonated_request"
+)
+
+// AuditEvent es una acción o consulta administrativa: quién la hizo, sobre qué
+// y desde dónde. Se guarda antes de hacer la acción; si la acción falla
+// después, el fallo queda en el log de seguridad. Los eventos no se modifican
+// ni se borran.
+type AuditEvent struct {
+	ID     primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Action string             `bson:"action" json:"action"`
+	// ActorEmail se guarda junto al id para que el evento se entienda aunque
+	// el usuario se borre después.
+	ActorID    primitive.ObjectID `bson:"actor_id" json:"actor_id"`
+	ActorEmail string             `bson:"actor_email" json:"actor_email"`
+	// TargetType es "user" o "role"; TargetID, el id del usuario o el nombre
+	// del rol.
+	TargetType string            `bson:"target_type" json:"target_type"`
+	TargetID   string            `bson:"target_id" json:"target_id"`
+	Details    map[string]string `bson:"details,omitempty" json:"details,omitempty"`
+	IP         string            `bson:"ip,omitempty" json:"ip,omitempty"`
+	UserAgent  string            `bson:"user_agent,omitempty" json:"user_agent,omitempty"`
+	CreatedAt  time.Time         `bson:"created_at" json:"created_at"`
+}
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// EmailVerification es un link de verificación enviado a Email. Se guarda solo
+// el hash del token; UsedAt marca que ya se usó.
+type EmailVerification struct {
+	ID     primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID primitive.ObjectID `bson:"user_id" json:"user_id"`
+	// Email es la dirección a la que se envió: si el usuario la cambia
+	// después, el link viejo no verifica la nueva.
+	Email     string     `bson:"email" json:"email"`
+	TokenHash string     `bson:"token_hash" json:"-"`
+	ExpiresAt time.Time  `bson:"expires_at" json:"expires_at"`
+	UsedAt    *time.Time `bson:"used_at,omitempty" json:"used_at,omitempty"`
+	CreatedAt time.Time  `bson:"created_at" json:"created_at"`
+}
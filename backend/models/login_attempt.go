@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// LoginAttempt cuenta los intentos fallidos de una clave ("email:..." o
+// "ip:..."). Cada intento se suma antes de verificar la credencial, así que
+// Failures incluye los que todavía se están verificando; los que resultan
+// válidos se descuentan. El documento vence ExpiresAt: pasado ese tiempo sin
+// intentos el contador vuelve a cero.
+type LoginAttempt struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Key           string             `bson:"key" json:"key"`
+	Failures      int                `bson:"failures" json:"failures"`
+	LastFailureAt time.Time          `bson:"last_failure_at" json:"last_failure_at"`
+	// LockedUntil solo se usa en las claves de email: la cuenta queda
+	// bloqueada hasta esa fecha o hasta que se use el link de desbloqueo.
+	LockedUntil     *time.Time `bson:"locked_until,omitempty" json:"locked_until,omitempty"`
+	UnlockTokenHash string     `bson:"unlock_token_hash,omitempty" json:"-"`
+	ExpiresAt       time.Time  `bson:"expires_at" json:"expires_at"`
+}
+
+// Locked indica si la clave está bloqueada en now.
+func (a LoginAttempt) Locked(now time.Time) bool {
+	return a.LockedUntil != nil && a.LockedUntil.After(now)
+}
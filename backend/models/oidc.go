@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// OIDCState es un login con un proveedor externo que todavía no volvió. Se
+// guarda el hash del state: el valor en claro solo lo tienen el navegador y
+// el proveedor.
+type OIDCState struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	StateHash    string             `bson:"state_hash" json:"-"`
+	Provider     string             `bson:"provider" json:"provider"`
+	Nonce        string             `bson:"nonce" json:"-"`
+	CodeVerifier string             `bson:"code_verifier" json:"-"`
+	ExpiresAt    time.Time          `bson:"expires_at" json:"expires_at"`
+	CreatedAt    time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// LinkedIdentity vincula un usuario con su cuenta (sub) en un proveedor
+// OpenID Connect.
+type LinkedIdentity struct {
+	ID       primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID   primitive.ObjectID `bson:"user_id" json:"user_id"`
+	Provider string             `bson:"provider" json:"provider"`
+	Subject  string             `bson:"subject" json:"subject"`
+	// Email es el que informó el proveedor al vincular; solo se muestra.
+	Email       string     `bson:"email,omitempty" json:"email,omitempty"`
+	CreatedAt   time.Time  `bson:"created_at" json:"created_at"`
+	LastLoginAt *time.Time `bson:"last_login_at,omitempty" json:"last_login_at,omitempty"`
+}
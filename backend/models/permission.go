@@ -0,0 +1,77 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Permission es una operación que se autoriza por rol. Las rutas piden
+// permisos, no roles: qué puede hacer cada rol se cambia sin tocar el código.
+type Permission string
+
+const (
+	// PermExerciseWrite crea ejercicios y edita o borra los propios.
+	PermExerciseWrite Permission = "exercise:write"
+	// PermExerciseModerate edita o borra cualquier ejercicio del catálogo.
+	PermExerciseModerate Permission = "exercise:moderate"
+	// PermRoutineModerate ve y borra rutinas de otros usuarios, aunque sean
+	// privadas.
+	PermRoutineModerate Permission = "routine:moderate"
+	// PermUserManage administra usuarios y les cambia el rol.
+	PermUserManage Permission = "user:manage"
+	// PermUserImpersonate emite tokens para ver la aplicación como otro
+	// usuario, con fines de soporte.
+	PermUserImpersonate Permission = "user:impersonate"
+	// PermRoleManage cambia los permisos de cada rol.
+	PermRoleManage Permission = "role:manage"
+	// PermAuditRead consulta el registro de auditoría.
+	PermAuditRead Permission = "audit:read"
+)
+
+// Permissions son todos los permisos que existen.
+var Permissions = []Permission{
+	PermExerciseWrite,
+	PermExerciseModerate,
+	PermRoutineModerate,
+	PermUserManage,
+	PermUserImpersonate,
+	PermRoleManage,
+	PermAuditRead,
+}
+
+// AdminPermissions son los permisos que dan control sobre otros usuarios o
+// sobre la autorización misma, los pueda tener el rol que sea.
+var AdminPermissions = []Permission{
+	PermUserManage,
+	PermUserImpersonate,
+	PermRoleManage,
+	PermAuditRead,
+}
+
+func (p Permission) Valid() bool {
+	for _, perm := range Permissions {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultRolePermissions son los permisos de cada rol mientras no se cambien
+// con la API de roles.
+var DefaultRolePermissions = map[Role][]Permission{
+	RoleUser:      {},
+	RoleCoach:     {PermExerciseWrite, PermExerciseModerate},
+	RoleModerator: {PermExerciseModerate, PermRoutineModerate},
+	RoleAdmin:     Permissions,
+}
+
+// RolePermissions es la asignación de permisos de un rol guardada en la base.
+// Reemplaza la de DefaultRolePermissions; borrarla vuelve a la de fábrica.
+type RolePermissions struct {
+	Role        Role               `bson:"_id" json:"role"`
+	Permissions []Permission       `bson:"permissions" json:"permissions"`
+	UpdatedBy   primitive.ObjectID `bson:"updated_by,omitempty" json:"updated_by,omitempty"`
+	UpdatedAt   time.Time          `bson:"updated_at" json:"updated_at"`
+}
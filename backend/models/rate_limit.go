@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// RateLimitBucket es un balde de tokens: se recarga de forma continua hasta
+// la capacidad de la política y cada pedido consume uno.
+type RateLimitBucket struct {
+	Key       string    `bson:"_id" json:"key"`
+	Tokens    float64   `bson:"tokens" json:"tokens"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
+	// Allowed indica si el último pedido pudo tomar un token.
+	Allowed bool `bson:"allowed" json:"allowed"`
+	// ExpiresAt es cuando el balde vuelve a estar lleno: desde ahí es igual a
+	// uno nuevo y se puede borrar.
+	ExpiresAt time.Time `bson:"expires_at" json:"expires_at"`
+}
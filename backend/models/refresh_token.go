@@ -7,11 +7,51 @@ import (
 )
 
 type RefreshToken struct {
-	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	UserID    primitive.ObjectID `bson:"user_id" json:"userId"`
-	Token     string             `bson:"token" json:"token"`
-	ExpiresAt time.Time          `bson:"expires_at" json:"expiresAt"`
-	Revoked   bool               `bson:"revoked" json:"revoked"`
-	CreatedAt time.Time          `bson:"created_at" json:"createdAt"`
-	RevokedAt *time.Time         `bson:"revoked_at,omitempty" json:"revokedAt,omitempty"`
+	ID     primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID primitive.ObjectID `bson:"user_id" json:"userId"`
+	// FamilyID agrupa los tokens que salen de un mismo login: cada rotación
+	// hereda la familia del token que reemplaza.
+	FamilyID primitive.ObjectID `bson:"family_id,omitempty" json:"familyId,omitempty"`
+	// TokenHash es el HMAC del refresh token (auth.HashRefreshToken). El
+	// token en claro nunca se guarda: un volcado de la base no da sesiones.
+	TokenHash string     `bson:"token_hash" json:"-"`
+	ExpiresAt time.Time  `bson:"expires_at" json:"expiresAt"`
+	Revoked   bool       `bson:"revoked" json:"revoked"`
+	CreatedAt time.Time  `bson:"created_at" json:"createdAt"`
+	RevokedAt *time.Time `bson:"revoked_at,omitempty" json:"revokedAt,omitempty"`
+	// Datos del dispositivo del último uso, para listar las sesiones.
+	UserAgent  string    `bson:"user_agent,omitempty" json:"userAgent,omitempty"`
+	IP         string    `bson:"ip,omitempty" json:"ip,omitempty"`
+	LastUsedAt time.Time `bson:"last_used_at,omitempty" json:"lastUsedAt,omitempty"`
+	// SessionStartedAt es el login que abrió la familia; se copia al rotar.
+	SessionStartedAt time.Time `bson:"session_started_at,omitempty" json:"sessionStartedAt,omitempty"`
+	// AMR son los métodos con los que se autenticó el login (claim amr); se
+	// copia al rotar para que el access token renovado los conserve.
+	AMR []string `bson:"amr,omitempty" json:"amr,omitempty"`
+	// ReplacedBy es el token que lo reemplazó al rotar. Un token revocado con
+	// ReplacedBy que vuelve a presentarse fue robado o filtrado.
+	ReplacedBy primitive.ObjectID `bson:"replaced_by,omitempty" json:"replacedBy,omitempty"`
+}
+
+// Family devuelve la familia del token. Los tokens emitidos antes de que
+// existieran las familias forman una familia propia con su ID.
+func (rt RefreshToken) Family() primitive.ObjectID {
+	if rt.FamilyID.IsZero() {
+		return rt.ID
+	}
+	return rt.FamilyID
+}
+
+// Rotated indica si el token ya fue reemplazado por otro de su familia.
+func (rt RefreshToken) Rotated() bool {
+	return !rt.ReplacedBy.IsZero()
+}
+
+// StartedAt devuelve el inicio de la sesión; los tokens anteriores a las
+// sesiones no lo guardan y se usa su fecha de creación.
+func (rt RefreshToken) StartedAt() time.Time {
+	if rt.SessionStartedAt.IsZero() {
+		return rt.CreatedAt
+	}
+	return rt.SessionStartedAt
 }
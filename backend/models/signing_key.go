@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// SigningKey es una clave de firma de JWT guardada para que todas las
+// instancias firmen y verifiquen con el mismo juego de claves.
+type SigningKey struct {
+	// ID es el kid que va en el header de los tokens firmados con esta clave.
+	ID        string `bson:"_id"`
+	Algorithm string `bson:"algorithm"`
+	// PrivateKey es la clave privada (PKCS#8, o el secreto para HS256)
+	// cifrada con AES-GCM; nunca se guarda en claro.
+	PrivateKey []byte    `bson:"private_key"`
+	CreatedAt  time.Time `bson:"created_at"`
+	// VerifyUntil se fija al rotar: desde ese momento la clave ya no firma y
+	// solo verifica tokens viejos hasta esa fecha. Nil mientras está activa.
+	VerifyUntil *time.Time `bson:"verify_until,omitempty"`
+}
@@ -11,8 +11,24 @@ type Role string
 const (
 	RoleAdmin Role = "admin"
 	RoleUser  Role = "user"
+	// RoleCoach es el entrenador: mantiene el catálogo de ejercicios.
+	RoleCoach Role = "coach"
+	// RoleModerator revisa el contenido que publican los usuarios.
+	RoleModerator Role = "moderator"
 )
 
+// Roles son los roles que se pueden asignar a un usuario.
+var Roles = []Role{RoleUser, RoleCoach, RoleModerator, RoleAdmin}
+
+func (r Role) Valid() bool {
+	for _, role := range Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
 type User struct {
 	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
 	Name         string             `bson:"name" json:"name"`
@@ -24,6 +40,36 @@ type User struct {
 	Height       float64            `bson:"height,omitempty" json:"height,omitempty"`
 	Level        string             `bson:"level,omitempty" json:"level,omitempty"`
 	Goals        []string           `bson:"goals,omitempty" json:"goals,omitempty"`
-	CreatedAt    time.Time          `bson:"created_at" json:"created_at"`
-	UpdatedAt    time.Time          `bson:"updated_at" json:"updated_at"`
+	Language     string             `bson:"language,omitempty" json:"language,omitempty"`
+	// EmailVerified se pone en true al confirmar el email con el link enviado
+	// al registrarse o al cambiarlo.
+	EmailVerified   bool       `bson:"email_verified" json:"email_verified"`
+	EmailVerifiedAt *time.Time `bson:"email_verified_at,omitempty" json:"email_verified_at,omitempty"`
+	// MFA no lo escribe UpdateUser: se cambia con SetMFA y los métodos que
+	// consumen códigos, para que un update de perfil no pise un código usado.
+	MFA MFA `bson:"mfa" json:"mfa"`
+	// DisabledAt marca una cuenta deshabilitada por un administrador: no
+	// puede iniciar sesión ni usar sus API keys. Tampoco lo escribe
+	// UpdateUser; se cambia con SetDisabled.
+	DisabledAt *time.Time `bson:"disabled_at,omitempty" json:"disabled_at,omitempty"`
+	CreatedAt  time.Time  `bson:"created_at" json:"created_at"`
+	UpdatedAt  time.Time  `bson:"updated_at" json:"updated_at"`
+}
+
+func (u User) Disabled() bool {
+	return u.DisabledAt != nil
+}
+
+// MFA es la configuración del segundo factor. Los secretos TOTP van cifrados
+// con auth.SealTOTPSecret y los códigos de recuperación, hasheados.
+type MFA struct {
+	Enabled    bool   `bson:"enabled" json:"enabled"`
+	TOTPSecret string `bson:"totp_secret,omitempty" json:"-"`
+	// PendingSecret es el secreto de un enrolamiento que todavía no se
+	// confirmó con un código.
+	PendingSecret string `bson:"pending_secret,omitempty" json:"-"`
+	// LastStep es el último paso TOTP aceptado: un código no sirve dos veces.
+	LastStep      int64      `bson:"last_step,omitempty" json:"-"`
+	RecoveryCodes []string   `bson:"recovery_codes,omitempty" json:"-"`
+	EnabledAt     *time.Time `bson:"enabled_at,omitempty" json:"enabled_at,omitempty"`
 }
@@ -0,0 +1,288 @@
+// Package oidc implementa el lado relying party de OpenID Connect: flujo
+// authorization code con PKCE y verificación del ID token con las claves que
+// publica el proveedor.
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"backend/auth"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	// ErrExchange es un rechazo del endpoint de tokens: código vencido, ya
+	// usado o verifier incorrecto.
+	ErrExchange = errors.New("oidc: el proveedor rechazó el código")
+	// ErrInvalidIDToken es un ID token con firma, emisor, audiencia,
+	// vencimiento o nonce incorrectos.
+	ErrInvalidIDToken = errors.New("oidc: id token inválido")
+)
+
+// keysMinRefresh limita las recargas de JWKS por kid desconocido, para que un
+// token con kid inventado no haga pegarle al proveedor en cada pedido.
+const keysMinRefresh = time.Minute
+
+type Config struct {
+	// Name identifica al proveedor en las rutas y en las identidades
+	// vinculadas.
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	// Scopes se agregan a "openid".
+	Scopes []string
+}
+
+// Claims son los datos del usuario que trae el ID token.
+type Claims struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+type Provider struct {
+	cfg    Config
+	client *http.Client
+
+	mu            sync.Mutex
+	meta          *metadata
+	keys          map[string]crypto.PublicKey
+	keysFetchedAt time.Time
+}
+
+// metadata es el documento de discovery (.well-known/openid-configuration).
+type metadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// NewProvider no hace pedidos: el discovery se resuelve en el primer uso, así
+// un proveedor caído no impide arrancar. client nil usa uno con timeout de 10s.
+func NewProvider(cfg Config, client *http.Client) *Provider {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &Provider{cfg: cfg, client: client}
+}
+
+func (p *Provider) Name() string { return p.cfg.Name }
+
+// AuthCodeURL es la URL del proveedor a la que se manda al usuario.
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, verifier string) (string, error) {
+	meta, err := p.metadata(ctx)
+	if err != nil {
+		return "", err
+	}
+	q := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.cfg.ClientID},
+		"redirect_uri":          {p.cfg.RedirectURL},
+		"scope":                 {strings.Join(append([]string{"openid"}, p.cfg.Scopes...), " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {CodeChallenge(verifier)},
+		"code_challenge_method": {"S256"},
+	}
+	sep := "?"
+	if strings.Contains(meta.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return meta.AuthorizationEndpoint + sep + q.Encode(), nil
+}
+
+// Authenticate canjea el código y verifica el ID token recibido.
+func (p *Provider) Authenticate(ctx context.Context, code, verifier, nonce string) (Claims, error) {
+	rawIDToken, err := p.exchange(ctx, code, verifier)
+	if err != nil {
+		return Claims{}, err
+	}
+	return p.VerifyIDToken(ctx, rawIDToken, nonce)
+}
+
+func (p *Provider) exchange(ctx context.Context, code, verifier string) (string, error) {
+	meta, err := p.metadata(ctx)
+	if err != nil {
+		return "", err
+	}
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.cfg.RedirectURL},
+		"code_verifier": {verifier},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, meta.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.cfg.ClientSecret))
+
+	res, err := p.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("oidc %s: token endpoint: %w", p.cfg.Name, err)
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if err != nil {
+		return "", fmt.Errorf("oidc %s: token endpoint: %w", p.cfg.Name, err)
+	}
+	// 400 es el error OAuth estándar (invalid_grant y similares); cualquier
+	// otro status es una falla del proveedor.
+	if res.StatusCode == http.StatusBadRequest || res.StatusCode == http.StatusUnauthorized {
+		return "", fmt.Errorf("%w: %s", ErrExchange, strings.TrimSpace(string(body)))
+	}
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("oidc %s: token endpoint respondió %d", p.cfg.Name, res.StatusCode)
+	}
+	var tokens struct {
+		IDToken string `json:"id_token"`
+	}
+	if err := json.Unmarshal(body, &tokens); err != nil || tokens.IDToken == "" {
+		return "", fmt.Errorf("oidc %s: respuesta del token endpoint sin id_token", p.cfg.Name)
+	}
+	return tokens.IDToken, nil
+}
+
+type idTokenClaims struct {
+	jwt.RegisteredClaims
+	Nonce         string       `json:"nonce"`
+	Email         string       `json:"email"`
+	EmailVerified flexibleBool `json:"email_verified"`
+	Name          string       `json:"name"`
+}
+
+// VerifyIDToken valida firma, emisor, audiencia, vencimiento y nonce.
+func (p *Provider) VerifyIDToken(ctx context.Context, raw, nonce string) (Claims, error) {
+	meta, err := p.metadata(ctx)
+	if err != nil {
+		return Claims{}, err
+	}
+	var claims idTokenClaims
+	_, err = jwt.ParseWithClaims(raw, &claims,
+		func(t *jwt.Token) (any, error) {
+			kid, _ := t.Header["kid"].(string)
+			return p.key(ctx, meta, kid)
+		},
+		jwt.WithValidMethods([]string{"RS256", "ES256", "EdDSA"}),
+		jwt.WithIssuer(meta.Issuer),
+		jwt.WithAudience(p.cfg.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return Claims{}, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+	if claims.Nonce != nonce || claims.Subject == "" {
+		return Claims{}, fmt.Errorf("%w: nonce o sub incorrectos", ErrInvalidIDToken)
+	}
+	return Claims{
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: bool(claims.EmailVerified),
+		Name:          claims.Name,
+	}, nil
+}
+
+func (p *Provider) metadata(ctx context.Context) (metadata, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.meta != nil {
+		return *p.meta, nil
+	}
+
+	var meta metadata
+	if err := p.getJSON(ctx, strings.TrimSuffix(p.cfg.Issuer, "/")+"/.well-known/openid-configuration", &meta); err != nil {
+		return metadata{}, err
+	}
+	// El emisor publicado tiene que ser el configurado (OIDC Discovery 4.3).
+	if meta.Issuer != p.cfg.Issuer || meta.AuthorizationEndpoint == "" || meta.TokenEndpoint == "" || meta.JWKSURI == "" {
+		return metadata{}, fmt.Errorf("oidc %s: documento de discovery inválido", p.cfg.Name)
+	}
+	p.meta = &meta
+	return meta, nil
+}
+
+// key devuelve la clave kid, recargando el JWKS si no la conoce (el proveedor
+// rotó sus claves).
+func (p *Provider) key(ctx context.Context, meta metadata, kid string) (crypto.PublicKey, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key, ok := p.keys[kid]; ok {
+		return key, nil
+	}
+	if time.Since(p.keysFetchedAt) < keysMinRefresh {
+		return nil, fmt.Errorf("clave %q desconocida", kid)
+	}
+	var set auth.JWKSet
+	if err := p.getJSON(ctx, meta.JWKSURI, &set); err != nil {
+		return nil, err
+	}
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		if pub, err := k.PublicKey(); err == nil {
+			keys[k.KeyID] = pub
+		}
+	}
+	p.keys, p.keysFetchedAt = keys, time.Now()
+
+	if key, ok := keys[kid]; ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("clave %q desconocida", kid)
+}
+
+func (p *Provider) getJSON(ctx context.Context, u string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	res, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("oidc %s: %w", p.cfg.Name, err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("oidc %s: GET %s respondió %d", p.cfg.Name, u, res.StatusCode)
+	}
+	if err := json.NewDecoder(io.LimitReader(res.Body, 1<<20)).Decode(out); err != nil {
+		return fmt.Errorf("oidc %s: GET %s: %w", p.cfg.Name, u, err)
+	}
+	return nil
+}
+
+// flexibleBool acepta true y "true": algunos proveedores mandan
+// email_verified como string.
+type flexibleBool bool
+
+func (b *flexibleBool) UnmarshalJSON(data []byte) error {
+	switch strings.Trim(string(data), `"`) {
+	case "true":
+		*b = true
+	default:
+		*b = false
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package oidc_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"backend/oidc"
+	"backend/oidc/oidctest"
+)
+
+func newTestProvider(t *testing.T) (*oidc.Provider, *oidctest.Provider) {
+	t.Helper()
+	srv, mock, err := oidctest.NewServer("fitness", "secret")
+	if err != nil {
+		t.Fatalf("mock provider: %v", err)
+	}
+	t.Cleanup(srv.Close)
+	p := oidc.NewProvider(oidc.Config{
+		Name:         "mock",
+		Issuer:       mock.Issuer,
+		ClientID:     "fitness",
+		ClientSecret: "secret",
+		RedirectURL:  "http://localhost:3000/oidc/callback",
+		Scopes:       []string{"email", "profile"},
+	}, srv.Client())
+	return p, mock
+}
+
+// authorize inicia el flujo y devuelve el code que recibiría el frontend.
+func authorize(t *testing.T, p *oidc.Provider, state, nonce, verifier string) string {
+	t.Helper()
+	authURL, err := p.AuthCodeURL(context.Background(), state, nonce, verifier)
+	if err != nil {
+		t.Fatalf("auth code url: %v", err)
+	}
+	code, gotState, err := oidctest.Authorize(authURL)
+	if err != nil {
+		t.Fatalf("authorize: %v", err)
+	}
+	if gotState != state {
+		t.Fatalf("state = %q, want %q", gotState, state)
+	}
+	return code
+}
+
+func TestProvider_AuthorizationCodeFlow(t *testing.T) {
+	p, mock := newTestProvider(t)
+	mock.SetIdentity(oidctest.Identity{Subject: "abc", Email: "ana@example.com", EmailVerified: true, Name: "Ana"})
+
+	verifier, _ := oidc.NewRandom()
+	code := authorize(t, p, "state-1", "nonce-1", verifier)
+	claims, err := p.Authenticate(context.Background(), code, verifier, "nonce-1")
+	if err != nil {
+		t.Fatalf("authenticate: %v", err)
+	}
+	want := oidc.Claims{Subject: "abc", Email: "ana@example.com", EmailVerified: true, Name: "Ana"}
+	if claims != want {
+		t.Fatalf("claims = %+v, want %+v", claims, want)
+	}
+
+	// El código es de un solo uso.
+	if _, err := p.Authenticate(context.Background(), code, verifier, "nonce-1"); !errors.Is(err, oidc.ErrExchange) {
+		t.Fatalf("reused code err = %v, want ErrExchange", err)
+	}
+}
+
+func TestProvider_RejectsWrongVerifier(t *testing.T) {
+	p, _ := newTestProvider(t)
+	verifier, _ := oidc.NewRandom()
+	other, _ := oidc.NewRandom()
+	code := authorize(t, p, "state", "nonce", verifier)
+
+	if _, err := p.Authenticate(context.Background(), code, other, "nonce"); !errors.Is(err, oidc.ErrExchange) {
+		t.Fatalf("err = %v, want ErrExchange", err)
+	}
+}
+
+func TestProvider_RejectsNonceMismatch(t *testing.T) {
+	p, _ := newTestProvider(t)
+	verifier, _ := oidc.NewRandom()
+	code := authorize(t, p, "state", "nonce", verifier)
+
+	if _, err := p.Authenticate(context.Background(), code, verifier, "other-nonce"); !errors.Is(err, oidc.ErrInvalidIDToken) {
+		t.Fatalf("err = %v, want ErrInvalidIDToken", err)
+	}
+}
@@ -0,0 +1,138 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"backend/apperrors"
+	"backend/database"
+	"backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type APIKeyRepositoryInterface interface {
+	Create(ctx context.Context, key models.APIKey) error
+	// GetByHash devuelve la clave con ese hash, aunque esté revocada o
+	// vencida, o ErrNotFound.
+	GetByHash(ctx context.Context, hash string) (models.APIKey, error)
+	// ListForUser devuelve las claves no revocadas del usuario, de la más
+	// nueva a la más vieja.
+	ListForUser(ctx context.Context, userID primitive.ObjectID) ([]models.APIKey, error)
+	// CountActiveForUser cuenta las claves no revocadas ni vencidas en now.
+	CountActiveForUser(ctx context.Context, userID primitive.ObjectID, now time.Time) (int64, error)
+	// Revoke revoca la clave id solo si es del usuario y no estaba revocada;
+	// si no, devuelve ErrNotFound.
+	Revoke(ctx context.Context, userID, id primitive.ObjectID, now time.Time) error
+	RevokeAllForUser(ctx context.Context, userID primitive.ObjectID, now time.Time) error
+	DeleteForUser(ctx context.Context, userID primitive.ObjectID) error
+	// Touch registra el último uso de la clave.
+	Touch(ctx context.Context, id primitive.ObjectID, at time.Time, ip string) error
+}
+
+type APIKeyRepository struct {
+	db database.DB
+}
+
+func NewAPIKeyRepository(db database.DB) *APIKeyRepository {
+	return &APIKeyRepository{db: db}
+}
+
+func (r APIKeyRepository) collection() *mongo.Collection {
+	return r.db.GetDatabase().Collection("api_keys")
+}
+
+func (r APIKeyRepository) Create(ctx context.Context, key models.APIKey) error {
+	ctx, cancel := r.db.QueryContext(ctx)
+	defer cancel()
+
+	if key.ID.IsZero() {
+		key.ID = primitive.NewObjectID()
+	}
+	if key.CreatedAt.IsZero() {
+		key.CreatedAt = time.Now()
+	}
+	_, err := r.collection().InsertOne(ctx, key)
+	return apperrors.FromMongo(err)
+}
+
+func (r APIKeyRepository) GetByHash(ctx context.Context, hash string) (models.APIKey, error) {
+	ctx, cancel := r.db.QueryContext(ctx)
+	defer cancel()
+
+	var key models.APIKey
+	err := r.collection().FindOne(ctx, bson.M{"key_hash": hash}).Decode(&key)
+	return key, apperrors.FromMongo(err)
+}
+
+func (r APIKeyRepository) ListForUser(ctx context.Context, userID primitive.ObjectID) ([]models.APIKey, error) {
+	ctx, cancel := r.db.QueryContext(ctx)
+	defer cancel()
+
+	filter := bson.M{"user_id": userID, "revoked_at": bson.M{"$exists": false}}
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cursor, err := r.collection().Find(ctx, filter, opts)
+	if err != nil {
+		return nil, apperrors.FromMongo(err)
+	}
+	defer cursor.Close(ctx)
+
+	keys := []models.APIKey{}
+	if err := cursor.All(ctx, &keys); err != nil {
+		return nil, apperrors.FromMongo(err)
+	}
+	return keys, nil
+}
+
+func (r APIKeyRepository) CountActiveForUser(ctx context.Context, userID primitive.ObjectID, now time.Time) (int64, error) {
+	ctx, cancel := r.db.QueryContext(ctx)
+	defer cancel()
+
+	filter := bson.M{"user_id": userID, "revoked_at": bson.M{"$exists": false}, "expires_at": bson.M{"$gt": now}}
+	n, err := r.collection().CountDocuments(ctx, filter)
+	return n, apperrors.FromMongo(err)
+}
+
+func (r APIKeyRepository) Revoke(ctx context.Context, userID, id primitive.ObjectID, now time.Time) error {
+	ctx, cancel := r.db.QueryContext(ctx)
+	defer cancel()
+
+	filter := bson.M{"_id": id, "user_id": userID, "revoked_at": bson.M{"$exists": false}}
+	res, err := r.collection().UpdateOne(ctx, filter, bson.M{"$set": bson.M{"revoked_at": now}})
+	if err != nil {
+		return apperrors.FromMongo(err)
+	}
+	if res.MatchedCount == 0 {
+		return apperrors.FromMongo(mongo.ErrNoDocuments)
+	}
+	return nil
+}
+
+func (r APIKeyRepository) RevokeAllForUser(ctx context.Context, userID primitive.ObjectID, now time.Time) error {
+	ctx, cancel := r.db.QueryContext(ctx)
+	defer cancel()
+
+	filter := bson.M{"user_id": userID, "revoked_at": bson.M{"$exists": false}}
+	_, err := r.collection().UpdateMany(ctx, filter, bson.M{"$set": bson.M{"revoked_at": now}})
+	return apperrors.FromMongo(err)
+}
+
+func (r APIKeyRepository) DeleteForUser(ctx context.Context, userID primitive.ObjectID) error {
+	ctx, cancel := r.db.QueryContext(ctx)
+	defer cancel()
+
+	_, err := r.collection().DeleteMany(ctx, bson.M{"user_id": userID})
+	return apperrors.FromMongo(err)
+}
+
+func (r APIKeyRepository) Touch(ctx context.Context, id primitive.ObjectID, at time.Time, ip string) error {
+	ctx, cancel := r.db.QueryContext(ctx)
+	defer cancel()
+
+	update := bson.M{"$set": bson.M{"last_used_at": at, "last_used_ip": ip}}
+	_, err := r.collection().UpdateOne(ctx, bson.M{"_id": id}, update)
+	return apperrors.FromMongo(err)
+}
@@ -0,0 +1,95 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"backend/apperrors"
+	"backend/database"
+	"backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// AuditRepositoryInterface guarda el registro de auditoría. Solo se agregan
+// eventos.
+type AuditRepositoryInterface interface {
+	Create(ctx context.Context, event models.AuditEvent) error
+	// List devuelve una página de eventos, los más nuevos primero, y el total
+	// que cumple el filtro.
+	List(ctx context.Context, filter AuditFilter) ([]models.AuditEvent, int64, error)
+}
+
+// AuditFilter filtra List; los campos vacíos no filtran.
+type AuditFilter struct {
+	ActorID  primitive.ObjectID
+	TargetID string
+	Action   string
+	Skip     int64
+	Limit    int64
+}
+
+type AuditRepository struct {
+	db database.DB
+}
+
+func NewAuditRepository(db database.DB) *AuditRepository {
+	return &AuditRepository{db: db}
+}
+
+func (r AuditRepository) collection() *mongo.Collection {
+	return r.db.GetDatabase().Collection("audit_events")
+}
+
+func (r AuditRepository) Create(ctx context.Context, event models.AuditEvent) error {
+	ctx, cancel := r.db.QueryContext(ctx)
+	defer cancel()
+
+	if event.ID.IsZero() {
+		event.ID = primitive.NewObjectID()
+	}
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+	_, err := r.collection().InsertOne(ctx, event)
+	return apperrors.FromMongo(err)
+}
+
+func (r AuditRepository) List(ctx context.Context, filter AuditFilter) ([]models.AuditEvent, int64, error) {
+	ctx, cancel := r.db.QueryContext(ctx)
+	defer cancel()
+
+	query := bson.M{}
+	if !filter.ActorID.IsZero() {
+		query["actor_id"] = filter.ActorID
+	}
+	if filter.TargetID != "" {
+		query["target_id"] = filter.TargetID
+	}
+	if filter.Action != "" {
+		query["action"] = filter.Action
+	}
+
+	total, err := r.collection().CountDocuments(ctx, query)
+	if err != nil {
+		return nil, 0, apperrors.FromMongo(err)
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetSkip(filter.Skip).
+		SetLimit(filter.Limit)
+	cursor, err := r.collection().Find(ctx, query, opts)
+	if err != nil {
+		return nil, 0, apperrors.FromMongo(err)
+	}
+	defer cursor.Close(ctx)
+
+	events := []models.AuditEvent{}
+	if err := cursor.All(ctx, &events); err != nil {
+		return nil, 0, apperrors.FromMongo(err)
+	}
+	return events, total, nil
+}
@@ -0,0 +1,88 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"backend/apperrors"
+	"backend/database"
+	"backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type EmailVerificationRepositoryInterface interface {
+	Create(ctx context.Context, v models.EmailVerification) error
+	// Consume marca como usado el token con ese hash si no se usó y no venció,
+	// y lo devuelve. Si no hay ninguno así devuelve ErrNotFound: dos pedidos
+	// con el mismo token no pueden usarlo los dos.
+	Consume(ctx context.Context, hash string, now time.Time) (models.EmailVerification, error)
+	// CountSince cuenta los tokens emitidos para el usuario desde since.
+	CountSince(ctx context.Context, userID primitive.ObjectID, since time.Time) (int64, error)
+	// InvalidateForUser marca como usados los tokens pendientes del usuario.
+	InvalidateForUser(ctx context.Context, userID primitive.ObjectID, now time.Time) error
+}
+
+type EmailVerificationRepository struct {
+	db database.DB
+}
+
+func NewEmailVerificationRepository(db database.DB) *EmailVerificationRepository {
+	return &EmailVerificationRepository{db: db}
+}
+
+func (r EmailVerificationRepository) collection() *mongo.Collection {
+	return r.db.GetDatabase().Collection("email_verifications")
+}
+
+func (r EmailVerificationRepository) Create(ctx context.Context, v models.EmailVerification) error {
+	ctx, cancel := r.db.QueryContext(ctx)
+	defer cancel()
+
+	if v.ID.IsZero() {
+		v.ID = primitive.NewObjectID()
+	}
+	if v.CreatedAt.IsZero() {
+		v.CreatedAt = time.Now()
+	}
+	_, err := r.collection().InsertOne(ctx, v)
+	return apperrors.FromMongo(err)
+}
+
+func (r EmailVerificationRepository) Consume(ctx context.Context, hash string, now time.Time) (models.EmailVerification, error) {
+	ctx, cancel := r.db.QueryContext(ctx)
+	defer cancel()
+
+	filter := bson.M{
+		"token_hash": hash,
+		"used_at":    bson.M{"$exists": false},
+		"expires_at": bson.M{"$gt": now},
+	}
+	update := bson.M{"$set": bson.M{"used_at": now}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var v models.EmailVerification
+	err := r.collection().FindOneAndUpdate(ctx, filter, update, opts).Decode(&v)
+	return v, apperrors.FromMongo(err)
+}
+
+func (r EmailVerificationRepository) CountSince(ctx context.Context, userID primitive.ObjectID, since time.Time) (int64, error) {
+	ctx, cancel := r.db.QueryContext(ctx)
+	defer cancel()
+
+	filter := bson.M{"user_id": userID, "created_at": bson.M{"$gte": since}}
+	n, err := r.collection().CountDocuments(ctx, filter)
+	return n, apperrors.FromMongo(err)
+}
+
+func (r EmailVerificationRepository) InvalidateForUser(ctx context.Context, userID primitive.ObjectID, now time.Time) error {
+	ctx, cancel := r.db.QueryContext(ctx)
+	defer cancel()
+
+	filter := bson.M{"user_id": userID, "used_at": bson.M{"$exists": false}}
+	_, err := r.collection().UpdateMany(ctx, filter, bson.M{"$set": bson.M{"used_at": now}})
+	return apperrors.FromMongo(err)
+}
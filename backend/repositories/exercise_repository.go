@@ -3,8 +3,10 @@ package repositories
 import (
 	"context"
 
+	"backend/apperrors"
 	"backend/database"
 	"backend/models"
+	"backend/utils"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -12,11 +14,11 @@ import (
 )
 
 type ExerciseRepositoryInterface interface {
-	GetExercises(name, category, muscleGroup string) ([]models.Exercise, error)
-	GetExerciseByID(id string) (models.Exercise, error)
-	CreateExercise(exercise models.Exercise) (*mongo.InsertOneResult, error)
-	UpdateExercise(exercise models.Exercise) (*mongo.UpdateResult, error)
-	DeleteExercise(id primitive.ObjectID) (*mongo.DeleteResult, error)
+	GetExercises(ctx context.Context, name, category, muscleGroup string) ([]models.Exercise, error)
+	GetExerciseByID(ctx context.Context, id string) (models.Exercise, error)
+	CreateExercise(ctx context.Context, exercise models.Exercise) (*mongo.InsertOneResult, error)
+	UpdateExercise(ctx context.Context, exercise models.Exercise) (*mongo.UpdateResult, error)
+	DeleteExercise(ctx context.Context, id primitive.ObjectID) (*mongo.DeleteResult, error)
 }
 
 type ExerciseRepository struct {
@@ -29,8 +31,10 @@ func NewExerciseRepository(db database.DB) *ExerciseRepository {
 	}
 }
 
-func (repository ExerciseRepository) GetExercises(name, category, muscleGroup string) ([]models.Exercise, error) {
-	collection := repository.db.GetClient().Database("fitness_db").Collection("exercises")
+func (repository ExerciseRepository) GetExercises(ctx context.Context, name, category, muscleGroup string) ([]models.Exercise, error) {
+	collection := repository.db.GetDatabase().Collection("exercises")
+	ctx, cancel := repository.db.QueryContext(ctx)
+	defer cancel()
 
 	filter := bson.M{}
 
@@ -44,14 +48,14 @@ func (repository ExerciseRepository) GetExercises(name, category, muscleGroup st
 		filter["muscle_group"] = muscleGroup
 	}
 
-	cursor, err := collection.Find(context.TODO(), filter)
+	cursor, err := collection.Find(ctx, filter)
 	if err != nil {
 		return nil, err
 	}
-	defer cursor.Close(context.Background())
+	defer cursor.Close(ctx)
 
 	var exercises []models.Exercise
-	for cursor.Next(context.Background()) {
+	for cursor.Next(ctx) {
 		var exercise models.Exercise
 		if err := cursor.Decode(&exercise); err != nil {
 			continue
@@ -59,31 +63,38 @@ func (repository ExerciseRepository) GetExercises(name, category, muscleGroup st
 		exercises = append(exercises, exercise)
 	}
 
-	return exercises, nil
+	return exercises, cursor.Err()
 }
 
-func (repository ExerciseRepository) GetExerciseByID(id string) (models.Exercise, error) {
-	collection := repository.db.GetClient().Database("fitness_db").Collection("exercises")
-	objectID, err := primitive.ObjectIDFromHex(id)
+func (repository ExerciseRepository) GetExerciseByID(ctx context.Context, id string) (models.Exercise, error) {
+	collection := repository.db.GetDatabase().Collection("exercises")
+	objectID, err := utils.ParseObjectID(id)
 	if err != nil {
 		return models.Exercise{}, err
 	}
+	ctx, cancel := repository.db.QueryContext(ctx)
+	defer cancel()
 
 	filter := bson.M{"_id": objectID}
 	var exercise models.Exercise
 
-	err = collection.FindOne(context.TODO(), filter).Decode(&exercise)
-	return exercise, err
+	err = collection.FindOne(ctx, filter).Decode(&exercise)
+	return exercise, apperrors.FromMongo(err)
 }
 
-func (repository ExerciseRepository) CreateExercise(exercise models.Exercise) (*mongo.InsertOneResult, error) {
-	collection := repository.db.GetClient().Database("fitness_db").Collection("exercises")
-	result, err := collection.InsertOne(context.TODO(), exercise)
-	return result, err
+func (repository ExerciseRepository) CreateExercise(ctx context.Context, exercise models.Exercise) (*mongo.InsertOneResult, error) {
+	collection := repository.db.GetDatabase().Collection("exercises")
+	ctx, cancel := repository.db.QueryContext(ctx)
+	defer cancel()
+
+	result, err := collection.InsertOne(ctx, exercise)
+	return result, apperrors.FromMongo(err)
 }
 
-func (repository ExerciseRepository) UpdateExercise(exercise models.Exercise) (*mongo.UpdateResult, error) {
-	collection := repository.db.GetClient().Database("fitness_db").Collection("exercises")
+func (repository ExerciseRepository) UpdateExercise(ctx context.Context, exercise models.Exercise) (*mongo.UpdateResult, error) {
+	collection := repository.db.GetDatabase().Collection("exercises")
+	ctx, cancel := repository.db.QueryContext(ctx)
+	defer cancel()
 
 	filter := bson.M{"_id": exercise.ID}
 	update := bson.M{"$set": bson.M{
@@ -97,14 +108,16 @@ func (repository ExerciseRepository) UpdateExercise(exercise models.Exercise) (*
 		"updated_at":   exercise.UpdatedAt,
 	}}
 
-	result, err := collection.UpdateOne(context.TODO(), filter, update)
-	return result, err
+	result, err := collection.UpdateOne(ctx, filter, update)
+	return result, apperrors.FromMongo(err)
 }
 
-func (repository ExerciseRepository) DeleteExercise(id primitive.ObjectID) (*mongo.DeleteResult, error) {
-	collection := repository.db.GetClient().Database("fitness_db").Collection("exercises")
+func (repository ExerciseRepository) DeleteExercise(ctx context.Context, id primitive.ObjectID) (*mongo.DeleteResult, error) {
+	collection := repository.db.GetDatabase().Collection("exercises")
+	ctx, cancel := repository.db.QueryContext(ctx)
+	defer cancel()
 
 	filter := bson.M{"_id": id}
-	result, err := collection.DeleteOne(context.TODO(), filter)
-	return result, err
+	result, err := collection.DeleteOne(ctx, filter)
+	return result, apperrors.FromMongo(err)
 }
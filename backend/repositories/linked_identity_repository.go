@@ -0,0 +1,114 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"backend/apperrors"
+	"backend/database"
+	"backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type LinkedIdentityRepositoryInterface interface {
+	// GetByProviderSubject devuelve la identidad sub de provider o
+	// ErrNotFound.
+	GetByProviderSubject(ctx context.Context, provider, subject string) (models.LinkedIdentity, error)
+	// Create devuelve ErrConflict si la identidad ya está vinculada.
+	Create(ctx context.Context, identity models.LinkedIdentity) error
+	// ListForUser devuelve las identidades del usuario, de la más vieja a la
+	// más nueva.
+	ListForUser(ctx context.Context, userID primitive.ObjectID) ([]models.LinkedIdentity, error)
+	// Delete borra la identidad id solo si es del usuario; si no, devuelve
+	// ErrNotFound.
+	Delete(ctx context.Context, userID, id primitive.ObjectID) error
+	DeleteForUser(ctx context.Context, userID primitive.ObjectID) error
+	TouchLogin(ctx context.Context, id primitive.ObjectID, at time.Time) error
+}
+
+type LinkedIdentityRepository struct {
+	db database.DB
+}
+
+func NewLinkedIdentityRepository(db database.DB) *LinkedIdentityRepository {
+	return &LinkedIdentityRepository{db: db}
+}
+
+func (r LinkedIdentityRepository) collection() *mongo.Collection {
+	return r.db.GetDatabase().Collection("linked_identities")
+}
+
+func (r LinkedIdentityRepository) GetByProviderSubject(ctx context.Context, provider, subject string) (models.LinkedIdentity, error) {
+	ctx, cancel := r.db.QueryContext(ctx)
+	defer cancel()
+
+	var identity models.LinkedIdentity
+	err := r.collection().FindOne(ctx, bson.M{"provider": provider, "subject": subject}).Decode(&identity)
+	return identity, apperrors.FromMongo(err)
+}
+
+func (r LinkedIdentityRepository) Create(ctx context.Context, identity models.LinkedIdentity) error {
+	ctx, cancel := r.db.QueryContext(ctx)
+	defer cancel()
+
+	if identity.ID.IsZero() {
+		identity.ID = primitive.NewObjectID()
+	}
+	if identity.CreatedAt.IsZero() {
+		identity.CreatedAt = time.Now()
+	}
+	_, err := r.collection().InsertOne(ctx, identity)
+	return apperrors.FromMongo(err)
+}
+
+func (r LinkedIdentityRepository) ListForUser(ctx context.Context, userID primitive.ObjectID) ([]models.LinkedIdentity, error) {
+	ctx, cancel := r.db.QueryContext(ctx)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	cursor, err := r.collection().Find(ctx, bson.M{"user_id": userID}, opts)
+	if err != nil {
+		return nil, apperrors.FromMongo(err)
+	}
+	defer cursor.Close(ctx)
+
+	identities := []models.LinkedIdentity{}
+	if err := cursor.All(ctx, &identities); err != nil {
+		return nil, apperrors.FromMongo(err)
+	}
+	return identities, nil
+}
+
+func (r LinkedIdentityRepository) Delete(ctx context.Context, userID, id primitive.ObjectID) error {
+	ctx, cancel := r.db.QueryContext(ctx)
+	defer cancel()
+
+	res, err := r.collection().DeleteOne(ctx, bson.M{"_id": id, "user_id": userID})
+	if err != nil {
+		return apperrors.FromMongo(err)
+	}
+	if res.DeletedCount == 0 {
+		return apperrors.FromMongo(mongo.ErrNoDocuments)
+	}
+	return nil
+}
+
+func (r LinkedIdentityRepository) DeleteForUser(ctx context.Context, userID primitive.ObjectID) error {
+	ctx, cancel := r.db.QueryContext(ctx)
+	defer cancel()
+
+	_, err := r.collection().DeleteMany(ctx, bson.M{"user_id": userID})
+	return apperrors.FromMongo(err)
+}
+
+func (r LinkedIdentityRepository) TouchLogin(ctx context.Context, id primitive.ObjectID, at time.Time) error {
+	ctx, cancel := r.db.QueryContext(ctx)
+	defer cancel()
+
+	_, err := r.collection().UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"last_login_at": at}})
+	return apperrors.FromMongo(err)
+}
@@ -0,0 +1,119 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"backend/apperrors"
+	"backend/database"
+	"backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type LoginAttemptRepositoryInterface interface {
+	// Get devuelve el contador de key o ErrNotFound. Puede devolver uno
+	// vencido que el TTL todavía no borró: quien llama debe mirar ExpiresAt.
+	Get(ctx context.Context, key string) (models.LoginAttempt, error)
+	// RecordAttempt suma un intento a key de forma atómica, empezando de cero
+	// si el contador venció, y devuelve el contador como estaba antes de
+	// sumarlo (el valor cero si no había). Así dos intentos simultáneos nunca
+	// ven el mismo estado.
+	RecordAttempt(ctx context.Context, key string, now, expiresAt time.Time) (models.LoginAttempt, error)
+	// Release descuenta un intento de key, sin bajar de cero.
+	Release(ctx context.Context, key string) error
+	// Lock bloquea key hasta until y guarda el hash del token de desbloqueo.
+	Lock(ctx context.Context, key string, until time.Time, unlockHash string) error
+	// Unlock borra el contador bloqueado con ese token y lo devuelve; si no
+	// hay ninguno vigente devuelve ErrNotFound.
+	Unlock(ctx context.Context, unlockHash string, now time.Time) (models.LoginAttempt, error)
+	Reset(ctx context.Context, key string) error
+}
+
+type LoginAttemptRepository struct {
+	db database.DB
+}
+
+func NewLoginAttemptRepository(db database.DB) *LoginAttemptRepository {
+	return &LoginAttemptRepository{db: db}
+}
+
+func (r LoginAttemptRepository) collection() *mongo.Collection {
+	return r.db.GetDatabase().Collection("login_attempts")
+}
+
+func (r LoginAttemptRepository) Get(ctx context.Context, key string) (models.LoginAttempt, error) {
+	ctx, cancel := r.db.QueryContext(ctx)
+	defer cancel()
+
+	var attempt models.LoginAttempt
+	err := r.collection().FindOne(ctx, bson.M{"key": key}).Decode(&attempt)
+	return attempt, apperrors.FromMongo(err)
+}
+
+func (r LoginAttemptRepository) RecordAttempt(ctx context.Context, key string, now, expiresAt time.Time) (models.LoginAttempt, error) {
+	ctx, cancel := r.db.QueryContext(ctx)
+	defer cancel()
+
+	// El TTL de Mongo borra con hasta un minuto de atraso: un contador vencido
+	// se descarta acá para que el upsert empiece de cero.
+	if _, err := r.collection().DeleteOne(ctx, bson.M{"key": key, "expires_at": bson.M{"$lte": now}}); err != nil {
+		return models.LoginAttempt{}, apperrors.FromMongo(err)
+	}
+
+	update := bson.M{
+		"$inc": bson.M{"failures": 1},
+		"$set": bson.M{"last_failure_at": now},
+		"$max": bson.M{"expires_at": expiresAt},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.Before)
+
+	var attempt models.LoginAttempt
+	err := r.collection().FindOneAndUpdate(ctx, bson.M{"key": key}, update, opts).Decode(&attempt)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return models.LoginAttempt{}, nil
+	}
+	return attempt, apperrors.FromMongo(err)
+}
+
+func (r LoginAttemptRepository) Release(ctx context.Context, key string) error {
+	ctx, cancel := r.db.QueryContext(ctx)
+	defer cancel()
+
+	filter := bson.M{"key": key, "failures": bson.M{"$gt": 0}}
+	_, err := r.collection().UpdateOne(ctx, filter, bson.M{"$inc": bson.M{"failures": -1}})
+	return apperrors.FromMongo(err)
+}
+
+func (r LoginAttemptRepository) Lock(ctx context.Context, key string, until time.Time, unlockHash string) error {
+	ctx, cancel := r.db.QueryContext(ctx)
+	defer cancel()
+
+	update := bson.M{
+		"$set": bson.M{"locked_until": until, "unlock_token_hash": unlockHash},
+		"$max": bson.M{"expires_at": until},
+	}
+	_, err := r.collection().UpdateOne(ctx, bson.M{"key": key}, update)
+	return apperrors.FromMongo(err)
+}
+
+func (r LoginAttemptRepository) Unlock(ctx context.Context, unlockHash string, now time.Time) (models.LoginAttempt, error) {
+	ctx, cancel := r.db.QueryContext(ctx)
+	defer cancel()
+
+	filter := bson.M{"unlock_token_hash": unlockHash, "locked_until": bson.M{"$gt": now}}
+	var attempt models.LoginAttempt
+	err := r.collection().FindOneAndDelete(ctx, filter).Decode(&attempt)
+	return attempt, apperrors.FromMongo(err)
+}
+
+func (r LoginAttemptRepository) Reset(ctx context.Context, key string) error {
+	ctx, cancel := r.db.QueryContext(ctx)
+	defer cancel()
+
+	_, err := r.collection().DeleteOne(ctx, bson.M{"key": key})
+	return apperrors.FromMongo(err)
+}
@@ -0,0 +1,172 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"backend/models"
+	"backend/repositories"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var _ repositories.APIKeyRepositoryInterface = (*APIKeyRepository)(nil)
+
+type APIKeyRepository struct {
+	mu   sync.RWMutex
+	keys []models.APIKey
+}
+
+func NewAPIKeyRepository() *APIKeyRepository {
+	return &APIKeyRepository{}
+}
+
+func (r *APIKeyRepository) Create(ctx context.Context, key models.APIKey) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if key.ID.IsZero() {
+		key.ID = primitive.NewObjectID()
+	}
+	if key.CreatedAt.IsZero() {
+		key.CreatedAt = time.Now()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.keys {
+		if existing.KeyHash == key.KeyHash {
+			return duplicateKeyError()
+		}
+	}
+	r.keys = append(r.keys, key)
+	return nil
+}
+
+func (r *APIKeyRepository) GetByHash(ctx context.Context, hash string) (models.APIKey, error) {
+	if err := ctx.Err(); err != nil {
+		return models.APIKey{}, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, key := range r.keys {
+		if key.KeyHash == hash {
+			return key, nil
+		}
+	}
+	return models.APIKey{}, notFoundError()
+}
+
+func (r *APIKeyRepository) ListForUser(ctx context.Context, userID primitive.ObjectID) ([]models.APIKey, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	out := []models.APIKey{}
+	for _, key := range r.keys {
+		if key.UserID == userID && key.RevokedAt == nil {
+			out = append(out, key)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	return out, nil
+}
+
+func (r *APIKeyRepository) CountActiveForUser(ctx context.Context, userID primitive.ObjectID, now time.Time) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var n int64
+	for _, key := range r.keys {
+		if key.UserID == userID && key.Active(now) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (r *APIKeyRepository) Revoke(ctx context.Context, userID, id primitive.ObjectID, now time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.keys {
+		key := &r.keys[i]
+		if key.ID == id && key.UserID == userID && key.RevokedAt == nil {
+			revoked := now
+			key.RevokedAt = &revoked
+			return nil
+		}
+	}
+	return notFoundError()
+}
+
+func (r *APIKeyRepository) RevokeAllForUser(ctx context.Context, userID primitive.ObjectID, now time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.keys {
+		key := &r.keys[i]
+		if key.UserID == userID && key.RevokedAt == nil {
+			revoked := now
+			key.RevokedAt = &revoked
+		}
+	}
+	return nil
+}
+
+func (r *APIKeyRepository) DeleteForUser(ctx context.Context, userID primitive.ObjectID) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	kept := r.keys[:0]
+	for _, key := range r.keys {
+		if key.UserID != userID {
+			kept = append(kept, key)
+		}
+	}
+	r.keys = kept
+	return nil
+}
+
+func (r *APIKeyRepository) Touch(ctx context.Context, id primitive.ObjectID, at time.Time, ip string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.keys {
+		if r.keys[i].ID == id {
+			used := at
+			r.keys[i].LastUsedAt = &used
+			r.keys[i].LastUsedIP = ip
+			return nil
+		}
+	}
+	return nil
+}
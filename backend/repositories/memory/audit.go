@@ -0,0 +1,76 @@
+package memory
+
+import (
+	"context"
+	"maps"
+	"sync"
+	"time"
+
+	"backend/models"
+	"backend/repositories"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var _ repositories.AuditRepositoryInterface = (*AuditRepository)(nil)
+
+type AuditRepository struct {
+	mu     sync.RWMutex
+	events []models.AuditEvent
+}
+
+func NewAuditRepository() *AuditRepository {
+	return &AuditRepository{}
+}
+
+func (r *AuditRepository) Create(ctx context.Context, event models.AuditEvent) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if event.ID.IsZero() {
+		event.ID = primitive.NewObjectID()
+	}
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+	event.Details = maps.Clone(event.Details)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.events = append(r.events, event)
+	return nil
+}
+
+func (r *AuditRepository) List(ctx context.Context, filter repositories.AuditFilter) ([]models.AuditEvent, int64, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	// Se agregan en orden: recorrer al revés da los más nuevos primero.
+	matched := []models.AuditEvent{}
+	for i := len(r.events) - 1; i >= 0; i-- {
+		e := r.events[i]
+		if !filter.ActorID.IsZero() && e.ActorID != filter.ActorID {
+			continue
+		}
+		if filter.TargetID != "" && e.TargetID != filter.TargetID {
+			continue
+		}
+		if filter.Action != "" && e.Action != filter.Action {
+			continue
+		}
+		matched = append(matched, e)
+	}
+
+	total := int64(len(matched))
+	start := min(filter.Skip, total)
+	end := total
+	if filter.Limit > 0 {
+		end = min(start+filter.Limit, total)
+	}
+	return matched[start:end], total, nil
+}
@@ -0,0 +1,100 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"backend/models"
+	"backend/repositories"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var _ repositories.EmailVerificationRepositoryInterface = (*EmailVerificationRepository)(nil)
+
+type EmailVerificationRepository struct {
+	mu            sync.RWMutex
+	verifications []models.EmailVerification
+}
+
+func NewEmailVerificationRepository() *EmailVerificationRepository {
+	return &EmailVerificationRepository{}
+}
+
+func (r *EmailVerificationRepository) Create(ctx context.Context, v models.EmailVerification) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if v.ID.IsZero() {
+		v.ID = primitive.NewObjectID()
+	}
+	if v.CreatedAt.IsZero() {
+		v.CreatedAt = time.Now()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.verifications {
+		if existing.TokenHash == v.TokenHash {
+			return duplicateKeyError()
+		}
+	}
+	r.verifications = append(r.verifications, v)
+	return nil
+}
+
+func (r *EmailVerificationRepository) Consume(ctx context.Context, hash string, now time.Time) (models.EmailVerification, error) {
+	if err := ctx.Err(); err != nil {
+		return models.EmailVerification{}, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.verifications {
+		v := &r.verifications[i]
+		if v.TokenHash == hash && v.UsedAt == nil && v.ExpiresAt.After(now) {
+			used := now
+			v.UsedAt = &used
+			return *v, nil
+		}
+	}
+	return models.EmailVerification{}, notFoundError()
+}
+
+func (r *EmailVerificationRepository) CountSince(ctx context.Context, userID primitive.ObjectID, since time.Time) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var n int64
+	for _, v := range r.verifications {
+		if v.UserID == userID && !v.CreatedAt.Before(since) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (r *EmailVerificationRepository) InvalidateForUser(ctx context.Context, userID primitive.ObjectID, now time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.verifications {
+		v := &r.verifications[i]
+		if v.UserID == userID && v.UsedAt == nil {
+			used := now
+			v.UsedAt = &used
+		}
+	}
+	return nil
+}
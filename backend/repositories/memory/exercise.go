@@ -0,0 +1,137 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"backend/models"
+	"backend/repositories"
+	"backend/utils"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var _ repositories.ExerciseRepositoryInterface = (*ExerciseRepository)(nil)
+
+type ExerciseRepository struct {
+	mu        sync.RWMutex
+	exercises []models.Exercise
+}
+
+func NewExerciseRepository() *ExerciseRepository {
+	return &ExerciseRepository{}
+}
+
+func (r *ExerciseRepository) GetExercises(ctx context.Context, name, category, muscleGroup string) ([]models.Exercise, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	match, err := nameMatcher(name)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var out []models.Exercise
+	for _, e := range r.exercises {
+		if !match(e.Name) {
+			continue
+		}
+		if category != "" && e.Category != category {
+			continue
+		}
+		if muscleGroup != "" && e.MuscleGroup != muscleGroup {
+			continue
+		}
+		out = append(out, e)
+	}
+	return out, nil
+}
+
+func (r *ExerciseRepository) GetExerciseByID(ctx context.Context, id string) (models.Exercise, error) {
+	if err := ctx.Err(); err != nil {
+		return models.Exercise{}, err
+	}
+	objectID, err := utils.ParseObjectID(id)
+	if err != nil {
+		return models.Exercise{}, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if i := r.indexOf(objectID); i >= 0 {
+		return r.exercises[i], nil
+	}
+	return models.Exercise{}, notFoundError()
+}
+
+func (r *ExerciseRepository) CreateExercise(ctx context.Context, exercise models.Exercise) (*mongo.InsertOneResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if exercise.ID.IsZero() {
+		exercise.ID = primitive.NewObjectID()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.indexOf(exercise.ID) >= 0 {
+		return nil, duplicateKeyError()
+	}
+	r.exercises = append(r.exercises, exercise)
+	return &mongo.InsertOneResult{InsertedID: exercise.ID}, nil
+}
+
+func (r *ExerciseRepository) UpdateExercise(ctx context.Context, exercise models.Exercise) (*mongo.UpdateResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.indexOf(exercise.ID)
+	if i < 0 {
+		return &mongo.UpdateResult{}, nil
+	}
+	existing := &r.exercises[i]
+	existing.Name = exercise.Name
+	existing.Description = exercise.Description
+	existing.Category = exercise.Category
+	existing.MuscleGroup = exercise.MuscleGroup
+	existing.Difficulty = exercise.Difficulty
+	existing.MediaURL = exercise.MediaURL
+	existing.Steps = exercise.Steps
+	existing.UpdatedAt = exercise.UpdatedAt
+	return &mongo.UpdateResult{MatchedCount: 1, ModifiedCount: 1}, nil
+}
+
+func (r *ExerciseRepository) DeleteExercise(ctx context.Context, id primitive.ObjectID) (*mongo.DeleteResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.indexOf(id)
+	if i < 0 {
+		return &mongo.DeleteResult{}, nil
+	}
+	r.exercises = append(r.exercises[:i], r.exercises[i+1:]...)
+	return &mongo.DeleteResult{DeletedCount: 1}, nil
+}
+
+func (r *ExerciseRepository) indexOf(id primitive.ObjectID) int {
+	for i, e := range r.exercises {
+		if e.ID == id {
+			return i
+		}
+	}
+	return -1
+}
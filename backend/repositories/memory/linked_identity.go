@@ -0,0 +1,133 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"backend/models"
+	"backend/repositories"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var _ repositories.LinkedIdentityRepositoryInterface = (*LinkedIdentityRepository)(nil)
+
+type LinkedIdentityRepository struct {
+	mu         sync.RWMutex
+	identities []models.LinkedIdentity
+}
+
+func NewLinkedIdentityRepository() *LinkedIdentityRepository {
+	return &LinkedIdentityRepository{}
+}
+
+func (r *LinkedIdentityRepository) GetByProviderSubject(ctx context.Context, provider, subject string) (models.LinkedIdentity, error) {
+	if err := ctx.Err(); err != nil {
+		return models.LinkedIdentity{}, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, identity := range r.identities {
+		if identity.Provider == provider && identity.Subject == subject {
+			return identity, nil
+		}
+	}
+	return models.LinkedIdentity{}, notFoundError()
+}
+
+func (r *LinkedIdentityRepository) Create(ctx context.Context, identity models.LinkedIdentity) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if identity.ID.IsZero() {
+		identity.ID = primitive.NewObjectID()
+	}
+	if identity.CreatedAt.IsZero() {
+		identity.CreatedAt = time.Now()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.identities {
+		if existing.Provider == identity.Provider && existing.Subject == identity.Subject {
+			return duplicateKeyError()
+		}
+	}
+	r.identities = append(r.identities, identity)
+	return nil
+}
+
+func (r *LinkedIdentityRepository) ListForUser(ctx context.Context, userID primitive.ObjectID) ([]models.LinkedIdentity, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	// identities está en orden de creación.
+	out := []models.LinkedIdentity{}
+	for _, identity := range r.identities {
+		if identity.UserID == userID {
+			out = append(out, identity)
+		}
+	}
+	return out, nil
+}
+
+func (r *LinkedIdentityRepository) Delete(ctx context.Context, userID, id primitive.ObjectID) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, identity := range r.identities {
+		if identity.ID == id && identity.UserID == userID {
+			r.identities = append(r.identities[:i], r.identities[i+1:]...)
+			return nil
+		}
+	}
+	return notFoundError()
+}
+
+func (r *LinkedIdentityRepository) DeleteForUser(ctx context.Context, userID primitive.ObjectID) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	kept := r.identities[:0]
+	for _, identity := range r.identities {
+		if identity.UserID != userID {
+			kept = append(kept, identity)
+		}
+	}
+	r.identities = kept
+	return nil
+}
+
+func (r *LinkedIdentityRepository) TouchLogin(ctx context.Context, id primitive.ObjectID, at time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.identities {
+		if r.identities[i].ID == id {
+			t := at
+			r.identities[i].LastLoginAt = &t
+			return nil
+		}
+	}
+	return nil
+}
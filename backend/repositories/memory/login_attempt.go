@@ -0,0 +1,145 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"backend/models"
+	"backend/repositories"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var _ repositories.LoginAttemptRepositoryInterface = (*LoginAttemptRepository)(nil)
+
+type LoginAttemptRepository struct {
+	mu       sync.RWMutex
+	attempts []models.LoginAttempt
+}
+
+func NewLoginAttemptRepository() *LoginAttemptRepository {
+	return &LoginAttemptRepository{}
+}
+
+func (r *LoginAttemptRepository) Get(ctx context.Context, key string) (models.LoginAttempt, error) {
+	if err := ctx.Err(); err != nil {
+		return models.LoginAttempt{}, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, attempt := range r.attempts {
+		if attempt.Key == key {
+			return attempt, nil
+		}
+	}
+	return models.LoginAttempt{}, notFoundError()
+}
+
+func (r *LoginAttemptRepository) RecordAttempt(ctx context.Context, key string, now, expiresAt time.Time) (models.LoginAttempt, error) {
+	if err := ctx.Err(); err != nil {
+		return models.LoginAttempt{}, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.attempts {
+		attempt := &r.attempts[i]
+		if attempt.Key != key {
+			continue
+		}
+		var before models.LoginAttempt
+		if attempt.ExpiresAt.After(now) {
+			before = *attempt
+		} else {
+			*attempt = models.LoginAttempt{ID: attempt.ID, Key: key}
+		}
+		attempt.Failures++
+		attempt.LastFailureAt = now
+		if expiresAt.After(attempt.ExpiresAt) {
+			attempt.ExpiresAt = expiresAt
+		}
+		return before, nil
+	}
+
+	attempt := models.LoginAttempt{
+		ID:            primitive.NewObjectID(),
+		Key:           key,
+		Failures:      1,
+		LastFailureAt: now,
+		ExpiresAt:     expiresAt,
+	}
+	r.attempts = append(r.attempts, attempt)
+	return models.LoginAttempt{}, nil
+}
+
+func (r *LoginAttemptRepository) Release(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.attempts {
+		if r.attempts[i].Key == key && r.attempts[i].Failures > 0 {
+			r.attempts[i].Failures--
+		}
+	}
+	return nil
+}
+
+func (r *LoginAttemptRepository) Lock(ctx context.Context, key string, until time.Time, unlockHash string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.attempts {
+		attempt := &r.attempts[i]
+		if attempt.Key == key {
+			locked := until
+			attempt.LockedUntil = &locked
+			attempt.UnlockTokenHash = unlockHash
+			if until.After(attempt.ExpiresAt) {
+				attempt.ExpiresAt = until
+			}
+		}
+	}
+	return nil
+}
+
+func (r *LoginAttemptRepository) Unlock(ctx context.Context, unlockHash string, now time.Time) (models.LoginAttempt, error) {
+	if err := ctx.Err(); err != nil {
+		return models.LoginAttempt{}, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, attempt := range r.attempts {
+		if attempt.UnlockTokenHash == unlockHash && attempt.Locked(now) {
+			r.attempts = slices.Delete(r.attempts, i, i+1)
+			return attempt, nil
+		}
+	}
+	return models.LoginAttempt{}, notFoundError()
+}
+
+func (r *LoginAttemptRepository) Reset(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.attempts = slices.DeleteFunc(r.attempts, func(a models.LoginAttempt) bool { return a.Key == key })
+	return nil
+}
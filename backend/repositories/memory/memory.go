@@ -0,0 +1,34 @@
+// Package memory implementa las interfaces de repositories sobre estructuras en
+// memoria, para correr el servidor sin MongoDB (modo desarrollo y tests de
+// integración). Replica la semántica de los repositorios de Mongo: búsqueda por
+// regex sin distinguir mayúsculas, filtrado por dueño y los mismos errores
+// (apperrors.FromMongo sobre mongo.ErrNoDocuments o duplicate key).
+package memory
+
+import (
+	"regexp"
+
+	"backend/apperrors"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// nameMatcher reproduce el filtro {"$regex": name, "$options": "i"}.
+func nameMatcher(name string) (func(string) bool, error) {
+	if name == "" {
+		return func(string) bool { return true }, nil
+	}
+	re, err := regexp.Compile("(?i)" + name)
+	if err != nil {
+		return nil, err
+	}
+	return re.MatchString, nil
+}
+
+func notFoundError() error {
+	return apperrors.FromMongo(mongo.ErrNoDocuments)
+}
+
+func duplicateKeyError() error {
+	return apperrors.FromMongo(mongo.WriteException{WriteErrors: mongo.WriteErrors{{Code: 11000, Message: "E11000 duplicate key error"}}})
+}
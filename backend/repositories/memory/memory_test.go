@@ -0,0 +1,229 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"backend/apperrors"
+	"backend/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestExerciseRepository_FiltersLikeMongo(t *testing.T) {
+	ctx := context.Background()
+	repo := NewExerciseRepository()
+	for _, e := range []models.Exercise{
+		{Name: "Bench Press", Category: "strength", MuscleGroup: "chest"},
+		{Name: "Incline bench", Category: "strength", MuscleGroup: "chest"},
+		{Name: "Squat", Category: "strength", MuscleGroup: "legs"},
+	} {
+		if _, err := repo.CreateExercise(ctx, e); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+	}
+
+	got, err := repo.GetExercises(ctx, "BENCH", "", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("expected case-insensitive regex to match 2, got %d", len(got))
+	}
+
+	got, _ = repo.GetExercises(ctx, "", "strength", "legs")
+	if len(got) != 1 || got[0].Name != "Squat" {
+		t.Fatalf("unexpected result filtering by muscle group: %+v", got)
+	}
+
+	if _, err := repo.GetExercises(ctx, "(", "", ""); err == nil {
+		t.Fatalf("expected error for invalid regex")
+	}
+}
+
+func TestRoutineRepository_ScopesByOwner(t *testing.T) {
+	ctx := context.Background()
+	repo := NewRoutineRepository()
+	owner, other := primitive.NewObjectID(), primitive.NewObjectID()
+	repo.CreateRoutine(ctx, models.Routine{OwnerID: owner, Name: "Push day"})
+	repo.CreateRoutine(ctx, models.Routine{OwnerID: other, Name: "Push day"})
+
+	got, err := repo.GetRoutines(ctx, owner, "push")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 1 || got[0].OwnerID != owner {
+		t.Fatalf("expected only owner routines, got %+v", got)
+	}
+}
+
+func TestGetByID_NotFoundAndDuplicate(t *testing.T) {
+	ctx := context.Background()
+	repo := NewUserRepository()
+
+	_, err := repo.GetUserByID(ctx, primitive.NewObjectID().Hex())
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		t.Fatalf("expected mongo.ErrNoDocuments, got %v", err)
+	}
+
+	u := models.User{ID: primitive.NewObjectID(), Name: "Ana"}
+	if _, err := repo.CreateUser(ctx, u); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if _, err := repo.CreateUser(ctx, u); !mongo.IsDuplicateKeyError(err) {
+		t.Fatalf("expected duplicate key error, got %v", err)
+	}
+}
+
+func TestRefreshTokenRepository_RevokeAllForUser(t *testing.T) {
+	ctx := context.Background()
+	repo := NewRefreshTokenRepository()
+	uid := primitive.NewObjectID()
+	repo.Save(ctx, models.RefreshToken{UserID: uid, TokenHash: "a"})
+	repo.Save(ctx, models.RefreshToken{UserID: uid, TokenHash: "b"})
+	repo.Save(ctx, models.RefreshToken{UserID: primitive.NewObjectID(), TokenHash: "c"})
+
+	res, err := repo.RevokeAllForUser(ctx, uid)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.ModifiedCount != 2 {
+		t.Fatalf("expected 2 revoked, got %d", res.ModifiedCount)
+	}
+	rt, _ := repo.GetByHash(ctx, "c")
+	if rt.Revoked {
+		t.Fatalf("token of another user must stay active")
+	}
+}
+
+func TestUserRepository_UniqueEmail(t *testing.T) {
+	ctx := context.Background()
+	repo := NewUserRepository()
+
+	ana := models.User{ID: primitive.NewObjectID(), Email: "ana@example.com"}
+	bob := models.User{ID: primitive.NewObjectID(), Email: "bob@example.com"}
+	repo.CreateUser(ctx, ana)
+	repo.CreateUser(ctx, bob)
+
+	if _, err := repo.CreateUser(ctx, models.User{Email: "ana@example.com"}); !mongo.IsDuplicateKeyError(err) {
+		t.Fatalf("expected duplicate key error on create, got %v", err)
+	}
+	bob.Email = "ana@example.com"
+	if _, err := repo.UpdateUser(ctx, bob); !mongo.IsDuplicateKeyError(err) {
+		t.Fatalf("expected duplicate key error on update, got %v", err)
+	}
+	ana.Name = "Ana"
+	if _, err := repo.UpdateUser(ctx, ana); err != nil {
+		t.Fatalf("updating without changing the email must succeed: %v", err)
+	}
+}
+
+// Un update de perfil hecho con un usuario leído antes no deshace un cambio
+// de rol, de contraseña ni una verificación hechos mientras tanto.
+func TestUserRepository_UpdateUserWritesOnlyProfile(t *testing.T) {
+	ctx := context.Background()
+	repo := NewUserRepository()
+	stale := models.User{ID: primitive.NewObjectID(), Email: "ana@example.com", PasswordHash: "old", Role: models.RoleAdmin}
+	repo.CreateUser(ctx, stale)
+
+	now := time.Now()
+	if err := repo.SetRole(ctx, stale.ID, models.RoleUser, now); err != nil {
+		t.Fatalf("set role: %v", err)
+	}
+	if err := repo.SetPasswordHash(ctx, stale.ID, "new", now); err != nil {
+		t.Fatalf("set password: %v", err)
+	}
+	if err := repo.MarkEmailVerified(ctx, stale.ID, "ana@example.com", now); err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	stale.Name = "Ana"
+	if _, err := repo.UpdateUser(ctx, stale); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	got, _ := repo.GetUserByID(ctx, stale.ID.Hex())
+	if got.Name != "Ana" || got.Role != models.RoleUser || got.PasswordHash != "new" || !got.EmailVerified {
+		t.Fatalf("stale update reverted concurrent changes: %+v", got)
+	}
+
+	stale.Email = "ana@other.com"
+	repo.UpdateUser(ctx, stale)
+	if got, _ := repo.GetUserByID(ctx, stale.ID.Hex()); got.EmailVerified || got.EmailVerifiedAt != nil {
+		t.Fatalf("a new email must be unverified, got %+v", got)
+	}
+	// El link del email anterior ya no verifica el nuevo.
+	repo.MarkEmailVerified(ctx, stale.ID, "ana@example.com", now)
+	if got, _ := repo.GetUserByID(ctx, stale.ID.Hex()); got.EmailVerified {
+		t.Fatalf("verifying the old email must not verify the new one")
+	}
+	if err := repo.SetRole(ctx, primitive.NewObjectID(), models.RoleUser, now); !errors.Is(err, apperrors.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound for a missing user, got %v", err)
+	}
+}
+
+func TestUserRepository_GetUserByEmail(t *testing.T) {
+	ctx := context.Background()
+	repo := NewUserRepository()
+	repo.CreateUser(ctx, models.User{ID: primitive.NewObjectID(), Email: "ana@example.com"})
+
+	u, err := repo.GetUserByEmail(ctx, " ANA@example.com")
+	if err != nil || u.Email != "ana@example.com" {
+		t.Fatalf("expected case-insensitive match, got %+v, %v", u, err)
+	}
+	if _, err := repo.GetUserByEmail(ctx, "bob@example.com"); !errors.Is(err, mongo.ErrNoDocuments) {
+		t.Fatalf("expected mongo.ErrNoDocuments, got %v", err)
+	}
+}
+
+func TestRefreshTokenRepository_RotationAndFamily(t *testing.T) {
+	ctx := context.Background()
+	repo := NewRefreshTokenRepository()
+	uid := primitive.NewObjectID()
+	// Token previo a las familias: su familia es su propio ID.
+	legacy := models.RefreshToken{ID: primitive.NewObjectID(), UserID: uid, TokenHash: "a"}
+	repo.Save(ctx, legacy)
+	next := models.RefreshToken{ID: primitive.NewObjectID(), UserID: uid, FamilyID: legacy.ID, TokenHash: "b"}
+
+	res, err := repo.MarkRotated(ctx, "a", next.ID)
+	if err != nil || res.ModifiedCount != 1 {
+		t.Fatalf("expected the first rotation to win, got %+v, %v", res, err)
+	}
+	if res, _ := repo.MarkRotated(ctx, "a", primitive.NewObjectID()); res.ModifiedCount != 0 {
+		t.Fatalf("a rotated token must not rotate again")
+	}
+	repo.Save(ctx, next)
+	repo.Save(ctx, models.RefreshToken{UserID: uid, TokenHash: "c"})
+
+	res, err = repo.RevokeFamily(ctx, legacy.ID)
+	if err != nil || res.ModifiedCount != 1 {
+		t.Fatalf("expected 1 revoked in the family, got %+v, %v", res, err)
+	}
+	if rt, _ := repo.GetByHash(ctx, "c"); rt.Revoked {
+		t.Fatalf("tokens of another family must stay active")
+	}
+}
+
+func TestRateLimitRepository_RefillsOverTime(t *testing.T) {
+	ctx := context.Background()
+	repo := NewRateLimitRepository()
+	now := time.Now()
+
+	for i := 0; i < 3; i++ {
+		if b, _ := repo.Take(ctx, "k", 3, 3*time.Second, now); !b.Allowed {
+			t.Fatalf("take %d: expected a full bucket to allow the burst", i+1)
+		}
+	}
+	if b, _ := repo.Take(ctx, "k", 3, 3*time.Second, now); b.Allowed {
+		t.Fatalf("expected an empty bucket to deny")
+	}
+
+	b, _ := repo.Take(ctx, "k", 3, 3*time.Second, now.Add(1500*time.Millisecond))
+	if !b.Allowed || b.Tokens < 0.49 || b.Tokens > 0.51 {
+		t.Fatalf("expected 1.5 tokens refilled and one taken, got %+v", b)
+	}
+	if b, _ := repo.Take(ctx, "k", 3, 3*time.Second, now.Add(time.Hour)); !b.Allowed || b.Tokens != 2 {
+		t.Fatalf("expected the bucket to refill only up to its capacity, got %+v", b)
+	}
+}
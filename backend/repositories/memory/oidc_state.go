@@ -0,0 +1,66 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"backend/models"
+	"backend/repositories"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var _ repositories.OIDCStateRepositoryInterface = (*OIDCStateRepository)(nil)
+
+type OIDCStateRepository struct {
+	mu     sync.Mutex
+	states map[string]models.OIDCState
+}
+
+func NewOIDCStateRepository() *OIDCStateRepository {
+	return &OIDCStateRepository{states: make(map[string]models.OIDCState)}
+}
+
+func (r *OIDCStateRepository) Create(ctx context.Context, state models.OIDCState) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if state.ID.IsZero() {
+		state.ID = primitive.NewObjectID()
+	}
+	if state.CreatedAt.IsZero() {
+		state.CreatedAt = time.Now()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.states[state.StateHash]; ok {
+		return duplicateKeyError()
+	}
+	// Sin TTL: los vencidos se descartan al crear uno nuevo.
+	for hash, s := range r.states {
+		if !s.ExpiresAt.After(state.CreatedAt) {
+			delete(r.states, hash)
+		}
+	}
+	r.states[state.StateHash] = state
+	return nil
+}
+
+func (r *OIDCStateRepository) Consume(ctx context.Context, hash string, now time.Time) (models.OIDCState, error) {
+	if err := ctx.Err(); err != nil {
+		return models.OIDCState{}, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	state, ok := r.states[hash]
+	if !ok || !state.ExpiresAt.After(now) {
+		return models.OIDCState{}, notFoundError()
+	}
+	delete(r.states, hash)
+	return state, nil
+}
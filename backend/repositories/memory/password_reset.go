@@ -0,0 +1,100 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"backend/models"
+	"backend/repositories"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var _ repositories.PasswordResetRepositoryInterface = (*PasswordResetRepository)(nil)
+
+type PasswordResetRepository struct {
+	mu     sync.RWMutex
+	resets []models.PasswordReset
+}
+
+func NewPasswordResetRepository() *PasswordResetRepository {
+	return &PasswordResetRepository{}
+}
+
+func (r *PasswordResetRepository) Create(ctx context.Context, reset models.PasswordReset) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if reset.ID.IsZero() {
+		reset.ID = primitive.NewObjectID()
+	}
+	if reset.CreatedAt.IsZero() {
+		reset.CreatedAt = time.Now()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.resets {
+		if existing.TokenHash == reset.TokenHash {
+			return duplicateKeyError()
+		}
+	}
+	r.resets = append(r.resets, reset)
+	return nil
+}
+
+func (r *PasswordResetRepository) Consume(ctx context.Context, hash string, now time.Time) (models.PasswordReset, error) {
+	if err := ctx.Err(); err != nil {
+		return models.PasswordReset{}, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.resets {
+		reset := &r.resets[i]
+		if reset.TokenHash == hash && reset.UsedAt == nil && reset.ExpiresAt.After(now) {
+			used := now
+			reset.UsedAt = &used
+			return *reset, nil
+		}
+	}
+	return models.PasswordReset{}, notFoundError()
+}
+
+func (r *PasswordResetRepository) CountSince(ctx context.Context, email string, since time.Time) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var n int64
+	for _, reset := range r.resets {
+		if reset.Email == email && !reset.CreatedAt.Before(since) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (r *PasswordResetRepository) InvalidateForUser(ctx context.Context, userID primitive.ObjectID, now time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.resets {
+		reset := &r.resets[i]
+		if reset.UserID == userID && reset.UsedAt == nil {
+			used := now
+			reset.UsedAt = &used
+		}
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"backend/models"
+	"backend/repositories"
+)
+
+var _ repositories.RateLimitRepositoryInterface = (*RateLimitRepository)(nil)
+
+// sweepInterval es cada cuánto se borran los baldes llenos, que equivalen a
+// no tenerlos. Sin esto el mapa crecería con cada IP que pasa una vez.
+const sweepInterval = time.Minute
+
+// RateLimitRepository sirve para una sola instancia: con varias, cada una
+// lleva su propia cuenta y el límite efectivo se multiplica.
+type RateLimitRepository struct {
+	mu        sync.Mutex
+	buckets   map[string]models.RateLimitBucket
+	lastSweep time.Time
+}
+
+func NewRateLimitRepository() *RateLimitRepository {
+	return &RateLimitRepository{buckets: make(map[string]models.RateLimitBucket)}
+}
+
+func (r *RateLimitRepository) Take(ctx context.Context, key string, capacity int, per time.Duration, now time.Time) (models.RateLimitBucket, error) {
+	if err := ctx.Err(); err != nil {
+		return models.RateLimitBucket{}, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if now.Sub(r.lastSweep) >= sweepInterval {
+		for k, b := range r.buckets {
+			if !b.ExpiresAt.After(now) {
+				delete(r.buckets, k)
+			}
+		}
+		r.lastSweep = now
+	}
+
+	perSecond := float64(capacity) / per.Seconds()
+	bucket, ok := r.buckets[key]
+	if !ok {
+		bucket = models.RateLimitBucket{Key: key, Tokens: float64(capacity), UpdatedAt: now}
+	}
+	elapsed := max(now.Sub(bucket.UpdatedAt).Seconds(), 0)
+	bucket.Tokens = min(float64(capacity), bucket.Tokens+elapsed*perSecond)
+	bucket.UpdatedAt = now
+	bucket.Allowed = bucket.Tokens >= 1
+	if bucket.Allowed {
+		bucket.Tokens--
+	}
+	bucket.ExpiresAt = now.Add(time.Duration((float64(capacity) - bucket.Tokens) / perSecond * float64(time.Second)))
+	r.buckets[key] = bucket
+	return bucket, nil
+}
@@ -0,0 +1,182 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"backend/models"
+	"backend/repositories"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var _ repositories.RefreshTokenRepositoryInterface = (*RefreshTokenRepository)(nil)
+
+type RefreshTokenRepository struct {
+	mu     sync.RWMutex
+	tokens []models.RefreshToken
+}
+
+func NewRefreshTokenRepository() *RefreshTokenRepository {
+	return &RefreshTokenRepository{}
+}
+
+func (r *RefreshTokenRepository) Save(ctx context.Context, token models.RefreshToken) (*mongo.InsertOneResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if token.ID.IsZero() {
+		token.ID = primitive.NewObjectID()
+	}
+	if token.CreatedAt.IsZero() {
+		token.CreatedAt = time.Now()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.tokens = append(r.tokens, token)
+	return &mongo.InsertOneResult{InsertedID: token.ID}, nil
+}
+
+func (r *RefreshTokenRepository) GetByHash(ctx context.Context, hash string) (models.RefreshToken, error) {
+	if err := ctx.Err(); err != nil {
+		return models.RefreshToken{}, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, rt := range r.tokens {
+		if rt.TokenHash == hash {
+			return rt, nil
+		}
+	}
+	return models.RefreshToken{}, notFoundError()
+}
+
+func (r *RefreshTokenRepository) Revoke(ctx context.Context, hash string) (*mongo.UpdateResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for i := range r.tokens {
+		if r.tokens[i].TokenHash == hash {
+			r.tokens[i].Revoked = true
+			r.tokens[i].RevokedAt = &now
+			return &mongo.UpdateResult{MatchedCount: 1, ModifiedCount: 1}, nil
+		}
+	}
+	return &mongo.UpdateResult{}, nil
+}
+
+func (r *RefreshTokenRepository) RevokeAllForUser(ctx context.Context, userID primitive.ObjectID) (*mongo.UpdateResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	res := &mongo.UpdateResult{}
+	for i := range r.tokens {
+		if r.tokens[i].UserID == userID && !r.tokens[i].Revoked {
+			r.tokens[i].Revoked = true
+			r.tokens[i].RevokedAt = &now
+			res.MatchedCount++
+			res.ModifiedCount++
+		}
+	}
+	return res, nil
+}
+
+func (r *RefreshTokenRepository) MarkRotated(ctx context.Context, hash string, replacedBy primitive.ObjectID) (*mongo.UpdateResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for i := range r.tokens {
+		if r.tokens[i].TokenHash == hash && !r.tokens[i].Revoked {
+			r.tokens[i].Revoked = true
+			r.tokens[i].RevokedAt = &now
+			r.tokens[i].ReplacedBy = replacedBy
+			return &mongo.UpdateResult{MatchedCount: 1, ModifiedCount: 1}, nil
+		}
+	}
+	return &mongo.UpdateResult{}, nil
+}
+
+func (r *RefreshTokenRepository) RevokeFamily(ctx context.Context, familyID primitive.ObjectID) (*mongo.UpdateResult, error) {
+	return r.revokeWhere(ctx, func(rt models.RefreshToken) bool {
+		return rt.Family() == familyID
+	})
+}
+
+func (r *RefreshTokenRepository) ListActiveForUser(ctx context.Context, userID primitive.ObjectID) ([]models.RefreshToken, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	now := time.Now()
+	out := []models.RefreshToken{}
+	for _, rt := range r.tokens {
+		if rt.UserID == userID && !rt.Revoked && rt.ExpiresAt.After(now) {
+			out = append(out, rt)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if !out[i].LastUsedAt.Equal(out[j].LastUsedAt) {
+			return out[i].LastUsedAt.After(out[j].LastUsedAt)
+		}
+		return out[i].CreatedAt.After(out[j].CreatedAt)
+	})
+	return out, nil
+}
+
+func (r *RefreshTokenRepository) RevokeSession(ctx context.Context, userID, sessionID primitive.ObjectID) (*mongo.UpdateResult, error) {
+	return r.revokeWhere(ctx, func(rt models.RefreshToken) bool {
+		return rt.UserID == userID && rt.Family() == sessionID
+	})
+}
+
+func (r *RefreshTokenRepository) RevokeOtherSessions(ctx context.Context, userID, keep primitive.ObjectID) (*mongo.UpdateResult, error) {
+	return r.revokeWhere(ctx, func(rt models.RefreshToken) bool {
+		return rt.UserID == userID && rt.Family() != keep
+	})
+}
+
+func (r *RefreshTokenRepository) revokeWhere(ctx context.Context, match func(models.RefreshToken) bool) (*mongo.UpdateResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	res := &mongo.UpdateResult{}
+	for i := range r.tokens {
+		if !r.tokens[i].Revoked && match(r.tokens[i]) {
+			r.tokens[i].Revoked = true
+			r.tokens[i].RevokedAt = &now
+			res.MatchedCount++
+			res.ModifiedCount++
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,65 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"backend/models"
+	"backend/repositories"
+)
+
+var _ repositories.RolePermissionsRepositoryInterface = (*RolePermissionsRepository)(nil)
+
+type RolePermissionsRepository struct {
+	mu    sync.RWMutex
+	roles map[models.Role]models.RolePermissions
+}
+
+func NewRolePermissionsRepository() *RolePermissionsRepository {
+	return &RolePermissionsRepository{roles: make(map[models.Role]models.RolePermissions)}
+}
+
+func (r *RolePermissionsRepository) List(ctx context.Context) ([]models.RolePermissions, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	out := make([]models.RolePermissions, 0, len(r.roles))
+	for _, perms := range r.roles {
+		perms.Permissions = slices.Clone(perms.Permissions)
+		out = append(out, perms)
+	}
+	return out, nil
+}
+
+func (r *RolePermissionsRepository) Save(ctx context.Context, perms models.RolePermissions) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	perms.Permissions = slices.Clone(perms.Permissions)
+	r.roles[perms.Role] = perms
+	return nil
+}
+
+func (r *RolePermissionsRepository) Delete(ctx context.Context, role models.Role) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.roles[role]; !ok {
+		return notFoundError()
+	}
+	delete(r.roles, role)
+	return nil
+}
@@ -0,0 +1,128 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"backend/models"
+	"backend/repositories"
+	"backend/utils"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var _ repositories.RoutineRepositoryInterface = (*RoutineRepository)(nil)
+
+type RoutineRepository struct {
+	mu       sync.RWMutex
+	routines []models.Routine
+}
+
+func NewRoutineRepository() *RoutineRepository {
+	return &RoutineRepository{}
+}
+
+func (r *RoutineRepository) GetRoutines(ctx context.Context, ownerID primitive.ObjectID, name string) ([]models.Routine, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	match, err := nameMatcher(name)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var out []models.Routine
+	for _, routine := range r.routines {
+		if routine.OwnerID == ownerID && match(routine.Name) {
+			out = append(out, routine)
+		}
+	}
+	return out, nil
+}
+
+func (r *RoutineRepository) GetRoutineByID(ctx context.Context, id string) (models.Routine, error) {
+	if err := ctx.Err(); err != nil {
+		return models.Routine{}, err
+	}
+	objectID, err := utils.ParseObjectID(id)
+	if err != nil {
+		return models.Routine{}, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if i := r.indexOf(objectID); i >= 0 {
+		return r.routines[i], nil
+	}
+	return models.Routine{}, notFoundError()
+}
+
+func (r *RoutineRepository) CreateRoutine(ctx context.Context, routine models.Routine) (*mongo.InsertOneResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if routine.ID.IsZero() {
+		routine.ID = primitive.NewObjectID()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.indexOf(routine.ID) >= 0 {
+		return nil, duplicateKeyError()
+	}
+	r.routines = append(r.routines, routine)
+	return &mongo.InsertOneResult{InsertedID: routine.ID}, nil
+}
+
+func (r *RoutineRepository) UpdateRoutine(ctx context.Context, routine models.Routine) (*mongo.UpdateResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.indexOf(routine.ID)
+	if i < 0 {
+		return &mongo.UpdateResult{}, nil
+	}
+	existing := &r.routines[i]
+	existing.OwnerID = routine.OwnerID
+	existing.Name = routine.Name
+	existing.Description = routine.Description
+	existing.Entries = routine.Entries
+	existing.IsPublic = routine.IsPublic
+	existing.UpdatedAt = routine.UpdatedAt
+	return &mongo.UpdateResult{MatchedCount: 1, ModifiedCount: 1}, nil
+}
+
+func (r *RoutineRepository) DeleteRoutine(ctx context.Context, id primitive.ObjectID) (*mongo.DeleteResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.indexOf(id)
+	if i < 0 {
+		return &mongo.DeleteResult{}, nil
+	}
+	r.routines = append(r.routines[:i], r.routines[i+1:]...)
+	return &mongo.DeleteResult{DeletedCount: 1}, nil
+}
+
+func (r *RoutineRepository) indexOf(id primitive.ObjectID) int {
+	for i, routine := range r.routines {
+		if routine.ID == id {
+			return i
+		}
+	}
+	return -1
+}
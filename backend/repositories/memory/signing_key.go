@@ -0,0 +1,75 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"backend/models"
+	"backend/repositories"
+)
+
+var _ repositories.SigningKeyRepositoryInterface = (*SigningKeyRepository)(nil)
+
+type SigningKeyRepository struct {
+	mu   sync.RWMutex
+	keys []models.SigningKey
+}
+
+func NewSigningKeyRepository() *SigningKeyRepository {
+	return &SigningKeyRepository{}
+}
+
+func (r *SigningKeyRepository) ListKeys(ctx context.Context) ([]models.SigningKey, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	now := time.Now()
+	out := []models.SigningKey{}
+	for _, k := range r.keys {
+		if k.VerifyUntil == nil || k.VerifyUntil.After(now) {
+			out = append(out, k)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out, nil
+}
+
+func (r *SigningKeyRepository) SaveKey(ctx context.Context, key models.SigningKey) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, k := range r.keys {
+		if k.ID == key.ID {
+			return duplicateKeyError()
+		}
+	}
+	r.keys = append(r.keys, key)
+	return nil
+}
+
+func (r *SigningKeyRepository) RetireKeys(ctx context.Context, keep string, verifyUntil time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.keys {
+		if r.keys[i].ID != keep && r.keys[i].VerifyUntil == nil {
+			until := verifyUntil
+			r.keys[i].VerifyUntil = &until
+		}
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"backend/repositories"
+)
+
+var _ repositories.TokenRevocationRepositoryInterface = (*TokenRevocationRepository)(nil)
+
+type expiringTime struct {
+	value     time.Time
+	expiresAt time.Time
+}
+
+// TokenRevocationRepository descarta las entradas vencidas al leerlas, como
+// haría el índice TTL de Mongo.
+type TokenRevocationRepository struct {
+	mu         sync.RWMutex
+	denied     map[string]time.Time
+	watermarks map[string]expiringTime
+}
+
+func NewTokenRevocationRepository() *TokenRevocationRepository {
+	return &TokenRevocationRepository{
+		denied:     make(map[string]time.Time),
+		watermarks: make(map[string]expiringTime),
+	}
+}
+
+func (r *TokenRevocationRepository) DenyToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.denied[jti] = expiresAt
+	return nil
+}
+
+func (r *TokenRevocationRepository) IsDenied(ctx context.Context, jti string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	expiresAt, ok := r.denied[jti]
+	return ok && expiresAt.After(time.Now()), nil
+}
+
+func (r *TokenRevocationRepository) SetIssuedBefore(ctx context.Context, userID string, issuedBefore, expiresAt time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	current := r.watermarks[userID]
+	if issuedBefore.After(current.value) {
+		current.value = issuedBefore
+	}
+	if expiresAt.After(current.expiresAt) {
+		current.expiresAt = expiresAt
+	}
+	r.watermarks[userID] = current
+	return nil
+}
+
+func (r *TokenRevocationRepository) GetIssuedBefore(ctx context.Context, userID string) (time.Time, error) {
+	if err := ctx.Err(); err != nil {
+		return time.Time{}, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	w, ok := r.watermarks[userID]
+	if !ok || !w.expiresAt.After(time.Now()) {
+		return time.Time{}, nil
+	}
+	return w.value, nil
+}
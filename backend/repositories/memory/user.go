@@ -0,0 +1,323 @@
+package memory
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"backend/models"
+	"backend/repositories"
+	"backend/utils"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var _ repositories.UserRepositoryInterface = (*UserRepository)(nil)
+
+type UserRepository struct {
+	mu    sync.RWMutex
+	users []models.User
+}
+
+func NewUserRepository() *UserRepository {
+	return &UserRepository{}
+}
+
+func (r *UserRepository) GetUser(ctx context.Context, name string) ([]models.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	match, err := nameMatcher(name)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var out []models.User
+	for _, u := range r.users {
+		if match(u.Name) {
+			out = append(out, u)
+		}
+	}
+	return out, nil
+}
+
+func (r *UserRepository) GetUserByID(ctx context.Context, id string) (models.User, error) {
+	if err := ctx.Err(); err != nil {
+		return models.User{}, err
+	}
+	objectID, err := utils.ParseObjectID(id)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if i := r.indexOf(objectID); i >= 0 {
+		return r.users[i], nil
+	}
+	return models.User{}, notFoundError()
+}
+
+func (r *UserRepository) GetUserByEmail(ctx context.Context, email string) (models.User, error) {
+	if err := ctx.Err(); err != nil {
+		return models.User{}, err
+	}
+	email = utils.NormalizeEmail(email)
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, u := range r.users {
+		if u.Email == email {
+			return u, nil
+		}
+	}
+	return models.User{}, notFoundError()
+}
+
+func (r *UserRepository) CreateUser(ctx context.Context, user models.User) (*mongo.InsertOneResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if user.ID.IsZero() {
+		user.ID = primitive.NewObjectID()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.indexOf(user.ID) >= 0 || r.emailTaken(user.Email, user.ID) {
+		return nil, duplicateKeyError()
+	}
+	r.users = append(r.users, user)
+	return &mongo.InsertOneResult{InsertedID: user.ID}, nil
+}
+
+func (r *UserRepository) UpdateUser(ctx context.Context, user models.User) (*mongo.UpdateResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.indexOf(user.ID)
+	if i < 0 {
+		return &mongo.UpdateResult{}, nil
+	}
+	if r.emailTaken(user.Email, user.ID) {
+		return nil, duplicateKeyError()
+	}
+	// Como en Mongo, UpdateUser escribe solo los datos de perfil.
+	u := &r.users[i]
+	if u.Email != user.Email {
+		u.EmailVerified = false
+		u.EmailVerifiedAt = nil
+	}
+	u.Name = user.Name
+	u.Email = user.Email
+	u.DateOfBirth = user.DateOfBirth
+	u.Weight = user.Weight
+	u.Height = user.Height
+	u.Level = user.Level
+	u.Goals = user.Goals
+	u.Language = user.Language
+	u.UpdatedAt = user.UpdatedAt
+	return &mongo.UpdateResult{MatchedCount: 1, ModifiedCount: 1}, nil
+}
+
+func (r *UserRepository) SetRole(ctx context.Context, id primitive.ObjectID, role models.Role, at time.Time) error {
+	return r.update(ctx, id, func(u *models.User) {
+		u.Role = role
+		u.UpdatedAt = at
+	})
+}
+
+func (r *UserRepository) SetPasswordHash(ctx context.Context, id primitive.ObjectID, hash string, at time.Time) error {
+	return r.update(ctx, id, func(u *models.User) {
+		u.PasswordHash = hash
+		u.UpdatedAt = at
+	})
+}
+
+func (r *UserRepository) MarkEmailVerified(ctx context.Context, id primitive.ObjectID, email string, at time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if i := r.indexOf(id); i >= 0 && r.users[i].Email == email && !r.users[i].EmailVerified {
+		verifiedAt := at
+		r.users[i].EmailVerified = true
+		r.users[i].EmailVerifiedAt = &verifiedAt
+		r.users[i].UpdatedAt = at
+	}
+	return nil
+}
+
+// update aplica fn al usuario id bajo el lock, o devuelve ErrNotFound.
+func (r *UserRepository) update(ctx context.Context, id primitive.ObjectID, fn func(u *models.User)) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.indexOf(id)
+	if i < 0 {
+		return notFoundError()
+	}
+	fn(&r.users[i])
+	return nil
+}
+
+func (r *UserRepository) DeleteUser(ctx context.Context, id primitive.ObjectID) (*mongo.DeleteResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.indexOf(id)
+	if i < 0 {
+		return &mongo.DeleteResult{}, nil
+	}
+	r.users = append(r.users[:i], r.users[i+1:]...)
+	return &mongo.DeleteResult{DeletedCount: 1}, nil
+}
+
+func (r *UserRepository) indexOf(id primitive.ObjectID) int {
+	for i, u := range r.users {
+		if u.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// emailTaken replica el índice único users_email_unique.
+func (r *UserRepository) emailTaken(email string, except primitive.ObjectID) bool {
+	for _, u := range r.users {
+		if u.Email == email && u.ID != except {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *UserRepository) SetMFA(ctx context.Context, id primitive.ObjectID, mfa models.MFA) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if i := r.indexOf(id); i >= 0 {
+		r.users[i].MFA = mfa
+	}
+	return nil
+}
+
+func (r *UserRepository) UseTOTPStep(ctx context.Context, id primitive.ObjectID, step int64) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.indexOf(id)
+	if i < 0 || r.users[i].MFA.LastStep >= step {
+		return false, nil
+	}
+	r.users[i].MFA.LastStep = step
+	return true, nil
+}
+
+func (r *UserRepository) ConsumeRecoveryCode(ctx context.Context, id primitive.ObjectID, hash string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.indexOf(id)
+	if i < 0 {
+		return false, nil
+	}
+	codes := r.users[i].MFA.RecoveryCodes
+	for j, c := range codes {
+		if c == hash {
+			// Slice nuevo: los usuarios ya devueltos comparten el anterior.
+			remaining := make([]string, 0, len(codes)-1)
+			remaining = append(remaining, codes[:j]...)
+			r.users[i].MFA.RecoveryCodes = append(remaining, codes[j+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *UserRepository) SearchUsers(ctx context.Context, filter repositories.UserFilter) ([]models.User, int64, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, 0, err
+	}
+	match, err := nameMatcher(regexp.QuoteMeta(filter.Query))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	r.mu.RLock()
+	matched := []models.User{}
+	for _, u := range r.users {
+		if !match(u.Name) && !match(u.Email) {
+			continue
+		}
+		if filter.Role != "" && u.Role != filter.Role {
+			continue
+		}
+		if filter.Disabled != nil && u.Disabled() != *filter.Disabled {
+			continue
+		}
+		matched = append(matched, u)
+	}
+	r.mu.RUnlock()
+
+	sort.SliceStable(matched, func(i, j int) bool { return matched[i].CreatedAt.After(matched[j].CreatedAt) })
+	total := int64(len(matched))
+	start := min(filter.Skip, total)
+	end := total
+	if filter.Limit > 0 {
+		end = min(start+filter.Limit, total)
+	}
+	return matched[start:end], total, nil
+}
+
+func (r *UserRepository) SetDisabled(ctx context.Context, id primitive.ObjectID, disabledAt *time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.indexOf(id)
+	if i < 0 {
+		return notFoundError()
+	}
+	r.users[i].DisabledAt = disabledAt
+	return nil
+}
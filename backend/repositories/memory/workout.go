@@ -0,0 +1,118 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"backend/models"
+	"backend/repositories"
+	"backend/utils"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var _ repositories.WorkoutRepositoryInterface = (*WorkoutRepository)(nil)
+
+type WorkoutRepository struct {
+	mu       sync.RWMutex
+	workouts []models.Workout
+}
+
+func NewWorkoutRepository() *WorkoutRepository {
+	return &WorkoutRepository{}
+}
+
+func (r *WorkoutRepository) GetWorkouts(ctx context.Context, userID primitive.ObjectID) ([]models.Workout, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var out []models.Workout
+	for _, w := range r.workouts {
+		if w.UserID == userID {
+			out = append(out, w)
+		}
+	}
+	return out, nil
+}
+
+func (r *WorkoutRepository) GetWorkoutByID(ctx context.Context, id string) (models.Workout, error) {
+	if err := ctx.Err(); err != nil {
+		return models.Workout{}, err
+	}
+	objectID, err := utils.ParseObjectID(id)
+	if err != nil {
+		return models.Workout{}, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if i := r.indexOf(objectID); i >= 0 {
+		return r.workouts[i], nil
+	}
+	return models.Workout{}, notFoundError()
+}
+
+func (r *WorkoutRepository) CreateWorkout(ctx context.Context, workout models.Workout) (*mongo.InsertOneResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if workout.ID.IsZero() {
+		workout.ID = primitive.NewObjectID()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.indexOf(workout.ID) >= 0 {
+		return nil, duplicateKeyError()
+	}
+	r.workouts = append(r.workouts, workout)
+	return &mongo.InsertOneResult{InsertedID: workout.ID}, nil
+}
+
+func (r *WorkoutRepository) UpdateWorkout(ctx context.Context, workout models.Workout) (*mongo.UpdateResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.indexOf(workout.ID)
+	if i < 0 {
+		return &mongo.UpdateResult{}, nil
+	}
+	r.workouts[i] = workout
+	return &mongo.UpdateResult{MatchedCount: 1, ModifiedCount: 1}, nil
+}
+
+func (r *WorkoutRepository) DeleteWorkout(ctx context.Context, id primitive.ObjectID) (*mongo.DeleteResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.indexOf(id)
+	if i < 0 {
+		return &mongo.DeleteResult{}, nil
+	}
+	r.workouts = append(r.workouts[:i], r.workouts[i+1:]...)
+	return &mongo.DeleteResult{DeletedCount: 1}, nil
+}
+
+func (r *WorkoutRepository) indexOf(id primitive.ObjectID) int {
+	for i, w := range r.workouts {
+		if w.ID == id {
+			return i
+		}
+	}
+	return -1
+}
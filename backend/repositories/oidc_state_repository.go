@@ -0,0 +1,58 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"backend/apperrors"
+	"backend/database"
+	"backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type OIDCStateRepositoryInterface interface {
+	Create(ctx context.Context, state models.OIDCState) error
+	// Consume borra y devuelve el state con ese hash si no venció; si no hay
+	// ninguno así devuelve ErrNotFound. Cada state sirve para un solo
+	// callback.
+	Consume(ctx context.Context, hash string, now time.Time) (models.OIDCState, error)
+}
+
+type OIDCStateRepository struct {
+	db database.DB
+}
+
+func NewOIDCStateRepository(db database.DB) *OIDCStateRepository {
+	return &OIDCStateRepository{db: db}
+}
+
+func (r OIDCStateRepository) collection() *mongo.Collection {
+	return r.db.GetDatabase().Collection("oidc_states")
+}
+
+func (r OIDCStateRepository) Create(ctx context.Context, state models.OIDCState) error {
+	ctx, cancel := r.db.QueryContext(ctx)
+	defer cancel()
+
+	if state.ID.IsZero() {
+		state.ID = primitive.NewObjectID()
+	}
+	if state.CreatedAt.IsZero() {
+		state.CreatedAt = time.Now()
+	}
+	_, err := r.collection().InsertOne(ctx, state)
+	return apperrors.FromMongo(err)
+}
+
+func (r OIDCStateRepository) Consume(ctx context.Context, hash string, now time.Time) (models.OIDCState, error) {
+	ctx, cancel := r.db.QueryContext(ctx)
+	defer cancel()
+
+	filter := bson.M{"state_hash": hash, "expires_at": bson.M{"$gt": now}}
+	var state models.OIDCState
+	err := r.collection().FindOneAndDelete(ctx, filter).Decode(&state)
+	return state, apperrors.FromMongo(err)
+}
@@ -0,0 +1,87 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"backend/apperrors"
+	"backend/database"
+	"backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type PasswordResetRepositoryInterface interface {
+	Create(ctx context.Context, reset models.PasswordReset) error
+	// Consume marca como usado el token con ese hash si no se usó y no venció,
+	// y lo devuelve; si no hay ninguno así devuelve ErrNotFound.
+	Consume(ctx context.Context, hash string, now time.Time) (models.PasswordReset, error)
+	// CountSince cuenta los pedidos para email desde since.
+	CountSince(ctx context.Context, email string, since time.Time) (int64, error)
+	// InvalidateForUser marca como usados los tokens pendientes del usuario.
+	InvalidateForUser(ctx context.Context, userID primitive.ObjectID, now time.Time) error
+}
+
+type PasswordResetRepository struct {
+	db database.DB
+}
+
+func NewPasswordResetRepository(db database.DB) *PasswordResetRepository {
+	return &PasswordResetRepository{db: db}
+}
+
+func (r PasswordResetRepository) collection() *mongo.Collection {
+	return r.db.GetDatabase().Collection("password_resets")
+}
+
+func (r PasswordResetRepository) Create(ctx context.Context, reset models.PasswordReset) error {
+	ctx, cancel := r.db.QueryContext(ctx)
+	defer cancel()
+
+	if reset.ID.IsZero() {
+		reset.ID = primitive.NewObjectID()
+	}
+	if reset.CreatedAt.IsZero() {
+		reset.CreatedAt = time.Now()
+	}
+	_, err := r.collection().InsertOne(ctx, reset)
+	return apperrors.FromMongo(err)
+}
+
+func (r PasswordResetRepository) Consume(ctx context.Context, hash string, now time.Time) (models.PasswordReset, error) {
+	ctx, cancel := r.db.QueryContext(ctx)
+	defer cancel()
+
+	filter := bson.M{
+		"token_hash": hash,
+		"used_at":    bson.M{"$exists": false},
+		"expires_at": bson.M{"$gt": now},
+	}
+	update := bson.M{"$set": bson.M{"used_at": now}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var reset models.PasswordReset
+	err := r.collection().FindOneAndUpdate(ctx, filter, update, opts).Decode(&reset)
+	return reset, apperrors.FromMongo(err)
+}
+
+func (r PasswordResetRepository) CountSince(ctx context.Context, email string, since time.Time) (int64, error) {
+	ctx, cancel := r.db.QueryContext(ctx)
+	defer cancel()
+
+	filter := bson.M{"email": email, "created_at": bson.M{"$gte": since}}
+	n, err := r.collection().CountDocuments(ctx, filter)
+	return n, apperrors.FromMongo(err)
+}
+
+func (r PasswordResetRepository) InvalidateForUser(ctx context.Context, userID primitive.ObjectID, now time.Time) error {
+	ctx, cancel := r.db.QueryContext(ctx)
+	defer cancel()
+
+	filter := bson.M{"user_id": userID, "used_at": bson.M{"$exists": false}}
+	_, err := r.collection().UpdateMany(ctx, filter, bson.M{"$set": bson.M{"used_at": now}})
+	return apperrors.FromMongo(err)
+}
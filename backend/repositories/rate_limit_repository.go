@@ -0,0 +1,67 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"backend/apperrors"
+	"backend/database"
+	"backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type RateLimitRepositoryInterface interface {
+	// Take recarga el balde de key según el tiempo transcurrido y, si tiene
+	// al menos un token, lo consume. Un balde que no existe empieza lleno.
+	// capacity tokens se recargan en per.
+	Take(ctx context.Context, key string, capacity int, per time.Duration, now time.Time) (models.RateLimitBucket, error)
+}
+
+type RateLimitRepository struct {
+	db database.DB
+}
+
+func NewRateLimitRepository(db database.DB) *RateLimitRepository {
+	return &RateLimitRepository{db: db}
+}
+
+func (r RateLimitRepository) collection() *mongo.Collection {
+	return r.db.GetDatabase().Collection("rate_limits")
+}
+
+// Take resuelve la recarga y el consumo en un solo update con pipeline para
+// que dos instancias no consuman el mismo token.
+func (r RateLimitRepository) Take(ctx context.Context, key string, capacity int, per time.Duration, now time.Time) (models.RateLimitBucket, error) {
+	ctx, cancel := r.db.QueryContext(ctx)
+	defer cancel()
+
+	perMilli := float64(capacity) / float64(per.Milliseconds())
+	elapsed := bson.M{"$subtract": bson.A{now, bson.M{"$ifNull": bson.A{"$updated_at", now}}}}
+	refilled := bson.M{"$min": bson.A{
+		float64(capacity),
+		bson.M{"$add": bson.A{
+			bson.M{"$ifNull": bson.A{"$tokens", float64(capacity)}},
+			bson.M{"$multiply": bson.A{elapsed, perMilli}},
+		}},
+	}}
+	hasToken := bson.M{"$gte": bson.A{"$tokens", 1}}
+	pipeline := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{"tokens": refilled, "updated_at": now}}},
+		{{Key: "$set", Value: bson.M{
+			"allowed": hasToken,
+			"tokens":  bson.M{"$cond": bson.A{hasToken, bson.M{"$subtract": bson.A{"$tokens", 1}}, "$tokens"}},
+		}}},
+		{{Key: "$set", Value: bson.M{"expires_at": bson.M{"$add": bson.A{
+			now,
+			bson.M{"$divide": bson.A{bson.M{"$subtract": bson.A{float64(capacity), "$tokens"}}, perMilli}},
+		}}}}},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var bucket models.RateLimitBucket
+	err := r.collection().FindOneAndUpdate(ctx, bson.M{"_id": key}, pipeline, opts).Decode(&bucket)
+	return bucket, apperrors.FromMongo(err)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"time"
 
+	"backend/apperrors"
 	"backend/database"
 	"backend/models"
 
@@ -13,11 +14,24 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// RefreshTokenRepositoryInterface busca y revoca por hash: los métodos que
+// reciben hash esperan el resultado de auth.HashRefreshToken.
 type RefreshTokenRepositoryInterface interface {
-	Save(token models.RefreshToken) (*mongo.InsertOneResult, error)
-	GetByToken(token string) (models.RefreshToken, error)
-	Revoke(token string) (*mongo.UpdateResult, error)
-	RevokeAllForUser(userID primitive.ObjectID) (*mongo.UpdateResult, error)
+	Save(ctx context.Context, token models.RefreshToken) (*mongo.InsertOneResult, error)
+	GetByHash(ctx context.Context, hash string) (models.RefreshToken, error)
+	Revoke(ctx context.Context, hash string) (*mongo.UpdateResult, error)
+	RevokeAllForUser(ctx context.Context, userID primitive.ObjectID) (*mongo.UpdateResult, error)
+	// MarkRotated revoca el token con ese hash y registra su reemplazo solo
+	// si seguía activo. ModifiedCount == 0 significa que otro pedido ya lo usó.
+	MarkRotated(ctx context.Context, hash string, replacedBy primitive.ObjectID) (*mongo.UpdateResult, error)
+	RevokeFamily(ctx context.Context, familyID primitive.ObjectID) (*mongo.UpdateResult, error)
+	// ListActiveForUser devuelve los tokens vigentes del usuario, uno por
+	// sesión, del uso más reciente al más viejo.
+	ListActiveForUser(ctx context.Context, userID primitive.ObjectID) ([]models.RefreshToken, error)
+	// RevokeSession revoca la familia sessionID solo si es del usuario.
+	RevokeSession(ctx context.Context, userID, sessionID primitive.ObjectID) (*mongo.UpdateResult, error)
+	// RevokeOtherSessions revoca todas las familias del usuario menos keep.
+	RevokeOtherSessions(ctx context.Context, userID, keep primitive.ObjectID) (*mongo.UpdateResult, error)
 }
 
 type RefreshTokenRepository struct {
@@ -29,43 +43,123 @@ func NewRefreshTokenRepository(db database.DB) *RefreshTokenRepository {
 }
 
 func (r RefreshTokenRepository) collection() *mongo.Collection {
-	return r.db.GetClient().Database("fitness_db").Collection("refresh_tokens")
+	return r.db.GetDatabase().Collection("refresh_tokens")
 }
 
-func (r RefreshTokenRepository) Save(token models.RefreshToken) (*mongo.InsertOneResult, error) {
+func (r RefreshTokenRepository) Save(ctx context.Context, token models.RefreshToken) (*mongo.InsertOneResult, error) {
 	if token.ID.IsZero() {
 		token.ID = primitive.NewObjectID()
 	}
 	if token.CreatedAt.IsZero() {
 		token.CreatedAt = time.Now()
 	}
-	return r.collection().InsertOne(context.TODO(), token)
+	ctx, cancel := r.db.QueryContext(ctx)
+	defer cancel()
+	res, err := r.collection().InsertOne(ctx, token)
+	return res, apperrors.FromMongo(err)
 }
 
-func (r RefreshTokenRepository) GetByToken(token string) (models.RefreshToken, error) {
+func (r RefreshTokenRepository) GetByHash(ctx context.Context, hash string) (models.RefreshToken, error) {
+	ctx, cancel := r.db.QueryContext(ctx)
+	defer cancel()
+
 	var rt models.RefreshToken
-	filter := bson.M{"token": token}
-	err := r.collection().FindOne(context.TODO(), filter).Decode(&rt)
-	return rt, err
+	filter := bson.M{"token_hash": hash}
+	err := r.collection().FindOne(ctx, filter).Decode(&rt)
+	return rt, apperrors.FromMongo(err)
 }
 
-func (r RefreshTokenRepository) Revoke(token string) (*mongo.UpdateResult, error) {
+func (r RefreshTokenRepository) Revoke(ctx context.Context, hash string) (*mongo.UpdateResult, error) {
+	ctx, cancel := r.db.QueryContext(ctx)
+	defer cancel()
+
 	now := time.Now()
-	filter := bson.M{"token": token}
+	filter := bson.M{"token_hash": hash}
 	update := bson.M{"$set": bson.M{"revoked": true, "revoked_at": now}}
-	return r.collection().UpdateOne(context.TODO(), filter, update)
+	return r.collection().UpdateOne(ctx, filter, update)
 }
 
-func (r RefreshTokenRepository) RevokeAllForUser(userID primitive.ObjectID) (*mongo.UpdateResult, error) {
+func (r RefreshTokenRepository) RevokeAllForUser(ctx context.Context, userID primitive.ObjectID) (*mongo.UpdateResult, error) {
+	ctx, cancel := r.db.QueryContext(ctx)
+	defer cancel()
+
 	now := time.Now()
 	filter := bson.M{"user_id": userID, "revoked": false}
 	update := bson.M{"$set": bson.M{"revoked": true, "revoked_at": now}}
 
 	opts := options.Update().SetUpsert(false)
-	res, err := r.collection().UpdateMany(context.TODO(), filter, update, opts)
+	res, err := r.collection().UpdateMany(ctx, filter, update, opts)
 	if err != nil {
 		return nil, err
 	}
 
 	return &mongo.UpdateResult{MatchedCount: res.MatchedCount, ModifiedCount: res.ModifiedCount}, nil
 }
+
+func (r RefreshTokenRepository) MarkRotated(ctx context.Context, hash string, replacedBy primitive.ObjectID) (*mongo.UpdateResult, error) {
+	ctx, cancel := r.db.QueryContext(ctx)
+	defer cancel()
+
+	now := time.Now()
+	filter := bson.M{"token_hash": hash, "revoked": false}
+	update := bson.M{"$set": bson.M{"revoked": true, "revoked_at": now, "replaced_by": replacedBy}}
+	res, err := r.collection().UpdateOne(ctx, filter, update)
+	return res, apperrors.FromMongo(err)
+}
+
+func (r RefreshTokenRepository) RevokeFamily(ctx context.Context, familyID primitive.ObjectID) (*mongo.UpdateResult, error) {
+	ctx, cancel := r.db.QueryContext(ctx)
+	defer cancel()
+
+	now := time.Now()
+	filter := bson.M{"$or": familyFilter(familyID), "revoked": false}
+	update := bson.M{"$set": bson.M{"revoked": true, "revoked_at": now}}
+	res, err := r.collection().UpdateMany(ctx, filter, update)
+	return res, apperrors.FromMongo(err)
+}
+
+// familyFilter incluye los tokens sin family_id, cuya familia es su _id.
+func familyFilter(familyID primitive.ObjectID) bson.A {
+	return bson.A{bson.M{"family_id": familyID}, bson.M{"_id": familyID}}
+}
+
+func (r RefreshTokenRepository) ListActiveForUser(ctx context.Context, userID primitive.ObjectID) ([]models.RefreshToken, error) {
+	ctx, cancel := r.db.QueryContext(ctx)
+	defer cancel()
+
+	filter := bson.M{"user_id": userID, "revoked": false, "expires_at": bson.M{"$gt": time.Now()}}
+	opts := options.Find().SetSort(bson.D{{Key: "last_used_at", Value: -1}, {Key: "created_at", Value: -1}})
+	cursor, err := r.collection().Find(ctx, filter, opts)
+	if err != nil {
+		return nil, apperrors.FromMongo(err)
+	}
+	defer cursor.Close(ctx)
+
+	tokens := []models.RefreshToken{}
+	if err := cursor.All(ctx, &tokens); err != nil {
+		return nil, apperrors.FromMongo(err)
+	}
+	return tokens, nil
+}
+
+func (r RefreshTokenRepository) RevokeSession(ctx context.Context, userID, sessionID primitive.ObjectID) (*mongo.UpdateResult, error) {
+	ctx, cancel := r.db.QueryContext(ctx)
+	defer cancel()
+
+	now := time.Now()
+	filter := bson.M{"user_id": userID, "$or": familyFilter(sessionID), "revoked": false}
+	update := bson.M{"$set": bson.M{"revoked": true, "revoked_at": now}}
+	res, err := r.collection().UpdateMany(ctx, filter, update)
+	return res, apperrors.FromMongo(err)
+}
+
+func (r RefreshTokenRepository) RevokeOtherSessions(ctx context.Context, userID, keep primitive.ObjectID) (*mongo.UpdateResult, error) {
+	ctx, cancel := r.db.QueryContext(ctx)
+	defer cancel()
+
+	now := time.Now()
+	filter := bson.M{"user_id": userID, "$nor": familyFilter(keep), "revoked": false}
+	update := bson.M{"$set": bson.M{"revoked": true, "revoked_at": now}}
+	res, err := r.collection().UpdateMany(ctx, filter, update)
+	return res, apperrors.FromMongo(err)
+}
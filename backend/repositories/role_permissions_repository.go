@@ -0,0 +1,75 @@
+package repositories
+
+import (
+	"context"
+
+	"backend/apperrors"
+	"backend/database"
+	"backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// RolePermissionsRepositoryInterface guarda los permisos de los roles que se
+// cambiaron en tiempo de ejecución. Los roles sin documento usan los de
+// models.DefaultRolePermissions.
+type RolePermissionsRepositoryInterface interface {
+	List(ctx context.Context) ([]models.RolePermissions, error)
+	// Save crea o reemplaza los permisos del rol.
+	Save(ctx context.Context, perms models.RolePermissions) error
+	// Delete devuelve ErrNotFound si el rol no tenía permisos guardados.
+	Delete(ctx context.Context, role models.Role) error
+}
+
+type RolePermissionsRepository struct {
+	db database.DB
+}
+
+func NewRolePermissionsRepository(db database.DB) *RolePermissionsRepository {
+	return &RolePermissionsRepository{db: db}
+}
+
+func (r RolePermissionsRepository) collection() *mongo.Collection {
+	return r.db.GetDatabase().Collection("role_permissions")
+}
+
+func (r RolePermissionsRepository) List(ctx context.Context) ([]models.RolePermissions, error) {
+	ctx, cancel := r.db.QueryContext(ctx)
+	defer cancel()
+
+	cursor, err := r.collection().Find(ctx, bson.M{})
+	if err != nil {
+		return nil, apperrors.FromMongo(err)
+	}
+	defer cursor.Close(ctx)
+
+	perms := []models.RolePermissions{}
+	if err := cursor.All(ctx, &perms); err != nil {
+		return nil, apperrors.FromMongo(err)
+	}
+	return perms, nil
+}
+
+func (r RolePermissionsRepository) Save(ctx context.Context, perms models.RolePermissions) error {
+	ctx, cancel := r.db.QueryContext(ctx)
+	defer cancel()
+
+	_, err := r.collection().ReplaceOne(ctx, bson.M{"_id": perms.Role}, perms, options.Replace().SetUpsert(true))
+	return apperrors.FromMongo(err)
+}
+
+func (r RolePermissionsRepository) Delete(ctx context.Context, role models.Role) error {
+	ctx, cancel := r.db.QueryContext(ctx)
+	defer cancel()
+
+	res, err := r.collection().DeleteOne(ctx, bson.M{"_id": role})
+	if err != nil {
+		return apperrors.FromMongo(err)
+	}
+	if res.DeletedCount == 0 {
+		return apperrors.FromMongo(mongo.ErrNoDocuments)
+	}
+	return nil
+}
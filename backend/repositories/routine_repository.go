@@ -3,8 +3,10 @@ package repositories
 import (
 	"context"
 
+	"backend/apperrors"
 	"backend/database"
 	"backend/models"
+	"backend/utils"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -12,11 +14,11 @@ import (
 )
 
 type RoutineRepositoryInterface interface {
-	GetRoutines(ownerID primitive.ObjectID, name string) ([]models.Routine, error)
-	GetRoutineByID(id string) (models.Routine, error)
-	CreateRoutine(routine models.Routine) (*mongo.InsertOneResult, error)
-	UpdateRoutine(routine models.Routine) (*mongo.UpdateResult, error)
-	DeleteRoutine(id primitive.ObjectID) (*mongo.DeleteResult, error)
+	GetRoutines(ctx context.Context, ownerID primitive.ObjectID, name string) ([]models.Routine, error)
+	GetRoutineByID(ctx context.Context, id string) (models.Routine, error)
+	CreateRoutine(ctx context.Context, routine models.Routine) (*mongo.InsertOneResult, error)
+	UpdateRoutine(ctx context.Context, routine models.Routine) (*mongo.UpdateResult, error)
+	DeleteRoutine(ctx context.Context, id primitive.ObjectID) (*mongo.DeleteResult, error)
 }
 
 type RoutineRepository struct {
@@ -29,22 +31,24 @@ func NewRoutineRepository(db database.DB) *RoutineRepository {
 	}
 }
 
-func (repository RoutineRepository) GetRoutines(ownerID primitive.ObjectID, name string) ([]models.Routine, error) {
-	collection := repository.db.GetClient().Database("fitness_db").Collection("routines")
+func (repository RoutineRepository) GetRoutines(ctx context.Context, ownerID primitive.ObjectID, name string) ([]models.Routine, error) {
+	collection := repository.db.GetDatabase().Collection("routines")
+	ctx, cancel := repository.db.QueryContext(ctx)
+	defer cancel()
 
 	filter := bson.M{"owner_id": ownerID}
 	if name != "" {
 		filter["name"] = bson.M{"$regex": name, "$options": "i"}
 	}
 
-	cursor, err := collection.Find(context.TODO(), filter)
+	cursor, err := collection.Find(ctx, filter)
 	if err != nil {
 		return nil, err
 	}
-	defer cursor.Close(context.Background())
+	defer cursor.Close(ctx)
 
 	var routines []models.Routine
-	for cursor.Next(context.Background()) {
+	for cursor.Next(ctx) {
 		var routine models.Routine
 		if err := cursor.Decode(&routine); err != nil {
 			continue
@@ -52,31 +56,38 @@ func (repository RoutineRepository) GetRoutines(ownerID primitive.ObjectID, name
 		routines = append(routines, routine)
 	}
 
-	return routines, nil
+	return routines, cursor.Err()
 }
 
-func (repository RoutineRepository) GetRoutineByID(id string) (models.Routine, error) {
-	collection := repository.db.GetClient().Database("fitness_db").Collection("routines")
-	objectID, err := primitive.ObjectIDFromHex(id)
+func (repository RoutineRepository) GetRoutineByID(ctx context.Context, id string) (models.Routine, error) {
+	collection := repository.db.GetDatabase().Collection("routines")
+	objectID, err := utils.ParseObjectID(id)
 	if err != nil {
 		return models.Routine{}, err
 	}
+	ctx, cancel := repository.db.QueryContext(ctx)
+	defer cancel()
 
 	filter := bson.M{"_id": objectID}
 	var routine models.Routine
 
-	err = collection.FindOne(context.TODO(), filter).Decode(&routine)
-	return routine, err
+	err = collection.FindOne(ctx, filter).Decode(&routine)
+	return routine, apperrors.FromMongo(err)
 }
 
-func (repository RoutineRepository) CreateRoutine(routine models.Routine) (*mongo.InsertOneResult, error) {
-	collection := repository.db.GetClient().Database("fitness_db").Collection("routines")
-	result, err := collection.InsertOne(context.TODO(), routine)
-	return result, err
+func (repository RoutineRepository) CreateRoutine(ctx context.Context, routine models.Routine) (*mongo.InsertOneResult, error) {
+	collection := repository.db.GetDatabase().Collection("routines")
+	ctx, cancel := repository.db.QueryContext(ctx)
+	defer cancel()
+
+	result, err := collection.InsertOne(ctx, routine)
+	return result, apperrors.FromMongo(err)
 }
 
-func (repository RoutineRepository) UpdateRoutine(routine models.Routine) (*mongo.UpdateResult, error) {
-	collection := repository.db.GetClient().Database("fitness_db").Collection("routines")
+func (repository RoutineRepository) UpdateRoutine(ctx context.Context, routine models.Routine) (*mongo.UpdateResult, error) {
+	collection := repository.db.GetDatabase().Collection("routines")
+	ctx, cancel := repository.db.QueryContext(ctx)
+	defer cancel()
 
 	filter := bson.M{"_id": routine.ID}
 	update := bson.M{"$set": bson.M{
@@ -88,14 +99,16 @@ func (repository RoutineRepository) UpdateRoutine(routine models.Routine) (*mong
 		"updated_at":  routine.UpdatedAt,
 	}}
 
-	result, err := collection.UpdateOne(context.TODO(), filter, update)
-	return result, err
+	result, err := collection.UpdateOne(ctx, filter, update)
+	return result, apperrors.FromMongo(err)
 }
 
-func (repository RoutineRepository) DeleteRoutine(id primitive.ObjectID) (*mongo.DeleteResult, error) {
-	collection := repository.db.GetClient().Database("fitness_db").Collection("routines")
+func (repository RoutineRepository) DeleteRoutine(ctx context.Context, id primitive.ObjectID) (*mongo.DeleteResult, error) {
+	collection := repository.db.GetDatabase().Collection("routines")
+	ctx, cancel := repository.db.QueryContext(ctx)
+	defer cancel()
 
 	filter := bson.M{"_id": id}
-	result, err := collection.DeleteOne(context.TODO(), filter)
-	return result, err
+	result, err := collection.DeleteOne(ctx, filter)
+	return result, apperrors.FromMongo(err)
 }
@@ -0,0 +1,76 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"backend/apperrors"
+	"backend/database"
+	"backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// SigningKeyRepositoryInterface cumple auth.KeyStore.
+type SigningKeyRepositoryInterface interface {
+	ListKeys(ctx context.Context) ([]models.SigningKey, error)
+	SaveKey(ctx context.Context, key models.SigningKey) error
+	// RetireKeys fija VerifyUntil en todas las claves activas menos keep.
+	RetireKeys(ctx context.Context, keep string, verifyUntil time.Time) error
+}
+
+type SigningKeyRepository struct {
+	db database.DB
+}
+
+func NewSigningKeyRepository(db database.DB) *SigningKeyRepository {
+	return &SigningKeyRepository{db: db}
+}
+
+func (r SigningKeyRepository) collection() *mongo.Collection {
+	return r.db.GetDatabase().Collection("signing_keys")
+}
+
+// ListKeys no devuelve las claves retiradas cuyo período de gracia terminó
+// aunque el índice TTL todavía no las haya borrado.
+func (r SigningKeyRepository) ListKeys(ctx context.Context) ([]models.SigningKey, error) {
+	ctx, cancel := r.db.QueryContext(ctx)
+	defer cancel()
+
+	filter := bson.M{"$or": bson.A{
+		bson.M{"verify_until": bson.M{"$exists": false}},
+		bson.M{"verify_until": bson.M{"$gt": time.Now()}},
+	}}
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	cursor, err := r.collection().Find(ctx, filter, opts)
+	if err != nil {
+		return nil, apperrors.FromMongo(err)
+	}
+	defer cursor.Close(ctx)
+
+	keys := []models.SigningKey{}
+	if err := cursor.All(ctx, &keys); err != nil {
+		return nil, apperrors.FromMongo(err)
+	}
+	return keys, nil
+}
+
+func (r SigningKeyRepository) SaveKey(ctx context.Context, key models.SigningKey) error {
+	ctx, cancel := r.db.QueryContext(ctx)
+	defer cancel()
+
+	_, err := r.collection().InsertOne(ctx, key)
+	return apperrors.FromMongo(err)
+}
+
+func (r SigningKeyRepository) RetireKeys(ctx context.Context, keep string, verifyUntil time.Time) error {
+	ctx, cancel := r.db.QueryContext(ctx)
+	defer cancel()
+
+	filter := bson.M{"_id": bson.M{"$ne": keep}, "verify_until": bson.M{"$exists": false}}
+	update := bson.M{"$set": bson.M{"verify_until": verifyUntil}}
+	_, err := r.collection().UpdateMany(ctx, filter, update)
+	return apperrors.FromMongo(err)
+}
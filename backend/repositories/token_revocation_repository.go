@@ -0,0 +1,86 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"backend/apperrors"
+	"backend/database"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// TokenRevocationRepositoryInterface guarda las revocaciones de access tokens:
+// tokens sueltos por jti (o sesiones enteras, con la clave que arma el
+// servicio) y, por usuario, la fecha antes de la cual todos sus
+// tokens dejan de valer. Ambos registros expiran cuando ya no hay tokens
+// vigentes a los que aplicar.
+type TokenRevocationRepositoryInterface interface {
+	DenyToken(ctx context.Context, jti string, expiresAt time.Time) error
+	IsDenied(ctx context.Context, jti string) (bool, error)
+	// SetIssuedBefore solo adelanta la marca: una más vieja no la pisa.
+	SetIssuedBefore(ctx context.Context, userID string, issuedBefore, expiresAt time.Time) error
+	// GetIssuedBefore devuelve el tiempo cero si el usuario no tiene marca.
+	GetIssuedBefore(ctx context.Context, userID string) (time.Time, error)
+}
+
+type TokenRevocationRepository struct {
+	db database.DB
+}
+
+func NewTokenRevocationRepository(db database.DB) *TokenRevocationRepository {
+	return &TokenRevocationRepository{db: db}
+}
+
+func (r TokenRevocationRepository) denylist() *mongo.Collection {
+	return r.db.GetDatabase().Collection("revoked_tokens")
+}
+
+func (r TokenRevocationRepository) watermarks() *mongo.Collection {
+	return r.db.GetDatabase().Collection("token_watermarks")
+}
+
+func (r TokenRevocationRepository) DenyToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	ctx, cancel := r.db.QueryContext(ctx)
+	defer cancel()
+
+	filter := bson.M{"_id": jti}
+	update := bson.M{"$set": bson.M{"expires_at": expiresAt}}
+	_, err := r.denylist().UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	return apperrors.FromMongo(err)
+}
+
+func (r TokenRevocationRepository) IsDenied(ctx context.Context, jti string) (bool, error) {
+	ctx, cancel := r.db.QueryContext(ctx)
+	defer cancel()
+
+	n, err := r.denylist().CountDocuments(ctx, bson.M{"_id": jti}, options.Count().SetLimit(1))
+	return n > 0, apperrors.FromMongo(err)
+}
+
+func (r TokenRevocationRepository) SetIssuedBefore(ctx context.Context, userID string, issuedBefore, expiresAt time.Time) error {
+	ctx, cancel := r.db.QueryContext(ctx)
+	defer cancel()
+
+	filter := bson.M{"_id": userID}
+	update := bson.M{"$max": bson.M{"issued_before": issuedBefore, "expires_at": expiresAt}}
+	_, err := r.watermarks().UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	return apperrors.FromMongo(err)
+}
+
+func (r TokenRevocationRepository) GetIssuedBefore(ctx context.Context, userID string) (time.Time, error) {
+	ctx, cancel := r.db.QueryContext(ctx)
+	defer cancel()
+
+	var doc struct {
+		IssuedBefore time.Time `bson:"issued_before"`
+	}
+	err := r.watermarks().FindOne(ctx, bson.M{"_id": userID}).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return time.Time{}, nil
+	}
+	return doc.IssuedBefore, apperrors.FromMongo(err)
+}
@@ -2,21 +2,62 @@ package repositories
 
 import (
 	"context"
+	"regexp"
+	"time"
 
+	"backend/apperrors"
 	"backend/database"
 	"backend/models"
+	"backend/utils"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type UserRepositoryInterface interface {
-	GetUser(name string) ([]models.User, error)
-	GetUserByID(id string) (models.User, error)
-	CreateUser(user models.User) (*mongo.InsertOneResult, error)
-	UpdateUser(user models.User) (*mongo.UpdateResult, error)
-	DeleteUser(id primitive.ObjectID) (*mongo.DeleteResult, error)
+	GetUser(ctx context.Context, name string) ([]models.User, error)
+	GetUserByID(ctx context.Context, id string) (models.User, error)
+	GetUserByEmail(ctx context.Context, email string) (models.User, error)
+	CreateUser(ctx context.Context, user models.User) (*mongo.InsertOneResult, error)
+	// UpdateUser escribe los datos de perfil. Si el email cambia, queda sin
+	// verificar. Rol, contraseña, verificación, segundo factor y
+	// deshabilitación tienen sus propios métodos, para que un update con un
+	// usuario leído antes no pise un cambio hecho mientras tanto.
+	UpdateUser(ctx context.Context, user models.User) (*mongo.UpdateResult, error)
+	// SetRole, SetPasswordHash y MarkEmailVerified devuelven ErrNotFound si
+	// el usuario no existe.
+	SetRole(ctx context.Context, id primitive.ObjectID, role models.Role, at time.Time) error
+	SetPasswordHash(ctx context.Context, id primitive.ObjectID, hash string, at time.Time) error
+	// MarkEmailVerified marca email como verificado solo si sigue siendo el
+	// email del usuario; si cambió, no hace nada.
+	MarkEmailVerified(ctx context.Context, id primitive.ObjectID, email string, at time.Time) error
+	DeleteUser(ctx context.Context, id primitive.ObjectID) (*mongo.DeleteResult, error)
+	// SetMFA reemplaza la configuración de segundo factor del usuario.
+	SetMFA(ctx context.Context, id primitive.ObjectID, mfa models.MFA) error
+	// UseTOTPStep registra step como último paso TOTP usado. Devuelve false si
+	// ya se había usado ese paso o uno posterior.
+	UseTOTPStep(ctx context.Context, id primitive.ObjectID, step int64) (bool, error)
+	// ConsumeRecoveryCode quita el código con ese hash. Devuelve false si no
+	// estaba.
+	ConsumeRecoveryCode(ctx context.Context, id primitive.ObjectID, hash string) (bool, error)
+	// SearchUsers devuelve una página de usuarios, los más nuevos primero, y
+	// el total que cumple el filtro.
+	SearchUsers(ctx context.Context, filter UserFilter) ([]models.User, int64, error)
+	// SetDisabled deshabilita al usuario desde disabledAt, o lo habilita si
+	// es nil. Devuelve ErrNotFound si el usuario no existe.
+	SetDisabled(ctx context.Context, id primitive.ObjectID, disabledAt *time.Time) error
+}
+
+// UserFilter filtra SearchUsers. Query busca sin distinguir mayúsculas en el
+// nombre y el email, como texto literal.
+type UserFilter struct {
+	Query    string
+	Role     models.Role
+	Disabled *bool
+	Skip     int64
+	Limit    int64
 }
 type UserRepository struct {
 	db database.DB
@@ -27,8 +68,10 @@ func NewUserRepository(db database.DB) *UserRepository {
 		db: db,
 	}
 }
-func (repository UserRepository) GetUser(name string) ([]models.User, error) {
-	collection := repository.db.GetClient().Database("fitness_db").Collection("users")
+func (repository UserRepository) GetUser(ctx context.Context, name string) ([]models.User, error) {
+	collection := repository.db.GetDatabase().Collection("users")
+	ctx, cancel := repository.db.QueryContext(ctx)
+	defer cancel()
 
 	var filter bson.M
 
@@ -38,14 +81,14 @@ func (repository UserRepository) GetUser(name string) ([]models.User, error) {
 		filter = bson.M{}
 	}
 
-	cursor, err := collection.Find(context.TODO(), filter)
+	cursor, err := collection.Find(ctx, filter)
 	if err != nil {
 		return nil, err
 	}
-	defer cursor.Close(context.Background())
+	defer cursor.Close(ctx)
 
 	var users []models.User
-	for cursor.Next(context.Background()) {
+	for cursor.Next(ctx) {
 		var user models.User
 		err := cursor.Decode(&user)
 		if err != nil {
@@ -54,54 +97,211 @@ func (repository UserRepository) GetUser(name string) ([]models.User, error) {
 		users = append(users, user)
 	}
 
-	return users, nil
+	return users, cursor.Err()
 }
-func (repository UserRepository) GetUserByID(id string) (models.User, error) {
-	collection := repository.db.GetClient().Database("fitness_db").Collection("users")
-	objectID, err := primitive.ObjectIDFromHex(id)
+func (repository UserRepository) GetUserByID(ctx context.Context, id string) (models.User, error) {
+	collection := repository.db.GetDatabase().Collection("users")
+	objectID, err := utils.ParseObjectID(id)
 	if err != nil {
 		return models.User{}, err
 	}
+	ctx, cancel := repository.db.QueryContext(ctx)
+	defer cancel()
 
 	filter := bson.M{"_id": objectID}
 	var user models.User
 
-	err = collection.FindOne(context.TODO(), filter).Decode(&user)
-	return user, err
+	err = collection.FindOne(ctx, filter).Decode(&user)
+	return user, apperrors.FromMongo(err)
 }
 
-func (repository UserRepository) CreateUser(user models.User) (*mongo.InsertOneResult, error) {
-	collection := repository.db.GetClient().Database("fitness_db").Collection("users")
-	result, err := collection.InsertOne(context.TODO(), user)
-	return result, err
+// GetUserByEmail busca por el email normalizado (ver utils.NormalizeEmail),
+// que es como se guarda, así la consulta usa users_email_unique.
+func (repository UserRepository) GetUserByEmail(ctx context.Context, email string) (models.User, error) {
+	collection := repository.db.GetDatabase().Collection("users")
+	ctx, cancel := repository.db.QueryContext(ctx)
+	defer cancel()
+
+	filter := bson.M{"email": utils.NormalizeEmail(email)}
+	var user models.User
+
+	err := collection.FindOne(ctx, filter).Decode(&user)
+	return user, apperrors.FromMongo(err)
 }
 
-func (repository UserRepository) UpdaterUser(user models.User) (*mongo.UpdateResult, error) {
-	collection := repository.db.GetClient().Database("fitness_db").Collection("users")
+func (repository UserRepository) CreateUser(ctx context.Context, user models.User) (*mongo.InsertOneResult, error) {
+	collection := repository.db.GetDatabase().Collection("users")
+	ctx, cancel := repository.db.QueryContext(ctx)
+	defer cancel()
 
-	filter := bson.M{"_id": user.ID}
-	update := bson.M{"$set": bson.M{
-		"name":             user.Name,
-		"email":            user.Email,
-		"password_hash":    user.PasswordHash,
-		"role":             user.Role,
-		"date_of_birth":    user.DateOfBirth,
-		"weight,omitempty": user.Weight,
-		"height,omitempty": user.Height,
-		"level,omitempty":  user.Level,
-		"goals,omitempty":  user.Goals,
-		"created_at":       user.CreatedAt,
-		"updated_at":       user.UpdatedAt,
-	}}
+	result, err := collection.InsertOne(ctx, user)
+	return result, apperrors.FromMongo(err)
+}
+
+func (repository UserRepository) UpdateUser(ctx context.Context, user models.User) (*mongo.UpdateResult, error) {
+	collection := repository.db.GetDatabase().Collection("users")
+	ctx, cancel := repository.db.QueryContext(ctx)
+	defer cancel()
 
-	result, err := collection.UpdateOne(context.TODO(), filter, update)
-	return result, err
+	profile := bson.M{
+		"name":          user.Name,
+		"email":         user.Email,
+		"date_of_birth": user.DateOfBirth,
+		"weight":        user.Weight,
+		"height":        user.Height,
+		"level":         user.Level,
+		"goals":         user.Goals,
+		"language":      user.Language,
+		"updated_at":    user.UpdatedAt,
+	}
+	// Con el mismo email la verificación no se toca.
+	result, err := collection.UpdateOne(ctx, bson.M{"_id": user.ID, "email": user.Email}, bson.M{"$set": profile})
+	if err != nil || result.MatchedCount > 0 {
+		return result, apperrors.FromMongo(err)
+	}
+	profile["email_verified"] = false
+	update := bson.M{"$set": profile, "$unset": bson.M{"email_verified_at": ""}}
+	result, err = collection.UpdateOne(ctx, bson.M{"_id": user.ID}, update)
+	return result, apperrors.FromMongo(err)
 }
 
-func (repository UserRepository) DeleteUser(id primitive.ObjectID) (*mongo.DeleteResult, error) {
-	collection := repository.db.GetClient().Database("fitness_db").Collection("users")
+func (repository UserRepository) SetRole(ctx context.Context, id primitive.ObjectID, role models.Role, at time.Time) error {
+	return repository.setFields(ctx, bson.M{"_id": id}, bson.M{"role": role, "updated_at": at})
+}
+
+func (repository UserRepository) SetPasswordHash(ctx context.Context, id primitive.ObjectID, hash string, at time.Time) error {
+	return repository.setFields(ctx, bson.M{"_id": id}, bson.M{"password_hash": hash, "updated_at": at})
+}
+
+func (repository UserRepository) MarkEmailVerified(ctx context.Context, id primitive.ObjectID, email string, at time.Time) error {
+	collection := repository.db.GetDatabase().Collection("users")
+	ctx, cancel := repository.db.QueryContext(ctx)
+	defer cancel()
+
+	filter := bson.M{"_id": id, "email": email, "email_verified": bson.M{"$ne": true}}
+	update := bson.M{"$set": bson.M{"email_verified": true, "email_verified_at": at, "updated_at": at}}
+	_, err := collection.UpdateOne(ctx, filter, update)
+	return apperrors.FromMongo(err)
+}
+
+// setFields actualiza solo fields del usuario que cumple filter, o devuelve
+// ErrNotFound.
+func (repository UserRepository) setFields(ctx context.Context, filter, fields bson.M) error {
+	collection := repository.db.GetDatabase().Collection("users")
+	ctx, cancel := repository.db.QueryContext(ctx)
+	defer cancel()
+
+	result, err := collection.UpdateOne(ctx, filter, bson.M{"$set": fields})
+	if err != nil {
+		return apperrors.FromMongo(err)
+	}
+	if result.MatchedCount == 0 {
+		return apperrors.FromMongo(mongo.ErrNoDocuments)
+	}
+	return nil
+}
+
+func (repository UserRepository) DeleteUser(ctx context.Context, id primitive.ObjectID) (*mongo.DeleteResult, error) {
+	collection := repository.db.GetDatabase().Collection("users")
+	ctx, cancel := repository.db.QueryContext(ctx)
+	defer cancel()
 
 	filter := bson.M{"_id": id}
-	result, err := collection.DeleteOne(context.TODO(), filter)
-	return result, err
+	result, err := collection.DeleteOne(ctx, filter)
+	return result, apperrors.FromMongo(err)
+}
+
+func (repository UserRepository) SetMFA(ctx context.Context, id primitive.ObjectID, mfa models.MFA) error {
+	collection := repository.db.GetDatabase().Collection("users")
+	ctx, cancel := repository.db.QueryContext(ctx)
+	defer cancel()
+
+	_, err := collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"mfa": mfa}})
+	return apperrors.FromMongo(err)
+}
+
+func (repository UserRepository) UseTOTPStep(ctx context.Context, id primitive.ObjectID, step int64) (bool, error) {
+	collection := repository.db.GetDatabase().Collection("users")
+	ctx, cancel := repository.db.QueryContext(ctx)
+	defer cancel()
+
+	filter := bson.M{"_id": id, "$or": bson.A{
+		bson.M{"mfa.last_step": bson.M{"$exists": false}},
+		bson.M{"mfa.last_step": bson.M{"$lt": step}},
+	}}
+	result, err := collection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"mfa.last_step": step}})
+	if err != nil {
+		return false, apperrors.FromMongo(err)
+	}
+	return result.ModifiedCount > 0, nil
+}
+
+func (repository UserRepository) ConsumeRecoveryCode(ctx context.Context, id primitive.ObjectID, hash string) (bool, error) {
+	collection := repository.db.GetDatabase().Collection("users")
+	ctx, cancel := repository.db.QueryContext(ctx)
+	defer cancel()
+
+	filter := bson.M{"_id": id, "mfa.recovery_codes": hash}
+	result, err := collection.UpdateOne(ctx, filter, bson.M{"$pull": bson.M{"mfa.recovery_codes": hash}})
+	if err != nil {
+		return false, apperrors.FromMongo(err)
+	}
+	return result.ModifiedCount > 0, nil
+}
+
+func (repository UserRepository) SearchUsers(ctx context.Context, filter UserFilter) ([]models.User, int64, error) {
+	collection := repository.db.GetDatabase().Collection("users")
+	ctx, cancel := repository.db.QueryContext(ctx)
+	defer cancel()
+
+	query := bson.M{}
+	if filter.Query != "" {
+		pattern := bson.M{"$regex": regexp.QuoteMeta(filter.Query), "$options": "i"}
+		query["$or"] = bson.A{bson.M{"name": pattern}, bson.M{"email": pattern}}
+	}
+	if filter.Role != "" {
+		query["role"] = filter.Role
+	}
+	if filter.Disabled != nil {
+		query["disabled_at"] = bson.M{"$exists": *filter.Disabled}
+	}
+
+	total, err := collection.CountDocuments(ctx, query)
+	if err != nil {
+		return nil, 0, apperrors.FromMongo(err)
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetSkip(filter.Skip).
+		SetLimit(filter.Limit)
+	cursor, err := collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, 0, apperrors.FromMongo(err)
+	}
+	defer cursor.Close(ctx)
+
+	users := []models.User{}
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, 0, apperrors.FromMongo(err)
+	}
+	return users, total, nil
+}
+
+func (repository UserRepository) SetDisabled(ctx context.Context, id primitive.ObjectID, disabledAt *time.Time) error {
+	collection := repository.db.GetDatabase().Collection("users")
+	ctx, cancel := repository.db.QueryContext(ctx)
+	defer cancel()
+
+	update := bson.M{"$unset": bson.M{"disabled_at": ""}}
+	if disabledAt != nil {
+		update = bson.M{"$set": bson.M{"disabled_at": *disabledAt}}
+	}
+	result, err := collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return apperrors.FromMongo(err)
+	}
+	if result.MatchedCount == 0 {
+		return apperrors.FromMongo(mongo.ErrNoDocuments)
+	}
+	return nil
 }
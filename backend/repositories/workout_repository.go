@@ -3,8 +3,10 @@ package repositories
 import (
 	"context"
 
+	"backend/apperrors"
 	"backend/database"
 	"backend/models"
+	"backend/utils"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -12,11 +14,11 @@ import (
 )
 
 type WorkoutRepositoryInterface interface {
-	GetWorkouts(userID primitive.ObjectID) ([]models.Workout, error)
-	GetWorkoutByID(id string) (models.Workout, error)
-	CreateWorkout(workout models.Workout) (*mongo.InsertOneResult, error)
-	UpdateWorkout(workout models.Workout) (*mongo.UpdateResult, error)
-	DeleteWorkout(id primitive.ObjectID) (*mongo.DeleteResult, error)
+	GetWorkouts(ctx context.Context, userID primitive.ObjectID) ([]models.Workout, error)
+	GetWorkoutByID(ctx context.Context, id string) (models.Workout, error)
+	CreateWorkout(ctx context.Context, workout models.Workout) (*mongo.InsertOneResult, error)
+	UpdateWorkout(ctx context.Context, workout models.Workout) (*mongo.UpdateResult, error)
+	DeleteWorkout(ctx context.Context, id primitive.ObjectID) (*mongo.DeleteResult, error)
 }
 
 type WorkoutRepository struct {
@@ -29,19 +31,21 @@ func NewWorkoutRepository(db database.DB) *WorkoutRepository {
 	}
 }
 
-func (repository WorkoutRepository) GetWorkouts(userID primitive.ObjectID) ([]models.Workout, error) {
-	collection := repository.db.GetClient().Database("fitness_db").Collection("workouts")
+func (repository WorkoutRepository) GetWorkouts(ctx context.Context, userID primitive.ObjectID) ([]models.Workout, error) {
+	collection := repository.db.GetDatabase().Collection("workouts")
+	ctx, cancel := repository.db.QueryContext(ctx)
+	defer cancel()
 
 	filter := bson.M{"user_id": userID}
 
-	cursor, err := collection.Find(context.TODO(), filter)
+	cursor, err := collection.Find(ctx, filter)
 	if err != nil {
 		return nil, err
 	}
-	defer cursor.Close(context.Background())
+	defer cursor.Close(ctx)
 
 	var workouts []models.Workout
-	for cursor.Next(context.Background()) {
+	for cursor.Next(ctx) {
 		var workout models.Workout
 		if err := cursor.Decode(&workout); err != nil {
 			continue
@@ -49,31 +53,38 @@ func (repository WorkoutRepository) GetWorkouts(userID primitive.ObjectID) ([]mo
 		workouts = append(workouts, workout)
 	}
 
-	return workouts, nil
+	return workouts, cursor.Err()
 }
 
-func (repository WorkoutRepository) GetWorkoutByID(id string) (models.Workout, error) {
-	collection := repository.db.GetClient().Database("fitness_db").Collection("workouts")
-	objectID, err := primitive.ObjectIDFromHex(id)
+func (repository WorkoutRepository) GetWorkoutByID(ctx context.Context, id string) (models.Workout, error) {
+	collection := repository.db.GetDatabase().Collection("workouts")
+	objectID, err := utils.ParseObjectID(id)
 	if err != nil {
 		return models.Workout{}, err
 	}
+	ctx, cancel := repository.db.QueryContext(ctx)
+	defer cancel()
 
 	filter := bson.M{"_id": objectID}
 	var workout models.Workout
 
-	err = collection.FindOne(context.TODO(), filter).Decode(&workout)
-	return workout, err
+	err = collection.FindOne(ctx, filter).Decode(&workout)
+	return workout, apperrors.FromMongo(err)
 }
 
-func (repository WorkoutRepository) CreateWorkout(workout models.Workout) (*mongo.InsertOneResult, error) {
-	collection := repository.db.GetClient().Database("fitness_db").Collection("workouts")
-	result, err := collection.InsertOne(context.TODO(), workout)
-	return result, err
+func (repository WorkoutRepository) CreateWorkout(ctx context.Context, workout models.Workout) (*mongo.InsertOneResult, error) {
+	collection := repository.db.GetDatabase().Collection("workouts")
+	ctx, cancel := repository.db.QueryContext(ctx)
+	defer cancel()
+
+	result, err := collection.InsertOne(ctx, workout)
+	return result, apperrors.FromMongo(err)
 }
 
-func (repository WorkoutRepository) UpdateWorkout(workout models.Workout) (*mongo.UpdateResult, error) {
-	collection := repository.db.GetClient().Database("fitness_db").Collection("workouts")
+func (repository WorkoutRepository) UpdateWorkout(ctx context.Context, workout models.Workout) (*mongo.UpdateResult, error) {
+	collection := repository.db.GetDatabase().Collection("workouts")
+	ctx, cancel := repository.db.QueryContext(ctx)
+	defer cancel()
 
 	filter := bson.M{"_id": workout.ID}
 	update := bson.M{"$set": bson.M{
@@ -86,14 +97,16 @@ func (repository WorkoutRepository) UpdateWorkout(workout models.Workout) (*mong
 		"notes":              workout.Notes,
 	}}
 
-	result, err := collection.UpdateOne(context.TODO(), filter, update)
-	return result, err
+	result, err := collection.UpdateOne(ctx, filter, update)
+	return result, apperrors.FromMongo(err)
 }
 
-func (repository WorkoutRepository) DeleteWorkout(id primitive.ObjectID) (*mongo.DeleteResult, error) {
-	collection := repository.db.GetClient().Database("fitness_db").Collection("workouts")
+func (repository WorkoutRepository) DeleteWorkout(ctx context.Context, id primitive.ObjectID) (*mongo.DeleteResult, error) {
+	collection := repository.db.GetDatabase().Collection("workouts")
+	ctx, cancel := repository.db.QueryContext(ctx)
+	defer cancel()
 
 	filter := bson.M{"_id": id}
-	result, err := collection.DeleteOne(context.TODO(), filter)
-	return result, err
+	result, err := collection.DeleteOne(ctx, filter)
+	return result, apperrors.FromMongo(err)
 }
@@ -0,0 +1,246 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"backend/apperrors"
+	"backend/auth"
+	"backend/dto"
+	"backend/models"
+	"backend/repositories"
+	"backend/utils"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// apiKeyPrefix distingue las API keys de otros tokens a simple vista (y en
+// los escáneres de secretos).
+const apiKeyPrefix = "fit_"
+
+// apiKeyTouchInterval evita escribir en la base en cada pedido: el último uso
+// se actualiza como mucho una vez por intervalo.
+const apiKeyTouchInterval = time.Minute
+
+type APIKeyServiceInterface interface {
+	// Create genera una clave para el usuario. amr son los métodos del login
+	// desde el que se crea: los pedidos con la clave cuentan como
+	// autenticados así.
+	Create(ctx context.Context, userID string, amr []string, req dto.CreateAPIKeyRequest) (dto.CreatedAPIKeyResponse, error)
+	List(ctx context.Context, userID string) ([]dto.APIKey, error)
+	Revoke(ctx context.Context, userID, keyID string) error
+	// Authenticate resuelve la clave presentada desde ip al usuario dueño.
+	Authenticate(ctx context.Context, key, ip string) (APIKeyPrincipal, error)
+}
+
+// APIKeyPrincipal es el usuario de un pedido autenticado con API key. Rol,
+// email e idioma salen del usuario actual, no de cuando se creó la clave.
+type APIKeyPrincipal struct {
+	KeyID  string
+	UserID string
+	Email  string
+	Role   string
+	Lang   string
+	Scopes []string
+	AMR    []string
+}
+
+type APIKeyOptions struct {
+	// DefaultTTL es la vigencia de las claves creadas sin fecha de
+	// vencimiento y MaxTTL, la mayor permitida.
+	DefaultTTL time.Duration
+	MaxTTL     time.Duration
+	// MaxPerUser limita las claves vigentes de cada usuario.
+	MaxPerUser int
+	// HashKey es la clave con la que se guardan las claves. Vacía, las API
+	// keys están apagadas: no se crean ni se aceptan.
+	HashKey []byte
+}
+
+type APIKeyService struct {
+	keys  repositories.APIKeyRepositoryInterface
+	users repositories.UserRepositoryInterface
+	opts  APIKeyOptions
+}
+
+func NewAPIKeyService(keys repositories.APIKeyRepositoryInterface, users repositories.UserRepositoryInterface, opts APIKeyOptions) *APIKeyService {
+	return &APIKeyService{keys: keys, users: users, opts: opts}
+}
+
+var (
+	errAPIKeysDisabled     = apperrors.Forbidden("api_keys_disabled", "las API keys no están habilitadas")
+	errAPIKeyInvalid       = apperrors.Unauthorized("api_key_invalid", "API key inválida, revocada o vencida")
+	errAPIKeyNotFound      = apperrors.NotFound("api_key_not_found", "API key no encontrada")
+	errAPIKeyScopeInvalid  = apperrors.Validation("api_key_scope_invalid", "scope inválido, use <recurso>:read o <recurso>:write")
+	errAPIKeyExpiryInvalid = apperrors.Validation("api_key_expiry_invalid", "fecha de vencimiento inválida o demasiado lejana")
+	errAPIKeyLimit         = apperrors.Conflict("api_key_limit", "alcanzó el máximo de API keys vigentes, revoque alguna")
+)
+
+var apiKeyResources = []string{models.APIKeyResourceExercises, models.APIKeyResourceRoutines, models.APIKeyResourceWorkouts}
+
+// normalizeScopes valida los scopes y los devuelve ordenados y sin repetir.
+func normalizeScopes(scopes []string) ([]string, error) {
+	out := make([]string, 0, len(scopes))
+	for _, s := range scopes {
+		resource, access, ok := strings.Cut(strings.ToLower(strings.TrimSpace(s)), ":")
+		if !ok || !slices.Contains(apiKeyResources, resource) || (access != "read" && access != "write") {
+			return nil, apperrors.WithDetail(errAPIKeyScopeInvalid, s)
+		}
+		out = append(out, resource+":"+access)
+	}
+	slices.Sort(out)
+	return slices.Compact(out), nil
+}
+
+func (s *APIKeyService) Create(ctx context.Context, userID string, amr []string, req dto.CreateAPIKeyRequest) (dto.CreatedAPIKeyResponse, error) {
+	if len(s.opts.HashKey) == 0 {
+		return dto.CreatedAPIKeyResponse{}, errAPIKeysDisabled
+	}
+	uid, err := utils.ParseObjectID(userID)
+	if err != nil {
+		return dto.CreatedAPIKeyResponse{}, err
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return dto.CreatedAPIKeyResponse{}, apperrors.Validation("incomplete_data", "datos incompletos")
+	}
+	scopes, err := normalizeScopes(req.Scopes)
+	if err != nil {
+		return dto.CreatedAPIKeyResponse{}, err
+	}
+	now := time.Now()
+	expiresAt := now.Add(s.opts.DefaultTTL)
+	if req.ExpiresAt != nil {
+		expiresAt = *req.ExpiresAt
+	}
+	if !expiresAt.After(now) || expiresAt.Sub(now) > s.opts.MaxTTL {
+		return dto.CreatedAPIKeyResponse{}, errAPIKeyExpiryInvalid
+	}
+
+	if s.opts.MaxPerUser > 0 {
+		n, err := s.keys.CountActiveForUser(ctx, uid, now)
+		if err != nil {
+			return dto.CreatedAPIKeyResponse{}, err
+		}
+		if n >= int64(s.opts.MaxPerUser) {
+			return dto.CreatedAPIKeyResponse{}, errAPIKeyLimit
+		}
+	}
+
+	secret, err := auth.NewOpaqueToken()
+	if err != nil {
+		return dto.CreatedAPIKeyResponse{}, err
+	}
+	plain := apiKeyPrefix + secret
+	key := models.APIKey{
+		ID:        primitive.NewObjectID(),
+		UserID:    uid,
+		Name:      name,
+		Prefix:    plain[:len(apiKeyPrefix)+8],
+		KeyHash:   auth.HashAPIKey(s.opts.HashKey, plain),
+		Scopes:    scopes,
+		AMR:       amr,
+		ExpiresAt: expiresAt,
+		CreatedAt: now,
+	}
+	if err := s.keys.Create(ctx, key); err != nil {
+		return dto.CreatedAPIKeyResponse{}, err
+	}
+	return dto.CreatedAPIKeyResponse{APIKey: apiKeyToDTO(key), Key: plain}, nil
+}
+
+func (s *APIKeyService) List(ctx context.Context, userID string) ([]dto.APIKey, error) {
+	uid, err := utils.ParseObjectID(userID)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := s.keys.ListForUser(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]dto.APIKey, 0, len(keys))
+	for _, key := range keys {
+		out = append(out, apiKeyToDTO(key))
+	}
+	return out, nil
+}
+
+func (s *APIKeyService) Revoke(ctx context.Context, userID, keyID string) error {
+	uid, err := utils.ParseObjectID(userID)
+	if err != nil {
+		return err
+	}
+	kid, err := utils.ParseObjectID(keyID)
+	if err != nil {
+		return err
+	}
+	err = s.keys.Revoke(ctx, uid, kid, time.Now())
+	if errors.Is(err, apperrors.ErrNotFound) {
+		return errAPIKeyNotFound
+	}
+	return err
+}
+
+func (s *APIKeyService) Authenticate(ctx context.Context, plain, ip string) (APIKeyPrincipal, error) {
+	if len(s.opts.HashKey) == 0 {
+		return APIKeyPrincipal{}, errAPIKeysDisabled
+	}
+	if !strings.HasPrefix(plain, apiKeyPrefix) {
+		return APIKeyPrincipal{}, errAPIKeyInvalid
+	}
+	key, err := s.keys.GetByHash(ctx, auth.HashAPIKey(s.opts.HashKey, plain))
+	if errors.Is(err, apperrors.ErrNotFound) {
+		return APIKeyPrincipal{}, errAPIKeyInvalid
+	}
+	if err != nil {
+		return APIKeyPrincipal{}, err
+	}
+	now := time.Now()
+	if !key.Active(now) {
+		return APIKeyPrincipal{}, errAPIKeyInvalid
+	}
+	user, err := s.users.GetUserByID(ctx, key.UserID.Hex())
+	if errors.Is(err, apperrors.ErrNotFound) {
+		return APIKeyPrincipal{}, errAPIKeyInvalid
+	}
+	if err != nil {
+		return APIKeyPrincipal{}, err
+	}
+	if user.Disabled() {
+		return APIKeyPrincipal{}, errAccountDisabled
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= apiKeyTouchInterval || key.LastUsedIP != ip {
+		// El último uso es informativo: si no se puede guardar, el pedido
+		// sigue igual.
+		if err := s.keys.Touch(ctx, key.ID, now, ip); err != nil {
+			log.Printf("security: event=api_key_touch_failed key=%s err=%q", key.ID.Hex(), err)
+		}
+	}
+	return APIKeyPrincipal{
+		KeyID:  key.ID.Hex(),
+		UserID: user.ID.Hex(),
+		Email:  user.Email,
+		Role:   string(user.Role),
+		Lang:   user.Language,
+		Scopes: key.Scopes,
+		AMR:    key.AMR,
+	}, nil
+}
+
+func apiKeyToDTO(key models.APIKey) dto.APIKey {
+	return dto.APIKey{
+		ID:         key.ID.Hex(),
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scopes:     key.Scopes,
+		ExpiresAt:  key.ExpiresAt,
+		LastUsedAt: key.LastUsedAt,
+		LastUsedIP: key.LastUsedIP,
+		CreatedAt:  key.CreatedAt,
+	}
+}
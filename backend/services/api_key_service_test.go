@@ -0,0 +1,151 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"backend/apperrors"
+	"backend/auth"
+	"backend/dto"
+	"backend/models"
+	"backend/repositories/memory"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func newAPIKeyFixture(t *testing.T, opts APIKeyOptions) (*APIKeyService, *memory.APIKeyRepository, models.User) {
+	t.Helper()
+	users := memory.NewUserRepository()
+	user := models.User{ID: primitive.NewObjectID(), Email: "ana@example.com", Role: models.RoleUser, Language: "en"}
+	if _, err := users.CreateUser(context.Background(), user); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	keys := memory.NewAPIKeyRepository()
+	return NewAPIKeyService(keys, users, opts), keys, user
+}
+
+var testAPIKeyOptions = APIKeyOptions{DefaultTTL: 24 * time.Hour, MaxTTL: 48 * time.Hour, MaxPerUser: 2, HashKey: []byte(strings.Repeat("k", 32))}
+
+func TestAPIKey_CreateAndAuthenticate(t *testing.T) {
+	svc, keys, user := newAPIKeyFixture(t, testAPIKeyOptions)
+	created, err := svc.Create(context.Background(), user.ID.Hex(), []string{auth.AMRPassword}, dto.CreateAPIKeyRequest{
+		Name:   "kiosk",
+		Scopes: []string{"workouts:read", "Workouts:Read", "routines:write"},
+	})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if !strings.HasPrefix(created.Key, apiKeyPrefix) || !strings.HasPrefix(created.Key, created.APIKey.Prefix) {
+		t.Fatalf("key %q does not match prefix %q", created.Key, created.APIKey.Prefix)
+	}
+	if got := strings.Join(created.APIKey.Scopes, ","); got != "routines:write,workouts:read" {
+		t.Fatalf("scopes = %s, want normalized and deduplicated", got)
+	}
+	stored, _ := keys.ListForUser(context.Background(), user.ID)
+	if stored[0].KeyHash == created.Key {
+		t.Fatal("API key stored in plain text")
+	}
+
+	principal, err := svc.Authenticate(context.Background(), created.Key, "10.0.0.1")
+	if err != nil {
+		t.Fatalf("authenticate: %v", err)
+	}
+	if principal.UserID != user.ID.Hex() || principal.Role != string(models.RoleUser) || principal.Lang != "en" {
+		t.Fatalf("principal = %+v, want the owner", principal)
+	}
+	listed, _ := svc.List(context.Background(), user.ID.Hex())
+	if listed[0].LastUsedAt == nil || listed[0].LastUsedIP != "10.0.0.1" {
+		t.Fatalf("last use not recorded: %+v", listed[0])
+	}
+}
+
+func TestAPIKey_RejectsInvalidRequests(t *testing.T) {
+	svc, _, user := newAPIKeyFixture(t, testAPIKeyOptions)
+	for _, scope := range []string{"workouts", "users:read", "workouts:delete"} {
+		_, err := svc.Create(context.Background(), user.ID.Hex(), nil, dto.CreateAPIKeyRequest{Name: "x", Scopes: []string{scope}})
+		if apperrors.Code(err) != "api_key_scope_invalid" {
+			t.Fatalf("scope %q err = %v, want api_key_scope_invalid", scope, err)
+		}
+	}
+	tooFar := time.Now().Add(72 * time.Hour)
+	_, err := svc.Create(context.Background(), user.ID.Hex(), nil, dto.CreateAPIKeyRequest{Name: "x", Scopes: []string{"workouts:read"}, ExpiresAt: &tooFar})
+	if !errors.Is(err, errAPIKeyExpiryInvalid) {
+		t.Fatalf("err = %v, want errAPIKeyExpiryInvalid", err)
+	}
+
+	for range 2 {
+		if _, err := svc.Create(context.Background(), user.ID.Hex(), nil, dto.CreateAPIKeyRequest{Name: "x", Scopes: []string{"workouts:read"}}); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+	}
+	_, err = svc.Create(context.Background(), user.ID.Hex(), nil, dto.CreateAPIKeyRequest{Name: "x", Scopes: []string{"workouts:read"}})
+	if !errors.Is(err, errAPIKeyLimit) {
+		t.Fatalf("err = %v, want errAPIKeyLimit", err)
+	}
+}
+
+func TestAPIKey_RevokedAndExpiredKeysAreRejected(t *testing.T) {
+	svc, keys, user := newAPIKeyFixture(t, testAPIKeyOptions)
+	created, err := svc.Create(context.Background(), user.ID.Hex(), nil, dto.CreateAPIKeyRequest{Name: "script", Scopes: []string{"workouts:write"}})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	if err := svc.Revoke(context.Background(), primitive.NewObjectID().Hex(), created.APIKey.ID); !errors.Is(err, errAPIKeyNotFound) {
+		t.Fatalf("revoke by another user err = %v, want errAPIKeyNotFound", err)
+	}
+	if err := svc.Revoke(context.Background(), user.ID.Hex(), created.APIKey.ID); err != nil {
+		t.Fatalf("revoke: %v", err)
+	}
+	if _, err := svc.Authenticate(context.Background(), created.Key, "10.0.0.1"); !errors.Is(err, errAPIKeyInvalid) {
+		t.Fatalf("revoked key err = %v, want errAPIKeyInvalid", err)
+	}
+
+	expired := models.APIKey{UserID: user.ID, Name: "old", KeyHash: auth.HashAPIKey(testAPIKeyOptions.HashKey, apiKeyPrefix+"old"), ExpiresAt: time.Now().Add(-time.Minute)}
+	if err := keys.Create(context.Background(), expired); err != nil {
+		t.Fatalf("create expired: %v", err)
+	}
+	if _, err := svc.Authenticate(context.Background(), apiKeyPrefix+"old", "10.0.0.1"); !errors.Is(err, errAPIKeyInvalid) {
+		t.Fatalf("expired key err = %v, want errAPIKeyInvalid", err)
+	}
+}
+
+func TestAPIKey_DisabledWithoutHashKey(t *testing.T) {
+	opts := testAPIKeyOptions
+	opts.HashKey = nil
+	svc, _, user := newAPIKeyFixture(t, opts)
+
+	_, err := svc.Create(context.Background(), user.ID.Hex(), nil, dto.CreateAPIKeyRequest{Name: "script", Scopes: []string{"workouts:read"}})
+	if !errors.Is(err, errAPIKeysDisabled) {
+		t.Fatalf("create err = %v, want errAPIKeysDisabled", err)
+	}
+	if _, err := svc.Authenticate(context.Background(), apiKeyPrefix+"whatever", "10.0.0.1"); !errors.Is(err, errAPIKeysDisabled) {
+		t.Fatalf("authenticate err = %v, want errAPIKeysDisabled", err)
+	}
+}
+
+type failingTouchKeys struct {
+	*memory.APIKeyRepository
+}
+
+func (failingTouchKeys) Touch(ctx context.Context, id primitive.ObjectID, at time.Time, ip string) error {
+	return errors.New("mongo caído")
+}
+
+// No poder registrar el último uso no deja afuera a quien tiene una clave
+// válida.
+func TestAPIKey_TouchFailureDoesNotDenyAccess(t *testing.T) {
+	svc, keys, user := newAPIKeyFixture(t, testAPIKeyOptions)
+	created, err := svc.Create(context.Background(), user.ID.Hex(), nil, dto.CreateAPIKeyRequest{Name: "script", Scopes: []string{"workouts:read"}})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	svc.keys = failingTouchKeys{keys}
+
+	if _, err := svc.Authenticate(context.Background(), created.Key, "10.0.0.1"); err != nil {
+		t.Fatalf("authenticate err = %v, want the key accepted", err)
+	}
+}
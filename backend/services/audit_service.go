@@ -0,0 +1,69 @@
+package services
+
+import (
+	"context"
+
+	"backend/dto"
+	"backend/models"
+	"backend/repositories"
+	"backend/utils"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AuditServiceInterface es el registro de las acciones administrativas.
+type AuditServiceInterface interface {
+	Record(ctx context.Context, event models.AuditEvent) error
+	List(ctx context.Context, search dto.AuditSearch) (dto.AuditPage, error)
+}
+
+type AuditService struct {
+	repo repositories.AuditRepositoryInterface
+}
+
+func NewAuditService(repo repositories.AuditRepositoryInterface) *AuditService {
+	return &AuditService{repo: repo}
+}
+
+func (s *AuditService) Record(ctx context.Context, event models.AuditEvent) error {
+	event.ID = primitive.NewObjectID()
+	return s.repo.Create(ctx, event)
+}
+
+func (s *AuditService) List(ctx context.Context, search dto.AuditSearch) (dto.AuditPage, error) {
+	page, perPage, skip := pageBounds(search.Page, search.PerPage)
+	filter := repositories.AuditFilter{
+		TargetID: search.TargetID,
+		Action:   search.Action,
+		Skip:     skip,
+		Limit:    int64(perPage),
+	}
+	if search.ActorID != "" {
+		actor, err := utils.ParseObjectID(search.ActorID)
+		if err != nil {
+			return dto.AuditPage{}, err
+		}
+		filter.ActorID = actor
+	}
+
+	events, total, err := s.repo.List(ctx, filter)
+	if err != nil {
+		return dto.AuditPage{}, err
+	}
+	out := dto.AuditPage{Events: make([]dto.AuditEvent, 0, len(events)), Total: total, Page: page, PerPage: perPage}
+	for _, e := range events {
+		out.Events = append(out.Events, dto.AuditEvent{
+			ID:         e.ID.Hex(),
+			Action:     e.Action,
+			ActorID:    e.ActorID.Hex(),
+			ActorEmail: e.ActorEmail,
+			TargetType: e.TargetType,
+			TargetID:   e.TargetID,
+			Details:    e.Details,
+			IP:         e.IP,
+			UserAgent:  e.UserAgent,
+			CreatedAt:  e.CreatedAt,
+		})
+	}
+	return out, nil
+}
@@ -0,0 +1,150 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"time"
+
+	"backend/apperrors"
+	"backend/auth"
+	"backend/mail"
+	"backend/models"
+	"backend/repositories"
+	"backend/utils"
+)
+
+type EmailVerificationServiceInterface interface {
+	// SendVerification emite un token nuevo y envía el link a user.Email.
+	SendVerification(ctx context.Context, user models.User) error
+	VerifyEmail(ctx context.Context, token string) error
+	// ResendVerification reenvía el link al usuario autenticado, respetando
+	// la espera mínima y el máximo por hora.
+	ResendVerification(ctx context.Context, userID string) error
+}
+
+type EmailVerificationOptions struct {
+	TokenTTL time.Duration
+	// ResendCooldown es la espera mínima entre dos envíos al mismo usuario.
+	ResendCooldown time.Duration
+	// MaxPerHour limita los envíos por usuario en la última hora.
+	MaxPerHour int
+	// LinkBaseURL es la página del frontend que recibe ?token= y llama a
+	// POST /auth/verify-email.
+	LinkBaseURL string
+}
+
+type EmailVerificationService struct {
+	users  repositories.UserRepositoryInterface
+	tokens repositories.EmailVerificationRepositoryInterface
+	mailer mail.Mailer
+	opts   EmailVerificationOptions
+}
+
+func NewEmailVerificationService(users repositories.UserRepositoryInterface, tokens repositories.EmailVerificationRepositoryInterface, mailer mail.Mailer, opts EmailVerificationOptions) *EmailVerificationService {
+	return &EmailVerificationService{users: users, tokens: tokens, mailer: mailer, opts: opts}
+}
+
+var (
+	errVerificationTokenInvalid = apperrors.Validation("verification_token_invalid", "link de verificación inválido, usado o vencido")
+	errEmailAlreadyVerified     = apperrors.Conflict("email_already_verified", "el email ya está verificado")
+	errVerificationThrottled    = apperrors.TooManyRequests("verification_throttled", "demasiados envíos de verificación, espere antes de pedir otro")
+)
+
+func (s *EmailVerificationService) SendVerification(ctx context.Context, user models.User) error {
+	token, err := auth.NewOpaqueToken()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	err = s.tokens.Create(ctx, models.EmailVerification{
+		UserID:    user.ID,
+		Email:     user.Email,
+		TokenHash: auth.HashOpaqueToken(token),
+		ExpiresAt: now.Add(s.opts.TokenTTL),
+		CreatedAt: now,
+	})
+	if err != nil {
+		return err
+	}
+	msg := mail.VerificationMessage(user.Email, user.Language, tokenLink(s.opts.LinkBaseURL, token), s.opts.TokenTTL)
+	return s.mailer.Send(ctx, msg)
+}
+
+// tokenLink agrega ?token= a la página del frontend que lo procesa,
+// conservando los parámetros que ya tenga.
+func tokenLink(base, token string) string {
+	u, err := url.Parse(base)
+	if err != nil {
+		return base + "?token=" + url.QueryEscape(token)
+	}
+	q := u.Query()
+	q.Set("token", token)
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+func (s *EmailVerificationService) VerifyEmail(ctx context.Context, token string) error {
+	if token == "" {
+		return errVerificationTokenInvalid
+	}
+	now := time.Now()
+	v, err := s.tokens.Consume(ctx, auth.HashOpaqueToken(token), now)
+	if errors.Is(err, apperrors.ErrNotFound) {
+		return errVerificationTokenInvalid
+	}
+	if err != nil {
+		return err
+	}
+
+	user, err := s.users.GetUserByID(ctx, v.UserID.Hex())
+	if errors.Is(err, apperrors.ErrNotFound) {
+		return errVerificationTokenInvalid
+	}
+	if err != nil {
+		return err
+	}
+	// El link era para otra dirección: el usuario cambió el email después.
+	if user.Email != v.Email {
+		return errVerificationTokenInvalid
+	}
+	if err := s.users.MarkEmailVerified(ctx, user.ID, v.Email, now); err != nil {
+		return err
+	}
+	return s.tokens.InvalidateForUser(ctx, user.ID, now)
+}
+
+func (s *EmailVerificationService) ResendVerification(ctx context.Context, userID string) error {
+	objID, err := utils.ParseObjectID(userID)
+	if err != nil {
+		return err
+	}
+	user, err := s.users.GetUserByID(ctx, userID)
+	if err != nil {
+		return notFoundAs(err, "user_not_found", "usuario no encontrado")
+	}
+	if user.EmailVerified {
+		return errEmailAlreadyVerified
+	}
+
+	now := time.Now()
+	if s.opts.ResendCooldown > 0 {
+		recent, err := s.tokens.CountSince(ctx, objID, now.Add(-s.opts.ResendCooldown))
+		if err != nil {
+			return err
+		}
+		if recent > 0 {
+			return errVerificationThrottled
+		}
+	}
+	if s.opts.MaxPerHour > 0 {
+		sent, err := s.tokens.CountSince(ctx, objID, now.Add(-time.Hour))
+		if err != nil {
+			return err
+		}
+		if sent >= int64(s.opts.MaxPerHour) {
+			return errVerificationThrottled
+		}
+	}
+	return s.SendVerification(ctx, user)
+}
@@ -0,0 +1,149 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"backend/mail"
+	"backend/models"
+	"backend/repositories/memory"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func newVerificationFixture(t *testing.T, opts EmailVerificationOptions) (*EmailVerificationService, *memory.UserRepository, *mail.Outbox, models.User) {
+	t.Helper()
+	users := memory.NewUserRepository()
+	user := models.User{ID: primitive.NewObjectID(), Name: "Alice", Email: "alice@example.com"}
+	if _, err := users.CreateUser(context.Background(), user); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	outbox := mail.NewOutbox("", "no-reply@example.com")
+	if opts.TokenTTL == 0 {
+		opts.TokenTTL = time.Hour
+	}
+	opts.LinkBaseURL = "https://app.example.com/verify"
+	svc := NewEmailVerificationService(users, memory.NewEmailVerificationRepository(), outbox, opts)
+	return svc, users, outbox, user
+}
+
+// tokenFromOutbox extrae el token del link del último correo enviado a to.
+func tokenFromOutbox(t *testing.T, outbox *mail.Outbox, to string) string {
+	t.Helper()
+	msg, ok := outbox.Last(to)
+	if !ok {
+		t.Fatalf("no message sent to %s", to)
+	}
+	for _, field := range strings.Fields(msg.Body) {
+		if u, err := url.Parse(field); err == nil && u.Query().Get("token") != "" {
+			return u.Query().Get("token")
+		}
+	}
+	t.Fatalf("no link in message: %q", msg.Body)
+	return ""
+}
+
+func TestEmailVerification_VerifyIsSingleUse(t *testing.T) {
+	ctx := context.Background()
+	svc, users, outbox, user := newVerificationFixture(t, EmailVerificationOptions{})
+
+	if err := svc.SendVerification(ctx, user); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	token := tokenFromOutbox(t, outbox, user.Email)
+
+	if err := svc.VerifyEmail(ctx, token); err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	got, _ := users.GetUserByID(ctx, user.ID.Hex())
+	if !got.EmailVerified || got.EmailVerifiedAt == nil {
+		t.Fatalf("expected the user to be verified, got %+v", got)
+	}
+	if err := svc.VerifyEmail(ctx, token); !errors.Is(err, errVerificationTokenInvalid) {
+		t.Fatalf("expected a reused token to be rejected, got %v", err)
+	}
+}
+
+func TestEmailVerification_RejectsExpiredAndUnknownTokens(t *testing.T) {
+	ctx := context.Background()
+	svc, _, outbox, user := newVerificationFixture(t, EmailVerificationOptions{TokenTTL: time.Nanosecond})
+
+	if err := svc.SendVerification(ctx, user); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	token := tokenFromOutbox(t, outbox, user.Email)
+	time.Sleep(time.Millisecond)
+
+	if err := svc.VerifyEmail(ctx, token); !errors.Is(err, errVerificationTokenInvalid) {
+		t.Fatalf("expected an expired token to be rejected, got %v", err)
+	}
+	if err := svc.VerifyEmail(ctx, "no-existe"); !errors.Is(err, errVerificationTokenInvalid) {
+		t.Fatalf("expected an unknown token to be rejected, got %v", err)
+	}
+}
+
+func TestEmailVerification_LinkForOldEmailDoesNotVerifyNewOne(t *testing.T) {
+	ctx := context.Background()
+	svc, users, outbox, user := newVerificationFixture(t, EmailVerificationOptions{})
+
+	if err := svc.SendVerification(ctx, user); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	token := tokenFromOutbox(t, outbox, user.Email)
+
+	user.Email = "other@example.com"
+	if _, err := users.UpdateUser(ctx, user); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if err := svc.VerifyEmail(ctx, token); !errors.Is(err, errVerificationTokenInvalid) {
+		t.Fatalf("expected the old link to be rejected, got %v", err)
+	}
+}
+
+func TestEmailVerification_ResendIsThrottled(t *testing.T) {
+	ctx := context.Background()
+	svc, _, outbox, user := newVerificationFixture(t, EmailVerificationOptions{ResendCooldown: time.Minute, MaxPerHour: 3})
+
+	if err := svc.ResendVerification(ctx, user.ID.Hex()); err != nil {
+		t.Fatalf("first resend: %v", err)
+	}
+	if err := svc.ResendVerification(ctx, user.ID.Hex()); !errors.Is(err, errVerificationThrottled) {
+		t.Fatalf("expected the cooldown to apply, got %v", err)
+	}
+	if n := len(outbox.Messages()); n != 1 {
+		t.Fatalf("expected 1 message, got %d", n)
+	}
+}
+
+func TestEmailVerification_ResendHourlyCap(t *testing.T) {
+	ctx := context.Background()
+	svc, _, outbox, user := newVerificationFixture(t, EmailVerificationOptions{MaxPerHour: 2})
+
+	for i := 0; i < 2; i++ {
+		if err := svc.ResendVerification(ctx, user.ID.Hex()); err != nil {
+			t.Fatalf("resend %d: %v", i, err)
+		}
+	}
+	if err := svc.ResendVerification(ctx, user.ID.Hex()); !errors.Is(err, errVerificationThrottled) {
+		t.Fatalf("expected the hourly cap to apply, got %v", err)
+	}
+	if n := len(outbox.Messages()); n != 2 {
+		t.Fatalf("expected 2 messages, got %d", n)
+	}
+}
+
+func TestEmailVerification_ResendWhenVerified(t *testing.T) {
+	ctx := context.Background()
+	svc, users, _, user := newVerificationFixture(t, EmailVerificationOptions{})
+
+	if err := users.MarkEmailVerified(ctx, user.ID, user.Email, time.Now()); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if err := svc.ResendVerification(ctx, user.ID.Hex()); !errors.Is(err, errEmailAlreadyVerified) {
+		t.Fatalf("expected email_already_verified, got %v", err)
+	}
+}
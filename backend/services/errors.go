@@ -0,0 +1,16 @@
+package services
+
+import (
+	"errors"
+
+	"backend/apperrors"
+)
+
+// notFoundAs cambia el not found genérico del repositorio por uno con el código
+// y el mensaje del recurso, conservando la causa original.
+func notFoundAs(err error, code, message string) error {
+	if errors.Is(err, apperrors.ErrNotFound) {
+		return &apperrors.Error{Kind: apperrors.ErrNotFound, Code: code, Message: message, Err: err}
+	}
+	return err
+}
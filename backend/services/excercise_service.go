@@ -1,9 +1,10 @@
 package services
 
 import (
-	"errors"
+	"context"
 	"time"
 
+	"backend/apperrors"
 	"backend/dto"
 	"backend/models"
 	"backend/repositories"
@@ -13,12 +14,16 @@ import (
 )
 
 type ExerciseInterface interface {
-	GetExercises(name, category, muscleGroup string) ([]models.Exercise, error)
-	GetExerciseByID(id string) (models.Exercise, error)
-	CreateExercise(exercise dto.ExerciseRequest) (dto.ExerciseResponse, error)
-	UpdateExercise(id string, exercise dto.ExerciseRequest) (dto.ExerciseResponse, error)
-	DeleteExercise(ownerID, exerciseID string) error
-	SearchExercises(search dto.ExerciseSearch) ([]dto.ExerciseResponse, error)
+	GetExercises(ctx context.Context, name, category, muscleGroup string) ([]models.Exercise, error)
+	GetExerciseByID(ctx context.Context, id string) (models.Exercise, error)
+	CreateExercise(ctx context.Context, exercise dto.ExerciseRequest) (dto.ExerciseResponse, error)
+	UpdateExercise(ctx context.Context, id string, exercise dto.ExerciseRequest) (dto.ExerciseResponse, error)
+	DeleteExercise(ctx context.Context, ownerID, exerciseID string) error
+	// ModerateExercise y DeleteAnyExercise no verifican el dueño: son para
+	// quien tiene exercise:moderate. El ejercicio conserva su dueño.
+	ModerateExercise(ctx context.Context, id string, exercise dto.ExerciseRequest) (dto.ExerciseResponse, error)
+	DeleteAnyExercise(ctx context.Context, exerciseID string) error
+	SearchExercises(ctx context.Context, search dto.ExerciseSearch) ([]dto.ExerciseResponse, error)
 }
 
 type ExerciseService struct {
@@ -29,41 +34,44 @@ func NewExerciseService(repo repositories.ExerciseRepositoryInterface) *Exercise
 	return &ExerciseService{repo: repo}
 }
 
-func (s *ExerciseService) GetExercises(name, category, muscleGroup string) ([]models.Exercise, error) {
-	return s.repo.GetExercises(name, category, muscleGroup)
+func (s *ExerciseService) GetExercises(ctx context.Context, name, category, muscleGroup string) ([]models.Exercise, error) {
+	return s.repo.GetExercises(ctx, name, category, muscleGroup)
 }
 
-func (s *ExerciseService) GetExerciseByID(id string) (models.Exercise, error) {
+func (s *ExerciseService) GetExerciseByID(ctx context.Context, id string) (models.Exercise, error) {
 	if id == "" {
-		return models.Exercise{}, errors.New("id required")
+		return models.Exercise{}, apperrors.Validation("id_required", "id required")
 	}
-	return s.repo.GetExerciseByID(id)
+	exercise, err := s.repo.GetExerciseByID(ctx, id)
+	return exercise, notFoundAs(err, "exercise_not_found", "Exercise not found")
 }
 
+var errInvalidExercise = apperrors.Validation("invalid_exercise", "datos de ejercicio inválidos")
+
 func validateExerciseRequest(request dto.ExerciseRequest) error {
 	if request.UserID == "" {
-		return errors.New("user id is required")
+		return apperrors.WithDetail(errInvalidExercise, "user id is required")
 	}
 	if request.Name == "" {
-		return errors.New("name is required")
+		return apperrors.WithDetail(errInvalidExercise, "name is required")
 	}
 	if request.Category == "" {
-		return errors.New("category is required")
+		return apperrors.WithDetail(errInvalidExercise, "category is required")
 	}
 	if request.MuscleGroup == "" {
-		return errors.New("muscle group is required")
+		return apperrors.WithDetail(errInvalidExercise, "muscle group is required")
 	}
 	if request.Difficulty == "" {
-		return errors.New("difficulty is required")
+		return apperrors.WithDetail(errInvalidExercise, "difficulty is required")
 	}
 
 	if _, err := primitive.ObjectIDFromHex(request.UserID); err != nil {
-		return errors.New("invalid user id")
+		return apperrors.WithDetail(errInvalidExercise, "invalid user id")
 	}
 	return nil
 }
 
-func (service *ExerciseService) CreateExercise(exercise dto.ExerciseRequest) (dto.ExerciseResponse, error) {
+func (service *ExerciseService) CreateExercise(ctx context.Context, exercise dto.ExerciseRequest) (dto.ExerciseResponse, error) {
 
 	if err := validateExerciseRequest(exercise); err != nil {
 		return dto.ExerciseResponse{}, err
@@ -71,40 +79,36 @@ func (service *ExerciseService) CreateExercise(exercise dto.ExerciseRequest) (dt
 
 	modelExercise := utils.ConvertRequestToExerciseModel(exercise)
 
-	result, err := service.repo.CreateExercise(modelExercise)
+	result, err := service.repo.CreateExercise(ctx, modelExercise)
 	if err != nil {
 		return dto.ExerciseResponse{}, err
 	}
-	createdExercise, err := service.repo.GetExerciseByID(result.InsertedID.(primitive.ObjectID).Hex())
+	createdExercise, err := service.repo.GetExerciseByID(ctx, result.InsertedID.(primitive.ObjectID).Hex())
 	if err != nil {
 		return dto.ExerciseResponse{}, err
 	}
 	return utils.ConvertExerciseModelToDTO(createdExercise), nil
 }
 
-func (service *ExerciseService) UpdateExercise(id string, exercise dto.ExerciseRequest) (dto.ExerciseResponse, error) {
+func (service *ExerciseService) UpdateExercise(ctx context.Context, id string, exercise dto.ExerciseRequest) (dto.ExerciseResponse, error) {
 	if err := validateExerciseRequest(exercise); err != nil {
 		return dto.ExerciseResponse{}, err
 	}
 
-	existing, err := service.repo.GetExerciseByID(id)
+	existing, err := service.repo.GetExerciseByID(ctx, id)
 	if err != nil {
-		return dto.ExerciseResponse{}, err
+		return dto.ExerciseResponse{}, notFoundAs(err, "exercise_not_found", "Exercise not found")
 	}
 	if existing.UserID != exercise.UserID {
-		return dto.ExerciseResponse{}, errors.New("unauthorized: cannot update exercise you do not own")
-	}
-
-	objectID, err := primitive.ObjectIDFromHex(id)
-	if err != nil {
-		return dto.ExerciseResponse{}, errors.New("invalid id")
+		return dto.ExerciseResponse{}, apperrors.Forbidden("not_exercise_owner", "unauthorized: cannot update exercise you do not own")
 	}
+	objectID := existing.ID
 
 	modelExercise := utils.ConvertRequestToExerciseModel(exercise)
 	modelExercise.ID = objectID
 	modelExercise.UpdatedAt = time.Now()
 
-	_, err = service.repo.UpdateExercise(modelExercise)
+	_, err = service.repo.UpdateExercise(ctx, modelExercise)
 	if err != nil {
 		return dto.ExerciseResponse{}, err
 	}
@@ -112,27 +116,53 @@ func (service *ExerciseService) UpdateExercise(id string, exercise dto.ExerciseR
 	return utils.ConvertExerciseModelToDTO(modelExercise), nil
 }
 
-func (s *ExerciseService) DeleteExercise(ownerID, exerciseID string) error {
+func (s *ExerciseService) DeleteExercise(ctx context.Context, ownerID, exerciseID string) error {
 	if ownerID == "" {
-		return errors.New("owner id is required")
+		return apperrors.Validation("id_required", "owner id is required")
 	}
-	existing, err := s.repo.GetExerciseByID(exerciseID)
+	existing, err := s.repo.GetExerciseByID(ctx, exerciseID)
 	if err != nil {
-		return err
+		return notFoundAs(err, "exercise_not_found", "Exercise not found")
 	}
 	if existing.UserID != ownerID {
-		return errors.New("unauthorized: cannot delete exercise you do not own")
+		return apperrors.Forbidden("not_exercise_owner", "unauthorized: cannot delete exercise you do not own")
+	}
+	_, err = s.repo.DeleteExercise(ctx, existing.ID)
+	return err
+}
+
+func (service *ExerciseService) ModerateExercise(ctx context.Context, id string, exercise dto.ExerciseRequest) (dto.ExerciseResponse, error) {
+	existing, err := service.repo.GetExerciseByID(ctx, id)
+	if err != nil {
+		return dto.ExerciseResponse{}, notFoundAs(err, "exercise_not_found", "Exercise not found")
+	}
+	exercise.UserID = existing.UserID
+	if err := validateExerciseRequest(exercise); err != nil {
+		return dto.ExerciseResponse{}, err
 	}
-	objID, err := primitive.ObjectIDFromHex(exerciseID)
+
+	modelExercise := utils.ConvertRequestToExerciseModel(exercise)
+	modelExercise.ID = existing.ID
+	modelExercise.CreatedAt = existing.CreatedAt
+	modelExercise.UpdatedAt = time.Now()
+
+	if _, err := service.repo.UpdateExercise(ctx, modelExercise); err != nil {
+		return dto.ExerciseResponse{}, err
+	}
+	return utils.ConvertExerciseModelToDTO(modelExercise), nil
+}
+
+func (s *ExerciseService) DeleteAnyExercise(ctx context.Context, exerciseID string) error {
+	existing, err := s.repo.GetExerciseByID(ctx, exerciseID)
 	if err != nil {
-		return err
+		return notFoundAs(err, "exercise_not_found", "Exercise not found")
 	}
-	_, err = s.repo.DeleteExercise(objID)
+	_, err = s.repo.DeleteExercise(ctx, existing.ID)
 	return err
 }
 
-func (s *ExerciseService) SearchExercises(search dto.ExerciseSearch) ([]dto.ExerciseResponse, error) {
-	exercises, err := s.repo.GetExercises(search.Name, search.Category, search.MuscleGroup)
+func (s *ExerciseService) SearchExercises(ctx context.Context, search dto.ExerciseSearch) ([]dto.ExerciseResponse, error) {
+	exercises, err := s.repo.GetExercises(ctx, search.Name, search.Category, search.MuscleGroup)
 	if err != nil {
 		return nil, err
 	}
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"testing"
 	"time"
 
@@ -19,20 +20,20 @@ type mockRepo struct {
 	deleteFn       func(id primitive.ObjectID) (bool, error)
 }
 
-func (m *mockRepo) GetExercises(name, category, muscleGroup string) ([]models.Exercise, error) {
+func (m *mockRepo) GetExercises(ctx context.Context, name, category, muscleGroup string) ([]models.Exercise, error) {
 	return m.getExercisesFn(name, category, muscleGroup)
 }
-func (m *mockRepo) GetExerciseByID(id string) (models.Exercise, error) {
+func (m *mockRepo) GetExerciseByID(ctx context.Context, id string) (models.Exercise, error) {
 	return m.getByIDFn(id)
 }
-func (m *mockRepo) CreateExercise(ex models.Exercise) (*mongo.InsertOneResult, error) {
+func (m *mockRepo) CreateExercise(ctx context.Context, ex models.Exercise) (*mongo.InsertOneResult, error) {
 	id, err := m.createFn(ex)
 	if err != nil {
 		return nil, err
 	}
 	return &mongo.InsertOneResult{InsertedID: id}, nil
 }
-func (m *mockRepo) UpdateExercise(ex models.Exercise) (*mongo.UpdateResult, error) {
+func (m *mockRepo) UpdateExercise(ctx context.Context, ex models.Exercise) (*mongo.UpdateResult, error) {
 	ok, err := m.updateFn(ex)
 	if err != nil {
 		return nil, err
@@ -42,7 +43,7 @@ func (m *mockRepo) UpdateExercise(ex models.Exercise) (*mongo.UpdateResult, erro
 	}
 	return &mongo.UpdateResult{MatchedCount: 0}, nil
 }
-func (m *mockRepo) DeleteExercise(id primitive.ObjectID) (*mongo.DeleteResult, error) {
+func (m *mockRepo) DeleteExercise(ctx context.Context, id primitive.ObjectID) (*mongo.DeleteResult, error) {
 	ok, err := m.deleteFn(id)
 	if err != nil {
 		return nil, err
@@ -55,7 +56,7 @@ func (m *mockRepo) DeleteExercise(id primitive.ObjectID) (*mongo.DeleteResult, e
 
 func TestGetExerciseByID_EmptyID(t *testing.T) {
 	svc := NewExerciseService(&mockRepo{})
-	_, err := svc.GetExerciseByID("")
+	_, err := svc.GetExerciseByID(context.Background(), "")
 	if err == nil {
 		t.Fatalf("expected error when id is empty")
 	}
@@ -92,7 +93,7 @@ func TestCreateExercise_Success(t *testing.T) {
 	}
 
 	svc := NewExerciseService(repo)
-	res, err := svc.CreateExercise(req)
+	res, err := svc.CreateExercise(context.Background(), req)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -113,7 +114,7 @@ func TestUpdateExercise_Unauthorized(t *testing.T) {
 		getByIDFn: func(id string) (models.Exercise, error) { return existing, nil },
 	}
 	svc := NewExerciseService(repo)
-	_, err := svc.UpdateExercise(existing.ID.Hex(), req)
+	_, err := svc.UpdateExercise(context.Background(), existing.ID.Hex(), req)
 	if err == nil {
 		t.Fatalf("expected unauthorized error")
 	}
@@ -125,7 +126,7 @@ func TestDeleteExercise_Unauthorized(t *testing.T) {
 		getByIDFn: func(id string) (models.Exercise, error) { return existing, nil },
 	}
 	svc := NewExerciseService(repo)
-	err := svc.DeleteExercise(primitive.NewObjectID().Hex(), existing.ID.Hex())
+	err := svc.DeleteExercise(context.Background(), primitive.NewObjectID().Hex(), existing.ID.Hex())
 	if err == nil {
 		t.Fatalf("expected unauthorized error on delete")
 	}
@@ -140,7 +141,7 @@ func TestSearchExercises_Mapping(t *testing.T) {
 		getExercisesFn: func(name, category, muscleGroup string) ([]models.Exercise, error) { return exercises, nil },
 	}
 	svc := NewExerciseService(repo)
-	res, err := svc.SearchExercises(dto.ExerciseSearch{})
+	res, err := svc.SearchExercises(context.Background(), dto.ExerciseSearch{})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
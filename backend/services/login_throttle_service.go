@@ -0,0 +1,232 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"backend/apperrors"
+	"backend/auth"
+	"backend/mail"
+	"backend/models"
+	"backend/repositories"
+	"backend/utils"
+)
+
+// LoginThrottleServiceInterface frena la adivinación de contraseñas y tokens.
+// Las fallas se cuentan por email y por IP: pasadas las primeras, cada intento
+// nuevo tiene que esperar el doble que el anterior, y con demasiadas fallas
+// seguidas la cuenta se bloquea y se le envía un link de desbloqueo.
+//
+// email puede ser vacío en los endpoints que no reciben uno (refresh, reset):
+// ahí solo se cuenta la IP.
+type LoginThrottleServiceInterface interface {
+	// Check devuelve ErrTooManyRequests, con RetryAfter, si email o ip tienen
+	// que esperar. No cuenta nada: es para los endpoints que no verifican
+	// una credencial.
+	Check(ctx context.Context, email, ip string) error
+	// Attempt cuenta un intento de email e ip y devuelve ErrTooManyRequests,
+	// con RetryAfter, si tienen que esperar. Se llama antes de verificar la
+	// credencial: el intento se suma de forma atómica, así que una ráfaga de
+	// pedidos en paralelo no pasa toda junta. Los intentos frenados también
+	// cuentan.
+	Attempt(ctx context.Context, email, ip string) error
+	// RecordFailure confirma que el intento de email falló y bloquea la
+	// cuenta si llegó al límite. El intento ya lo contó Attempt.
+	RecordFailure(ctx context.Context, email string) error
+	// RecordSuccess pone en cero el contador de email y le descuenta a la IP
+	// el intento que hizo Attempt. El resto del contador de la IP no se toca:
+	// puede ser compartida y vence solo.
+	RecordSuccess(ctx context.Context, email, ip string) error
+	// Unlock levanta el bloqueo con el token del correo.
+	Unlock(ctx context.Context, token string) error
+}
+
+type LoginThrottleOptions struct {
+	// EmailFreeAttempts e IPFreeAttempts son las fallas permitidas antes de
+	// empezar a demorar los intentos.
+	EmailFreeAttempts int
+	IPFreeAttempts    int
+	// La demora después de la primera falla fuera de las permitidas es
+	// BackoffBase y se duplica con cada una hasta BackoffMax.
+	BackoffBase time.Duration
+	BackoffMax  time.Duration
+	// LockoutThreshold es la cantidad de fallas de un email que bloquean la
+	// cuenta por LockoutDuration.
+	LockoutThreshold int
+	LockoutDuration  time.Duration
+	// Window es cuánto tiempo sin fallas hace falta para que un contador
+	// vuelva a cero.
+	Window time.Duration
+	// UnlockLinkBaseURL es la página del frontend que recibe ?token= y llama
+	// a POST /auth/unlock.
+	UnlockLinkBaseURL string
+}
+
+type LoginThrottleService struct {
+	attempts repositories.LoginAttemptRepositoryInterface
+	users    repositories.UserRepositoryInterface
+	mailer   mail.Mailer
+	opts     LoginThrottleOptions
+}
+
+func NewLoginThrottleService(attempts repositories.LoginAttemptRepositoryInterface, users repositories.UserRepositoryInterface, mailer mail.Mailer, opts LoginThrottleOptions) *LoginThrottleService {
+	return &LoginThrottleService{attempts: attempts, users: users, mailer: mailer, opts: opts}
+}
+
+var (
+	errTooManyAttempts    = apperrors.TooManyRequests("too_many_attempts", "demasiados intentos fallidos, espere antes de reintentar")
+	errAccountLocked      = apperrors.TooManyRequests("account_locked", "cuenta bloqueada por intentos fallidos")
+	errUnlockTokenInvalid = apperrors.Validation("unlock_token_invalid", "link de desbloqueo inválido, usado o vencido")
+)
+
+func emailKey(email string) string { return "email:" + utils.NormalizeEmail(email) }
+func ipKey(ip string) string       { return "ip:" + ip }
+
+func (s *LoginThrottleService) Check(ctx context.Context, email, ip string) error {
+	now := time.Now()
+	if email != "" {
+		if err := s.check(ctx, emailKey(email), s.opts.EmailFreeAttempts, now); err != nil {
+			return err
+		}
+	}
+	if ip != "" {
+		return s.check(ctx, ipKey(ip), s.opts.IPFreeAttempts, now)
+	}
+	return nil
+}
+
+func (s *LoginThrottleService) check(ctx context.Context, key string, free int, now time.Time) error {
+	attempt, err := s.attempts.Get(ctx, key)
+	if errors.Is(err, apperrors.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return s.wait(attempt, free, now)
+}
+
+func (s *LoginThrottleService) Attempt(ctx context.Context, email, ip string) error {
+	now := time.Now()
+	if email != "" {
+		if err := s.attempt(ctx, emailKey(email), s.opts.EmailFreeAttempts, now); err != nil {
+			return err
+		}
+	}
+	if ip != "" {
+		return s.attempt(ctx, ipKey(ip), s.opts.IPFreeAttempts, now)
+	}
+	return nil
+}
+
+// attempt suma el intento a key y decide con el contador como estaba antes:
+// cada pedido concurrente ve uno distinto.
+func (s *LoginThrottleService) attempt(ctx context.Context, key string, free int, now time.Time) error {
+	before, err := s.attempts.RecordAttempt(ctx, key, now, now.Add(s.opts.Window))
+	if err != nil {
+		return err
+	}
+	return s.wait(before, free, now)
+}
+
+// wait devuelve el error con la espera que impone attempt, o nil si no hay.
+func (s *LoginThrottleService) wait(attempt models.LoginAttempt, free int, now time.Time) error {
+	if !attempt.ExpiresAt.After(now) {
+		return nil
+	}
+	if attempt.Locked(now) {
+		return apperrors.WithRetryAfter(errAccountLocked, attempt.LockedUntil.Sub(now))
+	}
+	if wait := attempt.LastFailureAt.Add(s.backoff(attempt.Failures, free)).Sub(now); wait > 0 {
+		return apperrors.WithRetryAfter(errTooManyAttempts, wait)
+	}
+	return nil
+}
+
+// backoff es la espera después de failures fallas cuando se permiten free.
+func (s *LoginThrottleService) backoff(failures, free int) time.Duration {
+	if failures <= free {
+		return 0
+	}
+	wait := s.opts.BackoffBase
+	for i := free + 1; i < failures && wait < s.opts.BackoffMax; i++ {
+		wait *= 2
+	}
+	return min(wait, s.opts.BackoffMax)
+}
+
+func (s *LoginThrottleService) RecordFailure(ctx context.Context, email string) error {
+	if email == "" {
+		return nil
+	}
+
+	key := emailKey(email)
+	attempt, err := s.attempts.Get(ctx, key)
+	if errors.Is(err, apperrors.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	// Mientras dura el bloqueo Attempt rechaza los intentos; si sigue
+	// fallando después de que vence, se vuelve a bloquear.
+	now := time.Now()
+	if attempt.Failures < s.opts.LockoutThreshold || attempt.Locked(now) {
+		return nil
+	}
+	return s.lock(ctx, key, email, now)
+}
+
+// lock bloquea la cuenta y, si el email es de un usuario, le envía el link de
+// desbloqueo. Los emails desconocidos se bloquean igual para que la respuesta
+// no revele cuáles están registrados.
+func (s *LoginThrottleService) lock(ctx context.Context, key, email string, now time.Time) error {
+	token, err := auth.NewOpaqueToken()
+	if err != nil {
+		return err
+	}
+	if err := s.attempts.Lock(ctx, key, now.Add(s.opts.LockoutDuration), auth.HashOpaqueToken(token)); err != nil {
+		return err
+	}
+	log.Printf("security: event=account_locked key=%q until=%s", key, now.Add(s.opts.LockoutDuration).Format(time.RFC3339))
+
+	user, err := s.users.GetUserByEmail(ctx, utils.NormalizeEmail(email))
+	if errors.Is(err, apperrors.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	msg := mail.AccountUnlockMessage(user.Email, user.Language, tokenLink(s.opts.UnlockLinkBaseURL, token), s.opts.LockoutDuration)
+	if err := s.mailer.Send(ctx, msg); err != nil {
+		// El bloqueo ya está hecho y vence solo: un fallo del correo no
+		// cambia la respuesta del login.
+		log.Printf("no se pudo enviar el link de desbloqueo al usuario %s: %v", user.ID.Hex(), err)
+	}
+	return nil
+}
+
+func (s *LoginThrottleService) RecordSuccess(ctx context.Context, email, ip string) error {
+	if ip != "" {
+		if err := s.attempts.Release(ctx, ipKey(ip)); err != nil {
+			return err
+		}
+	}
+	if email == "" {
+		return nil
+	}
+	return s.attempts.Reset(ctx, emailKey(email))
+}
+
+func (s *LoginThrottleService) Unlock(ctx context.Context, token string) error {
+	if token == "" {
+		return errUnlockTokenInvalid
+	}
+	_, err := s.attempts.Unlock(ctx, auth.HashOpaqueToken(token), time.Now())
+	if errors.Is(err, apperrors.ErrNotFound) {
+		return errUnlockTokenInvalid
+	}
+	return err
+}
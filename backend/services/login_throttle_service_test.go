@@ -0,0 +1,182 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"backend/apperrors"
+	"backend/mail"
+	"backend/models"
+	"backend/repositories/memory"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func newTestThrottle(users *memory.UserRepository, outbox *mail.Outbox) *LoginThrottleService {
+	return NewLoginThrottleService(memory.NewLoginAttemptRepository(), users, outbox, LoginThrottleOptions{
+		EmailFreeAttempts: 3,
+		IPFreeAttempts:    20,
+		BackoffBase:       time.Second,
+		BackoffMax:        time.Minute,
+		LockoutThreshold:  5,
+		LockoutDuration:   30 * time.Minute,
+		Window:            time.Hour,
+		UnlockLinkBaseURL: "https://app.example.com/unlock",
+	})
+}
+
+// failLogins cuenta n intentos fallidos de email que pasaron el freno.
+func failLogins(t *testing.T, s *LoginThrottleService, email string, n int) {
+	t.Helper()
+	ctx := context.Background()
+	for i := 0; i < n; i++ {
+		now := time.Now()
+		if _, err := s.attempts.RecordAttempt(ctx, emailKey(email), now, now.Add(time.Hour)); err != nil {
+			t.Fatalf("attempt: %v", err)
+		}
+		if err := s.RecordFailure(ctx, email); err != nil {
+			t.Fatalf("record: %v", err)
+		}
+	}
+}
+
+func retryAfter(err error) time.Duration {
+	var appErr *apperrors.Error
+	if errors.As(err, &appErr) {
+		return appErr.RetryAfter
+	}
+	return 0
+}
+
+func TestLoginThrottle_BackoffDoubles(t *testing.T) {
+	s := newTestThrottle(nil, nil)
+	cases := map[int]time.Duration{
+		3:  0,
+		4:  time.Second,
+		5:  2 * time.Second,
+		6:  4 * time.Second,
+		20: time.Minute,
+	}
+	for failures, want := range cases {
+		if got := s.backoff(failures, 3); got != want {
+			t.Errorf("backoff(%d) = %v, want %v", failures, got, want)
+		}
+	}
+}
+
+func TestLoginThrottle_DelaysAfterFreeAttempts(t *testing.T) {
+	ctx := context.Background()
+	s := newTestThrottle(memory.NewUserRepository(), mail.NewOutbox("", "no-reply@example.com"))
+
+	for i := 0; i < 4; i++ {
+		if err := s.Attempt(ctx, "eve@example.com", "198.51.100.7"); err != nil {
+			t.Fatalf("attempt %d: the free attempts must not delay, got %v", i+1, err)
+		}
+		if err := s.RecordFailure(ctx, "eve@example.com"); err != nil {
+			t.Fatalf("record: %v", err)
+		}
+	}
+
+	err := s.Attempt(ctx, " EVE@example.com", "203.0.113.9")
+	if apperrors.Code(err) != "too_many_attempts" || retryAfter(err) <= 0 || retryAfter(err) > time.Second {
+		t.Fatalf("expected a 1s delay for the email from any IP, got %v (%v)", err, retryAfter(err))
+	}
+	if err := s.Check(ctx, "otra@example.com", "198.51.100.7"); err != nil {
+		t.Fatalf("the IP is still under its limit, got %v", err)
+	}
+
+	if err := s.RecordSuccess(ctx, "eve@example.com", "198.51.100.7"); err != nil {
+		t.Fatalf("success: %v", err)
+	}
+	if err := s.Attempt(ctx, "eve@example.com", "198.51.100.7"); err != nil {
+		t.Fatalf("a successful login must reset the email, got %v", err)
+	}
+}
+
+func TestLoginThrottle_ExpiredCounterStartsOver(t *testing.T) {
+	ctx := context.Background()
+	attempts := memory.NewLoginAttemptRepository()
+	s := newTestThrottle(nil, nil)
+	s.attempts = attempts
+
+	past := time.Now().Add(-2 * time.Hour)
+	for i := 0; i < 4; i++ {
+		if _, err := attempts.RecordAttempt(ctx, ipKey("198.51.100.7"), past, past.Add(time.Hour)); err != nil {
+			t.Fatalf("record: %v", err)
+		}
+	}
+	if err := s.Check(ctx, "", "198.51.100.7"); err != nil {
+		t.Fatalf("an expired counter must not delay, got %v", err)
+	}
+	if _, err := attempts.RecordAttempt(ctx, ipKey("198.51.100.7"), time.Now(), time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("record: %v", err)
+	}
+	got, err := attempts.Get(ctx, ipKey("198.51.100.7"))
+	if err != nil || got.Failures != 1 {
+		t.Fatalf("expected the counter to start over, got %d (%v)", got.Failures, err)
+	}
+}
+
+func TestLoginThrottle_LockoutAndUnlock(t *testing.T) {
+	ctx := context.Background()
+	users := memory.NewUserRepository()
+	outbox := mail.NewOutbox("", "no-reply@example.com")
+	user := models.User{ID: primitive.NewObjectID(), Email: "frank@example.com", Language: "en"}
+	if _, err := users.CreateUser(ctx, user); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	s := newTestThrottle(users, outbox)
+
+	failLogins(t, s, user.Email, 5)
+	err := s.Check(ctx, user.Email, "")
+	if apperrors.Code(err) != "account_locked" || retryAfter(err) < 29*time.Minute {
+		t.Fatalf("expected the account to be locked for 30m, got %v (%v)", err, retryAfter(err))
+	}
+
+	token := tokenFromOutbox(t, outbox, user.Email)
+	if err := s.Unlock(ctx, "otro"); !errors.Is(err, errUnlockTokenInvalid) {
+		t.Fatalf("expected an unknown token to be rejected, got %v", err)
+	}
+	if err := s.Unlock(ctx, token); err != nil {
+		t.Fatalf("unlock: %v", err)
+	}
+	if err := s.Check(ctx, user.Email, ""); err != nil {
+		t.Fatalf("expected the account to be unlocked, got %v", err)
+	}
+	if err := s.Unlock(ctx, token); !errors.Is(err, errUnlockTokenInvalid) {
+		t.Fatalf("expected the unlock link to be single use, got %v", err)
+	}
+}
+
+func TestLoginThrottle_UnknownEmailLockedWithoutMail(t *testing.T) {
+	ctx := context.Background()
+	outbox := mail.NewOutbox("", "no-reply@example.com")
+	s := newTestThrottle(memory.NewUserRepository(), outbox)
+
+	failLogins(t, s, "nadie@example.com", 5)
+	if err := s.Check(ctx, "nadie@example.com", ""); apperrors.Code(err) != "account_locked" {
+		t.Fatalf("unknown emails must look locked too, got %v", err)
+	}
+	if msgs := outbox.Messages(); len(msgs) != 0 {
+		t.Fatalf("expected no mail for an unknown email, got %d", len(msgs))
+	}
+}
+
+func TestPasswordReset_UnlocksAccount(t *testing.T) {
+	ctx := context.Background()
+	f := newResetFixture(t, PasswordResetOptions{})
+	failLogins(t, f.throttle, f.user.Email, 5)
+
+	if err := f.svc.RequestReset(ctx, f.user.Email); err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	f.svc.pending.Wait()
+	if err := f.svc.ResetPassword(ctx, tokenFromOutbox(t, f.outbox, f.user.Email), "nueva-clave"); err != nil {
+		t.Fatalf("reset: %v", err)
+	}
+	if err := f.throttle.Check(ctx, f.user.Email, ""); err != nil {
+		t.Fatalf("expected the reset to lift the lockout, got %v", err)
+	}
+}
@@ -0,0 +1,243 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"backend/apperrors"
+	"backend/auth"
+	"backend/dto"
+	"backend/models"
+	"backend/repositories"
+)
+
+// recoveryCodeCount es cuántos códigos de recuperación se entregan al activar
+// el segundo factor o al regenerarlos.
+const recoveryCodeCount = 10
+
+type MFAServiceInterface interface {
+	// StartTOTPEnrollment genera un secreto pendiente; no se activa hasta
+	// confirmarlo con un código.
+	StartTOTPEnrollment(ctx context.Context, userID string) (dto.TOTPEnrollment, error)
+	// ConfirmTOTPEnrollment activa el segundo factor y devuelve los códigos de
+	// recuperación.
+	ConfirmTOTPEnrollment(ctx context.Context, userID, code string) (dto.RecoveryCodesResponse, error)
+	RegenerateRecoveryCodes(ctx context.Context, userID, code string) (dto.RecoveryCodesResponse, error)
+	DisableMFA(ctx context.Context, userID string, req dto.DisableMFARequest) error
+	// VerifyLoginCode valida el segundo paso del login con un código TOTP o
+	// uno de recuperación, que queda consumido.
+	VerifyLoginCode(ctx context.Context, userID, code string) error
+}
+
+type MFAOptions struct {
+	// Issuer es el nombre que muestra la app autenticadora.
+	Issuer string
+	// RequiredRoles son los roles que no pueden desactivar el segundo factor.
+	RequiredRoles []string
+}
+
+type MFAService struct {
+	repo repositories.UserRepositoryInterface
+	opts MFAOptions
+}
+
+func NewMFAService(repo repositories.UserRepositoryInterface, opts MFAOptions) *MFAService {
+	return &MFAService{repo: repo, opts: opts}
+}
+
+var (
+	errMFAAlreadyEnabled   = apperrors.Conflict("mfa_already_enabled", "el segundo factor ya está activado")
+	errMFANotEnabled       = apperrors.Validation("mfa_not_enabled", "el segundo factor no está activado")
+	errMFAEnrollmentAbsent = apperrors.Validation("mfa_enrollment_not_started", "no hay un enrolamiento de segundo factor pendiente")
+	errMFACodeInvalid      = apperrors.Validation("mfa_code_invalid", "código de segundo factor inválido")
+	errMFARequiredForRole  = apperrors.Forbidden("mfa_required_for_role", "su rol requiere segundo factor, no puede desactivarlo")
+	// En el login el código inválido es un 401, como las credenciales.
+	errMFALoginCodeInvalid = apperrors.Unauthorized("mfa_code_invalid", "código de segundo factor inválido")
+)
+
+func (s *MFAService) StartTOTPEnrollment(ctx context.Context, userID string) (dto.TOTPEnrollment, error) {
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return dto.TOTPEnrollment{}, notFoundAs(err, "user_not_found", "usuario no encontrado")
+	}
+	if user.MFA.Enabled {
+		return dto.TOTPEnrollment{}, errMFAAlreadyEnabled
+	}
+
+	secret, err := auth.NewTOTPSecret()
+	if err != nil {
+		return dto.TOTPEnrollment{}, err
+	}
+	sealed, err := auth.SealTOTPSecret(secret, user.ID.Hex())
+	if err != nil {
+		return dto.TOTPEnrollment{}, err
+	}
+	mfa := user.MFA
+	mfa.PendingSecret = sealed
+	if err := s.repo.SetMFA(ctx, user.ID, mfa); err != nil {
+		return dto.TOTPEnrollment{}, err
+	}
+	return dto.TOTPEnrollment{
+		Secret:          secret,
+		ProvisioningURI: auth.TOTPProvisioningURI(s.opts.Issuer, user.Email, secret),
+	}, nil
+}
+
+func (s *MFAService) ConfirmTOTPEnrollment(ctx context.Context, userID, code string) (dto.RecoveryCodesResponse, error) {
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return dto.RecoveryCodesResponse{}, notFoundAs(err, "user_not_found", "usuario no encontrado")
+	}
+	if user.MFA.Enabled {
+		return dto.RecoveryCodesResponse{}, errMFAAlreadyEnabled
+	}
+	if user.MFA.PendingSecret == "" {
+		return dto.RecoveryCodesResponse{}, errMFAEnrollmentAbsent
+	}
+	secret, err := auth.OpenTOTPSecret(user.MFA.PendingSecret, user.ID.Hex())
+	if err != nil {
+		return dto.RecoveryCodesResponse{}, err
+	}
+	step, ok := auth.ValidateTOTP(secret, code, time.Now())
+	if !ok {
+		return dto.RecoveryCodesResponse{}, errMFACodeInvalid
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return dto.RecoveryCodesResponse{}, err
+	}
+	now := time.Now()
+	mfa := models.MFA{
+		Enabled:       true,
+		TOTPSecret:    user.MFA.PendingSecret,
+		LastStep:      step,
+		RecoveryCodes: hashes,
+		EnabledAt:     &now,
+	}
+	if err := s.repo.SetMFA(ctx, user.ID, mfa); err != nil {
+		return dto.RecoveryCodesResponse{}, err
+	}
+	return dto.RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+func (s *MFAService) RegenerateRecoveryCodes(ctx context.Context, userID, code string) (dto.RecoveryCodesResponse, error) {
+	user, err := s.enabledUser(ctx, userID)
+	if err != nil {
+		return dto.RecoveryCodesResponse{}, err
+	}
+	if err := s.checkTOTP(ctx, user, code); err != nil {
+		return dto.RecoveryCodesResponse{}, err
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return dto.RecoveryCodesResponse{}, err
+	}
+	// Se relee para no pisar el paso TOTP que acaba de registrar checkTOTP.
+	user, err = s.enabledUser(ctx, userID)
+	if err != nil {
+		return dto.RecoveryCodesResponse{}, err
+	}
+	mfa := user.MFA
+	mfa.RecoveryCodes = hashes
+	if err := s.repo.SetMFA(ctx, user.ID, mfa); err != nil {
+		return dto.RecoveryCodesResponse{}, err
+	}
+	return dto.RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+func (s *MFAService) DisableMFA(ctx context.Context, userID string, req dto.DisableMFARequest) error {
+	user, err := s.enabledUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if slices.Contains(s.opts.RequiredRoles, string(user.Role)) {
+		return errMFARequiredForRole
+	}
+	if !auth.CheckPasswordHash(req.Password, user.PasswordHash) {
+		return apperrors.Validation("wrong_password", "contraseña actual incorrecta")
+	}
+	if err := s.checkCode(ctx, user, req.Code, errMFACodeInvalid); err != nil {
+		return err
+	}
+	return s.repo.SetMFA(ctx, user.ID, models.MFA{})
+}
+
+func (s *MFAService) VerifyLoginCode(ctx context.Context, userID, code string) error {
+	user, err := s.enabledUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	return s.checkCode(ctx, user, code, errMFALoginCodeInvalid)
+}
+
+func (s *MFAService) enabledUser(ctx context.Context, userID string) (models.User, error) {
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return models.User{}, notFoundAs(err, "user_not_found", "usuario no encontrado")
+	}
+	if !user.MFA.Enabled {
+		return models.User{}, errMFANotEnabled
+	}
+	return user, nil
+}
+
+// checkCode acepta un código TOTP o, si no tiene forma de tal, uno de
+// recuperación. invalid es el error a devolver si no coincide.
+func (s *MFAService) checkCode(ctx context.Context, user models.User, code string, invalid error) error {
+	if auth.IsTOTPCode(code) {
+		if err := s.checkTOTP(ctx, user, code); err != nil {
+			if errors.Is(err, errMFACodeInvalid) {
+				return invalid
+			}
+			return err
+		}
+		return nil
+	}
+	ok, err := s.repo.ConsumeRecoveryCode(ctx, user.ID, auth.HashRecoveryCode(code))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return invalid
+	}
+	return nil
+}
+
+// checkTOTP valida el código y registra su paso, para que no pueda usarse otra
+// vez mientras siga vigente.
+func (s *MFAService) checkTOTP(ctx context.Context, user models.User, code string) error {
+	secret, err := auth.OpenTOTPSecret(user.MFA.TOTPSecret, user.ID.Hex())
+	if err != nil {
+		return err
+	}
+	step, ok := auth.ValidateTOTP(secret, code, time.Now())
+	if !ok {
+		return errMFACodeInvalid
+	}
+	fresh, err := s.repo.UseTOTPStep(ctx, user.ID, step)
+	if err != nil {
+		return err
+	}
+	if !fresh {
+		return errMFACodeInvalid
+	}
+	return nil
+}
+
+// newRecoveryCodes devuelve los códigos en claro para el usuario y sus hashes
+// para guardar.
+func newRecoveryCodes() ([]string, []string, error) {
+	codes, err := auth.NewRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, nil, err
+	}
+	hashes := make([]string, len(codes))
+	for i, c := range codes {
+		hashes[i] = auth.HashRecoveryCode(c)
+	}
+	return codes, hashes, nil
+}
@@ -0,0 +1,157 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"backend/auth"
+	"backend/dto"
+	"backend/models"
+	"backend/repositories/memory"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// enrolledUser crea un usuario con segundo factor activo y devuelve su secreto
+// y los códigos de recuperación.
+func enrolledUser(t *testing.T, svc *MFAService, users *memory.UserRepository, role models.Role) (models.User, string, []string) {
+	t.Helper()
+	hash, err := auth.HashPassword("secret123")
+	if err != nil {
+		t.Fatalf("hash: %v", err)
+	}
+	user := models.User{ID: primitive.NewObjectID(), Name: "Alice", Email: "alice@example.com", PasswordHash: hash, Role: role}
+	if _, err := users.CreateUser(context.Background(), user); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+
+	enrollment, err := svc.StartTOTPEnrollment(context.Background(), user.ID.Hex())
+	if err != nil {
+		t.Fatalf("start enrollment: %v", err)
+	}
+	code, err := auth.TOTPCode(enrollment.Secret, time.Now())
+	if err != nil {
+		t.Fatalf("totp code: %v", err)
+	}
+	recovery, err := svc.ConfirmTOTPEnrollment(context.Background(), user.ID.Hex(), code)
+	if err != nil {
+		t.Fatalf("confirm enrollment: %v", err)
+	}
+	return user, enrollment.Secret, recovery.RecoveryCodes
+}
+
+func TestMFA_EnrollmentStoresSealedSecret(t *testing.T) {
+	users := memory.NewUserRepository()
+	svc := NewMFAService(users, MFAOptions{Issuer: "Fitness"})
+	user, secret, codes := enrolledUser(t, svc, users, models.RoleUser)
+
+	if len(codes) != recoveryCodeCount {
+		t.Fatalf("recovery codes = %d, want %d", len(codes), recoveryCodeCount)
+	}
+	stored, _ := users.GetUserByID(context.Background(), user.ID.Hex())
+	if !stored.MFA.Enabled || stored.MFA.PendingSecret != "" {
+		t.Fatalf("mfa = %+v, want enabled without pending secret", stored.MFA)
+	}
+	if stored.MFA.TOTPSecret == secret {
+		t.Fatal("TOTP secret stored in plain text")
+	}
+	for _, c := range codes {
+		for _, h := range stored.MFA.RecoveryCodes {
+			if h == c {
+				t.Fatal("recovery code stored in plain text")
+			}
+		}
+	}
+
+	if _, err := svc.StartTOTPEnrollment(context.Background(), user.ID.Hex()); !errors.Is(err, errMFAAlreadyEnabled) {
+		t.Fatalf("second enrollment err = %v, want errMFAAlreadyEnabled", err)
+	}
+}
+
+func TestMFA_ConfirmRequiresValidCode(t *testing.T) {
+	users := memory.NewUserRepository()
+	svc := NewMFAService(users, MFAOptions{Issuer: "Fitness"})
+	user := models.User{ID: primitive.NewObjectID(), Email: "bob@example.com"}
+	if _, err := users.CreateUser(context.Background(), user); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+
+	if _, err := svc.ConfirmTOTPEnrollment(context.Background(), user.ID.Hex(), "123456"); !errors.Is(err, errMFAEnrollmentAbsent) {
+		t.Fatalf("err = %v, want errMFAEnrollmentAbsent", err)
+	}
+	if _, err := svc.StartTOTPEnrollment(context.Background(), user.ID.Hex()); err != nil {
+		t.Fatalf("start enrollment: %v", err)
+	}
+	if _, err := svc.ConfirmTOTPEnrollment(context.Background(), user.ID.Hex(), "000000"); !errors.Is(err, errMFACodeInvalid) {
+		t.Fatalf("err = %v, want errMFACodeInvalid", err)
+	}
+}
+
+func TestMFA_LoginCodeCannotBeReplayed(t *testing.T) {
+	users := memory.NewUserRepository()
+	svc := NewMFAService(users, MFAOptions{Issuer: "Fitness"})
+	user, secret, _ := enrolledUser(t, svc, users, models.RoleUser)
+
+	// El código del enrolamiento ya quedó registrado.
+	stored, _ := users.GetUserByID(context.Background(), user.ID.Hex())
+	used, _ := auth.TOTPCode(secret, time.Unix(stored.MFA.LastStep*30, 0))
+	if err := svc.VerifyLoginCode(context.Background(), user.ID.Hex(), used); !errors.Is(err, errMFALoginCodeInvalid) {
+		t.Fatalf("replayed code err = %v, want errMFALoginCodeInvalid", err)
+	}
+
+	next, _ := auth.TOTPCode(secret, time.Unix((stored.MFA.LastStep+1)*30, 0))
+	if err := svc.VerifyLoginCode(context.Background(), user.ID.Hex(), next); err != nil {
+		t.Fatalf("next code: %v", err)
+	}
+	if err := svc.VerifyLoginCode(context.Background(), user.ID.Hex(), next); !errors.Is(err, errMFALoginCodeInvalid) {
+		t.Fatalf("second use err = %v, want errMFALoginCodeInvalid", err)
+	}
+}
+
+func TestMFA_RecoveryCodesAreSingleUse(t *testing.T) {
+	users := memory.NewUserRepository()
+	svc := NewMFAService(users, MFAOptions{Issuer: "Fitness"})
+	user, _, codes := enrolledUser(t, svc, users, models.RoleUser)
+
+	if err := svc.VerifyLoginCode(context.Background(), user.ID.Hex(), codes[0]); err != nil {
+		t.Fatalf("recovery code: %v", err)
+	}
+	if err := svc.VerifyLoginCode(context.Background(), user.ID.Hex(), codes[0]); !errors.Is(err, errMFALoginCodeInvalid) {
+		t.Fatalf("reused recovery code err = %v, want errMFALoginCodeInvalid", err)
+	}
+	stored, _ := users.GetUserByID(context.Background(), user.ID.Hex())
+	if len(stored.MFA.RecoveryCodes) != recoveryCodeCount-1 {
+		t.Fatalf("remaining codes = %d, want %d", len(stored.MFA.RecoveryCodes), recoveryCodeCount-1)
+	}
+}
+
+func TestMFA_DisableBlockedForRequiredRoles(t *testing.T) {
+	users := memory.NewUserRepository()
+	svc := NewMFAService(users, MFAOptions{Issuer: "Fitness", RequiredRoles: []string{string(models.RoleAdmin)}})
+	user, _, codes := enrolledUser(t, svc, users, models.RoleAdmin)
+
+	err := svc.DisableMFA(context.Background(), user.ID.Hex(), dto.DisableMFARequest{Password: "secret123", Code: codes[0]})
+	if !errors.Is(err, errMFARequiredForRole) {
+		t.Fatalf("err = %v, want errMFARequiredForRole", err)
+	}
+}
+
+func TestMFA_DisableRequiresPasswordAndCode(t *testing.T) {
+	users := memory.NewUserRepository()
+	svc := NewMFAService(users, MFAOptions{Issuer: "Fitness", RequiredRoles: []string{string(models.RoleAdmin)}})
+	user, _, codes := enrolledUser(t, svc, users, models.RoleUser)
+
+	err := svc.DisableMFA(context.Background(), user.ID.Hex(), dto.DisableMFARequest{Password: "wrong", Code: codes[0]})
+	if err == nil {
+		t.Fatal("disabled with wrong password")
+	}
+	if err := svc.DisableMFA(context.Background(), user.ID.Hex(), dto.DisableMFARequest{Password: "secret123", Code: codes[0]}); err != nil {
+		t.Fatalf("disable: %v", err)
+	}
+	stored, _ := users.GetUserByID(context.Background(), user.ID.Hex())
+	if stored.MFA.Enabled || stored.MFA.TOTPSecret != "" || len(stored.MFA.RecoveryCodes) != 0 {
+		t.Fatalf("mfa = %+v, want cleared", stored.MFA)
+	}
+}
@@ -0,0 +1,320 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"backend/apperrors"
+	"backend/auth"
+	"backend/dto"
+	"backend/models"
+	"backend/oidc"
+	"backend/repositories"
+	"backend/utils"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// OIDCServiceInterface es el login con proveedores OpenID Connect. La cuenta
+// externa se vincula al usuario con el mismo email verificado, o se crea un
+// usuario sin contraseña si no hay ninguno.
+type OIDCServiceInterface interface {
+	// Providers devuelve los nombres de los proveedores configurados.
+	Providers() []string
+	// Start genera state, nonce y verifier PKCE y devuelve la URL del
+	// proveedor.
+	Start(ctx context.Context, provider string) (dto.OIDCStartResponse, error)
+	// Callback canjea el código y devuelve el usuario que inicia sesión.
+	Callback(ctx context.Context, req dto.OIDCCallbackRequest) (dto.User, error)
+	ListIdentities(ctx context.Context, userID string) ([]dto.LinkedIdentity, error)
+	// Unlink desvincula una identidad, salvo que sea la única forma de entrar
+	// de un usuario sin contraseña.
+	Unlink(ctx context.Context, userID, identityID string) error
+}
+
+type OIDCOptions struct {
+	// StateTTL es el tiempo para completar el login en el proveedor.
+	StateTTL time.Duration
+}
+
+type OIDCService struct {
+	providers   map[string]*oidc.Provider
+	names       []string
+	users       repositories.UserRepositoryInterface
+	states      repositories.OIDCStateRepositoryInterface
+	identities  repositories.LinkedIdentityRepositoryInterface
+	refreshRepo repositories.RefreshTokenRepositoryInterface
+	revocations TokenRevocationServiceInterface
+	opts        OIDCOptions
+}
+
+func NewOIDCService(providers []*oidc.Provider, users repositories.UserRepositoryInterface, states repositories.OIDCStateRepositoryInterface, identities repositories.LinkedIdentityRepositoryInterface, refreshRepo repositories.RefreshTokenRepositoryInterface, revocations TokenRevocationServiceInterface, opts OIDCOptions) *OIDCService {
+	s := &OIDCService{
+		providers:   make(map[string]*oidc.Provider, len(providers)),
+		users:       users,
+		states:      states,
+		identities:  identities,
+		refreshRepo: refreshRepo,
+		revocations: revocations,
+		opts:        opts,
+	}
+	for _, p := range providers {
+		s.providers[p.Name()] = p
+		s.names = append(s.names, p.Name())
+	}
+	return s
+}
+
+var (
+	errOIDCProviderUnknown  = apperrors.NotFound("oidc_provider_unknown", "proveedor de login desconocido")
+	errOIDCStateInvalid     = apperrors.Validation("oidc_state_invalid", "login externo inválido o vencido, vuelva a intentarlo")
+	errOIDCLoginFailed      = apperrors.Unauthorized("oidc_login_failed", "el proveedor no confirmó el login")
+	errOIDCEmailNotVerified = apperrors.Forbidden("oidc_email_not_verified", "el proveedor no confirma que el email esté verificado")
+	errOIDCIdentityNotFound = apperrors.NotFound("oidc_identity_not_found", "identidad vinculada no encontrada")
+	errOIDCLastLoginMethod  = apperrors.Conflict("oidc_last_login_method", "es la única forma de iniciar sesión de la cuenta; defina una contraseña antes de desvincularla")
+)
+
+func (s *OIDCService) Providers() []string {
+	return append([]string{}, s.names...)
+}
+
+func (s *OIDCService) Start(ctx context.Context, provider string) (dto.OIDCStartResponse, error) {
+	p, ok := s.providers[provider]
+	if !ok {
+		return dto.OIDCStartResponse{}, errOIDCProviderUnknown
+	}
+
+	var values [3]string
+	for i := range values {
+		v, err := oidc.NewRandom()
+		if err != nil {
+			return dto.OIDCStartResponse{}, err
+		}
+		values[i] = v
+	}
+	state, nonce, verifier := values[0], values[1], values[2]
+
+	authURL, err := p.AuthCodeURL(ctx, state, nonce, verifier)
+	if err != nil {
+		return dto.OIDCStartResponse{}, err
+	}
+	now := time.Now()
+	err = s.states.Create(ctx, models.OIDCState{
+		StateHash:    auth.HashOpaqueToken(state),
+		Provider:     provider,
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		ExpiresAt:    now.Add(s.opts.StateTTL),
+		CreatedAt:    now,
+	})
+	if err != nil {
+		return dto.OIDCStartResponse{}, err
+	}
+	return dto.OIDCStartResponse{AuthorizationURL: authURL, State: state}, nil
+}
+
+func (s *OIDCService) Callback(ctx context.Context, req dto.OIDCCallbackRequest) (dto.User, error) {
+	if req.Code == "" || req.State == "" {
+		return dto.User{}, errOIDCStateInvalid
+	}
+	now := time.Now()
+	state, err := s.states.Consume(ctx, auth.HashOpaqueToken(req.State), now)
+	if errors.Is(err, apperrors.ErrNotFound) {
+		return dto.User{}, errOIDCStateInvalid
+	}
+	if err != nil {
+		return dto.User{}, err
+	}
+	p, ok := s.providers[state.Provider]
+	if !ok {
+		return dto.User{}, errOIDCProviderUnknown
+	}
+
+	claims, err := p.Authenticate(ctx, req.Code, state.CodeVerifier, state.Nonce)
+	if errors.Is(err, oidc.ErrExchange) || errors.Is(err, oidc.ErrInvalidIDToken) {
+		log.Printf("security: event=oidc_login_failed provider=%q err=%q", state.Provider, err)
+		return dto.User{}, errOIDCLoginFailed
+	}
+	if err != nil {
+		return dto.User{}, err
+	}
+
+	user, err := s.resolveUser(ctx, state.Provider, claims, now)
+	if err != nil {
+		return dto.User{}, err
+	}
+	userdto := modelUserToDTO(user)
+	userdto.PasswordHash = ""
+	return userdto, nil
+}
+
+// resolveUser devuelve el usuario de la identidad, vinculándola o creando el
+// usuario si es la primera vez que entra.
+func (s *OIDCService) resolveUser(ctx context.Context, provider string, claims oidc.Claims, now time.Time) (models.User, error) {
+	identity, err := s.identities.GetByProviderSubject(ctx, provider, claims.Subject)
+	switch {
+	case err == nil:
+		user, err := s.users.GetUserByID(ctx, identity.UserID.Hex())
+		if err == nil {
+			return user, s.identities.TouchLogin(ctx, identity.ID, now)
+		}
+		if !errors.Is(err, apperrors.ErrNotFound) {
+			return models.User{}, err
+		}
+		// El usuario se borró: la identidad quedó huérfana y se vuelve a
+		// vincular como si fuera nueva.
+		if err := s.identities.Delete(ctx, identity.UserID, identity.ID); err != nil && !errors.Is(err, apperrors.ErrNotFound) {
+			return models.User{}, err
+		}
+	case !errors.Is(err, apperrors.ErrNotFound):
+		return models.User{}, err
+	}
+
+	// Sin email verificado por el proveedor no hay forma segura de saber a
+	// qué cuenta corresponde.
+	email := utils.NormalizeEmail(claims.Email)
+	if !claims.EmailVerified || !isValidEmail(email) {
+		return models.User{}, errOIDCEmailNotVerified
+	}
+
+	user, err := s.users.GetUserByEmail(ctx, email)
+	switch {
+	case err == nil:
+		if err := s.claimUnverifiedAccount(ctx, &user, provider, now); err != nil {
+			return models.User{}, err
+		}
+	case errors.Is(err, apperrors.ErrNotFound):
+		if user, err = s.createUser(ctx, email, claims.Name, now); err != nil {
+			return models.User{}, err
+		}
+	default:
+		return models.User{}, err
+	}
+
+	err = s.identities.Create(ctx, models.LinkedIdentity{
+		UserID:      user.ID,
+		Provider:    provider,
+		Subject:     claims.Subject,
+		Email:       email,
+		CreatedAt:   now,
+		LastLoginAt: &now,
+	})
+	// Dos callbacks simultáneos de la misma cuenta: el otro ya la vinculó.
+	if err != nil && !errors.Is(err, apperrors.ErrConflict) {
+		return models.User{}, err
+	}
+	log.Printf("security: event=oidc_identity_linked user=%s provider=%q", user.ID.Hex(), provider)
+	return user, nil
+}
+
+// claimUnverifiedAccount se aplica al vincular una cuenta local cuyo email
+// nunca se verificó. Quien la registró no probó ser el dueño del email (pudo
+// crearla para esperar al verdadero), así que se descartan su contraseña y
+// sus sesiones y el email queda verificado por el proveedor.
+func (s *OIDCService) claimUnverifiedAccount(ctx context.Context, user *models.User, provider string, now time.Time) error {
+	if user.EmailVerified {
+		return nil
+	}
+	if err := s.users.SetPasswordHash(ctx, user.ID, "", now); err != nil {
+		return err
+	}
+	if err := s.users.MarkEmailVerified(ctx, user.ID, user.Email, now); err != nil {
+		return err
+	}
+	user.PasswordHash = ""
+	user.EmailVerified = true
+	user.EmailVerifiedAt = &now
+	user.UpdatedAt = now
+	if _, err := s.refreshRepo.RevokeAllForUser(ctx, user.ID); err != nil {
+		return err
+	}
+	if err := s.revocations.RevokeAllForUser(ctx, user.ID.Hex()); err != nil {
+		return err
+	}
+	log.Printf("security: event=oidc_unverified_account_claimed user=%s provider=%q", user.ID.Hex(), provider)
+	return nil
+}
+
+// createUser registra un usuario sin contraseña: entra con el proveedor y
+// puede definir una con el flujo de recuperación.
+func (s *OIDCService) createUser(ctx context.Context, email, name string, now time.Time) (models.User, error) {
+	if name == "" {
+		name, _, _ = strings.Cut(email, "@")
+	}
+	user := models.User{
+		ID:              primitive.NewObjectID(),
+		Name:            name,
+		Email:           email,
+		Role:            models.RoleUser,
+		EmailVerified:   true,
+		EmailVerifiedAt: &now,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+	if _, err := s.users.CreateUser(ctx, user); err != nil {
+		// Se registró con el mismo email mientras tanto.
+		if errors.Is(err, apperrors.ErrConflict) {
+			return models.User{}, errEmailTaken
+		}
+		return models.User{}, err
+	}
+	return user, nil
+}
+
+func (s *OIDCService) ListIdentities(ctx context.Context, userID string) ([]dto.LinkedIdentity, error) {
+	id, err := utils.ParseObjectID(userID)
+	if err != nil {
+		return nil, err
+	}
+	identities, err := s.identities.ListForUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]dto.LinkedIdentity, 0, len(identities))
+	for _, identity := range identities {
+		out = append(out, dto.LinkedIdentity{
+			ID:          identity.ID.Hex(),
+			Provider:    identity.Provider,
+			Email:       identity.Email,
+			CreatedAt:   identity.CreatedAt,
+			LastLoginAt: identity.LastLoginAt,
+		})
+	}
+	return out, nil
+}
+
+func (s *OIDCService) Unlink(ctx context.Context, userID, identityID string) error {
+	uid, err := utils.ParseObjectID(userID)
+	if err != nil {
+		return err
+	}
+	iid, err := utils.ParseObjectID(identityID)
+	if err != nil {
+		return err
+	}
+	user, err := s.users.GetUserByID(ctx, userID)
+	if err != nil {
+		return notFoundAs(err, "user_not_found", "usuario no encontrado")
+	}
+	identities, err := s.identities.ListForUser(ctx, uid)
+	if err != nil {
+		return err
+	}
+	found := false
+	for _, identity := range identities {
+		if identity.ID == iid {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return errOIDCIdentityNotFound
+	}
+	if user.PasswordHash == "" && len(identities) == 1 {
+		return errOIDCLastLoginMethod
+	}
+	return notFoundAs(s.identities.Delete(ctx, uid, iid), "oidc_identity_not_found", "identidad vinculada no encontrada")
+}
@@ -0,0 +1,196 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"backend/auth"
+	"backend/dto"
+	"backend/models"
+	"backend/oidc"
+	"backend/oidc/oidctest"
+	"backend/repositories/memory"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type oidcFixture struct {
+	svc         *OIDCService
+	mock        *oidctest.Provider
+	users       *memory.UserRepository
+	refreshRepo *memory.RefreshTokenRepository
+}
+
+func newOIDCFixture(t *testing.T) oidcFixture {
+	t.Helper()
+	srv, mock, err := oidctest.NewServer("fitness", "secret")
+	if err != nil {
+		t.Fatalf("mock provider: %v", err)
+	}
+	t.Cleanup(srv.Close)
+	provider := oidc.NewProvider(oidc.Config{
+		Name:         "mock",
+		Issuer:       mock.Issuer,
+		ClientID:     "fitness",
+		ClientSecret: "secret",
+		RedirectURL:  "http://localhost:3000/oidc/callback",
+	}, srv.Client())
+
+	users := memory.NewUserRepository()
+	refreshRepo := memory.NewRefreshTokenRepository()
+	revocations := NewTokenRevocationService(memory.NewTokenRevocationRepository(), 0)
+	svc := NewOIDCService([]*oidc.Provider{provider}, users, memory.NewOIDCStateRepository(), memory.NewLinkedIdentityRepository(), refreshRepo, revocations, OIDCOptions{StateTTL: time.Minute})
+	return oidcFixture{svc: svc, mock: mock, users: users, refreshRepo: refreshRepo}
+}
+
+// login hace el recorrido completo como el frontend: start, redirección al
+// proveedor y callback.
+func (f oidcFixture) login(t *testing.T) (dto.User, error) {
+	t.Helper()
+	start, err := f.svc.Start(context.Background(), "mock")
+	if err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	code, state, err := oidctest.Authorize(start.AuthorizationURL)
+	if err != nil {
+		t.Fatalf("authorize: %v", err)
+	}
+	if state != start.State {
+		t.Fatalf("state = %q, want %q", state, start.State)
+	}
+	return f.svc.Callback(context.Background(), dto.OIDCCallbackRequest{Code: code, State: state})
+}
+
+func TestOIDC_FirstLoginCreatesUserWithoutPassword(t *testing.T) {
+	f := newOIDCFixture(t)
+	f.mock.SetIdentity(oidctest.Identity{Subject: "sub-1", Email: "Ana@Example.com", EmailVerified: true, Name: "Ana"})
+
+	user, err := f.login(t)
+	if err != nil {
+		t.Fatalf("first login: %v", err)
+	}
+	if user.Email != "ana@example.com" || !user.EmailVerified || user.Role != string(models.RoleUser) {
+		t.Fatalf("user = %+v, want a verified user with normalized email", user)
+	}
+	stored, _ := f.users.GetUserByID(context.Background(), user.ID.Hex())
+	if stored.PasswordHash != "" {
+		t.Fatal("federated user created with a password")
+	}
+
+	again, err := f.login(t)
+	if err != nil {
+		t.Fatalf("second login: %v", err)
+	}
+	if again.ID != user.ID {
+		t.Fatalf("second login user = %s, want %s", again.ID.Hex(), user.ID.Hex())
+	}
+	identities, _ := f.svc.ListIdentities(context.Background(), user.ID.Hex())
+	if len(identities) != 1 || identities[0].Provider != "mock" || identities[0].LastLoginAt == nil {
+		t.Fatalf("identities = %+v, want one mock identity", identities)
+	}
+}
+
+func TestOIDC_LinksVerifiedAccountByEmail(t *testing.T) {
+	f := newOIDCFixture(t)
+	hash, _ := auth.HashPassword("secret123")
+	existing := models.User{ID: primitive.NewObjectID(), Email: "bob@example.com", PasswordHash: hash, Role: models.RoleUser, EmailVerified: true}
+	if _, err := f.users.CreateUser(context.Background(), existing); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	f.mock.SetIdentity(oidctest.Identity{Subject: "sub-2", Email: "bob@example.com", EmailVerified: true})
+
+	user, err := f.login(t)
+	if err != nil {
+		t.Fatalf("login: %v", err)
+	}
+	if user.ID != existing.ID {
+		t.Fatalf("logged in as %s, want existing user %s", user.ID.Hex(), existing.ID.Hex())
+	}
+	stored, _ := f.users.GetUserByID(context.Background(), existing.ID.Hex())
+	if stored.PasswordHash != hash {
+		t.Fatal("linking a verified account must keep its password")
+	}
+}
+
+func TestOIDC_ClaimsUnverifiedAccount(t *testing.T) {
+	f := newOIDCFixture(t)
+	hash, _ := auth.HashPassword("attacker")
+	squatted := models.User{ID: primitive.NewObjectID(), Email: "carla@example.com", PasswordHash: hash, Role: models.RoleUser}
+	if _, err := f.users.CreateUser(context.Background(), squatted); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	if _, err := f.refreshRepo.Save(context.Background(), models.RefreshToken{UserID: squatted.ID, TokenHash: "h", ExpiresAt: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatalf("save refresh: %v", err)
+	}
+	f.mock.SetIdentity(oidctest.Identity{Subject: "sub-3", Email: "carla@example.com", EmailVerified: true})
+
+	if _, err := f.login(t); err != nil {
+		t.Fatalf("login: %v", err)
+	}
+	stored, _ := f.users.GetUserByID(context.Background(), squatted.ID.Hex())
+	if stored.PasswordHash != "" || !stored.EmailVerified {
+		t.Fatalf("user = %+v, want password removed and email verified", stored)
+	}
+	if active, _ := f.refreshRepo.ListActiveForUser(context.Background(), squatted.ID); len(active) != 0 {
+		t.Fatalf("active sessions = %d, want 0", len(active))
+	}
+}
+
+func TestOIDC_RejectsUnverifiedEmail(t *testing.T) {
+	f := newOIDCFixture(t)
+	f.mock.SetIdentity(oidctest.Identity{Subject: "sub-4", Email: "dan@example.com", EmailVerified: false})
+
+	if _, err := f.login(t); !errors.Is(err, errOIDCEmailNotVerified) {
+		t.Fatalf("err = %v, want errOIDCEmailNotVerified", err)
+	}
+	if _, err := f.users.GetUserByEmail(context.Background(), "dan@example.com"); err == nil {
+		t.Fatal("user created from an unverified email")
+	}
+}
+
+func TestOIDC_StateIsSingleUse(t *testing.T) {
+	f := newOIDCFixture(t)
+	start, err := f.svc.Start(context.Background(), "mock")
+	if err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	code, state, err := oidctest.Authorize(start.AuthorizationURL)
+	if err != nil {
+		t.Fatalf("authorize: %v", err)
+	}
+	if _, err := f.svc.Callback(context.Background(), dto.OIDCCallbackRequest{Code: code, State: state}); err != nil {
+		t.Fatalf("callback: %v", err)
+	}
+	if _, err := f.svc.Callback(context.Background(), dto.OIDCCallbackRequest{Code: code, State: state}); !errors.Is(err, errOIDCStateInvalid) {
+		t.Fatalf("replayed callback err = %v, want errOIDCStateInvalid", err)
+	}
+	if _, err := f.svc.Start(context.Background(), "other"); !errors.Is(err, errOIDCProviderUnknown) {
+		t.Fatalf("unknown provider err = %v, want errOIDCProviderUnknown", err)
+	}
+}
+
+func TestOIDC_UnlinkKeepsALoginMethod(t *testing.T) {
+	f := newOIDCFixture(t)
+	user, err := f.login(t)
+	if err != nil {
+		t.Fatalf("login: %v", err)
+	}
+	identities, _ := f.svc.ListIdentities(context.Background(), user.ID.Hex())
+
+	if err := f.svc.Unlink(context.Background(), user.ID.Hex(), identities[0].ID); !errors.Is(err, errOIDCLastLoginMethod) {
+		t.Fatalf("err = %v, want errOIDCLastLoginMethod", err)
+	}
+
+	hash, _ := auth.HashPassword("secret123")
+	if err := f.users.SetPasswordHash(context.Background(), user.ID, hash, time.Now()); err != nil {
+		t.Fatalf("update user: %v", err)
+	}
+	if err := f.svc.Unlink(context.Background(), user.ID.Hex(), identities[0].ID); err != nil {
+		t.Fatalf("unlink with password: %v", err)
+	}
+	if left, _ := f.svc.ListIdentities(context.Background(), user.ID.Hex()); len(left) != 0 {
+		t.Fatalf("identities = %+v, want none", left)
+	}
+}
@@ -0,0 +1,16 @@
+package services
+
+// defaultPerPage es el tamaño de página de los listados que no lo piden.
+const defaultPerPage = 20
+
+// pageBounds completa page y perPage con los valores por defecto y devuelve
+// cuántos resultados saltear.
+func pageBounds(page, perPage int) (int, int, int64) {
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 {
+		perPage = defaultPerPage
+	}
+	return page, perPage, int64(page-1) * int64(perPage)
+}
@@ -0,0 +1,176 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"backend/apperrors"
+	"backend/auth"
+	"backend/mail"
+	"backend/models"
+	"backend/repositories"
+	"backend/utils"
+)
+
+type PasswordResetServiceInterface interface {
+	// RequestReset envía un link si email pertenece a un usuario. Solo valida
+	// el formato: la búsqueda y el envío siguen en segundo plano y sus errores
+	// van al log, para que ni la respuesta ni su demora revelen qué emails
+	// están registrados.
+	RequestReset(ctx context.Context, email string) error
+	// ResetPassword cambia la contraseña con el token del link, cierra todas
+	// las sesiones del usuario y levanta el bloqueo por intentos fallidos.
+	ResetPassword(ctx context.Context, token, newPassword string) error
+}
+
+type PasswordResetOptions struct {
+	TokenTTL time.Duration
+	// MaxPerHour limita los correos de recuperación por email y hora.
+	MaxPerHour int
+	// LinkBaseURL es la página del frontend que recibe ?token= y llama a
+	// POST /auth/password/reset.
+	LinkBaseURL string
+}
+
+type PasswordResetService struct {
+	users       repositories.UserRepositoryInterface
+	resets      repositories.PasswordResetRepositoryInterface
+	refreshRepo repositories.RefreshTokenRepositoryInterface
+	revocations TokenRevocationServiceInterface
+	throttle    LoginThrottleServiceInterface
+	mailer      mail.Mailer
+	opts        PasswordResetOptions
+
+	// pending son los envíos en curso; los tests esperan a que terminen.
+	pending sync.WaitGroup
+}
+
+func NewPasswordResetService(users repositories.UserRepositoryInterface, resets repositories.PasswordResetRepositoryInterface, refreshRepo repositories.RefreshTokenRepositoryInterface, revocations TokenRevocationServiceInterface, throttle LoginThrottleServiceInterface, mailer mail.Mailer, opts PasswordResetOptions) *PasswordResetService {
+	return &PasswordResetService{
+		users:       users,
+		resets:      resets,
+		refreshRepo: refreshRepo,
+		revocations: revocations,
+		throttle:    throttle,
+		mailer:      mailer,
+		opts:        opts,
+	}
+}
+
+var errResetTokenInvalid = apperrors.Validation("reset_token_invalid", "link de recuperación inválido, usado o vencido")
+
+func (s *PasswordResetService) RequestReset(ctx context.Context, email string) error {
+	email = utils.NormalizeEmail(email)
+	if !isValidEmail(email) {
+		return errInvalidEmail
+	}
+
+	// El envío no depende del pedido: sigue aunque el cliente se desconecte.
+	ctx = context.WithoutCancel(ctx)
+	s.pending.Add(1)
+	go func() {
+		defer s.pending.Done()
+		if err := s.sendReset(ctx, email); err != nil {
+			log.Printf("no se pudo enviar el link de recuperación: %v", err)
+		}
+	}()
+	return nil
+}
+
+// sendReset genera el token y envía el link si email es de un usuario y no
+// superó el límite por hora.
+func (s *PasswordResetService) sendReset(ctx context.Context, email string) error {
+	user, err := s.users.GetUserByEmail(ctx, email)
+	if errors.Is(err, apperrors.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if s.opts.MaxPerHour > 0 {
+		sent, err := s.resets.CountSince(ctx, email, now.Add(-time.Hour))
+		if err != nil {
+			return err
+		}
+		if sent >= int64(s.opts.MaxPerHour) {
+			return nil
+		}
+	}
+
+	token, err := auth.NewOpaqueToken()
+	if err != nil {
+		return err
+	}
+	err = s.resets.Create(ctx, models.PasswordReset{
+		UserID:    user.ID,
+		Email:     user.Email,
+		TokenHash: auth.HashOpaqueToken(token),
+		ExpiresAt: now.Add(s.opts.TokenTTL),
+		CreatedAt: now,
+	})
+	if err != nil {
+		return err
+	}
+	msg := mail.PasswordResetMessage(user.Email, user.Language, tokenLink(s.opts.LinkBaseURL, token), s.opts.TokenTTL)
+	return s.mailer.Send(ctx, msg)
+}
+
+func (s *PasswordResetService) ResetPassword(ctx context.Context, token, newPassword string) error {
+	if token == "" {
+		return errResetTokenInvalid
+	}
+	// Antes de consumir el token: una contraseña rechazada no gasta el link.
+	if err := validatePassword(newPassword); err != nil {
+		return err
+	}
+	now := time.Now()
+	reset, err := s.resets.Consume(ctx, auth.HashOpaqueToken(token), now)
+	if errors.Is(err, apperrors.ErrNotFound) {
+		return errResetTokenInvalid
+	}
+	if err != nil {
+		return err
+	}
+
+	user, err := s.users.GetUserByID(ctx, reset.UserID.Hex())
+	if errors.Is(err, apperrors.ErrNotFound) {
+		return errResetTokenInvalid
+	}
+	if err != nil {
+		return err
+	}
+	// El link se envió a otra dirección: el usuario cambió el email después.
+	if user.Email != reset.Email {
+		return errResetTokenInvalid
+	}
+
+	hash, err := auth.HashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+	if err := s.users.SetPasswordHash(ctx, user.ID, string(hash), now); err != nil {
+		return err
+	}
+	// Usar el link prueba que el usuario recibe correo en esa dirección.
+	if err := s.users.MarkEmailVerified(ctx, user.ID, reset.Email, now); err != nil {
+		return err
+	}
+
+	if err := s.resets.InvalidateForUser(ctx, user.ID, now); err != nil {
+		return err
+	}
+	if _, err := s.refreshRepo.RevokeAllForUser(ctx, user.ID); err != nil {
+		return err
+	}
+	if err := s.revocations.RevokeAllForUser(ctx, user.ID.Hex()); err != nil {
+		return err
+	}
+	// Quien recibe el correo es el dueño: la cuenta no sigue bloqueada por los
+	// intentos de otro.
+	return s.throttle.RecordSuccess(ctx, user.Email, "")
+}
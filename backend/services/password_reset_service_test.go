@@ -0,0 +1,141 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"backend/auth"
+	"backend/mail"
+	"backend/models"
+	"backend/repositories/memory"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type resetFixture struct {
+	svc         *PasswordResetService
+	users       *memory.UserRepository
+	refresh     *memory.RefreshTokenRepository
+	revocations *mockRevocations
+	throttle    *LoginThrottleService
+	outbox      *mail.Outbox
+	user        models.User
+}
+
+func newResetFixture(t *testing.T, opts PasswordResetOptions) resetFixture {
+	t.Helper()
+	f := resetFixture{
+		users:       memory.NewUserRepository(),
+		refresh:     memory.NewRefreshTokenRepository(),
+		revocations: &mockRevocations{},
+		outbox:      mail.NewOutbox("", "no-reply@example.com"),
+		user:        models.User{ID: primitive.NewObjectID(), Name: "Alice", Email: "alice@example.com", PasswordHash: "x"},
+	}
+	if _, err := f.users.CreateUser(context.Background(), f.user); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	if opts.TokenTTL == 0 {
+		opts.TokenTTL = 30 * time.Minute
+	}
+	opts.LinkBaseURL = "https://app.example.com/reset"
+	f.throttle = newTestThrottle(f.users, f.outbox)
+	f.svc = NewPasswordResetService(f.users, memory.NewPasswordResetRepository(), f.refresh, f.revocations, f.throttle, f.outbox, opts)
+	return f
+}
+
+func TestPasswordReset_ResetsAndRevokesSessions(t *testing.T) {
+	ctx := context.Background()
+	f := newResetFixture(t, PasswordResetOptions{})
+	if _, err := f.refresh.Save(ctx, models.RefreshToken{UserID: f.user.ID, TokenHash: "h", ExpiresAt: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatalf("save refresh: %v", err)
+	}
+
+	if err := f.svc.RequestReset(ctx, " Alice@Example.com "); err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	f.svc.pending.Wait()
+	token := tokenFromOutbox(t, f.outbox, f.user.Email)
+
+	if err := f.svc.ResetPassword(ctx, token, "nueva-clave"); err != nil {
+		t.Fatalf("reset: %v", err)
+	}
+	got, _ := f.users.GetUserByID(ctx, f.user.ID.Hex())
+	if !auth.CheckPasswordHash("nueva-clave", got.PasswordHash) {
+		t.Fatalf("expected the new password to be stored")
+	}
+	if !got.EmailVerified {
+		t.Fatalf("expected a used reset link to verify the email")
+	}
+	if active, _ := f.refresh.ListActiveForUser(ctx, f.user.ID); len(active) != 0 {
+		t.Fatalf("expected refresh tokens to be revoked, got %d", len(active))
+	}
+	if len(f.revocations.users) != 1 || f.revocations.users[0] != f.user.ID.Hex() {
+		t.Fatalf("expected access tokens to be revoked, got %v", f.revocations.users)
+	}
+	if err := f.svc.ResetPassword(ctx, token, "otra-clave"); !errors.Is(err, errResetTokenInvalid) {
+		t.Fatalf("expected a used token to be rejected, got %v", err)
+	}
+}
+
+func TestPasswordReset_UnknownEmailIsSilent(t *testing.T) {
+	f := newResetFixture(t, PasswordResetOptions{})
+
+	if err := f.svc.RequestReset(context.Background(), "nadie@example.com"); err != nil {
+		t.Fatalf("unknown emails must not return an error, got %v", err)
+	}
+	f.svc.pending.Wait()
+	if n := len(f.outbox.Messages()); n != 0 {
+		t.Fatalf("expected no messages, got %d", n)
+	}
+}
+
+func TestPasswordReset_RateLimitedPerEmail(t *testing.T) {
+	ctx := context.Background()
+	f := newResetFixture(t, PasswordResetOptions{MaxPerHour: 2})
+
+	for i := 0; i < 3; i++ {
+		if err := f.svc.RequestReset(ctx, f.user.Email); err != nil {
+			t.Fatalf("request %d: over the limit must look like success, got %v", i, err)
+		}
+		f.svc.pending.Wait()
+	}
+	if n := len(f.outbox.Messages()); n != 2 {
+		t.Fatalf("expected 2 messages, got %d", n)
+	}
+}
+
+func TestPasswordReset_ExpiredToken(t *testing.T) {
+	ctx := context.Background()
+	f := newResetFixture(t, PasswordResetOptions{TokenTTL: time.Nanosecond})
+
+	if err := f.svc.RequestReset(ctx, f.user.Email); err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	f.svc.pending.Wait()
+	token := tokenFromOutbox(t, f.outbox, f.user.Email)
+	time.Sleep(time.Millisecond)
+
+	if err := f.svc.ResetPassword(ctx, token, "nueva-clave"); !errors.Is(err, errResetTokenInvalid) {
+		t.Fatalf("expected an expired token to be rejected, got %v", err)
+	}
+}
+
+func TestPasswordReset_WeakPasswordKeepsToken(t *testing.T) {
+	ctx := context.Background()
+	f := newResetFixture(t, PasswordResetOptions{})
+
+	if err := f.svc.RequestReset(ctx, f.user.Email); err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	f.svc.pending.Wait()
+	token := tokenFromOutbox(t, f.outbox, f.user.Email)
+
+	if err := f.svc.ResetPassword(ctx, token, "abc"); !errors.Is(err, errPasswordInvalid) {
+		t.Fatalf("expected the password policy to apply, got %v", err)
+	}
+	if err := f.svc.ResetPassword(ctx, token, "nueva-clave"); err != nil {
+		t.Fatalf("a rejected password must not consume the link, got %v", err)
+	}
+}
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"backend/repositories"
+)
+
+// RateLimitPolicy es el límite de un grupo de rutas: Requests pedidos cada
+// Per, con ráfagas de hasta Requests. Cada usuario (o IP, sin sesión) tiene su
+// propio balde.
+type RateLimitPolicy struct {
+	// Name separa los baldes de cada política: un mismo usuario tiene uno por
+	// grupo de rutas.
+	Name     string
+	Requests int
+	Per      time.Duration
+	// RoleRequests reemplaza Requests para los usuarios con ese rol.
+	RoleRequests map[string]int
+}
+
+// RequestsFor devuelve el límite que corresponde a role.
+func (p RateLimitPolicy) RequestsFor(role string) int {
+	if n, ok := p.RoleRequests[role]; ok {
+		return n
+	}
+	return p.Requests
+}
+
+// RateLimitResult trae lo necesario para las cabeceras X-RateLimit-*.
+type RateLimitResult struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset es cuánto falta para que el balde esté lleno otra vez.
+	Reset time.Duration
+	// RetryAfter es cuánto falta para el próximo token si no se permitió.
+	RetryAfter time.Duration
+}
+
+type RateLimiterInterface interface {
+	// Allow consume un pedido de subject (por ejemplo "user:<id>" o
+	// "ip:<addr>") en la política.
+	Allow(ctx context.Context, policy RateLimitPolicy, subject, role string) (RateLimitResult, error)
+}
+
+type RateLimiter struct {
+	store repositories.RateLimitRepositoryInterface
+}
+
+func NewRateLimiter(store repositories.RateLimitRepositoryInterface) *RateLimiter {
+	return &RateLimiter{store: store}
+}
+
+func (l *RateLimiter) Allow(ctx context.Context, policy RateLimitPolicy, subject, role string) (RateLimitResult, error) {
+	limit := policy.RequestsFor(role)
+	bucket, err := l.store.Take(ctx, policy.Name+":"+subject, limit, policy.Per, time.Now())
+	if err != nil {
+		return RateLimitResult{}, err
+	}
+
+	perToken := policy.Per / time.Duration(limit)
+	res := RateLimitResult{
+		Allowed:   bucket.Allowed,
+		Limit:     limit,
+		Remaining: int(math.Floor(bucket.Tokens)),
+		Reset:     time.Duration((float64(limit) - bucket.Tokens) * float64(perToken)),
+	}
+	if !bucket.Allowed {
+		res.RetryAfter = time.Duration((1 - bucket.Tokens) * float64(perToken))
+	}
+	return res, nil
+}
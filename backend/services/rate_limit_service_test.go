@@ -0,0 +1,55 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"backend/repositories/memory"
+)
+
+func TestRateLimiter_DeniesAfterBurst(t *testing.T) {
+	ctx := context.Background()
+	limiter := NewRateLimiter(memory.NewRateLimitRepository())
+	policy := RateLimitPolicy{Name: "workouts", Requests: 2, Per: time.Minute}
+
+	for i := 0; i < 2; i++ {
+		res, err := limiter.Allow(ctx, policy, "user:1", "user")
+		if err != nil || !res.Allowed {
+			t.Fatalf("request %d: expected to be allowed, got %+v (%v)", i+1, res, err)
+		}
+		if res.Limit != 2 || res.Remaining != 1-i {
+			t.Fatalf("request %d: unexpected counters %+v", i+1, res)
+		}
+	}
+
+	res, err := limiter.Allow(ctx, policy, "user:1", "user")
+	if err != nil || res.Allowed {
+		t.Fatalf("expected the third request to be denied, got %+v (%v)", res, err)
+	}
+	if res.RetryAfter <= 0 || res.RetryAfter > 30*time.Second {
+		t.Fatalf("expected to retry within one token (30s), got %v", res.RetryAfter)
+	}
+
+	if res, _ := limiter.Allow(ctx, policy, "user:2", "user"); !res.Allowed {
+		t.Fatalf("each subject must have its own bucket")
+	}
+	if res, _ := limiter.Allow(ctx, RateLimitPolicy{Name: "api", Requests: 2, Per: time.Minute}, "user:1", "user"); !res.Allowed {
+		t.Fatalf("each policy must have its own bucket")
+	}
+}
+
+func TestRateLimiter_RoleOverride(t *testing.T) {
+	ctx := context.Background()
+	limiter := NewRateLimiter(memory.NewRateLimitRepository())
+	policy := RateLimitPolicy{Name: "api", Requests: 1, Per: time.Minute, RoleRequests: map[string]int{"admin": 5}}
+
+	for i := 0; i < 5; i++ {
+		if res, _ := limiter.Allow(ctx, policy, "user:admin", "admin"); !res.Allowed || res.Limit != 5 {
+			t.Fatalf("request %d: expected the admin limit to apply, got %+v", i+1, res)
+		}
+	}
+	if res, _ := limiter.Allow(ctx, policy, "user:admin", "admin"); res.Allowed {
+		t.Fatalf("expected the admin to be limited too")
+	}
+}
@@ -0,0 +1,171 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	"backend/apperrors"
+	"backend/dto"
+	"backend/models"
+	"backend/repositories"
+	"backend/utils"
+)
+
+// RBACServiceInterface resuelve los permisos de cada rol y permite cambiarlos
+// en tiempo de ejecución.
+type RBACServiceInterface interface {
+	// Permissions devuelve los permisos del rol; un rol desconocido no tiene
+	// ninguno.
+	Permissions(ctx context.Context, role string) ([]string, error)
+	ListRoles(ctx context.Context) ([]dto.RolePermissions, error)
+	// SetPermissions reemplaza los permisos del rol. actorID es el usuario
+	// que hace el cambio.
+	SetPermissions(ctx context.Context, actorID, role string, perms []string) (dto.RolePermissions, error)
+	// ResetPermissions vuelve el rol a los permisos de fábrica.
+	ResetPermissions(ctx context.Context, actorID, role string) (dto.RolePermissions, error)
+}
+
+// RBACService cachea la tabla de permisos en memoria. Los cambios hechos en
+// esta instancia se ven enseguida; los hechos en otra tardan hasta cacheTTL
+// en aplicarse acá.
+type RBACService struct {
+	repo     repositories.RolePermissionsRepositoryInterface
+	cacheTTL time.Duration
+
+	mu       sync.Mutex
+	stored   map[models.Role]models.RolePermissions
+	loadedAt time.Time
+}
+
+// NewRBACService crea el servicio; cacheTTL 0 desactiva el cache.
+func NewRBACService(repo repositories.RolePermissionsRepositoryInterface, cacheTTL time.Duration) *RBACService {
+	return &RBACService{repo: repo, cacheTTL: cacheTTL}
+}
+
+var (
+	errRoleNotFound      = apperrors.NotFound("role_not_found", "rol inexistente")
+	errPermissionInvalid = apperrors.Validation("permission_invalid", "permiso inexistente")
+	errRoleLockout       = apperrors.Conflict("role_lockout", "el rol admin no puede perder el permiso de administrar roles")
+)
+
+// table devuelve los permisos guardados, del cache si no venció.
+func (s *RBACService) table(ctx context.Context) (map[models.Role]models.RolePermissions, error) {
+	now := time.Now()
+	s.mu.Lock()
+	stored, loadedAt := s.stored, s.loadedAt
+	s.mu.Unlock()
+	if stored != nil && now.Sub(loadedAt) < s.cacheTTL {
+		return stored, nil
+	}
+
+	list, err := s.repo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	stored = make(map[models.Role]models.RolePermissions, len(list))
+	for _, perms := range list {
+		stored[perms.Role] = perms
+	}
+	s.mu.Lock()
+	s.stored, s.loadedAt = stored, now
+	s.mu.Unlock()
+	return stored, nil
+}
+
+// invalidate hace que el próximo pedido relea la tabla.
+func (s *RBACService) invalidate() {
+	s.mu.Lock()
+	s.stored = nil
+	s.mu.Unlock()
+}
+
+func (s *RBACService) Permissions(ctx context.Context, role string) ([]string, error) {
+	r := models.Role(role)
+	if !r.Valid() {
+		return []string{}, nil
+	}
+	stored, err := s.table(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return rolePermissionsToDTO(r, stored).Permissions, nil
+}
+
+func (s *RBACService) ListRoles(ctx context.Context) ([]dto.RolePermissions, error) {
+	stored, err := s.table(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]dto.RolePermissions, 0, len(models.Roles))
+	for _, role := range models.Roles {
+		out = append(out, rolePermissionsToDTO(role, stored))
+	}
+	return out, nil
+}
+
+func (s *RBACService) SetPermissions(ctx context.Context, actorID, role string, perms []string) (dto.RolePermissions, error) {
+	r := models.Role(role)
+	if !r.Valid() {
+		return dto.RolePermissions{}, errRoleNotFound
+	}
+	actor, err := utils.ParseObjectID(actorID)
+	if err != nil {
+		return dto.RolePermissions{}, err
+	}
+	normalized := make([]models.Permission, 0, len(perms))
+	for _, p := range perms {
+		perm := models.Permission(p)
+		if !perm.Valid() {
+			return dto.RolePermissions{}, apperrors.WithDetail(errPermissionInvalid, p)
+		}
+		normalized = append(normalized, perm)
+	}
+	slices.Sort(normalized)
+	normalized = slices.Compact(normalized)
+	// Sin este permiso en admin nadie podría volver a cambiar los roles.
+	if r == models.RoleAdmin && !slices.Contains(normalized, models.PermRoleManage) {
+		return dto.RolePermissions{}, errRoleLockout
+	}
+
+	saved := models.RolePermissions{Role: r, Permissions: normalized, UpdatedBy: actor, UpdatedAt: time.Now()}
+	if err := s.repo.Save(ctx, saved); err != nil {
+		return dto.RolePermissions{}, err
+	}
+	s.invalidate()
+	log.Printf("security: event=role_permissions_changed role=%s actor=%s permissions=%v", r, actorID, normalized)
+	return rolePermissionsToDTO(r, map[models.Role]models.RolePermissions{r: saved}), nil
+}
+
+func (s *RBACService) ResetPermissions(ctx context.Context, actorID, role string) (dto.RolePermissions, error) {
+	r := models.Role(role)
+	if !r.Valid() {
+		return dto.RolePermissions{}, errRoleNotFound
+	}
+	if err := s.repo.Delete(ctx, r); err != nil && !errors.Is(err, apperrors.ErrNotFound) {
+		return dto.RolePermissions{}, err
+	}
+	s.invalidate()
+	log.Printf("security: event=role_permissions_reset role=%s actor=%s", r, actorID)
+	return rolePermissionsToDTO(r, nil), nil
+}
+
+// rolePermissionsToDTO arma los permisos vigentes del rol: los guardados o,
+// si no hay, los de fábrica.
+func rolePermissionsToDTO(role models.Role, stored map[models.Role]models.RolePermissions) dto.RolePermissions {
+	out := dto.RolePermissions{Role: string(role), Default: true}
+	perms := models.DefaultRolePermissions[role]
+	if saved, ok := stored[role]; ok {
+		perms = saved.Permissions
+		out.Default = false
+		out.UpdatedAt = &saved.UpdatedAt
+	}
+	out.Permissions = make([]string, 0, len(perms))
+	for _, p := range perms {
+		out.Permissions = append(out.Permissions, string(p))
+	}
+	return out
+}
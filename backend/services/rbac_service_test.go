@@ -0,0 +1,72 @@
+package services
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"backend/apperrors"
+	"backend/models"
+	"backend/repositories/memory"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestRBAC_DefaultPermissions(t *testing.T) {
+	ctx := context.Background()
+	svc := NewRBACService(memory.NewRolePermissionsRepository(), time.Minute)
+
+	coach, err := svc.Permissions(ctx, "coach")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Contains(coach, string(models.PermExerciseModerate)) || slices.Contains(coach, string(models.PermUserManage)) {
+		t.Fatalf("unexpected coach permissions: %v", coach)
+	}
+	if perms, _ := svc.Permissions(ctx, "superuser"); len(perms) != 0 {
+		t.Fatalf("unknown roles must have no permissions, got %v", perms)
+	}
+}
+
+func TestRBAC_SetAndResetPermissions(t *testing.T) {
+	ctx := context.Background()
+	svc := NewRBACService(memory.NewRolePermissionsRepository(), time.Minute)
+	actor := primitive.NewObjectID().Hex()
+
+	// Se carga el cache antes del cambio: el cambio local debe verse igual.
+	if _, err := svc.Permissions(ctx, "user"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := svc.SetPermissions(ctx, actor, "user", []string{"exercise:write", "exercise:write"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	perms, _ := svc.Permissions(ctx, "user")
+	if !slices.Equal(perms, []string{"exercise:write"}) {
+		t.Fatalf("expected the new permissions, got %v", perms)
+	}
+
+	reset, err := svc.ResetPermissions(ctx, actor, "user")
+	if err != nil || !reset.Default {
+		t.Fatalf("expected default permissions after reset, got %+v, %v", reset, err)
+	}
+	if perms, _ := svc.Permissions(ctx, "user"); len(perms) != 0 {
+		t.Fatalf("expected no permissions after reset, got %v", perms)
+	}
+}
+
+func TestRBAC_SetPermissionsValidation(t *testing.T) {
+	ctx := context.Background()
+	svc := NewRBACService(memory.NewRolePermissionsRepository(), 0)
+	actor := primitive.NewObjectID().Hex()
+
+	if _, err := svc.SetPermissions(ctx, actor, "superuser", nil); apperrors.Code(err) != "role_not_found" {
+		t.Fatalf("expected role_not_found, got %v", err)
+	}
+	if _, err := svc.SetPermissions(ctx, actor, "coach", []string{"exercise:fly"}); apperrors.Code(err) != "permission_invalid" {
+		t.Fatalf("expected permission_invalid, got %v", err)
+	}
+	if _, err := svc.SetPermissions(ctx, actor, "admin", []string{"user:manage"}); apperrors.Code(err) != "role_lockout" {
+		t.Fatalf("expected role_lockout, got %v", err)
+	}
+}
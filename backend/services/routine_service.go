@@ -1,11 +1,13 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strings"
 	"time"
 
+	"backend/apperrors"
 	"backend/dto"
 	"backend/models"
 	"backend/repositories"
@@ -15,43 +17,68 @@ import (
 )
 
 type RoutineServiceInterface interface {
-	CreateRoutine(ownerID string, input dto.RoutineRequest) (dto.RoutineResponse, error)
-	GetRoutines(ownerID string, name string) ([]dto.RoutineResponse, error)
-	GetRoutineByID(id string) (dto.RoutineResponse, error)
-	UpdateRoutine(ownerID string, routineID string, input dto.RoutineRequest) (dto.RoutineResponse, error)
-	DeleteRoutine(ownerID string, routineID string) error
-	DuplicateRoutine(ownerID string, sourceRoutineID string, newName string) (string, error)
+	CreateRoutine(ctx context.Context, ownerID string, input dto.RoutineRequest) (dto.RoutineResponse, error)
+	GetRoutines(ctx context.Context, ownerID string, name string) ([]dto.RoutineResponse, error)
+	GetRoutineByID(ctx context.Context, id string) (dto.RoutineResponse, error)
+	UpdateRoutine(ctx context.Context, ownerID string, routineID string, input dto.RoutineRequest) (dto.RoutineResponse, error)
+	DeleteRoutine(ctx context.Context, ownerID string, routineID string) error
+	// DeleteAnyRoutine no verifica el dueño: es para quien tiene
+	// routine:moderate.
+	DeleteAnyRoutine(ctx context.Context, routineID string) error
+	DuplicateRoutine(ctx context.Context, ownerID string, sourceRoutineID string, newName string) (string, error)
 }
 
 type RoutineService struct {
 	repo         repositories.RoutineRepositoryInterface
 	exerciseRepo repositories.ExerciseRepositoryInterface
+	userRepo     repositories.UserRepositoryInterface
 }
 
-func NewRoutineService(repo repositories.RoutineRepositoryInterface, exerciseRepo repositories.ExerciseRepositoryInterface) *RoutineService {
+func NewRoutineService(repo repositories.RoutineRepositoryInterface, exerciseRepo repositories.ExerciseRepositoryInterface, userRepo repositories.UserRepositoryInterface) *RoutineService {
 	return &RoutineService{
 		repo:         repo,
 		exerciseRepo: exerciseRepo,
+		userRepo:     userRepo,
 	}
 }
 
-func (s *RoutineService) CreateRoutine(ownerID string, input dto.RoutineRequest) (dto.RoutineResponse, error) {
+var errEmailNotVerified = apperrors.Forbidden("email_not_verified", "confirme su email para publicar rutinas")
+
+// requireVerifiedToPublish impide que una cuenta sin email confirmado haga
+// pública una rutina. Las privadas no necesitan verificación.
+func (s *RoutineService) requireVerifiedToPublish(ctx context.Context, ownerID primitive.ObjectID) error {
+	user, err := s.userRepo.GetUserByID(ctx, ownerID.Hex())
+	if err != nil {
+		return notFoundAs(err, "user_not_found", "usuario no encontrado")
+	}
+	if !user.EmailVerified {
+		return errEmailNotVerified
+	}
+	return nil
+}
+
+func (s *RoutineService) CreateRoutine(ctx context.Context, ownerID string, input dto.RoutineRequest) (dto.RoutineResponse, error) {
 	if ownerID == "" {
-		return dto.RoutineResponse{}, errors.New("ownerID requerido")
+		return dto.RoutineResponse{}, apperrors.Validation("owner_required", "ownerID requerido")
 	}
-	own, err := primitive.ObjectIDFromHex(ownerID)
+	own, err := utils.ParseObjectID(ownerID)
 	if err != nil {
-		return dto.RoutineResponse{}, fmt.Errorf("ownerID inválido: %w", err)
+		return dto.RoutineResponse{}, err
 	}
 	if err := validateRoutineEntries(input.Excercises); err != nil {
 		return dto.RoutineResponse{}, err
 	}
+	if input.IsPublic {
+		if err := s.requireVerifiedToPublish(ctx, own); err != nil {
+			return dto.RoutineResponse{}, err
+		}
+	}
 	var exIDs []primitive.ObjectID
 	for _, e := range input.Excercises {
 		id, _ := primitive.ObjectIDFromHex(e.ExerciseID)
 		exIDs = append(exIDs, id)
 	}
-	if err := s.verifyExercisesExist(exIDs); err != nil {
+	if err := s.verifyExercisesExist(ctx, exIDs); err != nil {
 		return dto.RoutineResponse{}, err
 	}
 	routine := models.Routine{
@@ -75,7 +102,7 @@ func (s *RoutineService) CreateRoutine(ownerID string, input dto.RoutineRequest)
 		})
 	}
 
-	res, err := s.repo.CreateRoutine(routine)
+	res, err := s.repo.CreateRoutine(ctx, routine)
 	if err != nil {
 		return dto.RoutineResponse{}, err
 	}
@@ -86,15 +113,15 @@ func (s *RoutineService) CreateRoutine(ownerID string, input dto.RoutineRequest)
 	return dto.RoutineResponse{}, nil
 }
 
-func (s *RoutineService) GetRoutines(ownerID string, name string) ([]dto.RoutineResponse, error) {
+func (s *RoutineService) GetRoutines(ctx context.Context, ownerID string, name string) ([]dto.RoutineResponse, error) {
 	if ownerID == "" {
-		return nil, errors.New("ownerID requerido")
+		return nil, apperrors.Validation("owner_required", "ownerID requerido")
 	}
-	own, err := primitive.ObjectIDFromHex(ownerID)
+	own, err := utils.ParseObjectID(ownerID)
 	if err != nil {
 		return nil, err
 	}
-	modelsList, err := s.repo.GetRoutines(own, name)
+	modelsList, err := s.repo.GetRoutines(ctx, own, name)
 	if err != nil {
 		return nil, err
 	}
@@ -105,35 +132,40 @@ func (s *RoutineService) GetRoutines(ownerID string, name string) ([]dto.Routine
 	return out, nil
 }
 
-func (s *RoutineService) GetRoutineByID(id string) (dto.RoutineResponse, error) {
-	m, err := s.repo.GetRoutineByID(id)
+func (s *RoutineService) GetRoutineByID(ctx context.Context, id string) (dto.RoutineResponse, error) {
+	m, err := s.repo.GetRoutineByID(ctx, id)
 	if err != nil {
-		return dto.RoutineResponse{}, err
+		return dto.RoutineResponse{}, notFoundAs(err, "routine_not_found", "Routine not found")
 	}
 	return utils.ConverModelToRoutineDTO(m), nil
 }
 
-func (s *RoutineService) UpdateRoutine(ownerID string, routineID string, input dto.RoutineRequest) (dto.RoutineResponse, error) {
-	existing, err := s.repo.GetRoutineByID(routineID)
+func (s *RoutineService) UpdateRoutine(ctx context.Context, ownerID string, routineID string, input dto.RoutineRequest) (dto.RoutineResponse, error) {
+	existing, err := s.repo.GetRoutineByID(ctx, routineID)
 	if err != nil {
-		return dto.RoutineResponse{}, err
+		return dto.RoutineResponse{}, notFoundAs(err, "routine_not_found", "Routine not found")
 	}
-	own, err := primitive.ObjectIDFromHex(ownerID)
+	own, err := utils.ParseObjectID(ownerID)
 	if err != nil {
 		return dto.RoutineResponse{}, err
 	}
 	if existing.OwnerID != own {
-		return dto.RoutineResponse{}, errors.New("no autorizado: no es el owner de la rutina")
+		return dto.RoutineResponse{}, errNotRoutineOwner
 	}
 	if err := validateRoutineEntries(input.Excercises); err != nil {
 		return dto.RoutineResponse{}, err
 	}
+	if input.IsPublic && !existing.IsPublic {
+		if err := s.requireVerifiedToPublish(ctx, own); err != nil {
+			return dto.RoutineResponse{}, err
+		}
+	}
 	var exIDs []primitive.ObjectID
 	for _, e := range input.Excercises {
 		id, _ := primitive.ObjectIDFromHex(e.ExerciseID)
 		exIDs = append(exIDs, id)
 	}
-	if err := s.verifyExercisesExist(exIDs); err != nil {
+	if err := s.verifyExercisesExist(ctx, exIDs); err != nil {
 		return dto.RoutineResponse{}, err
 	}
 	existing.Name = input.Name
@@ -151,50 +183,70 @@ func (s *RoutineService) UpdateRoutine(ownerID string, routineID string, input d
 			Weight:     e.Weight,
 		})
 	}
-	_, err = s.repo.UpdateRoutine(existing)
+	_, err = s.repo.UpdateRoutine(ctx, existing)
 	if err != nil {
 		return dto.RoutineResponse{}, err
 	}
-	updated, err := s.repo.GetRoutineByID(routineID)
+	updated, err := s.repo.GetRoutineByID(ctx, routineID)
 	if err != nil {
 		return dto.RoutineResponse{}, err
 	}
 	return utils.ConverModelToRoutineDTO(updated), nil
 }
 
-func (s *RoutineService) DeleteRoutine(ownerID string, routineID string) error {
-	existing, err := s.repo.GetRoutineByID(routineID)
+func (s *RoutineService) DeleteRoutine(ctx context.Context, ownerID string, routineID string) error {
+	existing, err := s.repo.GetRoutineByID(ctx, routineID)
 	if err != nil {
-		return err
+		return notFoundAs(err, "routine_not_found", "Routine not found")
 	}
-	own, err := primitive.ObjectIDFromHex(ownerID)
+	own, err := utils.ParseObjectID(ownerID)
 	if err != nil {
 		return err
 	}
 	if existing.OwnerID != own {
-		return errors.New("no autorizado: no es el owner de la rutina")
+		return errNotRoutineOwner
 	}
-	_, err = s.repo.DeleteRoutine(existing.ID)
+	_, err = s.repo.DeleteRoutine(ctx, existing.ID)
 	return err
 }
 
-func (s *RoutineService) DuplicateRoutine(ownerID string, sourceRoutineID string, newName string) (string, error) {
+func (s *RoutineService) DeleteAnyRoutine(ctx context.Context, routineID string) error {
+	existing, err := s.repo.GetRoutineByID(ctx, routineID)
+	if err != nil {
+		return notFoundAs(err, "routine_not_found", "Routine not found")
+	}
+	_, err = s.repo.DeleteRoutine(ctx, existing.ID)
+	return err
+}
+
+func (s *RoutineService) DuplicateRoutine(ctx context.Context, ownerID string, sourceRoutineID string, newName string) (string, error) {
 	if sourceRoutineID == "" {
-		return "", errors.New("sourceRoutineID requerido")
+		return "", apperrors.Validation("routine_required", "sourceRoutineID requerido")
 	}
-	src, err := s.repo.GetRoutineByID(sourceRoutineID)
+	src, err := s.repo.GetRoutineByID(ctx, sourceRoutineID)
 	if err != nil {
-		return "", err
+		return "", notFoundAs(err, "routine_not_found", "Routine not found")
 	}
-	own, err := primitive.ObjectIDFromHex(ownerID)
+	own, err := utils.ParseObjectID(ownerID)
 	if err != nil {
-		return "", fmt.Errorf("ownerID inválido: %w", err)
+		return "", err
+	}
+	// Copiar una rutina pública no es publicarla: sin email confirmado la
+	// copia queda privada.
+	isPublic := src.IsPublic
+	if isPublic {
+		err := s.requireVerifiedToPublish(ctx, own)
+		if errors.Is(err, errEmailNotVerified) {
+			isPublic = false
+		} else if err != nil {
+			return "", err
+		}
 	}
 	var exIDs []primitive.ObjectID
 	for _, e := range src.Entries {
 		exIDs = append(exIDs, e.ExerciseID)
 	}
-	if err := s.verifyExercisesExist(exIDs); err != nil {
+	if err := s.verifyExercisesExist(ctx, exIDs); err != nil {
 		return "", err
 	}
 	copy := models.Routine{
@@ -202,7 +254,7 @@ func (s *RoutineService) DuplicateRoutine(ownerID string, sourceRoutineID string
 		OwnerID:     own,
 		Name:        newName,
 		Description: src.Description,
-		IsPublic:    src.IsPublic,
+		IsPublic:    isPublic,
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
 	}
@@ -216,7 +268,7 @@ func (s *RoutineService) DuplicateRoutine(ownerID string, sourceRoutineID string
 			Weight:     e.Weight,
 		})
 	}
-	res, err := s.repo.CreateRoutine(copy)
+	res, err := s.repo.CreateRoutine(ctx, copy)
 	if err != nil {
 		return "", err
 	}
@@ -228,34 +280,34 @@ func (s *RoutineService) DuplicateRoutine(ownerID string, sourceRoutineID string
 
 func validateRoutineEntries(entries []dto.RoutineExcerciseList) error {
 	if len(entries) == 0 {
-		return errors.New("la rutina debe contener al menos un ejercicio")
+		return apperrors.WithDetail(errInvalidRoutine, "la rutina debe contener al menos un ejercicio")
 	}
 	orders := make(map[int]bool)
 	for i, e := range entries {
 		if e.ExerciseID == "" {
-			return fmt.Errorf("entry %d: exercise_id requerido", i)
+			return invalidEntry("entry %d: exercise_id requerido", i)
 		}
 		if _, err := primitive.ObjectIDFromHex(e.ExerciseID); err != nil {
-			return fmt.Errorf("entry %d: exercise_id inválido: %w", i, err)
+			return invalidEntry("entry %d: exercise_id inválido: %v", i, err)
 		}
 		if e.Order <= 0 {
-			return fmt.Errorf("entry %d: order debe ser > 0", i)
+			return invalidEntry("entry %d: order debe ser > 0", i)
 		}
 		if orders[e.Order] {
-			return fmt.Errorf("entry %d: order duplicado (%d)", i, e.Order)
+			return invalidEntry("entry %d: order duplicado (%d)", i, e.Order)
 		}
 		orders[e.Order] = true
 		if e.Sets <= 0 {
-			return fmt.Errorf("entry %d: sets debe ser > 0", i)
+			return invalidEntry("entry %d: sets debe ser > 0", i)
 		}
 		if e.Reps <= 0 {
-			return fmt.Errorf("entry %d: reps debe ser > 0", i)
+			return invalidEntry("entry %d: reps debe ser > 0", i)
 		}
 	}
 	return nil
 }
 
-func (s *RoutineService) verifyExercisesExist(ids []primitive.ObjectID) error {
+func (s *RoutineService) verifyExercisesExist(ctx context.Context, ids []primitive.ObjectID) error {
 	if len(ids) == 0 {
 		return nil
 	}
@@ -270,8 +322,8 @@ func (s *RoutineService) verifyExercisesExist(ids []primitive.ObjectID) error {
 			continue
 		}
 		seen[h] = true
-		exists, err := s.exerciseRepo.GetExerciseByID(h)
-		if err != nil {
+		exists, err := s.exerciseRepo.GetExerciseByID(ctx, h)
+		if err != nil && !errors.Is(err, apperrors.ErrNotFound) {
 			return err
 		}
 		if exists.ID.IsZero() {
@@ -279,7 +331,17 @@ func (s *RoutineService) verifyExercisesExist(ids []primitive.ObjectID) error {
 		}
 	}
 	if len(missing) > 0 {
-		return fmt.Errorf("exercises not found: %s", strings.Join(missing, ","))
+		return apperrors.WithDetail(errUnknownExercises, strings.Join(missing, ","))
 	}
 	return nil
 }
+
+var (
+	errNotRoutineOwner  = apperrors.Forbidden("not_routine_owner", "no autorizado: no es el owner de la rutina")
+	errInvalidRoutine   = apperrors.Validation("invalid_routine", "datos de rutina inválidos")
+	errUnknownExercises = apperrors.Validation("unknown_exercises", "exercises not found")
+)
+
+func invalidEntry(format string, args ...any) error {
+	return apperrors.WithDetail(errInvalidRoutine, fmt.Sprintf(format, args...))
+}
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"reflect"
 	"testing"
@@ -18,7 +19,7 @@ type mockRoutineRepo struct {
 	store   map[string]models.Routine
 }
 
-func (m *mockRoutineRepo) GetRoutines(ownerID primitive.ObjectID, name string) ([]models.Routine, error) {
+func (m *mockRoutineRepo) GetRoutines(ctx context.Context, ownerID primitive.ObjectID, name string) ([]models.Routine, error) {
 	out := []models.Routine{}
 	for _, r := range m.store {
 		if r.OwnerID == ownerID {
@@ -30,14 +31,14 @@ func (m *mockRoutineRepo) GetRoutines(ownerID primitive.ObjectID, name string) (
 	return out, nil
 }
 
-func (m *mockRoutineRepo) GetRoutineByID(id string) (models.Routine, error) {
+func (m *mockRoutineRepo) GetRoutineByID(ctx context.Context, id string) (models.Routine, error) {
 	if r, ok := m.store[id]; ok {
 		return r, nil
 	}
 	return models.Routine{}, errors.New("not found")
 }
 
-func (m *mockRoutineRepo) CreateRoutine(routine models.Routine) (*mongo.InsertOneResult, error) {
+func (m *mockRoutineRepo) CreateRoutine(ctx context.Context, routine models.Routine) (*mongo.InsertOneResult, error) {
 	m.created = routine
 	if m.store == nil {
 		m.store = map[string]models.Routine{}
@@ -47,7 +48,7 @@ func (m *mockRoutineRepo) CreateRoutine(routine models.Routine) (*mongo.InsertOn
 	return &mongo.InsertOneResult{InsertedID: routine.ID}, nil
 }
 
-func (m *mockRoutineRepo) UpdateRoutine(routine models.Routine) (*mongo.UpdateResult, error) {
+func (m *mockRoutineRepo) UpdateRoutine(ctx context.Context, routine models.Routine) (*mongo.UpdateResult, error) {
 	if m.store == nil {
 		return nil, errors.New("no store")
 	}
@@ -55,7 +56,7 @@ func (m *mockRoutineRepo) UpdateRoutine(routine models.Routine) (*mongo.UpdateRe
 	return &mongo.UpdateResult{}, nil
 }
 
-func (m *mockRoutineRepo) DeleteRoutine(id primitive.ObjectID) (*mongo.DeleteResult, error) {
+func (m *mockRoutineRepo) DeleteRoutine(ctx context.Context, id primitive.ObjectID) (*mongo.DeleteResult, error) {
 	if m.store == nil {
 		return nil, errors.New("no store")
 	}
@@ -67,7 +68,7 @@ type mockExerciseRepo struct {
 	byID map[string]models.Exercise
 }
 
-func (m *mockExerciseRepo) GetExercises(name, category, muscleGroup string) ([]models.Exercise, error) {
+func (m *mockExerciseRepo) GetExercises(ctx context.Context, name, category, muscleGroup string) ([]models.Exercise, error) {
 	out := []models.Exercise{}
 	for _, e := range m.byID {
 		out = append(out, e)
@@ -75,22 +76,22 @@ func (m *mockExerciseRepo) GetExercises(name, category, muscleGroup string) ([]m
 	return out, nil
 }
 
-func (m *mockExerciseRepo) GetExerciseByID(id string) (models.Exercise, error) {
+func (m *mockExerciseRepo) GetExerciseByID(ctx context.Context, id string) (models.Exercise, error) {
 	if e, ok := m.byID[id]; ok {
 		return e, nil
 	}
 	return models.Exercise{}, nil
 }
 
-func (m *mockExerciseRepo) CreateExercise(exercise models.Exercise) (*mongo.InsertOneResult, error) {
+func (m *mockExerciseRepo) CreateExercise(ctx context.Context, exercise models.Exercise) (*mongo.InsertOneResult, error) {
 	return &mongo.InsertOneResult{InsertedID: exercise.ID}, nil
 }
 
-func (m *mockExerciseRepo) UpdateExercise(exercise models.Exercise) (*mongo.UpdateResult, error) {
+func (m *mockExerciseRepo) UpdateExercise(ctx context.Context, exercise models.Exercise) (*mongo.UpdateResult, error) {
 	return &mongo.UpdateResult{}, nil
 }
 
-func (m *mockExerciseRepo) DeleteExercise(id primitive.ObjectID) (*mongo.DeleteResult, error) {
+func (m *mockExerciseRepo) DeleteExercise(ctx context.Context, id primitive.ObjectID) (*mongo.DeleteResult, error) {
 	return &mongo.DeleteResult{}, nil
 }
 
@@ -101,7 +102,7 @@ func TestCreateRoutine_Success(t *testing.T) {
 
 	exerRepo := &mockExerciseRepo{byID: map[string]models.Exercise{exerciseID.Hex(): {ID: exerciseID}}}
 	repo := &mockRoutineRepo{store: map[string]models.Routine{}}
-	svc := NewRoutineService(repo, exerRepo)
+	svc := NewRoutineService(repo, exerRepo, &mockUserRepo{})
 
 	req := dto.RoutineRequest{
 		Name: "legs",
@@ -113,7 +114,7 @@ func TestCreateRoutine_Success(t *testing.T) {
 		}},
 	}
 
-	got, err := svc.CreateRoutine(ownerID.Hex(), req)
+	got, err := svc.CreateRoutine(context.Background(), ownerID.Hex(), req)
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
 	}
@@ -130,9 +131,9 @@ func TestGetRoutines_Success(t *testing.T) {
 	ownerID := primitive.NewObjectID()
 	r := models.Routine{ID: primitive.NewObjectID(), OwnerID: ownerID, Name: "push", Entries: []models.RoutineExcerciseList{}}
 	repo := &mockRoutineRepo{store: map[string]models.Routine{r.ID.Hex(): r}}
-	svc := NewRoutineService(repo, &mockExerciseRepo{})
+	svc := NewRoutineService(repo, &mockExerciseRepo{}, &mockUserRepo{})
 
-	out, err := svc.GetRoutines(ownerID.Hex(), "")
+	out, err := svc.GetRoutines(context.Background(), ownerID.Hex(), "")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -143,9 +144,9 @@ func TestGetRoutines_Success(t *testing.T) {
 
 func TestGetRoutineByID_NotFound(t *testing.T) {
 	repo := &mockRoutineRepo{store: map[string]models.Routine{}}
-	svc := NewRoutineService(repo, &mockExerciseRepo{})
+	svc := NewRoutineService(repo, &mockExerciseRepo{}, &mockUserRepo{})
 
-	_, err := svc.GetRoutineByID(primitive.NewObjectID().Hex())
+	_, err := svc.GetRoutineByID(context.Background(), primitive.NewObjectID().Hex())
 	if err == nil {
 		t.Fatalf("expected error for missing routine")
 	}
@@ -156,10 +157,10 @@ func TestUpdateRoutine_Unauthorized(t *testing.T) {
 	otherOwner := primitive.NewObjectID()
 	r := models.Routine{ID: primitive.NewObjectID(), OwnerID: otherOwner, Name: "r1", Entries: []models.RoutineExcerciseList{{}}}
 	repo := &mockRoutineRepo{store: map[string]models.Routine{r.ID.Hex(): r}}
-	svc := NewRoutineService(repo, &mockExerciseRepo{})
+	svc := NewRoutineService(repo, &mockExerciseRepo{}, &mockUserRepo{})
 
 	req := dto.RoutineRequest{Name: "new", Excercises: []dto.RoutineExcerciseList{{ExerciseID: primitive.NewObjectID().Hex(), Order: 1, Sets: 1, Reps: 1}}}
-	_, err := svc.UpdateRoutine(ownerID.Hex(), r.ID.Hex(), req)
+	_, err := svc.UpdateRoutine(context.Background(), ownerID.Hex(), r.ID.Hex(), req)
 	if err == nil {
 		t.Fatalf("expected unauthorized error")
 	}
@@ -181,10 +182,10 @@ func TestDuplicateRoutine_Success(t *testing.T) {
 
 	exerRepo := &mockExerciseRepo{byID: map[string]models.Exercise{exID.Hex(): {ID: exID}}}
 	repo := &mockRoutineRepo{store: map[string]models.Routine{src.ID.Hex(): src}}
-	svc := NewRoutineService(repo, exerRepo)
+	svc := NewRoutineService(repo, exerRepo, &mockUserRepo{})
 
 	newName := "copy"
-	idHex, err := svc.DuplicateRoutine(ownerID.Hex(), src.ID.Hex(), newName)
+	idHex, err := svc.DuplicateRoutine(context.Background(), ownerID.Hex(), src.ID.Hex(), newName)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -222,5 +223,59 @@ func TestValidateRoutineEntries_Errors(t *testing.T) {
 	}
 }
 
+func TestCreateRoutine_PublicRequiresVerifiedEmail(t *testing.T) {
+	exerciseID := primitive.NewObjectID()
+	ownerID := primitive.NewObjectID()
+	exerRepo := &mockExerciseRepo{byID: map[string]models.Exercise{exerciseID.Hex(): {ID: exerciseID}}}
+	verified := false
+	users := &mockUserRepo{getUserByIDFn: func(id string) (models.User, error) {
+		return models.User{ID: ownerID, EmailVerified: verified}, nil
+	}}
+	svc := NewRoutineService(&mockRoutineRepo{store: map[string]models.Routine{}}, exerRepo, users)
+
+	req := dto.RoutineRequest{
+		Name:       "legs",
+		IsPublic:   true,
+		Excercises: []dto.RoutineExcerciseList{{ExerciseID: exerciseID.Hex(), Order: 1, Sets: 3, Reps: 10}},
+	}
+	if _, err := svc.CreateRoutine(context.Background(), ownerID.Hex(), req); !errors.Is(err, errEmailNotVerified) {
+		t.Fatalf("expected email_not_verified, got %v", err)
+	}
+
+	req.IsPublic = false
+	if _, err := svc.CreateRoutine(context.Background(), ownerID.Hex(), req); err != nil {
+		t.Fatalf("private routines must not require verification: %v", err)
+	}
+
+	verified = true
+	req.IsPublic = true
+	if _, err := svc.CreateRoutine(context.Background(), ownerID.Hex(), req); err != nil {
+		t.Fatalf("unexpected error for verified owner: %v", err)
+	}
+}
+
+func TestDuplicateRoutine_UnverifiedCopyIsPrivate(t *testing.T) {
+	ownerID := primitive.NewObjectID()
+	exID := primitive.NewObjectID()
+	src := models.Routine{
+		ID:       primitive.NewObjectID(),
+		OwnerID:  primitive.NewObjectID(),
+		Name:     "orig",
+		Entries:  []models.RoutineExcerciseList{{ExerciseID: exID, Order: 1, Sets: 2, Reps: 5}},
+		IsPublic: true,
+	}
+	exerRepo := &mockExerciseRepo{byID: map[string]models.Exercise{exID.Hex(): {ID: exID}}}
+	repo := &mockRoutineRepo{store: map[string]models.Routine{src.ID.Hex(): src}}
+	svc := NewRoutineService(repo, exerRepo, &mockUserRepo{})
+
+	idHex, err := svc.DuplicateRoutine(context.Background(), ownerID.Hex(), src.ID.Hex(), "copy")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if repo.store[idHex].IsPublic {
+		t.Fatalf("expected the copy of an unverified owner to be private")
+	}
+}
+
 var _ = reflect.TypeOf((*mockRoutineRepo)(nil))
 var _ = reflect.TypeOf((*mockExerciseRepo)(nil))
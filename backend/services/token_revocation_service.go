@@ -0,0 +1,197 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"backend/auth"
+	"backend/repositories"
+)
+
+// TokenRevocationServiceInterface invalida access tokens antes de que venzan.
+// AuthMiddleware consulta IsRevoked en cada pedido.
+type TokenRevocationServiceInterface interface {
+	// RevokeToken invalida solo ese token, hasta su vencimiento.
+	RevokeToken(ctx context.Context, claims *auth.Claims) error
+	// RevokeAllForUser invalida todos los access tokens emitidos hasta ahora
+	// para el usuario; los que se emitan después siguen valiendo.
+	RevokeAllForUser(ctx context.Context, userID string) error
+	// RevokeSession invalida los access tokens emitidos para la sesión (claim
+	// sid), para cuando se cierra esa sesión y no solo su refresh token.
+	RevokeSession(ctx context.Context, sessionID string) error
+	IsRevoked(ctx context.Context, claims *auth.Claims) (bool, error)
+}
+
+// sessionDenyPrefix separa en la denylist las sesiones revocadas de los jti.
+const sessionDenyPrefix = "sid:"
+
+// maxCacheEntries dispara la limpieza de entradas vencidas del cache.
+const maxCacheEntries = 10000
+
+type cachedDenial struct {
+	denied bool
+	until  time.Time
+}
+
+type cachedWatermark struct {
+	issuedBefore time.Time
+	until        time.Time
+}
+
+// TokenRevocationService guarda las revocaciones en el repositorio y las
+// cachea en memoria para no consultar la base en cada pedido. Las
+// revocaciones hechas en esta instancia se ven enseguida; las hechas en otra
+// instancia tardan hasta cacheTTL en aplicarse acá.
+type TokenRevocationService struct {
+	repo     repositories.TokenRevocationRepositoryInterface
+	cacheTTL time.Duration
+
+	mu         sync.Mutex
+	denied     map[string]cachedDenial
+	watermarks map[string]cachedWatermark
+}
+
+// NewTokenRevocationService crea el servicio; cacheTTL 0 desactiva el cache.
+func NewTokenRevocationService(repo repositories.TokenRevocationRepositoryInterface, cacheTTL time.Duration) *TokenRevocationService {
+	return &TokenRevocationService{
+		repo:       repo,
+		cacheTTL:   cacheTTL,
+		denied:     make(map[string]cachedDenial),
+		watermarks: make(map[string]cachedWatermark),
+	}
+}
+
+func (s *TokenRevocationService) RevokeToken(ctx context.Context, claims *auth.Claims) error {
+	// Los tokens emitidos antes del jti no se pueden revocar de a uno.
+	if claims.ID == "" || claims.ExpiresAt == nil {
+		return nil
+	}
+	return s.deny(ctx, claims.ID, claims.ExpiresAt.Time)
+}
+
+func (s *TokenRevocationService) RevokeSession(ctx context.Context, sessionID string) error {
+	if sessionID == "" {
+		return nil
+	}
+	// La sesión ya no emite tokens nuevos: alcanza con cubrir los vigentes.
+	return s.deny(ctx, sessionDenyPrefix+sessionID, time.Now().Add(auth.AccessTokenTTL()))
+}
+
+func (s *TokenRevocationService) deny(ctx context.Context, key string, expiresAt time.Time) error {
+	if err := s.repo.DenyToken(ctx, key, expiresAt); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.denied[key] = cachedDenial{denied: true, until: expiresAt}
+	return nil
+}
+
+func (s *TokenRevocationService) RevokeAllForUser(ctx context.Context, userID string) error {
+	// iat tiene precisión de segundos: la marca se redondea al segundo
+	// siguiente para que un token emitido en el mismo segundo, antes de
+	// revocar, no sobreviva. Uno emitido en ese segundo después de revocar
+	// también cae; el cliente lo renueva en el segundo siguiente.
+	issuedBefore := time.Now().Truncate(time.Second).Add(time.Second)
+	expiresAt := issuedBefore.Add(auth.AccessTokenTTL())
+	if err := s.repo.SetIssuedBefore(ctx, userID, issuedBefore, expiresAt); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if cached := s.watermarks[userID]; !cached.issuedBefore.After(issuedBefore) {
+		s.watermarks[userID] = cachedWatermark{issuedBefore: issuedBefore, until: time.Now().Add(s.cacheTTL)}
+	}
+	return nil
+}
+
+func (s *TokenRevocationService) IsRevoked(ctx context.Context, claims *auth.Claims) (bool, error) {
+	issuedBefore, err := s.issuedBefore(ctx, claims.UserID)
+	if err != nil {
+		return false, err
+	}
+	if !issuedBefore.IsZero() && (claims.IssuedAt == nil || claims.IssuedAt.Time.Before(issuedBefore)) {
+		return true, nil
+	}
+
+	if claims.SessionID != "" {
+		denied, err := s.isDenied(ctx, sessionDenyPrefix+claims.SessionID, claims)
+		if err != nil || denied {
+			return denied, err
+		}
+	}
+	if claims.ID == "" {
+		return false, nil
+	}
+	return s.isDenied(ctx, claims.ID, claims)
+}
+
+func (s *TokenRevocationService) issuedBefore(ctx context.Context, userID string) (time.Time, error) {
+	now := time.Now()
+	s.mu.Lock()
+	cached, ok := s.watermarks[userID]
+	s.mu.Unlock()
+	if ok && now.Before(cached.until) {
+		return cached.issuedBefore, nil
+	}
+
+	issuedBefore, err := s.repo.GetIssuedBefore(ctx, userID)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if s.cacheTTL > 0 {
+		s.mu.Lock()
+		s.watermarks[userID] = cachedWatermark{issuedBefore: issuedBefore, until: now.Add(s.cacheTTL)}
+		s.pruneLocked(now)
+		s.mu.Unlock()
+	}
+	return issuedBefore, nil
+}
+
+// isDenied consulta la denylist por key: el jti del token o la sesión.
+func (s *TokenRevocationService) isDenied(ctx context.Context, key string, claims *auth.Claims) (bool, error) {
+	now := time.Now()
+	s.mu.Lock()
+	cached, ok := s.denied[key]
+	s.mu.Unlock()
+	if ok && now.Before(cached.until) {
+		return cached.denied, nil
+	}
+
+	denied, err := s.repo.IsDenied(ctx, key)
+	if err != nil {
+		return false, err
+	}
+	if s.cacheTTL > 0 {
+		// Un token revocado no vuelve a ser válido: la respuesta positiva se
+		// cachea hasta que vence el token.
+		until := now.Add(s.cacheTTL)
+		if denied && claims.ExpiresAt != nil {
+			until = claims.ExpiresAt.Time
+		}
+		s.mu.Lock()
+		s.denied[key] = cachedDenial{denied: denied, until: until}
+		s.pruneLocked(now)
+		s.mu.Unlock()
+	}
+	return denied, nil
+}
+
+func (s *TokenRevocationService) pruneLocked(now time.Time) {
+	if len(s.denied)+len(s.watermarks) < maxCacheEntries {
+		return
+	}
+	for k, v := range s.denied {
+		if !now.Before(v.until) {
+			delete(s.denied, k)
+		}
+	}
+	for k, v := range s.watermarks {
+		if !now.Before(v.until) {
+			delete(s.watermarks, k)
+		}
+	}
+}
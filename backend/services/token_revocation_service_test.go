@@ -0,0 +1,126 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"backend/auth"
+	"backend/repositories/memory"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func claimsIssuedAt(userID, jti string, iat time.Time) *auth.Claims {
+	return &auth.Claims{
+		UserID: userID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
+			IssuedAt:  jwt.NewNumericDate(iat),
+			ExpiresAt: jwt.NewNumericDate(iat.Add(time.Hour)),
+		},
+	}
+}
+
+func TestTokenRevocation_DenyOneToken(t *testing.T) {
+	ctx := context.Background()
+	svc := NewTokenRevocationService(memory.NewTokenRevocationRepository(), time.Minute)
+	now := time.Now()
+	revoked := claimsIssuedAt("u1", "a", now)
+	other := claimsIssuedAt("u1", "b", now)
+
+	if ok, _ := svc.IsRevoked(ctx, revoked); ok {
+		t.Fatalf("token must start valid")
+	}
+	if err := svc.RevokeToken(ctx, revoked); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// El negativo cacheado antes de revocar no debe tapar la revocación local.
+	if ok, _ := svc.IsRevoked(ctx, revoked); !ok {
+		t.Fatalf("expected the token to be revoked")
+	}
+	if ok, _ := svc.IsRevoked(ctx, other); ok {
+		t.Fatalf("other tokens of the user must stay valid")
+	}
+}
+
+func TestTokenRevocation_WatermarkRevokesOlderTokens(t *testing.T) {
+	ctx := context.Background()
+	svc := NewTokenRevocationService(memory.NewTokenRevocationRepository(), time.Minute)
+	old := claimsIssuedAt("u1", "a", time.Now().Add(-time.Minute))
+
+	if ok, _ := svc.IsRevoked(ctx, old); ok {
+		t.Fatalf("token must start valid")
+	}
+	if err := svc.RevokeAllForUser(ctx, "u1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ok, _ := svc.IsRevoked(ctx, old); !ok {
+		t.Fatalf("expected tokens issued before the watermark to be revoked")
+	}
+	if ok, _ := svc.IsRevoked(ctx, claimsIssuedAt("u1", "b", time.Now().Add(time.Second))); ok {
+		t.Fatalf("tokens issued after the watermark must be valid")
+	}
+	if ok, _ := svc.IsRevoked(ctx, claimsIssuedAt("u2", "c", time.Now().Add(-time.Minute))); ok {
+		t.Fatalf("the watermark must only apply to its user")
+	}
+}
+
+// Un token emitido en el mismo segundo que la revocación, antes de ella, tiene
+// el mismo iat que la marca y tiene que quedar revocado.
+func TestTokenRevocation_SameSecondTokenIsRevoked(t *testing.T) {
+	ctx := context.Background()
+	svc := NewTokenRevocationService(memory.NewTokenRevocationRepository(), time.Minute)
+	access, _, _, err := auth.GenerateToken(auth.Subject{UserID: primitive.NewObjectID(), Email: "a@example.com", Role: "user"})
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	claims, err := auth.ValidateAccessToken(access)
+	if err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+
+	if err := svc.RevokeAllForUser(ctx, claims.UserID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ok, _ := svc.IsRevoked(ctx, claims); !ok {
+		t.Fatalf("a token issued right before the revocation must be revoked")
+	}
+}
+
+func TestTokenRevocation_OtherInstanceSeesItAfterCacheTTL(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewTokenRevocationRepository()
+	writer := NewTokenRevocationService(repo, time.Minute)
+	reader := NewTokenRevocationService(repo, 0)
+	old := claimsIssuedAt("u1", "a", time.Now().Add(-time.Minute))
+
+	reader.IsRevoked(ctx, old)
+	writer.RevokeAllForUser(ctx, "u1")
+	if ok, _ := reader.IsRevoked(ctx, old); !ok {
+		t.Fatalf("without cache the revocation must be visible immediately")
+	}
+}
+
+func TestTokenRevocation_SessionRevokesItsTokens(t *testing.T) {
+	ctx := context.Background()
+	svc := NewTokenRevocationService(memory.NewTokenRevocationRepository(), time.Minute)
+	inSession := claimsIssuedAt("u1", "a", time.Now())
+	inSession.SessionID = "s1"
+	otherSession := claimsIssuedAt("u1", "b", time.Now())
+	otherSession.SessionID = "s2"
+
+	if ok, _ := svc.IsRevoked(ctx, inSession); ok {
+		t.Fatalf("token must start valid")
+	}
+	if err := svc.RevokeSession(ctx, "s1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ok, _ := svc.IsRevoked(ctx, inSession); !ok {
+		t.Fatalf("access tokens of the revoked session must be revoked")
+	}
+	if ok, _ := svc.IsRevoked(ctx, otherSession); ok {
+		t.Fatalf("other sessions must stay valid")
+	}
+}
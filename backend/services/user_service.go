@@ -1,58 +1,84 @@
 package services
 
 import (
+	"context"
 	"errors"
+	"log"
 	"regexp"
+	"strings"
 	"time"
+	"unicode/utf8"
 
+	"backend/apperrors"
 	"backend/auth"
-	"backend/database"
 	"backend/dto"
+	"backend/i18n"
 	"backend/models"
 	"backend/repositories"
+	"backend/utils"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type UserServiceInterface interface {
-	Register(req dto.RegisterRequest) (string, error)
-	Login(req dto.LoginRequest) (dto.User, error)
-	GetUsers(name string) ([]dto.User, error)
-	GetUserByID(id string) (dto.User, error)
-	UpdateUser(id string, req dto.UpdateUserRequest) error
-	ChangePassword(id string, req dto.ChangePasswordRequest) error
-	DeleteUser(id string) error
+	Register(ctx context.Context, req dto.RegisterRequest) (dto.User, error)
+	Login(ctx context.Context, req dto.LoginRequest) (dto.User, error)
+	GetUsers(ctx context.Context, name string) ([]dto.User, error)
+	GetUserByID(ctx context.Context, id string) (dto.User, error)
+	UpdateUser(ctx context.Context, id string, req dto.UpdateUserRequest) error
+	ChangePassword(ctx context.Context, id string, req dto.ChangePasswordRequest) error
+	ChangeRole(ctx context.Context, id string, role string) error
+	DeleteUser(ctx context.Context, id string) error
+	// SearchUsers lista usuarios para la administración.
+	SearchUsers(ctx context.Context, search dto.UserSearch) (dto.UserPage, error)
+	// SetDisabled deshabilita o vuelve a habilitar la cuenta. Deshabilitarla
+	// cierra sus sesiones y revoca sus claves de API.
+	SetDisabled(ctx context.Context, id string, disabled bool) (dto.User, error)
+	// RevokeSessions cierra todas las sesiones del usuario y revoca sus access
+	// tokens y sus claves de API.
+	RevokeSessions(ctx context.Context, id string) error
 }
 
 type UserService struct {
-	repo repositories.UserRepositoryInterface
+	repo          repositories.UserRepositoryInterface
+	refreshRepo   repositories.RefreshTokenRepositoryInterface
+	revocations   TokenRevocationServiceInterface
+	verifications EmailVerificationServiceInterface
+	apiKeys       repositories.APIKeyRepositoryInterface
+	identities    repositories.LinkedIdentityRepositoryInterface
 }
 
-func NewUserService(repo repositories.UserRepositoryInterface) *UserService {
-	return &UserService{repo: repo}
+func NewUserService(repo repositories.UserRepositoryInterface, refreshRepo repositories.RefreshTokenRepositoryInterface, revocations TokenRevocationServiceInterface, verifications EmailVerificationServiceInterface, apiKeys repositories.APIKeyRepositoryInterface, identities repositories.LinkedIdentityRepositoryInterface) *UserService {
+	return &UserService{repo: repo, refreshRepo: refreshRepo, revocations: revocations, verifications: verifications, apiKeys: apiKeys, identities: identities}
 }
 
-func (s *UserService) Register(req dto.RegisterRequest) (dto.User, error) {
+func (s *UserService) Register(ctx context.Context, req dto.RegisterRequest) (dto.User, error) {
+	req.Email = utils.NormalizeEmail(req.Email)
 	if req.Name == "" || req.Email == "" || req.Password == "" || req.DateOfBirth == "" {
-		return dto.User{}, errors.New("datos incompletos")
+		return dto.User{}, apperrors.Validation("incomplete_data", "datos incompletos")
 	}
 	if !isValidEmail(req.Email) {
-		return dto.User{}, errors.New("email inválido")
+		return dto.User{}, errInvalidEmail
+	}
+	if err := validatePassword(req.Password); err != nil {
+		return dto.User{}, err
 	}
 	dob, err := time.Parse(time.RFC3339, req.DateOfBirth)
 	if err != nil {
 		dob, err = time.Parse("2006-01-02", req.DateOfBirth)
 		if err != nil {
-			return dto.User{}, errors.New("date_of_birth formato inválido, use ISO")
+			return dto.User{}, apperrors.Validation("invalid_date_of_birth", "date_of_birth formato inválido, use ISO")
 		}
 	}
-	candidates, err := s.repo.GetUser("")
+	// Chequeo previo para no pagar el hash con un email ya usado; la garantía
+	// real la da el índice users_email_unique al insertar.
+	_, err = s.repo.GetUserByEmail(ctx, req.Email)
 	if err == nil {
-		for _, u := range candidates {
-			if u.Email == req.Email {
-				return dto.User{}, errors.New("email ya registrado")
-			}
-		}
+		return dto.User{}, errEmailTaken
+	}
+	if !errors.Is(err, apperrors.ErrNotFound) {
+		return dto.User{}, err
 	}
 	hash, err := auth.HashPassword(req.Password)
 	if err != nil {
@@ -77,46 +103,55 @@ func (s *UserService) Register(req dto.RegisterRequest) (dto.User, error) {
 	}
 	user.Level = req.Level
 	user.Goals = req.Goals
+	if req.Language != "" {
+		if !i18n.Supported(req.Language) {
+			return dto.User{}, errUnsupportedLanguage
+		}
+		user.Language = req.Language
+	}
 
-	res, err := s.repo.CreateUser(user)
+	res, err := s.repo.CreateUser(ctx, user)
+	if mongo.IsDuplicateKeyError(err) {
+		return dto.User{}, errEmailTaken
+	}
 	if err != nil {
 		return dto.User{}, err
 	}
 	if res.InsertedID == nil {
 		return dto.User{}, errors.New("no se pudo crear el usuario")
 	}
+	s.sendVerification(ctx, user)
 	user.PasswordHash = ""
 	return modelUserToDTO(user), nil
 
 }
-func (s *UserService) Login(req dto.LoginRequest) (dto.User, error) {
+func (s *UserService) Login(ctx context.Context, req dto.LoginRequest) (dto.User, error) {
+	req.Email = utils.NormalizeEmail(req.Email)
 	if req.Email == "" || req.Password == "" {
-		return dto.User{}, errors.New("credenciales requeridas")
+		return dto.User{}, apperrors.Validation("credentials_required", "credenciales requeridas")
+	}
+	found, err := s.repo.GetUserByEmail(ctx, req.Email)
+	if errors.Is(err, apperrors.ErrNotFound) {
+		return dto.User{}, errInvalidCredentials
 	}
-	candidates, err := s.repo.GetUser("")
 	if err != nil {
 		return dto.User{}, err
 	}
-	var found *models.User
-	for _, u := range candidates {
-		if u.Email == req.Email {
-			found = &u
-			break
-		}
-	}
-	if found == nil {
-		return dto.User{}, errors.New("usuario no encontrado")
-	}
 	if !auth.CheckPasswordHash(req.Password, found.PasswordHash) {
-		return dto.User{}, errors.New("contraseña incorrecta")
+		return dto.User{}, errInvalidCredentials
 	}
-	userdto := modelUserToDTO(*found)
+	// Va después de la contraseña: sin ella no se revela el estado de la
+	// cuenta.
+	if found.Disabled() {
+		return dto.User{}, errAccountDisabled
+	}
+	userdto := modelUserToDTO(found)
 	userdto.PasswordHash = ""
 	return userdto, nil
 }
 
-func (s *UserService) GetUsers(name string) ([]dto.User, error) {
-	models, err := s.repo.GetUser(name)
+func (s *UserService) GetUsers(ctx context.Context, name string) ([]dto.User, error) {
+	models, err := s.repo.GetUser(ctx, name)
 	if err != nil {
 		return nil, err
 	}
@@ -127,27 +162,32 @@ func (s *UserService) GetUsers(name string) ([]dto.User, error) {
 	return out, nil
 }
 
-func (s *UserService) GetUserByID(id string) (dto.User, error) {
-	m, err := s.repo.GetUserByID(id)
+func (s *UserService) GetUserByID(ctx context.Context, id string) (dto.User, error) {
+	m, err := s.repo.GetUserByID(ctx, id)
 	if err != nil {
-		return dto.User{}, err
+		return dto.User{}, notFoundAs(err, "user_not_found", "usuario no encontrado")
 	}
 	return modelUserToDTO(m), nil
 }
 
-func (s *UserService) UpdateUser(id string, req dto.UpdateUserRequest) error {
-	m, err := s.repo.GetUserByID(id)
+func (s *UserService) UpdateUser(ctx context.Context, id string, req dto.UpdateUserRequest) error {
+	m, err := s.repo.GetUserByID(ctx, id)
 	if err != nil {
-		return err
+		return notFoundAs(err, "user_not_found", "usuario no encontrado")
 	}
+	req.Email = utils.NormalizeEmail(req.Email)
 	if req.Email != "" && !isValidEmail(req.Email) {
-		return errors.New("email inválido")
+		return errInvalidEmail
 	}
 	if req.Name != "" {
 		m.Name = req.Name
 	}
-	if req.Email != "" {
+	emailChanged := req.Email != "" && req.Email != m.Email
+	if emailChanged {
+		// La dirección nueva no está confirmada aunque la anterior lo estuviera.
 		m.Email = req.Email
+		m.EmailVerified = false
+		m.EmailVerifiedAt = nil
 	}
 	if req.Weight != 0 {
 		m.Weight = req.Weight
@@ -161,47 +201,237 @@ func (s *UserService) UpdateUser(id string, req dto.UpdateUserRequest) error {
 	if req.Goals != nil {
 		m.Goals = req.Goals
 	}
+	if req.Language != "" {
+		if !i18n.Supported(req.Language) {
+			return errUnsupportedLanguage
+		}
+		m.Language = req.Language
+	}
 	m.UpdatedAt = time.Now()
-	_, err = s.repo.UpdateUser(m)
-	return err
+	_, err = s.repo.UpdateUser(ctx, m)
+	if mongo.IsDuplicateKeyError(err) {
+		return errEmailTaken
+	}
+	if err != nil {
+		return err
+	}
+	if emailChanged {
+		s.sendVerification(ctx, m)
+	}
+	return nil
 }
 
-func (s *UserService) ChangePassword(id string, req dto.ChangePasswordRequest) error {
-	m, err := s.repo.GetUserByID(id)
+// sendVerification no hace fallar el registro ni el cambio de email: si el
+// correo no sale, el usuario puede pedir otro con POST /api/me/verification.
+func (s *UserService) sendVerification(ctx context.Context, user models.User) {
+	if err := s.verifications.SendVerification(ctx, user); err != nil {
+		log.Printf("no se pudo enviar la verificación de email al usuario %s: %v", user.ID.Hex(), err)
+	}
+}
+
+func (s *UserService) ChangePassword(ctx context.Context, id string, req dto.ChangePasswordRequest) error {
+	m, err := s.repo.GetUserByID(ctx, id)
 	if err != nil {
-		return err
+		return notFoundAs(err, "user_not_found", "usuario no encontrado")
 	}
 	if !auth.CheckPasswordHash(req.OldPassword, m.PasswordHash) {
-		return errors.New("contraseña actual incorrecta")
+		return apperrors.Validation("wrong_password", "contraseña actual incorrecta")
+	}
+	if err := validatePassword(req.NewPassword); err != nil {
+		return err
 	}
 	hash, err := auth.HashPassword(req.NewPassword)
 	if err != nil {
 		return err
 	}
-	m.PasswordHash = string(hash)
-	m.UpdatedAt = time.Now()
-	_, err = s.repo.UpdateUser(m)
+	if err := s.repo.SetPasswordHash(ctx, m.ID, string(hash), time.Now()); err != nil {
+		return notFoundAs(err, "user_not_found", "usuario no encontrado")
+	}
+
+	// Revocar todos los tokens del usuario para forzar re-login. Las claves de
+	// API no dependen de la contraseña y siguen valiendo.
+	return s.revokeTokens(ctx, m.ID)
+}
+
+// ChangeRole cambia el rol y revoca los access tokens del usuario, que llevan
+// el rol anterior. Los refresh tokens siguen valiendo: al renovar se emite un
+// access token con el rol nuevo.
+func (s *UserService) ChangeRole(ctx context.Context, id string, role string) error {
+	if !models.Role(role).Valid() {
+		return errInvalidRole
+	}
+	m, err := s.repo.GetUserByID(ctx, id)
 	if err != nil {
+		return notFoundAs(err, "user_not_found", "usuario no encontrado")
+	}
+	if m.Role == models.Role(role) {
+		return nil
+	}
+	if err := s.ensureNotLastAdmin(ctx, m); err != nil {
 		return err
 	}
+	if err := s.repo.SetRole(ctx, m.ID, models.Role(role), time.Now()); err != nil {
+		return notFoundAs(err, "user_not_found", "usuario no encontrado")
+	}
+	return s.revocations.RevokeAllForUser(ctx, m.ID.Hex())
+}
 
-	// Revocar todos los refresh tokens del usuario para forzar re-login
-	db := database.NewMongoDB()
-	refreshRepo := repositories.NewRefreshTokenRepository(db)
-	objID, err := primitive.ObjectIDFromHex(id)
-	if err == nil {
-		_, _ = refreshRepo.RevokeAllForUser(objID)
+func (s *UserService) DeleteUser(ctx context.Context, id string) error {
+	objID, err := utils.ParseObjectID(id)
+	if err != nil {
+		return err
+	}
+	m, err := s.repo.GetUserByID(ctx, id)
+	if err != nil {
+		return notFoundAs(err, "user_not_found", "usuario no encontrado")
+	}
+	if err := s.ensureNotLastAdmin(ctx, m); err != nil {
+		return err
+	}
+	// El usuario se borra al final: si falla un paso anterior el pedido se
+	// puede repetir. Borrado primero, sus claves e identidades vinculadas
+	// quedarían huérfanas; una identidad huérfana impediría además vincular
+	// esa cuenta del proveedor a un usuario nuevo.
+	if err := s.revokeAll(ctx, objID); err != nil {
+		return err
+	}
+	if err := s.apiKeys.DeleteForUser(ctx, objID); err != nil {
+		return err
+	}
+	if err := s.identities.DeleteForUser(ctx, objID); err != nil {
+		return err
+	}
+	res, err := s.repo.DeleteUser(ctx, objID)
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return errUserNotFound
 	}
-	return err
+	return nil
 }
 
-func (s *UserService) DeleteUser(id string) error {
-	objID, err := primitive.ObjectIDFromHex(id)
+func (s *UserService) SearchUsers(ctx context.Context, search dto.UserSearch) (dto.UserPage, error) {
+	if search.Role != "" && !models.Role(search.Role).Valid() {
+		return dto.UserPage{}, errInvalidRole
+	}
+	page, perPage, skip := pageBounds(search.Page, search.PerPage)
+	users, total, err := s.repo.SearchUsers(ctx, repositories.UserFilter{
+		Query:    strings.TrimSpace(search.Query),
+		Role:     models.Role(search.Role),
+		Disabled: search.Disabled,
+		Skip:     skip,
+		Limit:    int64(perPage),
+	})
 	if err != nil {
+		return dto.UserPage{}, err
+	}
+	out := dto.UserPage{Users: make([]dto.User, 0, len(users)), Total: total, Page: page, PerPage: perPage}
+	for _, u := range users {
+		out.Users = append(out.Users, modelUserToDTO(u))
+	}
+	return out, nil
+}
+
+func (s *UserService) SetDisabled(ctx context.Context, id string, disabled bool) (dto.User, error) {
+	m, err := s.repo.GetUserByID(ctx, id)
+	if err != nil {
+		return dto.User{}, notFoundAs(err, "user_not_found", "usuario no encontrado")
+	}
+	if m.Disabled() == disabled {
+		return modelUserToDTO(m), nil
+	}
+	var disabledAt *time.Time
+	if disabled {
+		if err := s.ensureNotLastAdmin(ctx, m); err != nil {
+			return dto.User{}, err
+		}
+		now := time.Now()
+		disabledAt = &now
+	}
+	if err := s.repo.SetDisabled(ctx, m.ID, disabledAt); err != nil {
+		return dto.User{}, notFoundAs(err, "user_not_found", "usuario no encontrado")
+	}
+	m.DisabledAt = disabledAt
+	// Login y Refresh rechazan la cuenta deshabilitada; revocar los access
+	// tokens la saca también de AuthMiddleware sin esperar a que venzan.
+	if disabled {
+		if err := s.revokeAll(ctx, m.ID); err != nil {
+			return dto.User{}, err
+		}
+	}
+	return modelUserToDTO(m), nil
+}
+
+func (s *UserService) RevokeSessions(ctx context.Context, id string) error {
+	m, err := s.repo.GetUserByID(ctx, id)
+	if err != nil {
+		return notFoundAs(err, "user_not_found", "usuario no encontrado")
+	}
+	return s.revokeAll(ctx, m.ID)
+}
+
+// ensureNotLastAdmin impide sacarle el acceso de administrador a m si es el
+// único administrador activo: sin él nadie podría volver a gestionar usuarios.
+func (s *UserService) ensureNotLastAdmin(ctx context.Context, m models.User) error {
+	if m.Role != models.RoleAdmin || m.Disabled() {
+		return nil
+	}
+	enabled := false
+	_, total, err := s.repo.SearchUsers(ctx, repositories.UserFilter{Role: models.RoleAdmin, Disabled: &enabled, Limit: 1})
+	if err != nil {
+		return err
+	}
+	if total <= 1 {
+		return errLastAdmin
+	}
+	return nil
+}
+
+// revokeTokens invalida los refresh tokens y los access tokens ya emitidos.
+func (s *UserService) revokeTokens(ctx context.Context, userID primitive.ObjectID) error {
+	if _, err := s.refreshRepo.RevokeAllForUser(ctx, userID); err != nil {
+		return err
+	}
+	return s.revocations.RevokeAllForUser(ctx, userID.Hex())
+}
+
+// revokeAll invalida además las claves de API, para que el usuario no
+// conserve ningún acceso.
+func (s *UserService) revokeAll(ctx context.Context, userID primitive.ObjectID) error {
+	if err := s.apiKeys.RevokeAllForUser(ctx, userID, time.Now()); err != nil {
 		return err
 	}
-	_, err = s.repo.DeleteUser(objID)
-	return err
+	return s.revokeTokens(ctx, userID)
+}
+
+var (
+	errInvalidEmail        = apperrors.Validation("invalid_email", "email inválido")
+	errEmailTaken          = apperrors.Conflict("email_taken", "email ya registrado")
+	errUnsupportedLanguage = apperrors.Validation("unsupported_language", "idioma no soportado")
+	errInvalidRole         = apperrors.Validation("invalid_role", "rol inválido")
+	errUserNotFound        = apperrors.NotFound("user_not_found", "usuario no encontrado")
+	errAccountDisabled     = apperrors.Forbidden("account_disabled", "la cuenta está deshabilitada")
+	errLastAdmin           = apperrors.Conflict("last_admin", "no se puede quitar al último administrador activo")
+	// Login no distingue entre usuario inexistente y contraseña incorrecta.
+	errInvalidCredentials = apperrors.Unauthorized("invalid_credentials", "credenciales inválidas")
+)
+
+// Límites de las contraseñas. bcrypt no admite más de maxPasswordBytes.
+const (
+	minPasswordLength = 6
+	maxPasswordBytes  = 72
+)
+
+var errPasswordInvalid = apperrors.Validation("password_invalid", "la contraseña debe tener al menos 6 caracteres y no más de 72 bytes")
+
+// validatePassword aplica la misma política al registrarse, al cambiar la
+// contraseña y al restablecerla.
+func validatePassword(password string) error {
+	if utf8.RuneCountInString(password) < minPasswordLength || len(password) > maxPasswordBytes {
+		return errPasswordInvalid
+	}
+	return nil
 }
 
 func isValidEmail(email string) bool {
@@ -211,16 +441,22 @@ func isValidEmail(email string) bool {
 
 func modelUserToDTO(m models.User) dto.User {
 	return dto.User{
-		ID:          m.ID,
-		Name:        m.Name,
-		Email:       m.Email,
-		Role:        string(m.Role),
-		DateOfBirth: m.DateOfBirth,
-		Weight:      m.Weight,
-		Height:      m.Height,
-		Level:       m.Level,
-		Goals:       m.Goals,
-		CreatedAt:   m.CreatedAt,
-		UpdatedAt:   m.UpdatedAt,
+		ID:              m.ID,
+		Name:            m.Name,
+		Email:           m.Email,
+		Role:            string(m.Role),
+		DateOfBirth:     m.DateOfBirth,
+		Weight:          m.Weight,
+		Height:          m.Height,
+		Level:           m.Level,
+		Goals:           m.Goals,
+		Language:        m.Language,
+		EmailVerified:   m.EmailVerified,
+		EmailVerifiedAt: m.EmailVerifiedAt,
+		MFAEnabled:      m.MFA.Enabled,
+		Disabled:        m.Disabled(),
+		DisabledAt:      m.DisabledAt,
+		CreatedAt:       m.CreatedAt,
+		UpdatedAt:       m.UpdatedAt,
 	}
 }
@@ -1,61 +1,177 @@
 package services
 
 import (
+	"context"
+	"errors"
 	"testing"
+	"time"
 
+	"backend/apperrors"
 	"backend/auth"
 	"backend/dto"
 	"backend/models"
+	"backend/repositories"
+	"backend/repositories/memory"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
+// errNoDocuments es lo que devuelven los repositorios cuando no hay resultado.
+var errNoDocuments = apperrors.FromMongo(mongo.ErrNoDocuments)
+
 type mockUserRepo struct {
 	getUserFn     func(name string) ([]models.User, error)
 	getUserByIDFn func(id string) (models.User, error)
+	getByEmailFn  func(email string) (models.User, error)
 	createUserFn  func(user models.User) (*mongo.InsertOneResult, error)
 	updateUserFn  func(user models.User) (*mongo.UpdateResult, error)
 	deleteUserFn  func(id primitive.ObjectID) (*mongo.DeleteResult, error)
+	setRoleFn     func(id primitive.ObjectID, role models.Role) error
+	setPasswordFn func(id primitive.ObjectID, hash string) error
 }
 
-func (m *mockUserRepo) GetUser(name string) ([]models.User, error) {
+func (m *mockUserRepo) GetUser(ctx context.Context, name string) ([]models.User, error) {
 	if m.getUserFn == nil {
 		return nil, nil
 	}
 	return m.getUserFn(name)
 }
-func (m *mockUserRepo) GetUserByID(id string) (models.User, error) {
+func (m *mockUserRepo) GetUserByID(ctx context.Context, id string) (models.User, error) {
 	if m.getUserByIDFn == nil {
 		return models.User{}, nil
 	}
 	return m.getUserByIDFn(id)
 }
-func (m *mockUserRepo) CreateUser(user models.User) (*mongo.InsertOneResult, error) {
+func (m *mockUserRepo) GetUserByEmail(ctx context.Context, email string) (models.User, error) {
+	if m.getByEmailFn == nil {
+		return models.User{}, errNoDocuments
+	}
+	return m.getByEmailFn(email)
+}
+func (m *mockUserRepo) CreateUser(ctx context.Context, user models.User) (*mongo.InsertOneResult, error) {
 	if m.createUserFn == nil {
 		return &mongo.InsertOneResult{InsertedID: user.ID}, nil
 	}
 	return m.createUserFn(user)
 }
-func (m *mockUserRepo) UpdateUser(user models.User) (*mongo.UpdateResult, error) {
+func (m *mockUserRepo) UpdateUser(ctx context.Context, user models.User) (*mongo.UpdateResult, error) {
 	if m.updateUserFn == nil {
 		return &mongo.UpdateResult{}, nil
 	}
 	return m.updateUserFn(user)
 }
-func (m *mockUserRepo) DeleteUser(id primitive.ObjectID) (*mongo.DeleteResult, error) {
+func (m *mockUserRepo) SetRole(ctx context.Context, id primitive.ObjectID, role models.Role, at time.Time) error {
+	if m.setRoleFn == nil {
+		return nil
+	}
+	return m.setRoleFn(id, role)
+}
+func (m *mockUserRepo) SetPasswordHash(ctx context.Context, id primitive.ObjectID, hash string, at time.Time) error {
+	if m.setPasswordFn == nil {
+		return nil
+	}
+	return m.setPasswordFn(id, hash)
+}
+func (m *mockUserRepo) MarkEmailVerified(ctx context.Context, id primitive.ObjectID, email string, at time.Time) error {
+	return nil
+}
+func (m *mockUserRepo) SetMFA(ctx context.Context, id primitive.ObjectID, mfa models.MFA) error {
+	return nil
+}
+func (m *mockUserRepo) UseTOTPStep(ctx context.Context, id primitive.ObjectID, step int64) (bool, error) {
+	return true, nil
+}
+func (m *mockUserRepo) ConsumeRecoveryCode(ctx context.Context, id primitive.ObjectID, hash string) (bool, error) {
+	return false, nil
+}
+func (m *mockUserRepo) SearchUsers(ctx context.Context, filter repositories.UserFilter) ([]models.User, int64, error) {
+	return nil, 0, nil
+}
+func (m *mockUserRepo) SetDisabled(ctx context.Context, id primitive.ObjectID, disabledAt *time.Time) error {
+	return nil
+}
+func (m *mockUserRepo) DeleteUser(ctx context.Context, id primitive.ObjectID) (*mongo.DeleteResult, error) {
 	if m.deleteUserFn == nil {
 		return &mongo.DeleteResult{}, nil
 	}
 	return m.deleteUserFn(id)
 }
 
-func TestRegister_Success(t *testing.T) {
-	repo := &mockUserRepo{
-		getUserFn: func(name string) ([]models.User, error) { return []models.User{}, nil },
+type mockRefreshTokenRepo struct {
+	saved   []models.RefreshToken
+	revoked []primitive.ObjectID
+}
+
+func (m *mockRefreshTokenRepo) Save(ctx context.Context, token models.RefreshToken) (*mongo.InsertOneResult, error) {
+	m.saved = append(m.saved, token)
+	return &mongo.InsertOneResult{InsertedID: token.ID}, nil
+}
+func (m *mockRefreshTokenRepo) GetByHash(ctx context.Context, hash string) (models.RefreshToken, error) {
+	for _, rt := range m.saved {
+		if rt.TokenHash == hash {
+			return rt, nil
+		}
 	}
+	return models.RefreshToken{}, errNoDocuments
+}
+func (m *mockRefreshTokenRepo) Revoke(ctx context.Context, token string) (*mongo.UpdateResult, error) {
+	return &mongo.UpdateResult{}, nil
+}
+func (m *mockRefreshTokenRepo) RevokeAllForUser(ctx context.Context, userID primitive.ObjectID) (*mongo.UpdateResult, error) {
+	m.revoked = append(m.revoked, userID)
+	return &mongo.UpdateResult{}, nil
+}
+func (m *mockRefreshTokenRepo) MarkRotated(ctx context.Context, hash string, replacedBy primitive.ObjectID) (*mongo.UpdateResult, error) {
+	return &mongo.UpdateResult{}, nil
+}
+func (m *mockRefreshTokenRepo) RevokeFamily(ctx context.Context, familyID primitive.ObjectID) (*mongo.UpdateResult, error) {
+	return &mongo.UpdateResult{}, nil
+}
+func (m *mockRefreshTokenRepo) ListActiveForUser(ctx context.Context, userID primitive.ObjectID) ([]models.RefreshToken, error) {
+	return nil, nil
+}
+func (m *mockRefreshTokenRepo) RevokeSession(ctx context.Context, userID, sessionID primitive.ObjectID) (*mongo.UpdateResult, error) {
+	return &mongo.UpdateResult{}, nil
+}
+func (m *mockRefreshTokenRepo) RevokeOtherSessions(ctx context.Context, userID, keep primitive.ObjectID) (*mongo.UpdateResult, error) {
+	return &mongo.UpdateResult{}, nil
+}
+
+type mockRevocations struct {
+	users []string
+	err   error
+}
 
-	svc := NewUserService(repo)
+func (m *mockRevocations) RevokeToken(ctx context.Context, claims *auth.Claims) error { return nil }
+func (m *mockRevocations) RevokeAllForUser(ctx context.Context, userID string) error {
+	m.users = append(m.users, userID)
+	return m.err
+}
+func (m *mockRevocations) RevokeSession(ctx context.Context, sessionID string) error { return nil }
+func (m *mockRevocations) IsRevoked(ctx context.Context, claims *auth.Claims) (bool, error) {
+	return false, nil
+}
+
+type mockVerifications struct {
+	sent []string
+	err  error
+}
+
+func (m *mockVerifications) SendVerification(ctx context.Context, user models.User) error {
+	m.sent = append(m.sent, user.Email)
+	return m.err
+}
+func (m *mockVerifications) VerifyEmail(ctx context.Context, token string) error { return nil }
+func (m *mockVerifications) ResendVerification(ctx context.Context, userID string) error {
+	return nil
+}
+
+func TestRegister_Success(t *testing.T) {
+	repo := &mockUserRepo{}
+	verifications := &mockVerifications{}
+
+	svc := NewUserService(repo, &mockRefreshTokenRepo{}, &mockRevocations{}, verifications, memory.NewAPIKeyRepository(), memory.NewLinkedIdentityRepository())
 
 	req := dto.RegisterRequest{
 		Name:        "Alice",
@@ -66,23 +182,42 @@ func TestRegister_Success(t *testing.T) {
 		Goals:       []string{"fitness"},
 	}
 
-	u, err := svc.Register(req)
+	u, err := svc.Register(context.Background(), req)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 	if u.Email != req.Email || u.Name != req.Name {
 		t.Fatalf("unexpected user returned: %+v", u)
 	}
+	if u.EmailVerified {
+		t.Fatalf("new users must start unverified")
+	}
+	if len(verifications.sent) != 1 || verifications.sent[0] != req.Email {
+		t.Fatalf("expected a verification email to %s, got %v", req.Email, verifications.sent)
+	}
+}
+
+func TestRegister_MailFailureDoesNotFail(t *testing.T) {
+	svc := NewUserService(&mockUserRepo{}, &mockRefreshTokenRepo{}, &mockRevocations{}, &mockVerifications{err: errors.New("smtp caído")}, memory.NewAPIKeyRepository(), memory.NewLinkedIdentityRepository())
+	req := dto.RegisterRequest{Name: "Alice", Email: "alice@example.com", Password: "secret", DateOfBirth: "2000-01-01"}
+	if _, err := svc.Register(context.Background(), req); err != nil {
+		t.Fatalf("a mail failure must not fail the registration: %v", err)
+	}
 }
 
 func TestRegister_DuplicateEmail(t *testing.T) {
 	existing := models.User{ID: primitive.NewObjectID(), Email: "bob@example.com"}
 	repo := &mockUserRepo{
-		getUserFn: func(name string) ([]models.User, error) { return []models.User{existing}, nil },
+		getByEmailFn: func(email string) (models.User, error) {
+			if email != existing.Email {
+				return models.User{}, errNoDocuments
+			}
+			return existing, nil
+		},
 	}
-	svc := NewUserService(repo)
-	req := dto.RegisterRequest{Name: "Bob", Email: "bob@example.com", Password: "p", DateOfBirth: "2000-01-01"}
-	_, err := svc.Register(req)
+	svc := NewUserService(repo, &mockRefreshTokenRepo{}, &mockRevocations{}, &mockVerifications{}, memory.NewAPIKeyRepository(), memory.NewLinkedIdentityRepository())
+	req := dto.RegisterRequest{Name: "Bob", Email: " Bob@Example.com ", Password: "secret", DateOfBirth: "2000-01-01"}
+	_, err := svc.Register(context.Background(), req)
 	if err == nil {
 		t.Fatalf("expected duplicate email error")
 	}
@@ -92,9 +227,14 @@ func TestLogin_Success(t *testing.T) {
 	pw := "mypw"
 	hash, _ := auth.HashPassword(pw)
 	stored := models.User{ID: primitive.NewObjectID(), Email: "c@example.com", PasswordHash: string(hash)}
-	repo := &mockUserRepo{getUserFn: func(name string) ([]models.User, error) { return []models.User{stored}, nil }}
-	svc := NewUserService(repo)
-	got, err := svc.Login(dto.LoginRequest{Email: "c@example.com", Password: pw})
+	repo := &mockUserRepo{getByEmailFn: func(email string) (models.User, error) {
+		if email != stored.Email {
+			return models.User{}, errNoDocuments
+		}
+		return stored, nil
+	}}
+	svc := NewUserService(repo, &mockRefreshTokenRepo{}, &mockRevocations{}, &mockVerifications{}, memory.NewAPIKeyRepository(), memory.NewLinkedIdentityRepository())
+	got, err := svc.Login(context.Background(), dto.LoginRequest{Email: "C@Example.com", Password: pw})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -106,9 +246,9 @@ func TestLogin_Success(t *testing.T) {
 func TestLogin_WrongPassword(t *testing.T) {
 	hash, _ := auth.HashPassword("right")
 	stored := models.User{ID: primitive.NewObjectID(), Email: "d@example.com", PasswordHash: string(hash)}
-	repo := &mockUserRepo{getUserFn: func(name string) ([]models.User, error) { return []models.User{stored}, nil }}
-	svc := NewUserService(repo)
-	_, err := svc.Login(dto.LoginRequest{Email: "d@example.com", Password: "wrong"})
+	repo := &mockUserRepo{getByEmailFn: func(email string) (models.User, error) { return stored, nil }}
+	svc := NewUserService(repo, &mockRefreshTokenRepo{}, &mockRevocations{}, &mockVerifications{}, memory.NewAPIKeyRepository(), memory.NewLinkedIdentityRepository())
+	_, err := svc.Login(context.Background(), dto.LoginRequest{Email: "d@example.com", Password: "wrong"})
 	if err == nil {
 		t.Fatalf("expected wrong password error")
 	}
@@ -117,8 +257,8 @@ func TestLogin_WrongPassword(t *testing.T) {
 func TestGetUsers_Mapping(t *testing.T) {
 	users := []models.User{{ID: primitive.NewObjectID(), Name: "U1"}, {ID: primitive.NewObjectID(), Name: "U2"}}
 	repo := &mockUserRepo{getUserFn: func(name string) ([]models.User, error) { return users, nil }}
-	svc := NewUserService(repo)
-	out, err := svc.GetUsers("")
+	svc := NewUserService(repo, &mockRefreshTokenRepo{}, &mockRevocations{}, &mockVerifications{}, memory.NewAPIKeyRepository(), memory.NewLinkedIdentityRepository())
+	out, err := svc.GetUsers(context.Background(), "")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -131,8 +271,8 @@ func TestChangePassword_WrongOldPassword(t *testing.T) {
 	hash, _ := auth.HashPassword("oldpass")
 	m := models.User{ID: primitive.NewObjectID(), PasswordHash: string(hash)}
 	repo := &mockUserRepo{getUserByIDFn: func(id string) (models.User, error) { return m, nil }}
-	svc := NewUserService(repo)
-	err := svc.ChangePassword(m.ID.Hex(), dto.ChangePasswordRequest{OldPassword: "bad", NewPassword: "new"})
+	svc := NewUserService(repo, &mockRefreshTokenRepo{}, &mockRevocations{}, &mockVerifications{}, memory.NewAPIKeyRepository(), memory.NewLinkedIdentityRepository())
+	err := svc.ChangePassword(context.Background(), m.ID.Hex(), dto.ChangePasswordRequest{OldPassword: "bad", NewPassword: "new"})
 	if err == nil {
 		t.Fatalf("expected error for wrong old password")
 	}
@@ -143,24 +283,282 @@ func TestChangePassword_Success(t *testing.T) {
 	hash, _ := auth.HashPassword(old)
 	m := models.User{ID: primitive.NewObjectID(), PasswordHash: string(hash)}
 	updated := false
+	refreshRepo := &mockRefreshTokenRepo{}
+	revocations := &mockRevocations{}
 	repo := &mockUserRepo{
 		getUserByIDFn: func(id string) (models.User, error) { return m, nil },
-		updateUserFn:  func(user models.User) (*mongo.UpdateResult, error) { updated = true; return &mongo.UpdateResult{}, nil },
+		setPasswordFn: func(id primitive.ObjectID, hash string) error { updated = true; return nil },
 	}
-	svc := NewUserService(repo)
-	err := svc.ChangePassword(m.ID.Hex(), dto.ChangePasswordRequest{OldPassword: old, NewPassword: "brandnew"})
+	svc := NewUserService(repo, refreshRepo, revocations, &mockVerifications{}, memory.NewAPIKeyRepository(), memory.NewLinkedIdentityRepository())
+	err := svc.ChangePassword(context.Background(), m.ID.Hex(), dto.ChangePasswordRequest{OldPassword: old, NewPassword: "brandnew"})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 	if !updated {
 		t.Fatalf("expected update to be called")
 	}
+	if len(refreshRepo.revoked) != 1 || refreshRepo.revoked[0] != m.ID {
+		t.Fatalf("expected refresh tokens of %s to be revoked, got %v", m.ID.Hex(), refreshRepo.revoked)
+	}
+	if len(revocations.users) != 1 || revocations.users[0] != m.ID.Hex() {
+		t.Fatalf("expected access tokens of %s to be revoked, got %v", m.ID.Hex(), revocations.users)
+	}
+}
+
+func TestChangeRole_RevokesAccessTokens(t *testing.T) {
+	m := models.User{ID: primitive.NewObjectID(), Role: models.RoleCoach}
+	var saved models.Role
+	revocations := &mockRevocations{}
+	repo := &mockUserRepo{
+		getUserByIDFn: func(id string) (models.User, error) { return m, nil },
+		setRoleFn:     func(id primitive.ObjectID, role models.Role) error { saved = role; return nil },
+	}
+	svc := NewUserService(repo, &mockRefreshTokenRepo{}, revocations, &mockVerifications{}, memory.NewAPIKeyRepository(), memory.NewLinkedIdentityRepository())
+
+	if err := svc.ChangeRole(context.Background(), m.ID.Hex(), "superuser"); !errors.Is(err, apperrors.ErrValidation) {
+		t.Fatalf("expected validation error for unknown role, got %v", err)
+	}
+	if err := svc.ChangeRole(context.Background(), m.ID.Hex(), "user"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if saved != models.RoleUser {
+		t.Fatalf("expected role to be saved, got %q", saved)
+	}
+	if len(revocations.users) != 1 || revocations.users[0] != m.ID.Hex() {
+		t.Fatalf("expected access tokens of %s to be revoked, got %v", m.ID.Hex(), revocations.users)
+	}
+}
+
+func TestLastActiveAdmin_CannotBeRemoved(t *testing.T) {
+	ctx := context.Background()
+	users := memory.NewUserRepository()
+	root := models.User{ID: primitive.NewObjectID(), Email: "root@example.com", Role: models.RoleAdmin}
+	other := models.User{ID: primitive.NewObjectID(), Email: "ops@example.com", Role: models.RoleAdmin}
+	for _, u := range []models.User{root, other} {
+		if _, err := users.CreateUser(ctx, u); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	svc := NewUserService(users, &mockRefreshTokenRepo{}, &mockRevocations{}, &mockVerifications{}, memory.NewAPIKeyRepository(), memory.NewLinkedIdentityRepository())
+
+	// Un administrador deshabilitado no cuenta como activo.
+	if _, err := svc.SetDisabled(ctx, other.ID.Hex(), true); err != nil {
+		t.Fatalf("disabling one of two admins must work, got %v", err)
+	}
+	if err := svc.ChangeRole(ctx, root.ID.Hex(), "user"); apperrors.Code(err) != "last_admin" {
+		t.Fatalf("expected last_admin demoting, got %v", err)
+	}
+	if _, err := svc.SetDisabled(ctx, root.ID.Hex(), true); apperrors.Code(err) != "last_admin" {
+		t.Fatalf("expected last_admin disabling, got %v", err)
+	}
+	if err := svc.DeleteUser(ctx, root.ID.Hex()); apperrors.Code(err) != "last_admin" {
+		t.Fatalf("expected last_admin deleting, got %v", err)
+	}
+	if m, _ := users.GetUserByID(ctx, root.ID.Hex()); m.Role != models.RoleAdmin || m.Disabled() {
+		t.Fatalf("the last admin must be left untouched, got %+v", m)
+	}
+
+	if _, err := svc.SetDisabled(ctx, other.ID.Hex(), false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := svc.ChangeRole(ctx, root.ID.Hex(), "user"); err != nil {
+		t.Fatalf("demoting with another active admin must work, got %v", err)
+	}
 }
 
 func TestDeleteUser_InvalidHex(t *testing.T) {
-	svc := NewUserService(&mockUserRepo{})
-	err := svc.DeleteUser("nothex")
+	svc := NewUserService(&mockUserRepo{}, &mockRefreshTokenRepo{}, &mockRevocations{}, &mockVerifications{}, memory.NewAPIKeyRepository(), memory.NewLinkedIdentityRepository())
+	err := svc.DeleteUser(context.Background(), "nothex")
 	if err == nil {
 		t.Fatalf("expected error for invalid hex id")
 	}
 }
+
+func TestRegister_DuplicateKeyOnInsert(t *testing.T) {
+	repo := &mockUserRepo{
+		createUserFn: func(user models.User) (*mongo.InsertOneResult, error) {
+			return nil, mongo.WriteException{WriteErrors: mongo.WriteErrors{{Code: 11000}}}
+		},
+	}
+	svc := NewUserService(repo, &mockRefreshTokenRepo{}, &mockRevocations{}, &mockVerifications{}, memory.NewAPIKeyRepository(), memory.NewLinkedIdentityRepository())
+	req := dto.RegisterRequest{Name: "Bob", Email: "bob@example.com", Password: "secret", DateOfBirth: "2000-01-01"}
+	_, err := svc.Register(context.Background(), req)
+	if err == nil || err.Error() != "email ya registrado" {
+		t.Fatalf("expected duplicate email error, got %v", err)
+	}
+}
+
+func TestUpdateUser_NormalizesEmail(t *testing.T) {
+	m := models.User{ID: primitive.NewObjectID(), Email: "old@example.com"}
+	var saved models.User
+	repo := &mockUserRepo{
+		getUserByIDFn: func(id string) (models.User, error) { return m, nil },
+		updateUserFn:  func(user models.User) (*mongo.UpdateResult, error) { saved = user; return &mongo.UpdateResult{}, nil },
+	}
+	svc := NewUserService(repo, &mockRefreshTokenRepo{}, &mockRevocations{}, &mockVerifications{}, memory.NewAPIKeyRepository(), memory.NewLinkedIdentityRepository())
+	if err := svc.UpdateUser(context.Background(), m.ID.Hex(), dto.UpdateUserRequest{Email: "  New@Example.COM "}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if saved.Email != "new@example.com" {
+		t.Fatalf("expected normalized email, got %q", saved.Email)
+	}
+}
+
+func TestUpdateUser_EmailChangeRequiresVerification(t *testing.T) {
+	now := time.Now()
+	m := models.User{ID: primitive.NewObjectID(), Email: "old@example.com", EmailVerified: true, EmailVerifiedAt: &now}
+	var saved models.User
+	repo := &mockUserRepo{
+		getUserByIDFn: func(id string) (models.User, error) { return m, nil },
+		updateUserFn:  func(user models.User) (*mongo.UpdateResult, error) { saved = user; return &mongo.UpdateResult{}, nil },
+	}
+	verifications := &mockVerifications{}
+	svc := NewUserService(repo, &mockRefreshTokenRepo{}, &mockRevocations{}, verifications, memory.NewAPIKeyRepository(), memory.NewLinkedIdentityRepository())
+	if err := svc.UpdateUser(context.Background(), m.ID.Hex(), dto.UpdateUserRequest{Email: "new@example.com"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if saved.EmailVerified || saved.EmailVerifiedAt != nil {
+		t.Fatalf("expected the new email to be unverified, got %+v", saved)
+	}
+	if len(verifications.sent) != 1 || verifications.sent[0] != "new@example.com" {
+		t.Fatalf("expected a verification email to the new address, got %v", verifications.sent)
+	}
+}
+
+func TestSetDisabled_BlocksLoginAndRevokesSessions(t *testing.T) {
+	ctx := context.Background()
+	hash, _ := auth.HashPassword("secret123")
+	users := memory.NewUserRepository()
+	m := models.User{ID: primitive.NewObjectID(), Email: "bob@example.com", PasswordHash: string(hash), Role: models.RoleUser}
+	if _, err := users.CreateUser(ctx, m); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	refreshRepo, revocations := &mockRefreshTokenRepo{}, &mockRevocations{}
+	svc := NewUserService(users, refreshRepo, revocations, &mockVerifications{}, memory.NewAPIKeyRepository(), memory.NewLinkedIdentityRepository())
+	login := dto.LoginRequest{Email: m.Email, Password: "secret123"}
+
+	disabled, err := svc.SetDisabled(ctx, m.ID.Hex(), true)
+	if err != nil || !disabled.Disabled {
+		t.Fatalf("expected the user to be disabled, got %+v, %v", disabled, err)
+	}
+	if len(refreshRepo.revoked) != 1 || len(revocations.users) != 1 {
+		t.Fatalf("disabling must revoke sessions and access tokens")
+	}
+	if _, err := svc.Login(ctx, login); apperrors.Code(err) != "account_disabled" {
+		t.Fatalf("expected account_disabled, got %v", err)
+	}
+	// Un update de perfil no vuelve a habilitar la cuenta.
+	if err := svc.UpdateUser(ctx, m.ID.Hex(), dto.UpdateUserRequest{Name: "Bobby"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := svc.Login(ctx, login); apperrors.Code(err) != "account_disabled" {
+		t.Fatalf("expected account_disabled after a profile update, got %v", err)
+	}
+
+	if _, err := svc.SetDisabled(ctx, m.ID.Hex(), false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := svc.Login(ctx, login); err != nil {
+		t.Fatalf("expected login to work after enabling, got %v", err)
+	}
+}
+
+func TestDisableAndDelete_RemoveAPIKeysAndIdentities(t *testing.T) {
+	ctx := context.Background()
+	users, keys, identities := memory.NewUserRepository(), memory.NewAPIKeyRepository(), memory.NewLinkedIdentityRepository()
+	m := models.User{ID: primitive.NewObjectID(), Email: "bob@example.com", Role: models.RoleUser}
+	if _, err := users.CreateUser(ctx, m); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	key := models.APIKey{UserID: m.ID, KeyHash: "hash", ExpiresAt: time.Now().Add(time.Hour)}
+	if err := keys.Create(ctx, key); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := identities.Create(ctx, models.LinkedIdentity{UserID: m.ID, Provider: "google", Subject: "123"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	svc := NewUserService(users, &mockRefreshTokenRepo{}, &mockRevocations{}, &mockVerifications{}, keys, identities)
+
+	if _, err := svc.SetDisabled(ctx, m.ID.Hex(), true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n, _ := keys.CountActiveForUser(ctx, m.ID, time.Now()); n != 0 {
+		t.Fatalf("disabling must revoke the API keys, %d still active", n)
+	}
+
+	if err := svc.DeleteUser(ctx, m.ID.Hex()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := keys.GetByHash(ctx, "hash"); !errors.Is(err, apperrors.ErrNotFound) {
+		t.Fatalf("expected the API key to be deleted, got %v", err)
+	}
+	if linked, _ := identities.ListForUser(ctx, m.ID); len(linked) != 0 {
+		t.Fatalf("expected linked identities to be deleted, got %+v", linked)
+	}
+}
+
+// Si falla la limpieza de lo que depende del usuario, el usuario sigue ahí y
+// el borrado se puede repetir.
+func TestDeleteUser_KeepsUserWhenCleanupFails(t *testing.T) {
+	ctx := context.Background()
+	users, keys, identities := memory.NewUserRepository(), memory.NewAPIKeyRepository(), memory.NewLinkedIdentityRepository()
+	m := models.User{ID: primitive.NewObjectID(), Email: "bob@example.com", Role: models.RoleUser}
+	if _, err := users.CreateUser(ctx, m); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := identities.Create(ctx, models.LinkedIdentity{UserID: m.ID, Provider: "google", Subject: "123"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	revocations := &mockRevocations{err: errors.New("mongo caído")}
+	svc := NewUserService(users, &mockRefreshTokenRepo{}, revocations, &mockVerifications{}, keys, identities)
+
+	if err := svc.DeleteUser(ctx, m.ID.Hex()); err == nil {
+		t.Fatalf("expected the revocation error")
+	}
+	if _, err := users.GetUserByID(ctx, m.ID.Hex()); err != nil {
+		t.Fatalf("the user must survive a failed cleanup, got %v", err)
+	}
+
+	revocations.err = nil
+	if err := svc.DeleteUser(ctx, m.ID.Hex()); err != nil {
+		t.Fatalf("expected the retry to work, got %v", err)
+	}
+	if _, err := users.GetUserByID(ctx, m.ID.Hex()); !errors.Is(err, apperrors.ErrNotFound) {
+		t.Fatalf("expected the user to be deleted, got %v", err)
+	}
+	if linked, _ := identities.ListForUser(ctx, m.ID); len(linked) != 0 {
+		t.Fatalf("expected linked identities to be deleted, got %+v", linked)
+	}
+}
+
+func TestSearchUsers_FiltersAndPaginates(t *testing.T) {
+	ctx := context.Background()
+	users := memory.NewUserRepository()
+	base := time.Now()
+	for i, email := range []string{"ana@example.com", "bruno@example.com", "ana.coach@example.com"} {
+		role := models.RoleUser
+		if i == 2 {
+			role = models.RoleCoach
+		}
+		u := models.User{ID: primitive.NewObjectID(), Name: email, Email: email, Role: role, CreatedAt: base.Add(time.Duration(i) * time.Minute)}
+		if _, err := users.CreateUser(ctx, u); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	svc := NewUserService(users, &mockRefreshTokenRepo{}, &mockRevocations{}, &mockVerifications{}, memory.NewAPIKeyRepository(), memory.NewLinkedIdentityRepository())
+
+	page, err := svc.SearchUsers(ctx, dto.UserSearch{Query: "ana", PerPage: 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if page.Total != 2 || len(page.Users) != 1 || page.Users[0].Email != "ana.coach@example.com" {
+		t.Fatalf("expected the newest of 2 matches, got %+v", page)
+	}
+	page, _ = svc.SearchUsers(ctx, dto.UserSearch{Role: "coach"})
+	if page.Total != 1 {
+		t.Fatalf("expected one coach, got %+v", page)
+	}
+	if _, err := svc.SearchUsers(ctx, dto.UserSearch{Role: "superuser"}); apperrors.Code(err) != "invalid_role" {
+		t.Fatalf("expected invalid_role, got %v", err)
+	}
+}
@@ -1,22 +1,25 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"time"
 
+	"backend/apperrors"
 	"backend/dto"
 	"backend/models"
 	"backend/repositories"
+	"backend/utils"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type WorkoutServiceInterface interface {
-	GetWorkouts(userID string) ([]dto.WorkoutDTO, error)
-	GetWorkoutByID(id string) (dto.WorkoutDTO, error)
-	CreateWorkout(input dto.WorkoutDTO) (string, error)
-	UpdateWorkout(input dto.WorkoutDTO) error
-	DeleteWorkout(id string) error
+	GetWorkouts(ctx context.Context, userID string) ([]dto.WorkoutDTO, error)
+	GetWorkoutByID(ctx context.Context, id string) (dto.WorkoutDTO, error)
+	CreateWorkout(ctx context.Context, input dto.WorkoutDTO) (string, error)
+	UpdateWorkout(ctx context.Context, input dto.WorkoutDTO) error
+	DeleteWorkout(ctx context.Context, id string) error
 }
 
 type WorkoutService struct {
@@ -27,15 +30,15 @@ func NewWorkoutService(repo repositories.WorkoutRepositoryInterface) *WorkoutSer
 	return &WorkoutService{repo: repo}
 }
 
-func (s *WorkoutService) GetWorkouts(userID string) ([]dto.WorkoutDTO, error) {
+func (s *WorkoutService) GetWorkouts(ctx context.Context, userID string) ([]dto.WorkoutDTO, error) {
 	if userID == "" {
-		return nil, errors.New("userID requerido")
+		return nil, apperrors.Validation("user_required", "userID requerido")
 	}
-	uid, err := primitive.ObjectIDFromHex(userID)
+	uid, err := utils.ParseObjectID(userID)
 	if err != nil {
 		return nil, err
 	}
-	modelsList, err := s.repo.GetWorkouts(uid)
+	modelsList, err := s.repo.GetWorkouts(ctx, uid)
 	if err != nil {
 		return nil, err
 	}
@@ -46,26 +49,26 @@ func (s *WorkoutService) GetWorkouts(userID string) ([]dto.WorkoutDTO, error) {
 	return dtos, nil
 }
 
-func (s *WorkoutService) GetWorkoutByID(id string) (dto.WorkoutDTO, error) {
-	m, err := s.repo.GetWorkoutByID(id)
+func (s *WorkoutService) GetWorkoutByID(ctx context.Context, id string) (dto.WorkoutDTO, error) {
+	m, err := s.repo.GetWorkoutByID(ctx, id)
 	if err != nil {
-		return dto.WorkoutDTO{}, err
+		return dto.WorkoutDTO{}, notFoundAs(err, "workout_not_found", "Workout no encontrado")
 	}
 	return modelToDTO(m), nil
 }
 
-func (s *WorkoutService) CreateWorkout(input dto.WorkoutDTO) (string, error) {
+func (s *WorkoutService) CreateWorkout(ctx context.Context, input dto.WorkoutDTO) (string, error) {
 	if input.UserID == "" {
-		return "", errors.New("user_id requerido")
+		return "", apperrors.Validation("user_required", "user_id requerido")
 	}
-	uid, err := primitive.ObjectIDFromHex(input.UserID)
+	uid, err := utils.ParseObjectID(input.UserID)
 	if err != nil {
 		return "", err
 	}
 
 	var rid primitive.ObjectID
 	if input.RoutineID != "" {
-		rid, err = primitive.ObjectIDFromHex(input.RoutineID)
+		rid, err = utils.ParseObjectID(input.RoutineID)
 		if err != nil {
 			return "", err
 		}
@@ -82,7 +85,7 @@ func (s *WorkoutService) CreateWorkout(input dto.WorkoutDTO) (string, error) {
 		Notes:             input.Notes,
 	}
 
-	res, err := s.repo.CreateWorkout(workout)
+	res, err := s.repo.CreateWorkout(ctx, workout)
 	if err != nil {
 		return "", err
 	}
@@ -95,21 +98,21 @@ func (s *WorkoutService) CreateWorkout(input dto.WorkoutDTO) (string, error) {
 	return "", nil
 }
 
-func (s *WorkoutService) UpdateWorkout(input dto.WorkoutDTO) error {
+func (s *WorkoutService) UpdateWorkout(ctx context.Context, input dto.WorkoutDTO) error {
 	if input.ID.IsZero() {
-		return errors.New("id requerido para actualizar")
+		return apperrors.Validation("id_required", "id requerido para actualizar")
 	}
 	var uid primitive.ObjectID
 	var err error
 	if input.UserID != "" {
-		uid, err = primitive.ObjectIDFromHex(input.UserID)
+		uid, err = utils.ParseObjectID(input.UserID)
 		if err != nil {
 			return err
 		}
 	}
 	var rid primitive.ObjectID
 	if input.RoutineID != "" {
-		rid, err = primitive.ObjectIDFromHex(input.RoutineID)
+		rid, err = utils.ParseObjectID(input.RoutineID)
 		if err != nil {
 			return err
 		}
@@ -126,19 +129,19 @@ func (s *WorkoutService) UpdateWorkout(input dto.WorkoutDTO) error {
 		Notes:             input.Notes,
 	}
 
-	_, err = s.repo.UpdateWorkout(workout)
+	_, err = s.repo.UpdateWorkout(ctx, workout)
 	return err
 }
 
-func (s *WorkoutService) DeleteWorkout(id string) error {
+func (s *WorkoutService) DeleteWorkout(ctx context.Context, id string) error {
 	if id == "" {
-		return errors.New("id requerido")
+		return apperrors.Validation("id_required", "id requerido")
 	}
-	objID, err := primitive.ObjectIDFromHex(id)
+	objID, err := utils.ParseObjectID(id)
 	if err != nil {
 		return err
 	}
-	_, err = s.repo.DeleteWorkout(objID)
+	_, err = s.repo.DeleteWorkout(ctx, objID)
 	return err
 }
 
@@ -162,4 +165,3 @@ func modelToDTO(m models.Workout) dto.WorkoutDTO {
 		EstimatedCalories: m.EstimatedCalories,
 	}
 }
-
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"testing"
 	"time"
@@ -21,19 +22,19 @@ type mockWorkoutRepo struct {
 	deleteWorkoutFn  func(id primitive.ObjectID) (*mongo.DeleteResult, error)
 }
 
-func (m *mockWorkoutRepo) GetWorkouts(userID primitive.ObjectID) ([]models.Workout, error) {
+func (m *mockWorkoutRepo) GetWorkouts(ctx context.Context, userID primitive.ObjectID) ([]models.Workout, error) {
 	return m.getWorkoutsFn(userID)
 }
-func (m *mockWorkoutRepo) GetWorkoutByID(id string) (models.Workout, error) {
+func (m *mockWorkoutRepo) GetWorkoutByID(ctx context.Context, id string) (models.Workout, error) {
 	return m.getWorkoutByIDFn(id)
 }
-func (m *mockWorkoutRepo) CreateWorkout(workout models.Workout) (*mongo.InsertOneResult, error) {
+func (m *mockWorkoutRepo) CreateWorkout(ctx context.Context, workout models.Workout) (*mongo.InsertOneResult, error) {
 	return m.createWorkoutFn(workout)
 }
-func (m *mockWorkoutRepo) UpdateWorkout(workout models.Workout) (*mongo.UpdateResult, error) {
+func (m *mockWorkoutRepo) UpdateWorkout(ctx context.Context, workout models.Workout) (*mongo.UpdateResult, error) {
 	return m.updateWorkoutFn(workout)
 }
-func (m *mockWorkoutRepo) DeleteWorkout(id primitive.ObjectID) (*mongo.DeleteResult, error) {
+func (m *mockWorkoutRepo) DeleteWorkout(ctx context.Context, id primitive.ObjectID) (*mongo.DeleteResult, error) {
 	return m.deleteWorkoutFn(id)
 }
 
@@ -52,7 +53,7 @@ func TestGetWorkouts_Success(t *testing.T) {
 	}
 
 	svc := NewWorkoutService(repo)
-	dtos, err := svc.GetWorkouts(uid.Hex())
+	dtos, err := svc.GetWorkouts(context.Background(), uid.Hex())
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
 	}
@@ -66,7 +67,7 @@ func TestGetWorkouts_Success(t *testing.T) {
 
 func TestGetWorkouts_InvalidUserID(t *testing.T) {
 	svc := NewWorkoutService(&mockWorkoutRepo{})
-	_, err := svc.GetWorkouts("")
+	_, err := svc.GetWorkouts(context.Background(), "")
 	if err == nil {
 		t.Fatalf("expected error for empty userID")
 	}
@@ -86,7 +87,7 @@ func TestGetWorkoutByID_Success(t *testing.T) {
 	}
 
 	svc := NewWorkoutService(repo)
-	_, err := svc.GetWorkoutByID(id)
+	_, err := svc.GetWorkoutByID(context.Background(), id)
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
 	}
@@ -99,7 +100,7 @@ func TestGetWorkoutByID_Error(t *testing.T) {
 		},
 	}
 	svc := NewWorkoutService(repo)
-	_, err := svc.GetWorkoutByID("any")
+	_, err := svc.GetWorkoutByID(context.Background(), "any")
 	if err == nil {
 		t.Fatalf("expected error")
 	}
@@ -116,7 +117,7 @@ func TestCreateWorkout_Success(t *testing.T) {
 	}
 
 	svc := NewWorkoutService(repo)
-	id, err := svc.CreateWorkout(input)
+	id, err := svc.CreateWorkout(context.Background(), input)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -127,7 +128,7 @@ func TestCreateWorkout_Success(t *testing.T) {
 
 func TestCreateWorkout_MissingUser(t *testing.T) {
 	svc := NewWorkoutService(&mockWorkoutRepo{})
-	_, err := svc.CreateWorkout(dto.WorkoutDTO{})
+	_, err := svc.CreateWorkout(context.Background(), dto.WorkoutDTO{})
 	if err == nil {
 		t.Fatalf("expected error when user_id missing")
 	}
@@ -148,7 +149,7 @@ func TestUpdateWorkout_Success(t *testing.T) {
 	}
 
 	svc := NewWorkoutService(repo)
-	err := svc.UpdateWorkout(input)
+	err := svc.UpdateWorkout(context.Background(), input)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -156,7 +157,7 @@ func TestUpdateWorkout_Success(t *testing.T) {
 
 func TestUpdateWorkout_MissingID(t *testing.T) {
 	svc := NewWorkoutService(&mockWorkoutRepo{})
-	err := svc.UpdateWorkout(dto.WorkoutDTO{})
+	err := svc.UpdateWorkout(context.Background(), dto.WorkoutDTO{})
 	if err == nil {
 		t.Fatalf("expected error when id missing")
 	}
@@ -174,7 +175,7 @@ func TestDeleteWorkout_Success(t *testing.T) {
 	}
 
 	svc := NewWorkoutService(repo)
-	err := svc.DeleteWorkout(id.Hex())
+	err := svc.DeleteWorkout(context.Background(), id.Hex())
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -182,7 +183,7 @@ func TestDeleteWorkout_Success(t *testing.T) {
 
 func TestDeleteWorkout_InvalidID(t *testing.T) {
 	svc := NewWorkoutService(&mockWorkoutRepo{})
-	err := svc.DeleteWorkout("")
+	err := svc.DeleteWorkout(context.Background(), "")
 	if err == nil {
 		t.Fatalf("expected error for empty id")
 	}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"backend/config"
+	"backend/database"
+	"backend/repositories"
+	"backend/repositories/memory"
+	"backend/services"
+)
+
+type repositorySet struct {
+	users         repositories.UserRepositoryInterface
+	exercises     repositories.ExerciseRepositoryInterface
+	routines      repositories.RoutineRepositoryInterface
+	workouts      repositories.WorkoutRepositoryInterface
+	refreshTokens repositories.RefreshTokenRepositoryInterface
+	revocations   repositories.TokenRevocationRepositoryInterface
+	signingKeys   repositories.SigningKeyRepositoryInterface
+	verifications repositories.EmailVerificationRepositoryInterface
+	resets        repositories.PasswordResetRepositoryInterface
+	loginAttempts repositories.LoginAttemptRepositoryInterface
+	rateLimits    repositories.RateLimitRepositoryInterface
+	oidcStates    repositories.OIDCStateRepositoryInterface
+	identities    repositories.LinkedIdentityRepositoryInterface
+	apiKeys       repositories.APIKeyRepositoryInterface
+	roles         repositories.RolePermissionsRepositoryInterface
+	audit         repositories.AuditRepositoryInterface
+}
+
+func newMongoRepositories(db database.DB) repositorySet {
+	return repositorySet{
+		users:         repositories.NewUserRepository(db),
+		exercises:     repositories.NewExerciseRepository(db),
+		routines:      repositories.NewRoutineRepository(db),
+		workouts:      repositories.NewWorkoutRepository(db),
+		refreshTokens: repositories.NewRefreshTokenRepository(db),
+		revocations:   repositories.NewTokenRevocationRepository(db),
+		signingKeys:   repositories.NewSigningKeyRepository(db),
+		verifications: repositories.NewEmailVerificationRepository(db),
+		resets:        repositories.NewPasswordResetRepository(db),
+		loginAttempts: repositories.NewLoginAttemptRepository(db),
+		rateLimits:    repositories.NewRateLimitRepository(db),
+		oidcStates:    repositories.NewOIDCStateRepository(db),
+		identities:    repositories.NewLinkedIdentityRepository(db),
+		apiKeys:       repositories.NewAPIKeyRepository(db),
+		roles:         repositories.NewRolePermissionsRepository(db),
+		audit:         repositories.NewAuditRepository(db),
+	}
+}
+
+func newMemoryRepositories() repositorySet {
+	return repositorySet{
+		users:         memory.NewUserRepository(),
+		exercises:     memory.NewExerciseRepository(),
+		routines:      memory.NewRoutineRepository(),
+		workouts:      memory.NewWorkoutRepository(),
+		refreshTokens: memory.NewRefreshTokenRepository(),
+		revocations:   memory.NewTokenRevocationRepository(),
+		signingKeys:   memory.NewSigningKeyRepository(),
+		verifications: memory.NewEmailVerificationRepository(),
+		resets:        memory.NewPasswordResetRepository(),
+		loginAttempts: memory.NewLoginAttemptRepository(),
+		rateLimits:    memory.NewRateLimitRepository(),
+		oidcStates:    memory.NewOIDCStateRepository(),
+		identities:    memory.NewLinkedIdentityRepository(),
+		apiKeys:       memory.NewAPIKeyRepository(),
+		roles:         memory.NewRolePermissionsRepository(),
+		audit:         memory.NewAuditRepository(),
+	}
+}
+
+// newRateLimiter usa los repositorios del almacenamiento con rate_limit.store
+// "mongo" y un contador en memoria de esta instancia con "memory", aunque los
+// datos estén en Mongo.
+func newRateLimiter(cfg config.RateLimitConfig, repos repositorySet) *services.RateLimiter {
+	store := repos.rateLimits
+	if cfg.Store == config.StorageMemory {
+		store = memory.NewRateLimitRepository()
+	}
+	return services.NewRateLimiter(store)
+}
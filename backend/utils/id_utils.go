@@ -0,0 +1,17 @@
+package utils
+
+import (
+	"backend/apperrors"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ParseObjectID convierte un id recibido del cliente; si no es válido devuelve
+// un error de validación en vez del error del driver.
+func ParseObjectID(id string) (primitive.ObjectID, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return primitive.NilObjectID, apperrors.Validation("invalid_id", "id inválido")
+	}
+	return objectID, nil
+}
@@ -3,8 +3,16 @@ package utils
 import (
 	"backend/dto"
 	"backend/models"
+	"strings"
 )
 
+// NormalizeEmail es la forma en que se guardan y buscan los emails: sin
+// espacios alrededor y en minúsculas. Así la búsqueda por email no distingue
+// mayúsculas y puede usar el índice único.
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
 func ConvertUserModelToRegisterRequest(user models.User) dto.RegisterRequest {
 	return dto.RegisterRequest{
 		Name:     user.Name,
@@ -33,5 +41,3 @@ func ConvertUserModelToChangePasswordRequest(user models.User, newPassword strin
 		NewPassword: newPassword,
 	}
 }
-
-
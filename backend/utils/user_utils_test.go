@@ -1 +1,9 @@
 package utils
+
+import "testing"
+
+func TestNormalizeEmail(t *testing.T) {
+	if got := NormalizeEmail("  Ana.Perez@Example.COM "); got != "ana.perez@example.com" {
+		t.Fatalf("unexpected normalized email %q", got)
+	}
+}